@@ -0,0 +1,212 @@
+// Утилита fitmf подбирает функции принадлежности нечетких множеств "экономичность" и "динамика" по ответам
+// пользователей на вопросы опроса методом Левенберга-Марквардта и публикует их как новую версию.
+// Нечеткий алгоритм переходит на новую версию только после ее проверки и одобрения.
+//
+// Использование (из каталога cmd, где находится каталог config):
+//
+//	fitmf fit [-out <файл>] [-publish] [-type2 <множитель>]
+//	                                     подбирает функции и выводит отчет; -out записывает новую версию
+//	                                     в файл YAML, -publish записывает ее в таблицу membership_functions,
+//	                                     -type2 также выводит нижние и верхние функции принадлежности второго типа
+//	fitmf approve <версия>               одобряет версию в таблице membership_functions
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+	"vehicles/config"
+	"vehicles/packages/adapters/gateway"
+	"vehicles/packages/domain/fuzzy"
+	"vehicles/packages/infrastructure/datastore"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("fitmf: ")
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	if err := config.Init(); err != nil {
+		log.Fatalf("%s", err.Error())
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "fit":
+		err = fit(context.Background(), os.Args[2:])
+	case "approve":
+		err = approve(context.Background(), os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatalf("%s", err.Error())
+	}
+}
+
+// usage выводит справку и завершает работу
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: fitmf fit [-out <file>] [-publish] [-type2 <spread>]")
+	fmt.Fprintln(os.Stderr, "       fitmf approve <version>")
+	os.Exit(2)
+}
+
+// fit подбирает функции принадлежности по ответам пользователей
+// Входные параметры: ctx - контекст, args - аргументы команды
+func fit(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("fit", flag.ExitOnError)
+	out := flags.String("out", "", "YAML file for the new version")
+	publish := flags.Bool("publish", false, "publish the new version to the table membership_functions")
+	spread := flags.Float64("type2", 0, "if positive, also fit interval type-2 functions with this spread of answers")
+	flags.Parse(args)
+
+	surveyDB, err := datastore.CreateNewDBForSurvey()
+	if err != nil {
+		return fmt.Errorf("error from `CreateNewDBForSurvey` function, package `datastore`: %v", err)
+	}
+	defer surveyDB.Close()
+
+	answers, err := gateway.NewSurveyAnswersRepository(surveyDB).LoadSurveyAnswers(ctx)
+	if err != nil {
+		return fmt.Errorf("error from `LoadSurveyAnswers` method, package `gateway`: %v", err)
+	}
+
+	current, err := currentMemberships(ctx)
+	if err != nil {
+		return err
+	}
+
+	results, err := fuzzy.FitMemberships(answers, current)
+	if err != nil {
+		return fmt.Errorf("error from `FitMemberships` function, package `fuzzy`: %v", err)
+	}
+	printReport(results)
+
+	if *spread > 0 {
+		intervals, err := fuzzy.FitIntervalMemberships(answers, current, *spread)
+		if err != nil {
+			return fmt.Errorf("error from `FitIntervalMemberships` function, package `fuzzy`: %v", err)
+		}
+		printIntervalReport(intervals)
+	}
+
+	candidate, err := fuzzy.CandidateConfig("survey-"+time.Now().Format("20060102-150405"), current, results)
+	if err != nil {
+		return fmt.Errorf("error from `CandidateConfig` function, package `fuzzy`: %v", err)
+	}
+	if _, err = fuzzy.BuildMemberships(candidate); err != nil {
+		return fmt.Errorf("error from `BuildMemberships` function, package `fuzzy`: %v", err)
+	}
+
+	if *out != "" {
+		data, err := yaml.Marshal(candidate)
+		if err != nil {
+			return fmt.Errorf("error from `Marshal` function, package `yaml`: %v", err)
+		}
+		if err = os.WriteFile(*out, data, 0o644); err != nil {
+			return fmt.Errorf("error from `WriteFile` function, package `os`: %v", err)
+		}
+		fmt.Printf("version %q is written to %s\n", candidate.Version, *out)
+	}
+
+	if *publish {
+		vehiclesDB, err := datastore.CreateNewDBForVehicles()
+		if err != nil {
+			return fmt.Errorf("error from `CreateNewDBForVehicles` function, package `datastore`: %v", err)
+		}
+		defer vehiclesDB.Close()
+
+		version, err := gateway.NewMembershipRepository(vehiclesDB).PublishMemberships(ctx, candidate)
+		if err != nil {
+			return fmt.Errorf("error from `PublishMemberships` method, package `gateway`: %v", err)
+		}
+		fmt.Printf("version %s is published, approve it with `fitmf approve %s` after review\n", version, version)
+	}
+	return nil
+}
+
+// approve одобряет версию функций принадлежности в таблице membership_functions
+// Входные параметры: ctx - контекст, args - аргументы команды
+func approve(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		usage()
+	}
+
+	vehiclesDB, err := datastore.CreateNewDBForVehicles()
+	if err != nil {
+		return fmt.Errorf("error from `CreateNewDBForVehicles` function, package `datastore`: %v", err)
+	}
+	defer vehiclesDB.Close()
+
+	if err = gateway.NewMembershipRepository(vehiclesDB).ApproveMemberships(ctx, args[0]); err != nil {
+		return fmt.Errorf("error from `ApproveMemberships` method, package `gateway`: %v", err)
+	}
+	fmt.Printf("version %s is approved\n", args[0])
+	return nil
+}
+
+// currentMemberships загружает текущие функции принадлежности из источника, заданного в конфигурации
+// Входной параметр: ctx - контекст
+func currentMemberships(ctx context.Context) (map[string]map[string]fuzzy.MembershipFunction, error) {
+	var loader fuzzy.MembershipLoader
+	switch source := viper.GetString("fuzzy.memberships.source"); source {
+	case "":
+		return fuzzy.DefaultVariables().Memberships(), nil
+	case "file":
+		loader = gateway.NewMembershipFileLoader(viper.GetString("fuzzy.memberships.file"))
+	case "db":
+		vehiclesDB, err := datastore.CreateNewDBForVehicles()
+		if err != nil {
+			return nil, fmt.Errorf("error from `CreateNewDBForVehicles` function, package `datastore`: %v", err)
+		}
+		defer vehiclesDB.Close()
+		loader = gateway.NewMembershipRepository(vehiclesDB)
+	default:
+		return nil, fmt.Errorf("error, unknown membership functions source %q", source)
+	}
+
+	current, err := loader.LoadMemberships(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error from `LoadMemberships` method, package `fuzzy`: %v", err)
+	}
+	functions, err := fuzzy.BuildMemberships(current)
+	if err != nil {
+		return nil, fmt.Errorf("error from `BuildMemberships` function, package `fuzzy`: %v", err)
+	}
+	return functions, nil
+}
+
+// printReport выводит подобранные функции принадлежности и точки, по которым они подобраны
+// Входной параметр: results - подобранные функции принадлежности
+func printReport(results []fuzzy.FitResult) {
+	for _, result := range results {
+		definition := result.Definition
+		fmt.Printf("%s %s: %s %v, RMSE %.4f\n", definition.Variable, definition.Term, definition.Type,
+			definition.Params, result.RMSE)
+		for _, point := range result.Points {
+			fmt.Printf("    x = %g: %.3f\n", point.X, point.Degree)
+		}
+	}
+}
+
+// printIntervalReport выводит нижние и верхние функции принадлежности второго типа
+// Входной параметр: results - подобранные интервальные функции принадлежности
+func printIntervalReport(results []fuzzy.IntervalFitResult) {
+	fmt.Println("interval type-2 functions:")
+	for _, result := range results {
+		lower, upper := result.Lower.Definition, result.Upper.Definition
+		fmt.Printf("%s %s: lower %s %v, RMSE %.4f; upper %s %v, RMSE %.4f\n", lower.Variable, lower.Term,
+			lower.Type, lower.Params, result.Lower.RMSE, upper.Type, upper.Params, result.Upper.RMSE)
+		for idx, point := range result.Lower.Points {
+			fmt.Printf("    x = %g: [%.3f, %.3f]\n", point.X, point.Degree, result.Upper.Points[idx].Degree)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+// Утилита fuzzydiff сравнивает две версии нечеткого алгоритма на автомобилях эталона и выводит изменившиеся
+// коэффициенты, значения функций принадлежности и места автомобилей в рейтингах. Версия нечеткого алгоритма - это
+// эталон, записанный командой snapshot, или нечеткий алгоритм, заданный флагами: каталогом правил, шаблоном правил,
+// файлами функций принадлежности и формул коэффициентов, методом дефаззификации и операторами.
+//
+// Использование (из каталога cmd):
+//
+//	fuzzydiff snapshot -out <файл> [флаги нечеткого алгоритма]
+//	                          записывает эталон нечеткого алгоритма, заданного флагами
+//	fuzzydiff diff [флаги нечеткого алгоритма] <исходный эталон> [<новый эталон>]
+//	                          сравнивает исходный эталон с новым или с нечетким алгоритмом, заданным флагами;
+//	                          завершается с кодом 1, если есть различия
+//
+// Например, чтобы увидеть, как изменение коэффициентов переставляет автомобили, эталон записывается до изменения
+// (go run ./fuzzydiff snapshot -out /tmp/before.json), а после изменения выполняется
+// go run ./fuzzydiff diff /tmp/before.json
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"vehicles/packages/adapters/gateway"
+	"vehicles/packages/domain/fuzzy"
+)
+
+// defaultCarsFile - автомобили эталона, который проверяется тестами пакета fuzzy
+const defaultCarsFile = "../packages/domain/fuzzy/testdata/golden/cars.json"
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("fuzzydiff: ")
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "snapshot":
+		err = snapshot(context.Background(), os.Args[2:])
+	case "diff":
+		err = diff(context.Background(), os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatalf("%s", err.Error())
+	}
+}
+
+// usage выводит справку и завершает работу
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: fuzzydiff snapshot -out <file> [engine flags]")
+	fmt.Fprintln(os.Stderr, "       fuzzydiff diff [engine flags] <base snapshot> [<candidate snapshot>]")
+	fmt.Fprintln(os.Stderr, "engine flags: [-cars <file>] [-rules <dir>] [-template <name>] [-memberships <file>]")
+	fmt.Fprintln(os.Stderr, "              [-formulas <file>] [-defuzzifier <name>] [-tnorm <name>] [-snorm <name>]")
+	fmt.Fprintln(os.Stderr, "              [-implication <name>]")
+	os.Exit(2)
+}
+
+// engineFlags - флаги, задающие версию нечеткого алгоритма
+type engineFlags struct {
+	cars        *string
+	rules       *string
+	template    *string
+	memberships *string
+	formulas    *string
+	defuzzifier *string
+	tNorm       *string
+	sNorm       *string
+	implication *string
+}
+
+// addEngineFlags добавляет флаги, задающие версию нечеткого алгоритма
+// Входной параметр: flags - флаги команды
+func addEngineFlags(flags *flag.FlagSet) engineFlags {
+	return engineFlags{
+		cars:        flags.String("cars", defaultCarsFile, "JSON file with cars"),
+		rules:       flags.String("rules", "", "directory with priorities.txt and rules/*_rules.txt (embedded rules if empty)"),
+		template:    flags.String("template", "", "rule template used instead of rule files: lexicographic, linear"),
+		memberships: flags.String("memberships", "", "YAML file with membership functions (default functions if empty)"),
+		formulas:    flags.String("formulas", "", "YAML file with coefficient formulas (default formulas if empty)"),
+		defuzzifier: flags.String("defuzzifier", "", "defuzzification method (numerical_centroid if empty)"),
+		tNorm:       flags.String("tnorm", "", "t-norm (min if empty)"),
+		sNorm:       flags.String("snorm", "", "s-norm (max if empty)"),
+		implication: flags.String("implication", "", "implication operator (mamdani if empty)"),
+	}
+}
+
+// version возвращает описание версии нечеткого алгоритма по флагам
+func (efl engineFlags) version() string {
+	parts := []string{"cars=" + *efl.cars}
+	for _, flagValue := range []struct{ name, value string }{
+		{"rules", *efl.rules}, {"template", *efl.template}, {"memberships", *efl.memberships}, {"formulas", *efl.formulas},
+		{"defuzzifier", *efl.defuzzifier}, {"tnorm", *efl.tNorm}, {"snorm", *efl.sNorm}, {"implication", *efl.implication},
+	} {
+		if flagValue.value != "" {
+			parts = append(parts, flagValue.name+"="+flagValue.value)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// takeSnapshot создает нечеткий алгоритм, заданный флагами, и получает его эталон на автомобилях из файла
+// Входные параметры: ctx - контекст, efl - флаги нечеткого алгоритма
+func (efl engineFlags) takeSnapshot(ctx context.Context) (fuzzy.Snapshot, error) {
+	cars, err := fuzzy.ReadGoldenCars(*efl.cars)
+	if err != nil {
+		return fuzzy.Snapshot{}, fmt.Errorf("error from `ReadGoldenCars` function, package `fuzzy`: %v", err)
+	}
+
+	// правила для расстановок, которых нет в файлах, строятся так же, как на сервере с настройкой
+	// fuzzy.generate_missing_rules
+	variables := fuzzy.DefaultVariables()
+	generator := fuzzy.NewRuleGenerator(fuzzy.LexicographicTemplate{}, variables)
+	var rules fuzzy.RuleSource
+	switch {
+	case *efl.template != "":
+		template, err := fuzzy.NewRuleTemplate(*efl.template)
+		if err != nil {
+			return fuzzy.Snapshot{}, fmt.Errorf("error from `NewRuleTemplate` function, package `fuzzy`: %v", err)
+		}
+		rules = fuzzy.NewRuleGenerator(template, variables)
+	case *efl.rules != "":
+		rules, err = fuzzy.LoadRuleIndex(os.DirFS(*efl.rules), variables, generator)
+	default:
+		rules, err = fuzzy.LoadEmbeddedRuleIndex(variables, generator)
+	}
+	if err != nil {
+		return fuzzy.Snapshot{}, fmt.Errorf("error from `LoadRuleIndex` function, package `fuzzy`: %v", err)
+	}
+
+	engine := fuzzy.NewEngine(rules, variables)
+	if *efl.memberships != "" {
+		config, err := gateway.NewMembershipFileLoader(*efl.memberships).LoadMemberships(ctx)
+		if err != nil {
+			return fuzzy.Snapshot{}, fmt.Errorf("error from `LoadMemberships` method, package `gateway`: %v", err)
+		}
+		functions, err := fuzzy.BuildMemberships(config)
+		if err != nil {
+			return fuzzy.Snapshot{}, fmt.Errorf("error from `BuildMemberships` function, package `fuzzy`: %v", err)
+		}
+		engine.Memberships = fuzzy.NewMembershipTable(config.Version, functions)
+	}
+	if *efl.formulas != "" {
+		config, err := gateway.NewFormulaFileLoader(*efl.formulas).LoadFormulas(ctx)
+		if err != nil {
+			return fuzzy.Snapshot{}, fmt.Errorf("error from `LoadFormulas` method, package `gateway`: %v", err)
+		}
+		if err = engine.Formulas.Update(config); err != nil {
+			return fuzzy.Snapshot{}, fmt.Errorf("error from `Update` method, package `fuzzy`: %v", err)
+		}
+	}
+	if *efl.defuzzifier != "" {
+		if engine.Defuzzifier, err = fuzzy.NewDefuzzifier(*efl.defuzzifier); err != nil {
+			return fuzzy.Snapshot{}, fmt.Errorf("error from `NewDefuzzifier` function, package `fuzzy`: %v", err)
+		}
+	}
+	if *efl.tNorm != "" {
+		if engine.TNorm, err = fuzzy.NewTNorm(*efl.tNorm); err != nil {
+			return fuzzy.Snapshot{}, fmt.Errorf("error from `NewTNorm` function, package `fuzzy`: %v", err)
+		}
+	}
+	if *efl.sNorm != "" {
+		if engine.SNorm, err = fuzzy.NewSNorm(*efl.sNorm); err != nil {
+			return fuzzy.Snapshot{}, fmt.Errorf("error from `NewSNorm` function, package `fuzzy`: %v", err)
+		}
+	}
+	if *efl.implication != "" {
+		if engine.Implication, err = fuzzy.NewImplication(*efl.implication); err != nil {
+			return fuzzy.Snapshot{}, fmt.Errorf("error from `NewImplication` function, package `fuzzy`: %v", err)
+		}
+	}
+
+	snapshot, err := fuzzy.TakeSnapshot(ctx, engine, efl.version(), cars, fuzzy.GoldenOrderings())
+	if err != nil {
+		return fuzzy.Snapshot{}, fmt.Errorf("error from `TakeSnapshot` function, package `fuzzy`: %v", err)
+	}
+	return snapshot, nil
+}
+
+// snapshot записывает эталон нечеткого алгоритма, заданного флагами
+// Входные параметры: ctx - контекст, args - аргументы команды
+func snapshot(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	out := flags.String("out", "", "JSON file for the snapshot")
+	engine := addEngineFlags(flags)
+	flags.Parse(args)
+	if *out == "" || flags.NArg() != 0 {
+		usage()
+	}
+
+	taken, err := engine.takeSnapshot(ctx)
+	if err != nil {
+		return err
+	}
+	if err = fuzzy.WriteSnapshot(*out, taken); err != nil {
+		return fmt.Errorf("error from `WriteSnapshot` function, package `fuzzy`: %v", err)
+	}
+	fmt.Printf("snapshot of %d cars and %d rankings is written to %s\n", len(taken.Cars), len(taken.Rankings), *out)
+	return nil
+}
+
+// diff сравнивает исходный эталон с новым эталоном или с нечетким алгоритмом, заданным флагами
+// Входные параметры: ctx - контекст, args - аргументы команды
+func diff(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	engine := addEngineFlags(flags)
+	tolerance := flags.Float64("tolerance", fuzzy.DefaultGoldenTolerance, "ignore smaller differences of values")
+	flags.Parse(args)
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		usage()
+	}
+
+	base, err := fuzzy.ReadSnapshot(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("error from `ReadSnapshot` function, package `fuzzy`: %v", err)
+	}
+	var candidate fuzzy.Snapshot
+	if flags.NArg() == 2 {
+		candidate, err = fuzzy.ReadSnapshot(flags.Arg(1))
+		if err != nil {
+			return fmt.Errorf("error from `ReadSnapshot` function, package `fuzzy`: %v", err)
+		}
+	} else if candidate, err = engine.takeSnapshot(ctx); err != nil {
+		return err
+	}
+
+	fmt.Printf("base:      %s\ncandidate: %s\n", base.Version, candidate.Version)
+	snapshotDiff := fuzzy.CompareSnapshots(base, candidate, *tolerance)
+	printDiff(snapshotDiff, len(base.Rankings))
+	if !snapshotDiff.Empty() {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// printDiff выводит различия эталонов
+// Входные параметры: snapshotDiff - различия эталонов, numberOfRankings - количество рейтингов исходного эталона
+func printDiff(snapshotDiff fuzzy.SnapshotDiff, numberOfRankings int) {
+	if snapshotDiff.Empty() {
+		fmt.Println("no differences")
+		return
+	}
+
+	for _, change := range snapshotDiff.Coefficients {
+		fmt.Printf("coefficient %s, %s: %.4f -> %.4f\n", change.FullName, change.Variable, change.Base, change.Candidate)
+	}
+	for _, change := range snapshotDiff.Memberships {
+		fmt.Printf("membership %s, %s %s: %.4f -> %.4f\n", change.FullName, change.Variable, change.Term,
+			change.Base, change.Candidate)
+	}
+
+	var reordered int
+	for _, ranking := range snapshotDiff.Rankings {
+		fmt.Printf("priorities %s: tau %.3f\n", strings.Join(ranking.Priorities, " "), ranking.Tau)
+		for _, change := range ranking.Changes {
+			fmt.Printf("    %-60s %2s -> %-2s %8.4f -> %.4f\n", change.FullName, rank(change.BaseRank),
+				rank(change.CandidateRank), change.BaseValue, change.CandidateValue)
+		}
+		if ranking.Tau < 1 {
+			reordered++
+		}
+	}
+	for _, missing := range snapshotDiff.Missing {
+		fmt.Println(missing)
+	}
+	fmt.Printf("%d coefficients, %d memberships changed; %d of %d rankings differ, %d reordered\n",
+		len(snapshotDiff.Coefficients), len(snapshotDiff.Memberships), len(snapshotDiff.Rankings), numberOfRankings, reordered)
+}
+
+// rank возвращает место в рейтинге или "-", если автомобиля нет в рейтинге
+func rank(place int) string {
+	if place == 0 {
+		return "-"
+	}
+	return fmt.Sprint(place)
+}
@@ -0,0 +1,164 @@
+// Утилита learnrules подбирает рекомендации нечетких правил по отзывам пользователей (открытым страницам
+// автомобилей, добавлениям в избранное и отказам) и сравнивает варианты A/B-теста баз правил.
+// Новая версия базы правил записывается в отдельный каталог и подключается как вариант A/B-теста
+// параметрами fuzzy.experiment.
+//
+// Использование (из каталога cmd, где находится каталог config):
+//
+//	learnrules learn -out <каталог> [-since <длительность>] [-rules <каталог>] [-memberships <файл>]
+//	                          обучает рекомендации и записывает priorities.txt и rules/*_rules.txt
+//	learnrules report [-since <длительность>]
+//	                          выводит показатели вариантов A/B-теста
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"vehicles/config"
+	"vehicles/packages/adapters/gateway"
+	"vehicles/packages/domain/fuzzy"
+	"vehicles/packages/infrastructure/datastore"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("learnrules: ")
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	if err := config.Init(); err != nil {
+		log.Fatalf("%s", err.Error())
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "learn":
+		err = learn(context.Background(), os.Args[2:])
+	case "report":
+		err = report(context.Background(), os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatalf("%s", err.Error())
+	}
+}
+
+// usage выводит справку и завершает работу
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: learnrules learn -out <dir> [-since <duration>] [-rules <dir>] [-memberships <file>]")
+	fmt.Fprintln(os.Stderr, "       learnrules report [-since <duration>]")
+	os.Exit(2)
+}
+
+// learn подбирает рекомендации нечетких правил по отзывам пользователей и записывает новую версию базы правил
+// Входные параметры: ctx - контекст, args - аргументы команды
+func learn(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("learn", flag.ExitOnError)
+	out := flags.String("out", "", "directory for priorities.txt and rules/*_rules.txt of the new version")
+	since := flags.Duration("since", 30*24*time.Hour, "use feedback of this period")
+	rulesDir := flags.String("rules", "", "directory of the current rule base (embedded rules if empty)")
+	membershipsFile := flags.String("memberships", "", "YAML file with membership functions (default functions if empty)")
+	flags.Parse(args)
+	if *out == "" || flags.NArg() != 0 {
+		usage()
+	}
+
+	events, err := loadFeedback(ctx, *since)
+	if err != nil {
+		return err
+	}
+
+	var ruleSets []fuzzy.RuleSet
+	if *rulesDir == "" {
+		ruleSets, err = fuzzy.ReadEmbeddedRuleFiles()
+	} else {
+		ruleSets, err = fuzzy.ReadRuleFiles(os.DirFS(*rulesDir))
+	}
+	if err != nil {
+		return fmt.Errorf("error from `ReadRuleFiles` function, package `fuzzy`: %v", err)
+	}
+	variables := fuzzy.DefaultVariables()
+	if errs := variables.ValidateRuleBase(ruleSets); len(errs) > 0 {
+		return fmt.Errorf("error from `ValidateRuleBase` method, package `fuzzy`: %v (%d errors in total)", errs[0], len(errs))
+	}
+
+	// правила для расстановок, которых нет в базе, строятся так же, как в нечетком алгоритме
+	engine := fuzzy.NewEngine(fuzzy.NewRuleGenerator(fuzzy.LexicographicTemplate{}, variables), variables)
+	if *membershipsFile != "" {
+		current, err := gateway.NewMembershipFileLoader(*membershipsFile).LoadMemberships(ctx)
+		if err != nil {
+			return fmt.Errorf("error from `LoadMemberships` method, package `gateway`: %v", err)
+		}
+		functions, err := fuzzy.BuildMemberships(current)
+		if err != nil {
+			return fmt.Errorf("error from `BuildMemberships` function, package `fuzzy`: %v", err)
+		}
+		engine.Memberships = fuzzy.NewMembershipTable(current.Version, functions)
+	}
+
+	learned, learningReport, err := fuzzy.NewConsequentLearner(engine).Learn(ruleSets, events)
+	if err != nil {
+		return fmt.Errorf("error from `Learn` method, package `fuzzy`: %v", err)
+	}
+	fmt.Printf("%d of %d feedback events are used, %d rules are changed\n", learningReport.UsedEvents,
+		learningReport.Events, len(learningReport.Changes))
+	for _, change := range learningReport.Changes {
+		fmt.Printf("%s, rule %d: %d -> %d (support %.2f, reward %+.2f)\n", strings.Join(change.Priorities, " "),
+			change.Rule, change.Before, change.After, change.Support, change.Reward)
+	}
+
+	if err = fuzzy.WriteRuleFiles(*out, learned); err != nil {
+		return fmt.Errorf("error from `WriteRuleFiles` function, package `fuzzy`: %v", err)
+	}
+	fmt.Printf("%d rule sets are written to %s, test them with fuzzy.experiment.candidate_dir\n", len(learned), *out)
+	return nil
+}
+
+// report выводит показатели вариантов A/B-теста по отзывам пользователей
+// Входные параметры: ctx - контекст, args - аргументы команды
+func report(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	since := flags.Duration("since", 30*24*time.Hour, "use feedback of this period")
+	flags.Parse(args)
+
+	events, err := loadFeedback(ctx, *since)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-20s %-10s %8s %6s %10s %9s %8s %8s %6s\n", "experiment", "variant", "sessions", "opens",
+		"favourites", "dismisses", "fav/ses", "dis/ses", "MRR")
+	for _, stats := range fuzzy.CompareVariants(events) {
+		experiment, variant := stats.Experiment, stats.Variant
+		if experiment == "" {
+			experiment, variant = "-", "-"
+		}
+		fmt.Printf("%-20s %-10s %8d %6d %10d %9d %8.2f %8.2f %6.3f\n", experiment, variant, stats.Sessions,
+			stats.Opens, stats.Favourites, stats.Dismisses, stats.FavouritesPerSession, stats.DismissesPerSession,
+			stats.MeanReciprocalRank)
+	}
+	return nil
+}
+
+// loadFeedback получает из таблицы feedback_events отзывы пользователей за заданный период
+// Входные параметры: ctx - контекст, since - период
+func loadFeedback(ctx context.Context, since time.Duration) ([]fuzzy.FeedbackEvent, error) {
+	vehiclesDB, err := datastore.CreateNewDBForVehicles()
+	if err != nil {
+		return nil, fmt.Errorf("error from `CreateNewDBForVehicles` function, package `datastore`: %v", err)
+	}
+	defer vehiclesDB.Close()
+
+	events, err := gateway.NewFeedbackRepository(vehiclesDB).LoadFeedback(ctx, time.Now().Add(-since))
+	if err != nil {
+		return nil, fmt.Errorf("error from `LoadFeedback` method, package `gateway`: %v", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,311 @@
+// Утилита rulec проверяет и компилирует описание нечетких правил на языке "IF ... AND ... THEN recommendation IS n"
+// в файл priorities.txt и файлы rules/*_rules.txt, которые использует нечеткий алгоритм.
+//
+// Использование:
+//
+//	rulec check <источник>             проверяет покрытие, противоречия и недостижимые правила
+//	rulec build -out <каталог> <источник>  проверяет правила и записывает priorities.txt и rules/*_rules.txt
+//	rulec import -out <файл> <каталог>     переводит priorities.txt и rules/*_rules.txt в описание на языке правил
+//	rulec generate [-template <шаблон>] [-variables <список>] [-max <k>] [-scales <список>] [-out <каталог>] [приоритеты]
+//	                                       строит правила по монотонному шаблону (lexicographic, linear) для
+//	                                       указанных приоритетов или для всех расстановок от 1 до k нечетких
+//	                                       множеств из списка и записывает priorities.txt и rules/*_rules.txt
+//	                                       или, если каталог не задан, выводит описание на языке правил
+//
+// Источник - файл с описанием правил или каталог с файлами *.rules, которые читаются в порядке имен.
+// Шкалы задаются как "экономичность=5,динамика=7"
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"vehicles/packages/domain/fuzzy"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("rulec: ")
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "check":
+		err = check(os.Args[2:])
+	case "build":
+		err = build(os.Args[2:])
+	case "import":
+		err = importRuleFiles(os.Args[2:])
+	case "generate":
+		err = generate(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatalf("%s", err.Error())
+	}
+}
+
+// usage выводит справку и завершает работу
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: rulec check <source>")
+	fmt.Fprintln(os.Stderr, "       rulec build -out <dir> <source>")
+	fmt.Fprintln(os.Stderr, "       rulec import -out <file> <dir>")
+	fmt.Fprintln(os.Stderr, "       rulec generate [-template <name>] [-variables <list>] [-max <k>] [-scales <list>] [-out <dir>] [priorities]")
+	os.Exit(2)
+}
+
+// check проверяет описание нечетких правил
+// Входной параметр: args - аргументы команды
+func check(args []string) error {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		usage()
+	}
+
+	ruleSets, err := readAndValidate(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d rule sets are valid\n", len(ruleSets))
+	return nil
+}
+
+// build проверяет описание нечетких правил и записывает файлы priorities.txt и rules/*_rules.txt
+// Входной параметр: args - аргументы команды
+func build(args []string) error {
+	flags := flag.NewFlagSet("build", flag.ExitOnError)
+	out := flags.String("out", ".", "directory for priorities.txt and rules/*_rules.txt")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		usage()
+	}
+
+	ruleSets, err := readAndValidate(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if err = fuzzy.WriteRuleFiles(*out, ruleSets); err != nil {
+		return fmt.Errorf("error from `WriteRuleFiles` function, package `fuzzy`: %v", err)
+	}
+	fmt.Printf("%d rule sets are written to %s\n", len(ruleSets), *out)
+	return nil
+}
+
+// importRuleFiles переводит файлы priorities.txt и rules/*_rules.txt в описание на языке правил
+// Входной параметр: args - аргументы команды
+func importRuleFiles(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	out := flags.String("out", "", "output file (standard output if empty)")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		usage()
+	}
+
+	ruleSets, err := fuzzy.ReadRuleFiles(os.DirFS(flags.Arg(0)))
+	if err != nil {
+		return fmt.Errorf("error from `ReadRuleFiles` function, package `fuzzy`: %v", err)
+	}
+
+	if *out == "" {
+		return fuzzy.FormatRuleBase(os.Stdout, ruleSets)
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		return fmt.Errorf("error from `Create` function, package `os`: %v", err)
+	}
+	defer file.Close()
+	return fuzzy.FormatRuleBase(file, ruleSets)
+}
+
+// generate строит нечеткие правила по шаблону и записывает файлы priorities.txt и rules/*_rules.txt
+// или выводит описание правил
+// Входной параметр: args - аргументы команды
+func generate(args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	templateName := flags.String("template", fuzzy.LexicographicTemplateName, "rule template: lexicographic, linear")
+	variables := flags.String("variables", strings.Join([]string{fuzzy.Economy, fuzzy.Dynamics, fuzzy.Handling,
+		fuzzy.Comfort, fuzzy.Safety}, ","), "comma-separated fuzzy sets for all arrangements of priorities")
+	maxPriorities := flags.Int("max", fuzzy.MaxPriorities, "maximum number of priorities in an arrangement")
+	scales := flags.String("scales", "", "comma-separated scales, for example экономичность=5")
+	out := flags.String("out", "", "directory for priorities.txt and rules/*_rules.txt (rule language to standard output if empty)")
+	flags.Parse(args)
+
+	template, err := fuzzy.NewRuleTemplate(*templateName)
+	if err != nil {
+		return fmt.Errorf("error from `NewRuleTemplate` function, package `fuzzy`: %v", err)
+	}
+	variableSet, err := scaledVariables(*scales)
+	if err != nil {
+		return err
+	}
+
+	arrangements := [][]string{flags.Args()}
+	if flags.NArg() == 0 {
+		arrangements = allArrangements(strings.Split(*variables, ","), *maxPriorities)
+	}
+
+	ruleSets := make([]fuzzy.RuleSet, 0, len(arrangements))
+	for _, priorities := range arrangements {
+		ruleSet, err := variableSet.GenerateRuleSet(priorities, template)
+		if err != nil {
+			return fmt.Errorf("error from `GenerateRuleSet` method, package `fuzzy`: %v", err)
+		}
+		ruleSets = append(ruleSets, ruleSet)
+	}
+
+	if *out == "" {
+		return fuzzy.FormatRuleBase(os.Stdout, ruleSets)
+	}
+	if err = fuzzy.WriteRuleFiles(*out, ruleSets); err != nil {
+		return fmt.Errorf("error from `WriteRuleFiles` function, package `fuzzy`: %v", err)
+	}
+	fmt.Printf("%d rule sets are written to %s\n", len(ruleSets), *out)
+	return nil
+}
+
+// scaledVariables возвращает нечеткие множества по умолчанию с замененными шкалами
+// Входной параметр: scales - шкалы, например, "экономичность=5,динамика=7"
+func scaledVariables(scales string) (*fuzzy.VariableSet, error) {
+	variableSet := fuzzy.DefaultVariables()
+	if scales == "" {
+		return variableSet, nil
+	}
+	for _, scale := range strings.Split(scales, ",") {
+		variable, value, ok := strings.Cut(scale, "=")
+		if !ok {
+			return nil, fmt.Errorf("error, malformed scale %q, expected <fuzzy set>=<number of terms>", scale)
+		}
+		numberOfTerms, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("error from `Atoi` function, package `strconv`: %v", err)
+		}
+		if variableSet, err = variableSet.WithTermScale(variable, numberOfTerms); err != nil {
+			return nil, fmt.Errorf("error from `WithTermScale` method, package `fuzzy`: %v", err)
+		}
+	}
+	return variableSet, nil
+}
+
+// allArrangements возвращает все расстановки от 1 до maxPriorities нечетких множеств в том же порядке,
+// что и в файле priorities.txt: по количеству приоритетов, затем по сочетаниям и по перестановкам внутри сочетания
+// Входные параметры: variables - нечеткие множества, maxPriorities - наибольшее количество приоритетов
+func allArrangements(variables []string, maxPriorities int) [][]string {
+	var arrangements [][]string
+	for size := 1; size <= maxPriorities && size <= len(variables); size++ {
+		for _, combination := range combinations(len(variables), size) {
+			for _, permutation := range permutations(combination) {
+				arrangement := make([]string, 0, size)
+				for _, idx := range permutation {
+					arrangement = append(arrangement, variables[idx])
+				}
+				arrangements = append(arrangements, arrangement)
+			}
+		}
+	}
+	return arrangements
+}
+
+// combinations возвращает все сочетания из n по k в лексикографическом порядке
+// Входные параметры: n - количество элементов, k - размер сочетания
+func combinations(n, k int) [][]int {
+	if k == 0 {
+		return [][]int{{}}
+	}
+	var result [][]int
+	for last := k - 1; last < n; last++ {
+		for _, prefix := range combinations(last, k-1) {
+			result = append(result, append(prefix, last))
+		}
+	}
+	sort.Slice(result, func(idx, jdx int) bool {
+		for pos := range result[idx] {
+			if result[idx][pos] != result[jdx][pos] {
+				return result[idx][pos] < result[jdx][pos]
+			}
+		}
+		return false
+	})
+	return result
+}
+
+// permutations возвращает все перестановки элементов в лексикографическом порядке их позиций
+// Входной параметр: items - элементы
+func permutations(items []int) [][]int {
+	if len(items) <= 1 {
+		return [][]int{append([]int(nil), items...)}
+	}
+	var result [][]int
+	for idx, item := range items {
+		rest := make([]int, 0, len(items)-1)
+		rest = append(append(rest, items[:idx]...), items[idx+1:]...)
+		for _, permutation := range permutations(rest) {
+			result = append(result, append([]int{item}, permutation...))
+		}
+	}
+	return result
+}
+
+// readAndValidate читает описание нечетких правил и выводит все найденные ошибки
+// Входной параметр: source - файл с описанием правил или каталог с файлами *.rules
+func readAndValidate(source string) ([]fuzzy.RuleSet, error) {
+	fileNames, err := sourceFiles(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var ruleSets []fuzzy.RuleSet
+	for _, fileName := range fileNames {
+		file, err := os.Open(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("error from `Open` function, package `os`: %v", err)
+		}
+		parsed, err := fuzzy.ParseRuleBase(fileName, file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		ruleSets = append(ruleSets, parsed...)
+	}
+
+	errs := fuzzy.DefaultVariables().ValidateRuleBase(ruleSets)
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%d errors found", len(errs))
+	}
+	return ruleSets, nil
+}
+
+// sourceFiles возвращает файлы с описанием правил
+// Входной параметр: source - файл с описанием правил или каталог с файлами *.rules
+func sourceFiles(source string) ([]string, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, fmt.Errorf("error from `Stat` function, package `os`: %v", err)
+	}
+	if !info.IsDir() {
+		return []string{source}, nil
+	}
+
+	fileNames, err := filepath.Glob(filepath.Join(source, "*.rules"))
+	if err != nil {
+		return nil, fmt.Errorf("error from `Glob` function, package `filepath`: %v", err)
+	}
+	if len(fileNames) == 0 {
+		return nil, fmt.Errorf("error, there are no *.rules files in %s", source)
+	}
+	sort.Strings(fileNames)
+	return fileNames, nil
+}
@@ -0,0 +1,81 @@
+// Утилита trimcache удаляет записи из кэша характеристик страниц комплектаций и поколений, общего для обычного
+// поиска и подбора. Удаленные страницы загружаются с портала заново при следующем сборе автомобилей.
+//
+// Использование:
+//
+//	trimcache invalidate <ссылка>...  удаляет характеристики страниц комплектаций или все варианты выбора
+//	                                  комплектации на страницах поколений
+//	trimcache clear                   удаляет характеристики всех страниц
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+	"vehicles/packages/adapters/gateway"
+	"vehicles/packages/infrastructure/datastore"
+	"vehicles/packages/usecases/repository"
+)
+
+// timeout - наибольшее время работы утилиты
+const timeout = time.Minute
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("trimcache: ")
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	trims := gateway.NewTrimCache(datastore.CreateNewTrimCacheRDB(), 0)
+
+	var err error
+	switch os.Args[1] {
+	case "invalidate":
+		if len(os.Args) < 3 {
+			usage()
+		}
+		err = invalidate(ctx, trims, os.Args[2:])
+	case "clear":
+		err = clearCache(ctx, trims)
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatalf("%s", err.Error())
+	}
+}
+
+// usage выводит справку и завершает работу
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: trimcache invalidate <link>...")
+	fmt.Fprintln(os.Stderr, "       trimcache clear")
+	os.Exit(2)
+}
+
+// invalidate удаляет из кэша характеристики страниц
+// Входные параметры: ctx - контекст, trims - кэш, links - ссылки на страницы комплектаций или поколений
+func invalidate(ctx context.Context, trims repository.TrimCache, links []string) error {
+	for _, link := range links {
+		if err := trims.InvalidateTrim(ctx, link); err != nil {
+			return fmt.Errorf("error from `InvalidateTrim` method, package `gateway`: %#v", err)
+		}
+		fmt.Printf("invalidated %s\n", link)
+	}
+	return nil
+}
+
+// clearCache удаляет из кэша характеристики всех страниц
+// Входные параметры: ctx - контекст, trims - кэш
+func clearCache(ctx context.Context, trims repository.TrimCache) error {
+	deleted, err := trims.Clear(ctx)
+	if err != nil {
+		return fmt.Errorf("error from `Clear` method, package `gateway`: %#v", err)
+	}
+	fmt.Printf("deleted %d entries\n", deleted)
+	return nil
+}
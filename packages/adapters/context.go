@@ -1,6 +1,11 @@
 package adapters
 
+import "context"
+
+// Context - контекст HTTP-запроса. Он также является context.Context, который отменяется,
+// когда клиент отключается
 type Context interface {
+	context.Context
 	Bind(i interface{}) error
 	BindJSON(i interface{}) error
 	HTML(code int, fileName string, i interface{})
@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"fmt"
+	"vehicles/packages/adapters"
+	"vehicles/packages/domain/models"
+	usecase "vehicles/packages/usecases/usecases"
+)
+
+type jobController struct {
+	ctx  adapters.Context
+	jobs usecase.JobQueue
+}
+
+// Job содержит методы, которые обслуживают задания сбора и ранжирования автомобилей, выполняемые в фоне
+type Job interface {
+	ShowJob(sessionID string) (models.Job, bool, error)
+	CancelJob(sessionID string) error
+}
+
+func NewJobController(ctx adapters.Context, jobs usecase.JobQueue) Job {
+	return &jobController{ctx, jobs}
+}
+
+// ShowJob ответственен за получение состояния задания сессии: состояния, количества загруженных страниц
+// и собранных автомобилей. Если задания нет, возвращается false
+// Входной параметр: sessionID - идентификатор сессии
+func (jbc *jobController) ShowJob(sessionID string) (models.Job, bool, error) {
+	job, ok, err := jbc.jobs.GetJob(jbc.ctx, sessionID)
+	if err != nil {
+		return models.Job{}, false, fmt.Errorf("error from `GetJob` method, package `usecase`: %#v", err)
+	}
+	return job, ok, nil
+}
+
+// CancelJob ответственен за отмену незавершенного задания сессии
+// Входной параметр: sessionID - идентификатор сессии
+func (jbc *jobController) CancelJob(sessionID string) error {
+	if err := jbc.jobs.CancelJob(jbc.ctx, sessionID); err != nil {
+		return fmt.Errorf("error from `CancelJob` method, package `usecase`: %#v", err)
+	}
+	return nil
+}
@@ -2,7 +2,11 @@ package controller
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"vehicles/packages/adapters"
+	"vehicles/packages/domain/fuzzy"
+	"vehicles/packages/domain/models"
 	usecase "vehicles/packages/usecases/usecases"
 )
 
@@ -20,11 +24,18 @@ type Selection interface {
 	PutPrice() error
 	ChooseManufacturers()
 	PutManufacturers() error
+	ChooseConstraints()
+	PutConstraints() error
 	ChooseSource()
 	GetSelectionFromDBCars() error
 	GetSelectionFromInternetCars() error
 	DisplaySelectionCarAd(sessionID string, carID int, choice bool) error
+	PutFeedback() error
 	TransferSelectionCarsData(sessionID string, choice bool) error
+	ShowSensitivity(sessionID string, choice bool) error
+	GetSensitivity() error
+	ShowParetoFronts(sessionID string, choice bool) error
+	GetParetoFronts() error
 }
 
 func NewSelectionController(ctx adapters.Context, slu usecase.SelectionInput) Selection {
@@ -32,17 +43,19 @@ func NewSelectionController(ctx adapters.Context, slu usecase.SelectionInput) Se
 }
 
 // ChoosePriorities ответственен за формирование веб-страницы, предлагающей пользователю
-// расставить приоритеты: "Комфорт", "Экономичность", "Безопасность", "Динамика", "Управляемость", по
-// которым будут ранжироваться автомобили
+// расставить приоритеты, по которым будут ранжироваться автомобили: "Комфорт", "Экономичность", "Безопасность",
+// "Динамика", "Управляемость" и другие нечеткие множества нечеткого алгоритма
 func (slc *selectionController) ChoosePriorities() {
 	slc.selectionUseCase.PickPriorities()
 }
 
-// PutPriorities ответственен за сбор приоритетов, расставленных пользователем, и сохранение их в cookie
+// PutPriorities ответственен за сбор приоритетов, расставленных пользователем, или весов приоритетов,
+// например, {"экономичность": 70, "динамика": 30}, и сохранение их в cookie
 func (slc *selectionController) PutPriorities() error {
 	type priorities struct {
-		Priorities []string `json:"priorities"`
-		SessionID  string   `json:"sessionID"`
+		Priorities []string           `json:"priorities"`
+		Weights    map[string]float64 `json:"weights"`
+		SessionID  string             `json:"sessionID"`
 	}
 
 	prs := new(priorities)
@@ -51,7 +64,9 @@ func (slc *selectionController) PutPriorities() error {
 		return fmt.Errorf("error from `BindJSON` method, package `gin`: %#v", err)
 	}
 
-	slc.selectionUseCase.SelectPriorities(prs.SessionID, prs.Priorities)
+	if err = slc.selectionUseCase.SelectPriorities(prs.SessionID, prs.Priorities, prs.Weights); err != nil {
+		return fmt.Errorf("error from `SelectPriorities` method, package `usecase`: %#v", err)
+	}
 	return nil
 }
 
@@ -100,6 +115,25 @@ func (slc *selectionController) PutManufacturers() error {
 	return nil
 }
 
+// ChooseConstraints ответственен за формирование веб-страницы, предлагающей пользователю задать
+// жесткие ограничения и обязательные опции
+func (slc *selectionController) ChooseConstraints() {
+	slc.selectionUseCase.PickConstraints()
+}
+
+// PutConstraints ответственен за сбор жестких ограничений, заданных пользователем, и их сохранение в cookie
+func (slc *selectionController) PutConstraints() error {
+	constraints := new(models.Constraints)
+	if err := slc.ctx.BindJSON(constraints); err != nil {
+		return fmt.Errorf("error from `BindJSON` method, package `gin`: %#v", err)
+	}
+
+	if err := slc.selectionUseCase.SelectConstraints(*constraints); err != nil {
+		return fmt.Errorf("error from `SelectConstraints` method, package `usecase`: %#v", err)
+	}
+	return nil
+}
+
 // ChooseSource ответственен за формирование веб-страницы, предлагающей пользователю выбрать,
 // из какого источника: базы данных или интернета он хочет получить ранжированный по его предпочтениям
 // список автомобилей
@@ -156,3 +190,117 @@ func (slc *selectionController) DisplaySelectionCarAd(sessionID string, carID in
 	}
 	return nil
 }
+
+// PutFeedback ответственен за сохранение отзыва пользователя об автомобиле из рейтинга, например,
+// {"sessionID": "...", "carID": 3, "action": "favourite"}; действие "favourite" или "dismiss"
+func (slc *selectionController) PutFeedback() error {
+	type feedback struct {
+		SessionID string `json:"sessionID"`
+		CarID     int    `json:"carID"`
+		Action    string `json:"action"`
+	}
+
+	fdb := new(feedback)
+	if err := slc.ctx.BindJSON(&fdb); err != nil {
+		return fmt.Errorf("error from `BindJSON` method, package `gin`: %#v", err)
+	}
+
+	if err := slc.selectionUseCase.SaveFeedback(fdb.SessionID, fdb.CarID, fdb.Action); err != nil {
+		return fmt.Errorf("error from `SaveFeedback` method, package `usecase`: %#v", err)
+	}
+	return nil
+}
+
+// ShowSensitivity ответственен за формирование веб-страницы анализа чувствительности рейтинга к приоритетам.
+// Количество первых мест и доля изменения веса задаются параметрами запроса top и delta
+// Входные параметры: sessionID - идентификатор сессии, choice - автомобили получены из интернета (true) или из БД (false)
+func (slc *selectionController) ShowSensitivity(sessionID string, choice bool) error {
+	topN, delta, err := sensitivityParams(slc.ctx.Query("top"), slc.ctx.Query("delta"))
+	if err != nil {
+		return fmt.Errorf("error from `sensitivityParams` function, package `controller`: %#v", err)
+	}
+
+	if err = slc.selectionUseCase.PresentSensitivity(sessionID, topN, delta, choice); err != nil {
+		return fmt.Errorf("error from `PresentSensitivity` method, package `usecase`: %#v", err)
+	}
+	return nil
+}
+
+// GetSensitivity ответственен за анализ чувствительности рейтинга к приоритетам или весам, переданным в теле
+// запроса, например, {"sessionID": "...", "selection": {"weights": {"динамика": 30, "комфорт": 70}}, "top": 3}
+func (slc *selectionController) GetSensitivity() error {
+	type sensitivity struct {
+		SessionID string           `json:"sessionID"`
+		Selection models.Selection `json:"selection"`
+		Top       int              `json:"top"`
+		Delta     float64          `json:"delta"`
+	}
+
+	sns := new(sensitivity)
+	if err := slc.ctx.BindJSON(&sns); err != nil {
+		return fmt.Errorf("error from `BindJSON` method, package `gin`: %#v", err)
+	}
+	if sns.Top == 0 {
+		sns.Top = fuzzy.DefaultSensitivityTopN
+	}
+	if sns.Delta == 0 {
+		sns.Delta = fuzzy.DefaultSensitivityDelta
+	}
+
+	if err := slc.selectionUseCase.ReportSensitivity(sns.SessionID, sns.Selection, sns.Top, sns.Delta); err != nil {
+		return fmt.Errorf("error from `ReportSensitivity` method, package `usecase`: %#v", err)
+	}
+	return nil
+}
+
+// sensitivityParams разбирает параметры анализа чувствительности. Пустые параметры заменяются значениями по умолчанию
+// Входные параметры: top - количество первых мест рейтинга, delta - доля изменения веса приоритета
+func sensitivityParams(top, delta string) (int, float64, error) {
+	topN, share := fuzzy.DefaultSensitivityTopN, fuzzy.DefaultSensitivityDelta
+	var err error
+	if top != "" {
+		if topN, err = strconv.Atoi(top); err != nil {
+			return 0, 0, fmt.Errorf("error from `Atoi` function, package `strconv`: %#v", err)
+		}
+	}
+	if delta != "" {
+		if share, err = strconv.ParseFloat(delta, 64); err != nil {
+			return 0, 0, fmt.Errorf("error from `ParseFloat` function, package `strconv`: %#v", err)
+		}
+	}
+	return topN, share, nil
+}
+
+// ShowParetoFronts ответственен за формирование веб-страницы с фронтами Парето. Критерии задаются параметром
+// запроса criteria через запятую, например, criteria=комфорт,безопасность; по умолчанию используются все
+// Входные параметры: sessionID - идентификатор сессии, choice - автомобили получены из интернета (true) или из БД (false)
+func (slc *selectionController) ShowParetoFronts(sessionID string, choice bool) error {
+	var criteria []string
+	if query := slc.ctx.Query("criteria"); query != "" {
+		criteria = strings.Split(query, ",")
+	}
+
+	if err := slc.selectionUseCase.PresentParetoFronts(sessionID, criteria, choice); err != nil {
+		return fmt.Errorf("error from `PresentParetoFronts` method, package `usecase`: %#v", err)
+	}
+	return nil
+}
+
+// GetParetoFronts ответственен за вычисление фронтов Парето по критериям, переданным в теле запроса, например,
+// {"sessionID": "...", "criteria": ["комфорт", "экономичность"]}
+func (slc *selectionController) GetParetoFronts() error {
+	type pareto struct {
+		SessionID string   `json:"sessionID"`
+		Criteria  []string `json:"criteria"`
+	}
+
+	prt := new(pareto)
+	if err := slc.ctx.BindJSON(&prt); err != nil {
+		return fmt.Errorf("error from `BindJSON` method, package `gin`: %#v", err)
+	}
+
+	if err := slc.selectionUseCase.ReportParetoFronts(prt.SessionID, prt.Criteria); err != nil {
+		return fmt.Errorf("error from `ReportParetoFronts` method, package `usecase`: %#v", err)
+	}
+	return nil
+}
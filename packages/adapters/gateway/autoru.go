@@ -0,0 +1,510 @@
+package gateway
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"vehicles/packages/domain/models"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+/****************************************************************************************************************************
+*   Сбор данных об автомобилях с интернет-портала объявлений auto.ru (реализация интерфейса ListingSource). Страница         *
+* объявлений содержит карточки ListingItem с названием, годом выпуска и ценой автомобиля. Страница автомобиля содержит       *
+* строки CardInfoRow (год выпуска, пробег, кузов, цвет, двигатель, коробка передач, привод, руль), описание, фотографии,      *
+* перечень опций комплектации и ссылку на страницу характеристик модификации в каталоге. Со страницы каталога собираются     *
+* размеры, масса, динамика, расход топлива, подвеска, тормоза и шины. Значения приводятся к тем же названиям, что и на       *
+* auto.drom.ru (например, "автомат" - "АКПП", "передний" - "Передний"), чтобы формулы коэффициентов их учитывали. В отличие  *
+* от auto.drom.ru, характеристика, значение которой не удалось разобрать, остается неизвестной, а не прерывает сбор данных.  *
+****************************************************************************************************************************/
+
+// autoRuMakes - названия марок на auto.ru, которые отличаются от названий марок на auto.drom.ru (см. carMakes.json)
+var autoRuMakes = map[string]string{
+	"mercedes-benz":   "mercedes",
+	"rolls-royce":     "rolls_royce",
+	"lada":            "vaz",
+	"moskvitch":       "moskvich",
+	"cheryexeed":      "exeed",
+	"li":              "lixiang",
+	"renault_samsung": "samsung",
+}
+
+// параметры поиска главной страницы (значения auto.drom.ru) и соответствующие им значения auto.ru
+var (
+	autoRuGearboxes = map[string][]string{
+		"1":  {"MECHANICAL"},
+		"AT": {"AUTOMATIC", "ROBOT", "VARIATOR"},
+		"3":  {"VARIATOR"},
+		"4":  {"ROBOT"},
+	}
+	autoRuFuels = map[string]string{
+		"1": "GASOLINE",
+		"2": "DIESEL",
+		"4": "ELECTRO",
+		"5": "HYBRID",
+	}
+	autoRuDrives = map[string]string{
+		"1": "FORWARD_CONTROL",
+		"2": "REAR_DRIVE",
+		"3": "ALL_WHEEL_DRIVE",
+	}
+)
+
+// autoRuGearboxNames - названия коробок передач на auto.ru и соответствующие им названия auto.drom.ru
+var autoRuGearboxNames = map[string]string{
+	"автомат":  "АКПП",
+	"механика": "МКПП",
+	"вариатор": "Вариатор",
+	"робот":    "Робот",
+}
+
+// autoRuNumber - шаблон регулярного выражения для числа со страниц auto.ru, например, "8,7" или "1 550"
+var autoRuNumber = regexp.MustCompile(`\d+(?:[.,]\d+)?`)
+
+type autoRuSource struct{}
+
+// Name возвращает название портала
+func (ars *autoRuSource) Name() string {
+	return AutoRuSource
+}
+
+// Charset возвращает кодировку веб-страниц портала
+func (ars *autoRuSource) Charset() string {
+	return "utf-8"
+}
+
+// SearchLink формирует ссылку на страницу объявлений auto.ru согласно параметрам обычного поиска
+// Входной параметр: search - параметры поиска пользователя, которые он вводил на главное странице в большой форме сверху
+func (ars *autoRuSource) SearchLink(search models.Search) string {
+	link := "https://auto.ru/cars/"
+	if search.Mark != "" && search.Mark != "all" {
+		link = fmt.Sprintf("%s%s/", link, autoRuMake(search.Mark))
+		if search.Model != "" {
+			link = fmt.Sprintf("%s%s/", link, search.Model)
+		}
+	}
+	if search.IsNewCar == newWord {
+		link = fmt.Sprintf("%snew/", link)
+	} else {
+		link = fmt.Sprintf("%sall/", link)
+	}
+
+	query := url.Values{}
+	setQueryValue(query, "price_from", search.LowPriceLimit)
+	setQueryValue(query, "price_to", search.HighPriceLimit)
+	setQueryValue(query, "year_from", search.EarliestYear)
+	setQueryValue(query, "year_to", search.LatestYear)
+	for _, transmission := range autoRuGearboxes[search.Gearbox] {
+		query.Add("transmission", transmission)
+	}
+	setQueryValue(query, "engine_group", autoRuFuels[search.Fuel])
+	setQueryValue(query, "gear_type", autoRuDrives[search.Drive])
+	query.Set("output_type", "list")
+	return fmt.Sprintf("%s?%s", link, query.Encode())
+}
+
+// MakeLink формирует ссылку на страницу объявлений auto.ru об автомобилях одной марки в диапазоне цен
+// Входные параметры: minPrice  - минимальная цена, maxPrice - максимальная цена, make - название марки
+func (ars *autoRuSource) MakeLink(minPrice, maxPrice, make string) string {
+	query := url.Values{}
+	setQueryValue(query, "price_from", minPrice)
+	setQueryValue(query, "price_to", maxPrice)
+	query.Set("output_type", "list")
+	return fmt.Sprintf("https://auto.ru/cars/%s/all/?%s", autoRuMake(make), query.Encode())
+}
+
+// autoRuMake возвращает название марки на auto.ru
+// Входной параметр: make - название марки на auto.drom.ru
+func autoRuMake(make string) string {
+	if name, ok := autoRuMakes[make]; ok {
+		return name
+	}
+	return make
+}
+
+// setQueryValue добавляет в параметры ссылки непустое значение
+// Входные параметры: query - параметры ссылки, key - название параметра, value - значение
+func setQueryValue(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+// ParseListPage собирает ссылки на страницы автомобилей, а также их названия с годом выпуска и цены
+// Входные параметры: document - страница объявлений, quantity - количество автомобилей для поиска
+func (ars *autoRuSource) ParseListPage(document *goquery.Document, quantity int) []Listing {
+	listings := make([]Listing, 0, quantity)
+	document.Find("div.ListingItem").EachWithBreak(func(i int, item *goquery.Selection) bool {
+		if len(listings) == quantity {
+			return false
+		}
+
+		title := item.Find("a.ListingItemTitle__link")
+		href, exists := title.Attr("href")
+		if !exists {
+			return true
+		}
+
+		// название на auto.ru не содержит года выпуска, поэтому он добавляется, как в названиях auto.drom.ru
+		name := strings.TrimSpace(title.Text())
+		if year := strings.TrimSpace(item.Find(".ListingItem__year").Text()); year != "" {
+			name = fmt.Sprintf("%s, %s", name, year)
+		}
+
+		listing := Listing{
+			Link:  href,
+			Name:  name,
+			Price: autoRuPrice(item.Find(".ListingItemPrice__content").First().Text()),
+		}
+		// ссылка на страницу автомобиля: https://auto.ru/cars/used/sale/<марка>/<модель>/<номер>/
+		if segments := linkSegments(href); len(segments) == 6 && segments[2] == "sale" {
+			listing.Make, listing.Model = segments[3], segments[4]
+		}
+		listings = append(listings, listing)
+		return true
+	})
+	return listings
+}
+
+// autoRuPrice убирает из цены знак валюты и слово "от" у новых автомобилей
+// Входной параметр: price - цена со страницы объявлений
+func autoRuPrice(price string) string {
+	price = strings.ReplaceAll(price, "\u00a0", " ")
+	price = strings.TrimPrefix(strings.TrimSpace(price), "от ")
+	return strings.TrimSpace(strings.TrimSuffix(price, "₽"))
+}
+
+// ParseCarPage собирает характеристики автомобиля с его страницы и возвращает ссылку на страницу характеристик
+// модификации в каталоге
+// Входные параметры: document - страница автомобиля, car - автомобиль
+func (ars *autoRuSource) ParseCarPage(document *goquery.Document, car *models.Car) (TrimReference, error) {
+	if description := strings.TrimSpace(document.Find(".CardDescriptionHTML").Text()); description != "" {
+		car.Description = description
+	}
+
+	document.Find("img.ImageGalleryDesktop__image").Each(func(i int, img *goquery.Selection) {
+		if src, ok := img.Attr("src"); ok {
+			if strings.HasPrefix(src, "//") {
+				src = fmt.Sprintf("https:%s", src)
+			}
+			car.Offering.PhotoURLs = append(car.Offering.PhotoURLs, src)
+		}
+	})
+
+	var err error
+	year := autoRuCardValue(document, "year")
+	if car.Offering.Year, err = strconv.Atoi(year); err != nil {
+		car.Offering.Year, err = findYearOfManufacture(car.FullName)
+		if err != nil {
+			return TrimReference{}, fmt.Errorf("error from `findYearOfManufacture` function, package `gateway`: %#v", err)
+		}
+	}
+
+	if kilometerage := autoRuCardValue(document, "kmAge"); kilometerage != "" {
+		car.Offering.Kilometerage = kilometerage
+	} else if strings.EqualFold(autoRuCardValue(document, "state"), "новый") {
+		car.Offering.Kilometerage = newCarWord
+	}
+	if body := autoRuCardValue(document, "bodytype"); body != "" {
+		car.Specs.Body = body
+	}
+	if color := autoRuCardValue(document, "color"); color != "" {
+		car.Features.Color = color
+	}
+	switch strings.ToLower(autoRuCardValue(document, "wheel")) {
+	case "левый":
+		car.Specs.SteeringWheel.SteeringWheelPosition = models.LeftPos
+	case "правый":
+		car.Specs.SteeringWheel.SteeringWheelPosition = models.RightPos
+	}
+	setAutoRuGearbox(car, autoRuCardValue(document, "transmission"))
+	if drive := autoRuCardValue(document, "drive"); drive != "" {
+		car.Specs.Drive = upperFirst(drive)
+	}
+	setAutoRuEngine(car, autoRuCardValue(document, "engine"))
+
+	document.Find(".ComplectationGroups__itemContentEl").Each(func(i int, item *goquery.Selection) {
+		setAutoRuOption(car, strings.TrimSpace(item.Text()))
+	})
+
+	var trimLink string
+	document.Find("a[href*='/catalog/cars/']").EachWithBreak(func(i int, a *goquery.Selection) bool {
+		href, _ := a.Attr("href")
+		if strings.Contains(href, "specifications") {
+			trimLink = href
+			return false
+		}
+		return true
+	})
+	return TrimReference{Link: trimLink}, nil
+}
+
+// autoRuCardValue возвращает значение строки CardInfoRow страницы автомобиля
+// Входные параметры: document - страница автомобиля, row - название строки, например, "year" или "engine"
+func autoRuCardValue(document *goquery.Document, row string) string {
+	cells := document.Find(fmt.Sprintf("li.CardInfoRow_%s .CardInfoRow__cell", row))
+	return strings.TrimSpace(strings.ReplaceAll(cells.Last().Text(), "\u00a0", " "))
+}
+
+// setAutoRuGearbox устанавливает тип трансмиссии, приводя название auto.ru к названию auto.drom.ru
+// Входные параметры: car - автомобиль, gearbox - название коробки передач на auto.ru
+func setAutoRuGearbox(car *models.Car, gearbox string) {
+	gearbox = strings.ToLower(strings.TrimSpace(gearbox))
+	if gearbox == "" {
+		return
+	}
+	if name, ok := autoRuGearboxNames[gearbox]; ok {
+		car.Specs.Gearbox = name
+		return
+	}
+	car.Specs.Gearbox = upperFirst(gearbox)
+}
+
+// setAutoRuEngine устанавливает объем, мощность и топливо двигателя из строки вида "2.5 л / 200 л.с. / Бензин"
+// Входные параметры: car - автомобиль, engine - сведения о двигателе со страницы автомобиля
+func setAutoRuEngine(car *models.Car, engine string) {
+	for _, part := range strings.Split(engine, "/") {
+		part = strings.TrimSpace(part)
+		switch {
+		case strings.HasSuffix(part, "л.с."):
+			if power, ok := parseAutoRuNumber(part); ok {
+				car.Specs.Engine.MaxPower = power
+			}
+		case strings.HasSuffix(part, " л"):
+			// объем в литрах, а в характеристиках - в кубических сантиметрах
+			if capacity, ok := parseAutoRuNumber(part); ok {
+				car.Specs.Engine.Capacity = capacity * 1000
+			}
+		case part != "":
+			car.Specs.Engine.FuelUsed = strings.ToLower(part)
+		}
+	}
+}
+
+// setAutoRuOption отмечает опцию из перечня опций комплектации на странице автомобиля
+// Входные параметры: car - автомобиль, option - название опции на auto.ru
+func setAutoRuOption(car *models.Car, option string) {
+	features := &car.Features
+	switch option {
+	case "Светодиодные фары":
+		features.Lights.Headlights = "Светодиодные фары"
+		return
+	case "Ксеноновые/Биксеноновые фары":
+		features.Lights.Headlights = "Биксеноновые фары"
+		return
+	case "Лазерные фары":
+		features.Lights.Headlights = "Лазерные фары"
+		return
+	case "Кожа (Материал салона)":
+		features.Interior.Upholstery = "Кожаная"
+		return
+	case "Ткань (Материал салона)":
+		features.Interior.Upholstery = "Тканевая"
+		return
+	case "Комбинированный (Материал салона)":
+		features.Interior.Upholstery = "Комбинированная"
+		return
+	case "Гидроусилитель руля":
+		car.Specs.SteeringWheel.PowerSteering = models.HydraulicPS
+		return
+	case "Электроусилитель руля":
+		car.Specs.SteeringWheel.PowerSteering = models.ElectricPS
+		return
+	case "Электрогидроусилитель руля":
+		car.Specs.SteeringWheel.PowerSteering = models.ElectrohydraulicPS
+		return
+	}
+
+	options := map[string]*models.Availability{
+		"Антиблокировочная система (ABS)":            &features.SafetyAndMotionControlSystem.ABS,
+		"Система стабилизации (ESP)":                 &features.SafetyAndMotionControlSystem.ESP,
+		"Распределение тормозных усилий (EBD)":       &features.SafetyAndMotionControlSystem.EBD,
+		"Система помощи при торможении (BAS)":        &features.SafetyAndMotionControlSystem.BAS,
+		"Антипробуксовочная система (ASR)":           &features.SafetyAndMotionControlSystem.TCS,
+		"Парктроник передний":                        &features.SafetyAndMotionControlSystem.FrontParkingSensor,
+		"Парктроник задний":                          &features.SafetyAndMotionControlSystem.BackParkingSensor,
+		"Камера заднего вида":                        &features.SafetyAndMotionControlSystem.RearViewCamera,
+		"Круиз-контроль":                             &features.SafetyAndMotionControlSystem.CruiseControl,
+		"Подушка безопасности водителя":              &features.Airbags.DriverAirbag,
+		"Подушка безопасности пассажира":             &features.Airbags.FrontPassengerAirbag,
+		"Подушки безопасности боковые":               &features.Airbags.SideAirbags,
+		"Подушки безопасности оконные (шторки)":      &features.Airbags.CurtainAirbags,
+		"Кондиционер":                                &features.CabinMicroclimate.AirConditioner,
+		"Климат-контроль":                            &features.CabinMicroclimate.ClimateControl,
+		"Датчик света":                               &features.Lights.LightSensor,
+		"Противотуманные фары":                       &features.Lights.FrontFogLights,
+		"Светодиодные ходовые огни":                  &features.Lights.LEDRunningLights,
+		"Светодиодные задние фонари":                 &features.Lights.LEDTailLights,
+		"Задние противотуманные фонари":              &features.Lights.BackFogLights,
+		"Датчик дождя":                               &features.ElectricOptions.RainSensor,
+		"Электростеклоподъёмники передние":           &features.ElectricOptions.ElectricFrontSideWindowsLifts,
+		"Электростеклоподъёмники задние":             &features.ElectricOptions.ElectricBackSideWindowsLifts,
+		"Подогрев передних сидений":                  &features.ElectricOptions.ElectricHeatingOfFrontSeats,
+		"Подогрев задних сидений":                    &features.ElectricOptions.ElectricHeatingOfBackSeats,
+		"Подогрев рулевого колеса":                   &features.ElectricOptions.ElectricHeatingOfSteeringWheel,
+		"Электрообогрев лобового стекла":             &features.ElectricOptions.ElectricHeatingOfWindshield,
+		"Электрообогрев заднего стекла":              &features.ElectricOptions.ElectricHeatingOfRearWindow,
+		"Электрообогрев зеркал":                      &features.ElectricOptions.ElectricHeatingOfSideMirrors,
+		"Электропривод зеркал":                       &features.ElectricOptions.ElectricDriveOfSideMirrors,
+		"Электрорегулировка сиденья водителя":        &features.ElectricOptions.ElectricDriveOfDriverSeat,
+		"Электрорегулировка передних сидений":        &features.ElectricOptions.ElectricDriveOfFrontSeats,
+		"Электропривод крышки багажника":             &features.ElectricOptions.ElectricTrunkOpener,
+		"Бортовой компьютер":                         &features.MultimediaSystems.OnBoardComputer,
+		"Мультимедиа система с поддержкой MP3 и USB": &features.MultimediaSystems.MP3Support,
+		"Bluetooth":    &features.MultimediaSystems.HandsFreeSupport,
+		"Сигнализация": &features.CarAlarm,
+	}
+	for name, availability := range options {
+		// у климат-контроля и некоторых других опций на auto.ru есть уточнения, например, "Климат-контроль 2-зонный"
+		if strings.HasPrefix(option, name) {
+			*availability = models.YesValue
+			return
+		}
+	}
+}
+
+// EnrichTrim собирает характеристики автомобиля со страницы характеристик модификации в каталоге auto.ru.
+// Страницы поколения у auto.ru нет: модификация указана в ссылке со страницы автомобиля
+// Входные параметры: document - страница характеристик, car - автомобиль, trim - ссылка на эту страницу
+func (ars *autoRuSource) EnrichTrim(document *goquery.Document, car *models.Car, trim TrimReference) (TrimReference, error) {
+	if name := strings.TrimSpace(document.Find(".CatalogHeader__complectation").Text()); name != "" {
+		car.TrimLevel = name
+	}
+
+	document.Find("dl dt").Each(func(i int, dt *goquery.Selection) {
+		label := strings.TrimSpace(dt.Text())
+		value := strings.TrimSpace(strings.ReplaceAll(dt.Next().Text(), "\u00a0", " "))
+		if value == "" || value == "—" {
+			return
+		}
+		setAutoRuSpecification(car, label, value)
+	})
+	return TrimReference{}, nil
+}
+
+// setAutoRuSpecification устанавливает характеристику автомобиля со страницы характеристик модификации
+// Входные параметры: car - автомобиль, label - название характеристики на auto.ru, value - значение
+// Цикломатическая сложность игнорируется в целях оптимизации
+//
+//gocyclo:ignore
+func setAutoRuSpecification(car *models.Car, label, value string) {
+	specs := &car.Specs
+	numbers := map[string]*float64{
+		"Длина":         &specs.Length,
+		"Ширина":        &specs.Width,
+		"Высота":        &specs.Height,
+		"Клиренс":       &specs.GroundClearance,
+		"Колёсная база": &specs.Wheelbase,
+		"Ширина передней колеи":       &specs.FrontTrackWidth,
+		"Ширина задней колеи":         &specs.BackTrackWidth,
+		"Снаряженная масса, кг":       &specs.Mass,
+		"Разгон до 100 км/ч, с":       &specs.Acceleration0To100,
+		"Максимальная скорость, км/ч": &specs.MaxSpeed,
+		"Объем двигателя, см³":        &specs.Engine.Capacity,
+		"Мощность":                    &specs.Engine.MaxPower,
+	}
+	if field, ok := numbers[label]; ok {
+		if number, ok := parseAutoRuNumber(value); ok {
+			*field = number
+		}
+		return
+	}
+
+	switch label {
+	case "Объем багажника мин/макс, л":
+		// наименьший объем багажника, как на auto.drom.ru
+		if number, ok := parseAutoRuNumber(value); ok {
+			specs.TrunkVolume = number
+		}
+	case "Расход топлива, л город/трасса/смешанный":
+		consumption := make([]float64, 0, 3)
+		for _, part := range strings.Split(value, "/") {
+			number, ok := parseAutoRuNumber(part)
+			if !ok {
+				return
+			}
+			consumption = append(consumption, number)
+		}
+		if len(consumption) == 3 {
+			specs.CityFuelConsumption, specs.HighwayFuelConsumption, specs.MixedFuelConsumption =
+				consumption[0], consumption[1], consumption[2]
+		}
+	case "Количество мест":
+		if number, ok := parseAutoRuNumber(value); ok {
+			specs.NumberOfSeats = int(number)
+		}
+	case "Тип кузова":
+		if specs.Body == "" || specs.Body == models.UndefinedStr {
+			specs.Body = value
+		}
+	case "Коробка":
+		setAutoRuGearbox(car, value)
+	case "Привод":
+		specs.Drive = upperFirst(value)
+	case "Тип двигателя":
+		specs.Engine.EngineType = value
+	case "Топливо":
+		specs.Engine.FuelUsed = strings.ToLower(value)
+	case "Максимальный крутящий момент":
+		specs.Engine.MaxTorque = value
+	case "Тип передней подвески":
+		specs.Suspension.FrontSuspension = upperFirst(value)
+	case "Тип задней подвески":
+		specs.Suspension.BackSuspension = upperFirst(value)
+	case "Передние тормоза":
+		specs.Brakes.FrontBrakes = upperFirst(value)
+	case "Задние тормоза":
+		specs.Brakes.BackBrakes = upperFirst(value)
+	case "Размер колёс":
+		setAutoRuTires(car, value)
+	}
+}
+
+// setAutoRuTires устанавливает размеры шин из строки вида "215/55/R17" или "215/55 R17 245/45 R17" (передние и задние)
+// Входные параметры: car - автомобиль, value - размер колес со страницы характеристик
+func setAutoRuTires(car *models.Car, value string) {
+	sizes := regexp.MustCompile(`(\d{3})/(\d{2})[/ ]?R(\d{2})`).FindAllStringSubmatch(value, 2)
+	if len(sizes) == 0 {
+		return
+	}
+	front, back := sizes[0], sizes[len(sizes)-1]
+	tires := &car.Specs.Tires
+	tires.FrontTiresWidth, _ = strconv.Atoi(front[1])
+	tires.FrontTiresAspectRatio, _ = strconv.Atoi(front[2])
+	tires.FrontTiresRimDiameter, _ = strconv.Atoi(front[3])
+	tires.BackTiresWidth, _ = strconv.Atoi(back[1])
+	tires.BackTiresAspectRatio, _ = strconv.Atoi(back[2])
+	tires.BackTiresRimDiameter, _ = strconv.Atoi(back[3])
+}
+
+// parseAutoRuNumber извлекает первое число из значения характеристики, например, 1550 из "1 550 кг" или 8.7 из "8,7 с"
+// Входной параметр: value - значение характеристики
+func parseAutoRuNumber(value string) (float64, bool) {
+	// пробелы разделяют разряды числа
+	value = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, value)
+	number, err := strconv.ParseFloat(strings.Replace(autoRuNumber.FindString(value), ",", ".", 1), 64)
+	if err != nil {
+		return 0, false
+	}
+	return number, true
+}
+
+// upperFirst делает первую букву строки заглавной, например, "передний" - "Передний"
+// Входной параметр: value - строка
+func upperFirst(value string) string {
+	first, size := utf8.DecodeRuneInString(value)
+	if first == utf8.RuneError {
+		return value
+	}
+	return string(unicode.ToUpper(first)) + value[size:]
+}
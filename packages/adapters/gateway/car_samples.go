@@ -0,0 +1,65 @@
+package gateway
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"vehicles/packages/domain/fuzzy"
+	"vehicles/packages/domain/models"
+)
+
+type carSampleRepository struct {
+	// vehiclesDB - клиент для подключения к реляционной БД под управлением PostgreSQL
+	vehiclesDB *sql.DB
+}
+
+// NewCarSampleRepository создает источник комплектаций из БД vehicles, по которым восстанавливаются
+// неизвестные характеристики автомобилей
+// Входной параметр: vehiclesDB - клиент для подключения к БД
+func NewCarSampleRepository(vehiclesDB *sql.DB) fuzzy.CarSampleLoader {
+	return &carSampleRepository{vehiclesDB}
+}
+
+// LoadCarSamples получает из БД марку, модель, поколение, тип кузова и числовые характеристики всех комплектаций.
+// Отсутствующие значения заменяются нулями, то есть считаются неизвестными
+func (csr *carSampleRepository) LoadCarSamples(ctx context.Context) ([]models.Car, error) {
+	query := `
+        SELECT makes.make, models.model, generations.generation, COALESCE(body_types.body, ''),
+            COALESCE(trim_levels.mixed_fuel_consumption, 0), COALESCE(trim_levels.acceleration_0_to_100, 0),
+            COALESCE(engines.power, 0), COALESCE(trim_levels.mass, 0), COALESCE(specifications.wheelbase, 0),
+            COALESCE(specifications.length, 0), COALESCE(specifications.width, 0), COALESCE(specifications.height, 0),
+            COALESCE(specifications.ground_clearance, 0), COALESCE(specifications.front_track_width, 0),
+            COALESCE(specifications.back_track_width, 0), COALESCE(trim_levels.trunk_volume, 0),
+            COALESCE(specifications.crash_test_estimate, 0)
+        FROM makes
+        INNER JOIN models ON makes.id = models.make_id
+        INNER JOIN generations ON models.id = generations.model_id
+        INNER JOIN specifications ON generations.id = specifications.generation_id
+        INNER JOIN trim_levels ON specifications.id = trim_levels.specification_id
+        INNER JOIN engines ON trim_levels.engine_id = engines.id
+        LEFT JOIN body_types ON specifications.body_type_id = body_types.id;
+    `
+
+	rows, err := csr.vehiclesDB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error from `QueryContext` method, package `sql`: %#v", err)
+	}
+	defer rows.Close()
+
+	var samples []models.Car
+	for rows.Next() {
+		var car models.Car
+		if err := rows.Scan(&car.Make, &car.Model, &car.Generation, &car.Specs.Body, &car.Specs.MixedFuelConsumption,
+			&car.Specs.Acceleration0To100, &car.Specs.Engine.MaxPower, &car.Specs.Mass, &car.Specs.Wheelbase, &car.Specs.Length, &car.Specs.Width,
+			&car.Specs.Height, &car.Specs.GroundClearance, &car.Specs.FrontTrackWidth, &car.Specs.BackTrackWidth,
+			&car.Specs.TrunkVolume, &car.Specs.CrashTestEstimate); err != nil {
+			return nil, fmt.Errorf("error from `Scan` method, package `sql`: %#v", err)
+		}
+		samples = append(samples, car)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error from `Err` method, package `sql`: %#v", err)
+	}
+	return samples, nil
+}
@@ -1,9 +1,11 @@
 package gateway
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"vehicles/packages/adapters"
+	"vehicles/packages/domain/fuzzy"
 	"vehicles/packages/domain/models"
 	"vehicles/packages/usecases/repository"
 
@@ -52,3 +54,89 @@ func (slr *carsRepository) GetCarsData(sessionID string) ([]models.Car, error) {
 	}
 	return cars, nil
 }
+
+// LoadExplanationsData загружает в БД под управлением Redis объяснения результатов нечеткого алгоритма,
+// упорядоченные так же, как ранжированные автомобили
+// Входные параметры: sessionID - идентификатор сессии, explanations - объяснения
+func (slr *carsRepository) LoadExplanationsData(sessionID string, explanations []fuzzy.Explanation) error {
+	explanationsJSON, err := json.Marshal(explanations)
+	if err != nil {
+		return fmt.Errorf("error from `Marshal` function, package `json`: %#v", err)
+	}
+
+	if err = slr.rdb.Set(slr.ctx.(*gin.Context), explanationsKey(sessionID), string(explanationsJSON), 0).Err(); err != nil {
+		return fmt.Errorf("error from `Set` method, package `redis`: %#v", err)
+	}
+	return nil
+}
+
+// LoadJobCarsData загружает в БД под управлением Redis автомобили, собранные заданием, только если Redis содержит
+// задание сессии с тем же идентификатором или не содержит задания сессии. Если задание заменено другим,
+// возвращается false
+// Входные параметры: ctx - контекст задания, sessionID - идентификатор сессии, jobID - идентификатор задания,
+// cars - автомобили
+func (slr *carsRepository) LoadJobCarsData(ctx context.Context, sessionID, jobID string, cars []models.Car) (bool, error) {
+	carsJSON, err := json.Marshal(cars)
+	if err != nil {
+		return false, fmt.Errorf("error from `Marshal` function, package `json`: %#v", err)
+	}
+
+	loaded, err := watchJob(ctx, slr.rdb, sessionID, jobID, func(pipe redis.Pipeliner) {
+		pipe.Set(ctx, sessionID, string(carsJSON), 0)
+	})
+	if err != nil {
+		return false, fmt.Errorf("error from `watchJob` function, package `gateway`: %#v", err)
+	}
+	return loaded, nil
+}
+
+// LoadJobSelectionData загружает в БД под управлением Redis ранжированные заданием автомобили и объяснения одной
+// транзакцией, только если Redis содержит задание сессии с тем же идентификатором или не содержит задания сессии.
+// Поэтому задание, замененное новым, не перезаписывает его результаты. Если задание заменено другим, возвращается false
+// Входные параметры: ctx - контекст задания, sessionID - идентификатор сессии, jobID - идентификатор задания,
+// cars - автомобили, explanations - объяснения
+func (slr *carsRepository) LoadJobSelectionData(ctx context.Context, sessionID, jobID string, cars []models.Car,
+	explanations []fuzzy.Explanation) (bool, error) {
+	carsJSON, err := json.Marshal(cars)
+	if err != nil {
+		return false, fmt.Errorf("error from `Marshal` function, package `json`: %#v", err)
+	}
+	explanationsJSON, err := json.Marshal(explanations)
+	if err != nil {
+		return false, fmt.Errorf("error from `Marshal` function, package `json`: %#v", err)
+	}
+
+	loaded, err := watchJob(ctx, slr.rdb, sessionID, jobID, func(pipe redis.Pipeliner) {
+		pipe.Set(ctx, sessionID, string(carsJSON), 0)
+		pipe.Set(ctx, explanationsKey(sessionID), string(explanationsJSON), 0)
+	})
+	if err != nil {
+		return false, fmt.Errorf("error from `watchJob` function, package `gateway`: %#v", err)
+	}
+	return loaded, nil
+}
+
+// GetExplanationsData получает из БД под управлением Redis объяснения результатов нечеткого алгоритма.
+// Если объяснений нет, то возвращается пустой срез
+// Входной параметр: sessionID - идентификатор сессии
+func (slr *carsRepository) GetExplanationsData(sessionID string) ([]fuzzy.Explanation, error) {
+	explanationsJSON, err := slr.rdb.Get(slr.ctx.(*gin.Context), explanationsKey(sessionID)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error from `Get` method, package `redis`: %#v", err)
+	}
+
+	var explanations []fuzzy.Explanation
+	if err := json.Unmarshal([]byte(explanationsJSON), &explanations); err != nil {
+		return nil, fmt.Errorf("error from `Unmarshal` function, package `json`: %#v", err)
+	}
+	return explanations, nil
+}
+
+// explanationsKey возвращает ключ, под которым хранятся объяснения результатов нечеткого алгоритма
+// Входной параметр: sessionID - идентификатор сессии
+func explanationsKey(sessionID string) string {
+	return fmt.Sprintf("%s:explanations", sessionID)
+}
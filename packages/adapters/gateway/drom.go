@@ -2,7 +2,6 @@ package gateway
 
 import (
 	"fmt"
-	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
@@ -10,36 +9,35 @@ import (
 	"vehicles/packages/domain/models"
 
 	"github.com/PuerkitoBio/goquery"
-	"github.com/djimenez/iconv-go"
 )
 
 /***************************************************************************************************************************************************************/
-/*   Здесь представлен алгоритм, собирающий данные об автомобилях различных марок с интернет-портала объявлений о продаже автомобилей. Эти данные необходимы   *
-* для ранжирования автомобилей и далее для отображения на страницах данного веб-приложения. На примере сбора данных автомобилей одной марки можно понять       *
-* работу алгоритма. Данные собираются следующим образом: функция prepareLink формирует url, который ведет на страницу марки. Эта страница содержит множество   *
-* объявлений, каждое из которых включает название автомобиля с годом выпуска, цену, краткий перечень характеристик (тип двигателя: например, "дизель 3.0",     *
-* "бензин 2.0", количество л.с., тип коробки передач, тип привода) и фотографию. HTML-код каждого объявления содержит ссылку на страницу конкретного           *
-* автомобиля. Будем далее считать ссылки на страницы автомобилей ссылками на автомобили. Функция scrapeLinksNamesPrices собирает с страницы марки              *
-* указанное количество ссылок на автомобили(в метод GetCarsUsingScraping передается срез makes, который имеет поле NumberOfCars, задающее ограничение          *
-* по количеству ссылок на автомобили данной марки). Вместе с ссылками на автомобили функция scrapeLinksNamesPrices собирает также из объявлений названия       *
-* автомобилей с годом выпуска и их цены. Далее алгоритм вызывает функцию scrapeCharacteristics, которая переходит по ссылкам на страницы автомобилей и         *
-* собирает там информацию, такую как описание автомобиля, его фотографии, пробег в км, год выпуска, расположение руля, цвет, тип кузова, краткий перечень      *
-* характеристик (тип двигателя: например, "дизель 3.0", "бензин 2.0"; количество л.с.; тип коробки передач; тип привода), а также ссылку на страницу           *
-* комплектации и ссылку на страницу поколения, если они есть. Название же комплектации и название поколения автомобиля перечислены на странице автомобиля и их *
-* HTML-коды содержат ссылки на страницу комплектации и на страницу поколения. Если есть название комплектации, то алгоритм вызывает функцию                    *
-* scrapePageOfComplectationLink, которая переходит по ссылке, закрепленной за названием комплектации, и попадает на страницу комплектации, где перечислены     *
-* подробно разичные характеристики данного автомобиля, и собирает некоторые из их. Если названия комплектации нет, а название поколения есть, то алгоритм      *
-* вызывает функцию scrapePageOfGenerationLink, которая переходит по ссылке, закрепленной за названием поколения, и попадает на страницу, где представлены      *
-* названия различных комплектаций, каждая из которых характеризуется кратким перечнем характеристик (тип двигателя: например, "дизель 3.0", "бензин 2.0";      *
-* количество л.с.; тип коробки передач; тип привода). Если есть совпадение по всем характеристикам между кратким перечнем характеристик какой-либо             *
-* комплектации и кратким перечнем характеристик на странице автомобиля, то алгоритм считает, что автомобиль имеет такую комплектацию и вызывает функцию        *
-* scrapePageOfComplectationLink, которая переходит по ссылке, закрепленной за названием пододшедшей комплектации, на страницу данной комлпектации и оттуда     *
-* собирает требуемые характеристики. Если подошедшая комплектация не была найдена, либо на странице автомобиля нет ни названия комплектации, ни названия       *
-* поколения(либо названия есть, но ссылок нет), то в качестве характеристик автомобиля остаются некоторые из следующих: название, цена, пробег в км,           *
-* расположение руля, цвет, тип кузова, краткий перечень характеристик (тип двигателя: например, "дизель 3.0", "бензин 2.0"; количество л.с.;                   *
-* тип коробки передач; тип привода). Также остаются фотографии автомобиля, если они есть. Некоторые из перечисленных только что характеристик берутся в        *
-* качестве дополнительных характеристик и в случае наличия ссылок на страницу поколения и страницу комплектации, где содержатся основные характеристики        *
-* В то же время какие-то характеристики и из дополнительных и основных могут отсутствовать.                                                                    *                                                                 *
+/*   Здесь представлен алгоритм, собирающий данные об автомобилях различных марок с интернет-портала объявлений о продаже автомобилей auto.drom.ru             *
+* (реализация интерфейса ListingSource). Эти данные необходимы для ранжирования автомобилей и далее для отображения на страницах данного веб-приложения.       *
+* Веб-страницы загружает общий для всех порталов алгоритм (см. listingRepository), а функции и методы ниже формируют ссылки и разбирают страницы. На примере   *
+* сбора данных автомобилей одной марки можно понять работу алгоритма. Данные собираются следующим образом: функция prepareLink формирует url, который ведет на *
+* страницу марки. Эта страница содержит множество объявлений, каждое из которых включает название автомобиля с годом выпуска, цену, краткий перечень           *
+* характеристик (тип двигателя: например, "дизель 3.0", "бензин 2.0", количество л.с., тип коробки передач, тип привода) и фотографию. HTML-код каждого        *
+* объявления содержит ссылку на страницу конкретного автомобиля. Будем далее считать ссылки на страницы автомобилей ссылками на автомобили. Метод              *
+* ParseListPage собирает с страницы марки указанное количество ссылок на автомобили(в метод ScrapeSelectionCars передается срез makes, который имеет поле      *
+* NumberOfCars, задающее ограничение по количеству ссылок на автомобили данной марки). Вместе с ссылками на автомобили метод ParseListPage собирает также из   *
+* объявлений названия автомобилей с годом выпуска и их цены. Далее алгоритм переходит по ссылкам на страницы автомобилей, и метод ParseCarPage собирает там    *
+* информацию, такую как описание автомобиля, его фотографии, пробег в км, год выпуска, расположение руля, цвет, тип кузова, краткий перечень характеристик     *
+* (тип двигателя: например, "дизель 3.0", "бензин 2.0"; количество л.с.; тип коробки передач; тип привода), а также ссылку на страницу комплектации и ссылку   *
+* на страницу поколения, если они есть. Название же комплектации и название поколения автомобиля перечислены на странице автомобиля и их HTML-коды содержат    *
+* ссылки на страницу комплектации и на страницу поколения. Если есть название комплектации, то алгоритм переходит по ссылке, закрепленной за названием         *
+* комплектации, на страницу комплектации, где перечислены подробно разичные характеристики данного автомобиля, и функция parseComplectationPage собирает       *
+* некоторые из их. Если названия комплектации нет, а название поколения есть, то алгоритм переходит по ссылке, закрепленной за названием поколения, на         *
+* страницу, где представлены названия различных комплектаций, каждая из которых характеризуется кратким перечнем характеристик (тип двигателя: например,       *
+* "дизель 3.0", "бензин 2.0"; количество л.с.; тип коробки передач; тип привода). Если есть совпадение по всем характеристикам между кратким перечнем          *
+* характеристик какой-либо комплектации и кратким перечнем характеристик на странице автомобиля, то функция findComplectationLink считает, что автомобиль      *
+* имеет такую комплектацию, и алгоритм переходит по ссылке, закрепленной за названием пододшедшей комплектации, на страницу данной комлпектации, откуда        *
+* функция parseComplectationPage собирает требуемые характеристики. Если подошедшая комплектация не была найдена, либо на странице автомобиля нет ни названия  *
+* комплектации, ни названия поколения(либо названия есть, но ссылок нет), то в качестве характеристик автомобиля остаются некоторые из следующих: название,    *
+* цена, пробег в км, расположение руля, цвет, тип кузова, краткий перечень характеристик (тип двигателя: например, "дизель 3.0", "бензин 2.0"; количество      *
+* л.с.; тип коробки передач; тип привода). Также остаются фотографии автомобиля, если они есть. Некоторые из перечисленных только что характеристик берутся в  *
+* качестве дополнительных характеристик и в случае наличия ссылок на страницу поколения и страницу комплектации, где содержатся основные характеристики. В то  *
+* же время какие-то характеристики и из дополнительных и основных могут отсутствовать.                                                                         *
 ****************************************************************************************************************************************************************/
 
 const (
@@ -49,191 +47,57 @@ const (
 	newCarWord = "новый автомобиль"
 )
 
-// ScrapeSelectionCars собирает данные автомобилей из интернета
-// Входные параметры: minPrice  - минимальная цена, maxPrice - максимальная цена, makes - срез марок
-func (slr *selectionRepository) ScrapeSelectionCars(minPrice, maxPrice string, makes []models.Makes) ([]models.Car, error) {
-	// ссылки на страницы марок
-	linksToMakePage := make([]string, len(makes))
-	for idx, make := range makes {
-		linksToMakePage[idx] = prepareLink(minPrice, maxPrice, make.Make)
-	}
-
-	// 1-я размерность - марки, 2-я размерность - автомобили этих марок
-	// rawLinks - ссылки, представляющие конкретные автомобили разных марок
-	rawLinks := make([][]string, len(makes))
-	// rawNames - названия
-	rawNames := make([][]string, len(makes))
-	// rawPrices - цены
-	rawPrices := make([][]string, len(makes))
-
-	var newLinks, newNames, newPrices []string
-	var err error
-	for index, thisMake := range makes {
-		newLinks, newNames, newPrices, err = scrapeLinksNamesPrices(linksToMakePage[index], thisMake.NumberOfCars)
-		if err != nil {
-			return nil, err
-		}
-		if len(newLinks) != 0 {
-			rawLinks[index], rawNames[index], rawPrices[index] = newLinks, newNames, newPrices
-		}
-	}
+type dromSource struct{}
 
-	links, names, prices, numberOfCars := getLinksNamesPrices(rawLinks, rawNames, rawPrices)
-
-	cars := make([]models.Car, numberOfCars)
-	uniqueID := 0
-	for idx := 0; idx < len(links); idx++ {
-		for jx := 0; jx < len(links[idx]); jx++ {
-			car := models.NewCar()
-			car.ID = uniqueID
-			car.FullName = names[idx][jx]
-			car.Offering.Price = fmt.Sprintf("%s₽", prices[idx][jx])
-			err := scrapeCharacteristics(&car, links[idx][jx], car.FullName)
-			if err != nil {
-				return nil, fmt.Errorf("error from `scrapeCharacteristics` function, package `gateway`: %#v", err)
-			}
-
-			cars[uniqueID] = car
-			uniqueID++
-		}
-	}
-	return cars, nil
+// Name возвращает название портала
+func (drs *dromSource) Name() string {
+	return DromSource
 }
 
-// prepareLink формирует и возвращает ссылку на страницу марки автомобиля, откуда будут собираться данные
-// Входные параметры: minPrice  - минимальная цена, maxPrice - максимальная цена, make - название марки
-func prepareLink(minPrice, maxPrice, make string) string {
-	var link = fmt.Sprintf("https://auto.drom.ru/%s/all/?", make)
-	if minPrice != "" {
-		link = fmt.Sprintf("%sminprice=%s&", link, maxPrice)
-	}
-
-	if maxPrice != "" {
-		link = fmt.Sprintf("%smaxprice=%s&", link, maxPrice)
-	}
-
-	link = fmt.Sprintf("%sph=1&unsold=1", link)
-	return link
+// Charset возвращает кодировку веб-страниц портала
+func (drs *dromSource) Charset() string {
+	return "windows-1251"
 }
 
-// scrapeLinksNamesPrices собирает ссылки на страницы, содержащие сведения о автомобилях, а также
+// ParseListPage собирает ссылки на страницы, содержащие сведения о автомобилях, а также
 // их названия и цены.
-// Входные параметры: link - ссылка на страницу марки, quantity - количество автомобилей для поиска
-func scrapeLinksNamesPrices(link string, quantity int) ([]string, []string, []string, error) {
-	document, err := getWebPage(link)
-	if err != nil {
-		return nil, nil, nil, fmt.Errorf("error from `getWebPage` function, package `gateway`: %#v", err)
-	}
-
-	links := make([]string, 0, quantity)
-	names := make([]string, 0, quantity)
-	prices := make([]string, 0, quantity)
+// Входные параметры: document - страница марки, quantity - количество автомобилей для поиска
+func (drs *dromSource) ParseListPage(document *goquery.Document, quantity int) []Listing {
+	listings := make([]Listing, 0, quantity)
 
 	div := document.Find("div[data-bulletin-list=true]")
-	limit := 0
 	div.Find("a").EachWithBreak(func(i int, a *goquery.Selection) bool {
-		if limit == quantity {
+		if len(listings) == quantity {
 			return false
 		}
 
 		href, exists := a.Attr("href")
-		if exists {
-			links = append(links, href)
-		} else {
+		if !exists {
 			return false
 		}
 
-		span := a.Find("span[data-ftid=bull_title]")
-		content := span.Text()
-		if content != "" {
-			names = append(names, content)
+		listing := Listing{
+			Link:  href,
+			Name:  a.Find("span[data-ftid=bull_title]").Text(),
+			Price: a.Find("span[data-ftid=bull_price]").Text(),
 		}
-
-		spanPrice := a.Find("span[data-ftid=bull_price]")
-		contentPrice := spanPrice.Text()
-		if contentPrice != "" {
-			prices = append(prices, contentPrice)
+		// ссылка на страницу автомобиля: https://<город>.drom.ru/<марка>/<модель>/<номер>.html
+		if segments := linkSegments(href); len(segments) == 3 {
+			listing.Make, listing.Model = segments[0], segments[1]
 		}
-
-		limit++
+		listings = append(listings, listing)
 		return true
 	})
-
-	return links, names, prices, nil
-}
-
-// getWebPage получает какую-либо веб-страницу
-// Входной параметр: link - ссылка на веб-страницу
-func getWebPage(link string) (*goquery.Document, error) {
-	response, err := http.Get(link)
-	if err != nil {
-		return nil, fmt.Errorf("error from `Get` function, package `http`: error while sending GET request: %#v", err)
-	}
-
-	// смена кодировки страницы с windows-1251 на utf-8
-	utfBody, err := iconv.NewReader(response.Body, "windows-1251", "utf-8")
-	if err != nil {
-		return nil, fmt.Errorf("error from `NewReader` function, package `iconv`: error while converting charset from windows-1251 to utf-8: %#v", err)
-	}
-
-	// создание объекта структуры, представляющего HTML документ
-	document, err := goquery.NewDocumentFromReader(utfBody)
-	if err != nil {
-		return nil, fmt.Errorf("error from `NewDocumentFromReader` function, package `goquery`: %#v", err)
-	}
-
-	err = response.Body.Close()
-	if err != nil {
-		return nil, fmt.Errorf("error from `Close` method, package `io`): %#v", err)
-	}
-
-	return document, nil
+	return listings
 }
 
-// getLinksNamesPrices удаляет пустые срезы из срезов срезов и получает точное количество автомобилей,
-// которое будет показано пользователю
-// Входные параметры: rawLinks - ссылки на страницы автомобилей, rawNames - названия и rawPrices - цены
-func getLinksNamesPrices(rawLinks, rawNames, rawPrices [][]string) ([][]string, [][]string, [][]string, int) {
-	count := 0
-	numberOfCars := 0
-	for _, slice := range rawLinks {
-		if len(slice) > 0 {
-			count++
-			numberOfCars += len(slice)
-		}
-	}
-
-	links := make([][]string, 0, count)
-	names := make([][]string, 0, count)
-	prices := make([][]string, 0, count)
-
-	for idx := range rawLinks {
-		if len(rawLinks[idx]) > 0 {
-			links = append(links, append([]string{}, rawLinks[idx]...))
-		}
-
-		if len(rawNames[idx]) > 0 {
-			names = append(names, append([]string{}, rawNames[idx]...))
-		}
-
-		if len(rawPrices[idx]) > 0 {
-			prices = append(prices, append([]string{}, rawPrices[idx]...))
-		}
-	}
-	return links, names, prices, numberOfCars
-}
-
-// scrapeCharacteristics собирает характеристики автомобиля с его страницы
-// Входные параметры: car - автомобиль, link - ссылка на страницу автомобиля, carName - название автомобиля
+// ParseCarPage собирает характеристики автомобиля с его страницы и возвращает ссылку на страницу комплектации, а
+// если ее нет, то на страницу поколения
+// Входные параметры: document - страница автомобиля, car - автомобиль
 // Цикломатическая сложность игнорируется в целях оптимизации
 //
 //gocyclo:ignore
-func scrapeCharacteristics(car *models.Car, link, carName string) error {
-	document, err := getWebPage(link)
-	if err != nil {
-		return fmt.Errorf("error from `getWebPage` function, package `gateway`: %#v", err)
-	}
-
+func (drs *dromSource) ParseCarPage(document *goquery.Document, car *models.Car) (TrimReference, error) {
 	span := document.Find("span.css-1kb7l9z.e162wx9x0").Eq(1)
 	if span.Text() != "" {
 		car.Description = span.Text()
@@ -263,9 +127,10 @@ func scrapeCharacteristics(car *models.Car, link, carName string) error {
 	rexp := regexp.MustCompile(`(\W+\D\d.\d\D\W)|(\W+)`)
 	additionalParams["Двигатель"] = rexp.FindString(document.Find("span.css-1jygg09.e162wx9x0").Text())
 
-	car.Offering.Year, err = findYearOfManufacture(carName)
+	var err error
+	car.Offering.Year, err = findYearOfManufacture(car.FullName)
 	if err != nil {
-		return fmt.Errorf("error from `findYearOfManufacture` function, package `gateway`: %#v", err)
+		return TrimReference{}, fmt.Errorf("error from `findYearOfManufacture` function, package `gateway`: %#v", err)
 	}
 
 	// complectationLink - ссылка на страницу комплектации
@@ -324,18 +189,27 @@ func scrapeCharacteristics(car *models.Car, link, carName string) error {
 	})
 
 	if complectationLink != "" {
-		err = scrapePageOfComplectationLink(car, complectationLink)
-		if err != nil {
-			return fmt.Errorf("error from `scrapePageOfComplectationLink` function, package `gateway`: %#v", err)
-		}
+		return TrimReference{Link: complectationLink}, nil
+	}
+	return TrimReference{Link: generationLink, Generation: generationLink != "", Params: additionalParams}, nil
+}
 
-	} else if complectationLink == "" && generationLink != "" {
-		err := scrapePageOfGenerationLink(car, additionalParams, generationLink)
-		if err != nil {
-			return fmt.Errorf("error from `scrapePageOfGenerationLink` function, package `gateway`: %#v", err)
+// EnrichTrim собирает характеристики автомобиля со страницы комплектации, а на странице поколения находит
+// подходящую автомобилю комплектацию и возвращает ссылку на ее страницу
+// Входные параметры: document - страница комплектации или поколения, car - автомобиль, trim - ссылка на эту страницу
+func (drs *dromSource) EnrichTrim(document *goquery.Document, car *models.Car, trim TrimReference) (TrimReference, error) {
+	if trim.Generation {
+		complectationLink := findComplectationLink(document, trim.Params)
+		if complectationLink == "" {
+			return TrimReference{}, nil
 		}
+		return TrimReference{Link: fmt.Sprintf("https://www.drom.ru%s", complectationLink)}, nil
 	}
-	return nil
+
+	if err := parseComplectationPage(car, document); err != nil {
+		return TrimReference{}, fmt.Errorf("error from `parseComplectationPage` function, package `gateway`: %#v", err)
+	}
+	return TrimReference{}, nil
 }
 
 // findYearOfManufacture находит год выпуска в названии автомобиля
@@ -355,17 +229,13 @@ func findYearOfManufacture(carName string) (int, error) {
 	}
 }
 
-// scrapePageOfComplectationLink собирает характеристики автомобиля с страницы, содержащей сведения о комплектации автомобиля
-// Входные параметры: car - автомобиль, complectationLink - ссылка на страницу комплектации
+// parseComplectationPage собирает характеристики автомобиля с страницы, содержащей сведения о комплектации автомобиля
+// Входные параметры: car - автомобиль, document - страница комплектации
 // Цикломатическая сложность игнорируется в целях оптимизации
 //
 //gocyclo:ignore
-func scrapePageOfComplectationLink(car *models.Car, complectationLink string) error {
-	document, err := getWebPage(complectationLink)
-	if err != nil {
-		return fmt.Errorf("error from `getWebPage` function, package `gateway`: %#v", err)
-	}
-
+func parseComplectationPage(car *models.Car, document *goquery.Document) error {
+	var err error
 	var errCapacity, errAcceleration, errMaxSpeed, errClearance, errLength, errWidth, errHeight,
 		errNumberOfSeats, errWheelbase, errFrontTrackWidth, errBackTrackWidth, errMassKg,
 		errTrunkVolume, errDragCoeff, errEngineMaxPower, errCityConsum, errHighwayConsum,
@@ -863,16 +733,12 @@ func extractEnginePowerValue(maxpower string) string {
 	return string(digits)
 }
 
-// scrapePageOfGenerationLink находит на странице поколения автомобиля, которая содержит ссылки на страницы комплектаций,
-// комплектацию, которая подходит текущему автомобилю. С подходящей страницы комплектации собирается информация о характеристиках.
-// Входные параметры: car - автомобиль, generationLink - ссылка на страницу поколения этого автомобиля,
+// findComplectationLink находит на странице поколения автомобиля, которая содержит ссылки на страницы комплектаций,
+// комплектацию, которая подходит текущему автомобилю, и возвращает ссылку на ее страницу относительно www.drom.ru.
+// Если подходящей комплектации нет, возвращается пустая строка
+// Входные параметры: document - страница поколения этого автомобиля,
 // additionalParams - краткий перечень характеристик автомобиля, собранный с страницы автомобиля
-func scrapePageOfGenerationLink(car *models.Car, additionalParams map[string]string, generationLink string) error {
-	document, err := getWebPage(generationLink)
-	if err != nil {
-		return fmt.Errorf("error from `getWebPage` function, package `gateway`: %#v", err)
-	}
-
+func findComplectationLink(document *goquery.Document, additionalParams map[string]string) string {
 	// specificCharacteristics - краткий перечень характеристик автомобиля, собранный с страницы автомобиля.
 	// Будет сопоставляться с краткими перечнями характеристик различных комплектаций,
 	// представленных на странице поколения
@@ -910,7 +776,9 @@ func scrapePageOfGenerationLink(car *models.Car, additionalParams map[string]str
 
 		// удаление "л" из "2.3 л", поскольку команда выше удаляет пробел, и получается "2.3л",
 		// которая не равна строке "2.3 л", находящейся на странице комплектаций
-		fuel[1] = strings.Replace(fuel[1], "л", "", 1)
+		if len(fuel) > 1 {
+			fuel[1] = strings.Replace(fuel[1], "л", "", 1)
+		}
 
 		// удаление строки вида "бензин, 2.3 л"
 		delete(specificCharacteristics, "Двигатель")
@@ -960,10 +828,5 @@ func scrapePageOfGenerationLink(car *models.Car, additionalParams map[string]str
 		return true
 	})
 
-	complectationLink = fmt.Sprintf("https://www.drom.ru%s", complectationLink)
-	err = scrapePageOfComplectationLink(car, complectationLink)
-	if err != nil {
-		return fmt.Errorf("error from `scrapePageOfComplectationLink` function, package `gateway`: %#v", err)
-	}
-	return nil
+	return complectationLink
 }
@@ -2,57 +2,37 @@ package gateway
 
 import (
 	"fmt"
-	"vehicles/packages/adapters"
 	"vehicles/packages/domain/models"
-	"vehicles/packages/usecases/repository"
-
-	"github.com/redis/go-redis/v9"
 )
 
-// ограничение количества автомобилей
-var limitValue int = 10
-
 const newWord = "new"
 
-type searchRepository struct {
-	// ctx - переменная контекста
-	ctx adapters.Context
-	// rdb - клиент Redis для подключения к NoSQL БД, хранящей данные выбранных пользователем автомобилей
-	rdb *redis.Client
+// SearchLink формирует ссылку на страницу объявлений auto.drom.ru согласно параметрам обычного поиска
+// Входной параметр: search - параметры поиска пользователя, которые он вводил на главное странице в большой форме сверху
+func (drs *dromSource) SearchLink(search models.Search) string {
+	return prepareLinkForSearch(search)
 }
 
-func NewSearchRepository(ctx adapters.Context, rdb *redis.Client) repository.SearchRepository {
-	return &searchRepository{ctx, rdb}
+// MakeLink формирует ссылку на страницу объявлений auto.drom.ru об автомобилях одной марки в диапазоне цен
+// Входные параметры: minPrice  - минимальная цена, maxPrice - максимальная цена, make - название марки
+func (drs *dromSource) MakeLink(minPrice, maxPrice, make string) string {
+	return prepareLink(minPrice, maxPrice, make)
 }
 
-// ScrapeSearchCars собирает данные автомобилей из интернета
-// Входной параметр: search - параметры поиска пользователя, которые он вводил на главное странице в большой форме сверху
-func (srp *searchRepository) ScrapeSearchCars(search models.Search) ([]models.Car, error) {
-	// ссылка на страницу, содержащую объявления об автомобилях конкретной марки с названиями и ценами
-	var link = prepareLinkForSearch(search)
-
-	// получение ссылок на страницы автомобилей, их названий и цен
-	links, names, prices, err := scrapeLinksNamesPrices(link, limitValue)
-	if err != nil {
-		return nil, fmt.Errorf("error from `scrapeLinksNamesPrices` function, package `gateway`: %#v", err)
+// prepareLink формирует и возвращает ссылку на страницу марки автомобиля, откуда будут собираться данные
+// Входные параметры: minPrice  - минимальная цена, maxPrice - максимальная цена, make - название марки
+func prepareLink(minPrice, maxPrice, make string) string {
+	var link = fmt.Sprintf("https://auto.drom.ru/%s/all/?", make)
+	if minPrice != "" {
+		link = fmt.Sprintf("%sminprice=%s&", link, minPrice)
 	}
 
-	cars := make([]models.Car, 0, limitValue)
-	for i, link := range links {
-		car := models.NewCar()
-		car.ID = i
-		car.FullName = names[i]
-
-		car.Offering.Price = fmt.Sprintf("%s₽", prices[i])
-
-		err := scrapeCharacteristics(&car, link, names[i])
-		if err != nil {
-			return nil, fmt.Errorf("error from `scrapeCharacteristics` function, package `gateway`): %#v", err)
-		}
-
-		cars = append(cars, car)
+	if maxPrice != "" {
+		link = fmt.Sprintf("%smaxprice=%s&", link, maxPrice)
 	}
-	return cars, nil
+
+	link = fmt.Sprintf("%sph=1&unsold=1", link)
+	return link
 }
 
 // prepareLinkForSearch формирует и возвращает ссылку на веб-страницу согласно параметрам запроса
@@ -108,8 +88,8 @@ func prepareLinkForSearch(search models.Search) string {
 		link = fmt.Sprintf("%smaxyear=%s", link, search.LatestYear)
 	}
 
-	link = fmt.Sprintf("%s%s", link, prepareSecondPartOfLink(search, link))
-	link = fmt.Sprintf("%s%s", link, prepareThirdPartOfLink(search, link))
+	link = prepareSecondPartOfLink(search, link)
+	link = prepareThirdPartOfLink(search, link)
 	return link
 }
 
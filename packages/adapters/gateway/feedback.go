@@ -0,0 +1,91 @@
+package gateway
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+	"vehicles/packages/domain/fuzzy"
+	"vehicles/packages/usecases/repository"
+
+	"github.com/lib/pq"
+)
+
+type feedbackRepository struct {
+	// vehiclesDB - клиент для подключения к реляционной БД под управлением PostgreSQL,
+	// хранящей таблицу feedback_events
+	vehiclesDB *sql.DB
+}
+
+// NewFeedbackRepository создает хранилище действий пользователей с автомобилями из рейтинга в таблице feedback_events
+// Входной параметр: vehiclesDB - клиент для подключения к БД
+func NewFeedbackRepository(vehiclesDB *sql.DB) repository.FeedbackRepository {
+	return &feedbackRepository{vehiclesDB}
+}
+
+// InsertFeedback записывает в БД действие пользователя с автомобилем из рейтинга
+// Входные параметры: ctx - контекст, event - действие пользователя
+func (fbr *feedbackRepository) InsertFeedback(ctx context.Context, event fuzzy.FeedbackEvent) error {
+	coefficients, err := json.Marshal(event.Coefficients)
+	if err != nil {
+		return fmt.Errorf("error from `Marshal` function, package `json`: %#v", err)
+	}
+	confidences, err := json.Marshal(event.Confidences)
+	if err != nil {
+		return fmt.Errorf("error from `Marshal` function, package `json`: %#v", err)
+	}
+
+	query := `
+        INSERT INTO feedback_events (session_id, priorities, experiment, variant, rank, action, coefficients,
+            confidences, memberships_version, formulas_version, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);
+    `
+	if _, err = fbr.vehiclesDB.ExecContext(ctx, query, event.SessionID, pq.Array(event.Priorities), event.Experiment,
+		event.Variant, event.Rank, event.Action, coefficients, confidences, event.MembershipsVersion,
+		event.FormulasVersion, event.CreatedAt); err != nil {
+		return fmt.Errorf("error from `ExecContext` method, package `sql`: %#v", err)
+	}
+	return nil
+}
+
+// LoadFeedback получает из БД действия пользователей, совершенные не раньше заданного времени, в порядке их совершения
+// Входные параметры: ctx - контекст, since - время, с которого учитываются действия
+func (fbr *feedbackRepository) LoadFeedback(ctx context.Context, since time.Time) ([]fuzzy.FeedbackEvent, error) {
+	query := `
+        SELECT session_id, priorities, experiment, variant, rank, action, coefficients, confidences,
+            memberships_version, formulas_version, created_at
+        FROM feedback_events
+        WHERE created_at >= $1
+        ORDER BY id;
+    `
+
+	rows, err := fbr.vehiclesDB.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("error from `QueryContext` method, package `sql`: %#v", err)
+	}
+	defer rows.Close()
+
+	var events []fuzzy.FeedbackEvent
+	for rows.Next() {
+		var event fuzzy.FeedbackEvent
+		var coefficients, confidences []byte
+		if err := rows.Scan(&event.SessionID, pq.Array(&event.Priorities), &event.Experiment, &event.Variant,
+			&event.Rank, &event.Action, &coefficients, &confidences, &event.MembershipsVersion, &event.FormulasVersion,
+			&event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error from `Scan` method, package `sql`: %#v", err)
+		}
+		if err := json.Unmarshal(coefficients, &event.Coefficients); err != nil {
+			return nil, fmt.Errorf("error from `Unmarshal` function, package `json`: %#v", err)
+		}
+		if err := json.Unmarshal(confidences, &event.Confidences); err != nil {
+			return nil, fmt.Errorf("error from `Unmarshal` function, package `json`: %#v", err)
+		}
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error from `Err` method, package `sql`: %#v", err)
+	}
+	return events, nil
+}
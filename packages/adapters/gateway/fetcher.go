@@ -0,0 +1,233 @@
+package gateway
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// режимы загрузки веб-страниц интернет-порталов объявлений (настройка scraping.fetcher.mode)
+const (
+	// LiveFetch - загрузка страниц из интернета
+	LiveFetch = "live"
+	// RecordFetch - загрузка страниц из интернета с сохранением их в каталог образцов
+	RecordFetch = "record"
+	// ReplayFetch - чтение сохраненных страниц из каталога образцов без обращения к интернету
+	ReplayFetch = "replay"
+)
+
+// значения настроек загрузки страниц по умолчанию
+const (
+	// DefaultPageTimeout - наибольшее время загрузки одной страницы
+	DefaultPageTimeout = 20 * time.Second
+	// DefaultRetryBackoff - пауза перед первой повторной загрузкой страницы
+	DefaultRetryBackoff = time.Second
+)
+
+// fixtureNameChars - символы ссылки, которые заменяются в названии файла образца
+var fixtureNameChars = regexp.MustCompile(`[^a-zA-Z0-9.=-]+`)
+
+// fixtureNameLength - наибольшая длина читаемой части названия файла образца
+const fixtureNameLength = 100
+
+// Fetcher загружает веб-страницы интернет-порталов объявлений. Страницы загружаются только через Fetcher, поэтому
+// разбор страниц можно проверить на сохраненных образцах без обращения к интернету
+type Fetcher interface {
+	// Fetch загружает веб-страницу и возвращает ее содержимое в исходной кодировке. Загрузка прерывается, когда
+	// отменяется контекст
+	// Входные параметры: ctx - контекст запроса пользователя, link - ссылка на веб-страницу
+	Fetch(ctx context.Context, link string) ([]byte, error)
+}
+
+// FetcherConfig - настройки загрузки веб-страниц (настройка scraping.fetcher)
+type FetcherConfig struct {
+	// Mode - режим (LiveFetch, RecordFetch, ReplayFetch; "" - LiveFetch)
+	Mode string
+	// FixturesDir - каталог образцов для режимов RecordFetch и ReplayFetch
+	FixturesDir string
+	// PageTimeout - наибольшее время загрузки одной страницы; 0 - DefaultPageTimeout
+	PageTimeout time.Duration
+	// Rate - наибольшее количество запросов в секунду к одному сайту; 0 - без ограничения
+	Rate float64
+	// Burst - количество запросов к одному сайту, которые можно отправить подряд без ожидания; 0 - 1
+	Burst int
+	// Retries - количество повторных загрузок страницы, на которую сайт ответил статусом 429 или 5xx
+	Retries int
+	// Backoff - пауза перед первой повторной загрузкой, каждая следующая пауза вдвое длиннее;
+	// 0 - DefaultRetryBackoff
+	Backoff time.Duration
+}
+
+// NewFetcher создает загрузчик веб-страниц в заданном режиме. Страницы из интернета загружаются с ограничением
+// частоты запросов к каждому сайту и с повторными загрузками при ответах 429 и 5xx
+// Входной параметр: config - настройки загрузки веб-страниц
+func NewFetcher(config FetcherConfig) (Fetcher, error) {
+	if config.Mode == ReplayFetch {
+		if config.FixturesDir == "" {
+			return nil, fmt.Errorf("error, there is no fixtures directory for the %q mode", config.Mode)
+		}
+		return NewReplayFetcher(config.FixturesDir), nil
+	}
+
+	if config.PageTimeout <= 0 {
+		config.PageTimeout = DefaultPageTimeout
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = DefaultRetryBackoff
+	}
+	// каждая повторная загрузка тоже ожидает своей очереди в ограничителе частоты запросов
+	fetcher := NewRetryFetcher(NewRateLimitedFetcher(NewLiveFetcher(&http.Client{Timeout: config.PageTimeout}),
+		config.Rate, config.Burst), config.Retries, config.Backoff)
+
+	switch config.Mode {
+	case "", LiveFetch:
+		return fetcher, nil
+	case RecordFetch:
+		if config.FixturesDir == "" {
+			return nil, fmt.Errorf("error, there is no fixtures directory for the %q mode", config.Mode)
+		}
+		return NewRecordFetcher(fetcher, config.FixturesDir), nil
+	default:
+		return nil, fmt.Errorf("error, unknown fetcher mode %q", config.Mode)
+	}
+}
+
+// StatusError - ответ сайта со статусом, отличным от 2xx
+type StatusError struct {
+	// Link - ссылка на веб-страницу
+	Link string
+	// StatusCode - статус ответа
+	StatusCode int
+	// RetryAfter - пауза из заголовка Retry-After; 0, если заголовка нет
+	RetryAfter time.Duration
+}
+
+// Error возвращает описание ошибки
+func (ste *StatusError) Error() string {
+	return fmt.Sprintf("error, GET %s returned status %d", ste.Link, ste.StatusCode)
+}
+
+// Temporary сообщает, что страницу стоит загрузить повторно: сайт ограничил частоту запросов (429) или
+// временно не смог ответить (5xx)
+func (ste *StatusError) Temporary() bool {
+	return ste.StatusCode == http.StatusTooManyRequests || ste.StatusCode >= http.StatusInternalServerError
+}
+
+type liveFetcher struct {
+	// client - HTTP-клиент
+	client *http.Client
+}
+
+// NewLiveFetcher создает загрузчик веб-страниц из интернета
+// Входной параметр: client - HTTP-клиент с ограничением времени запроса
+func NewLiveFetcher(client *http.Client) Fetcher {
+	return &liveFetcher{client}
+}
+
+// Fetch загружает веб-страницу из интернета. Если сайт ответил статусом, отличным от 2xx, возвращается *StatusError
+// Входные параметры: ctx - контекст запроса пользователя, link - ссылка на веб-страницу
+func (lvf *liveFetcher) Fetch(ctx context.Context, link string) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error from `NewRequestWithContext` function, package `http`: %#v", err)
+	}
+	response, err := lvf.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("error from `Do` method, package `http`: error while sending GET request: %#v", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		statusErr := &StatusError{Link: link, StatusCode: response.StatusCode}
+		if seconds, errAtoi := strconv.Atoi(response.Header.Get("Retry-After")); errAtoi == nil && seconds > 0 {
+			statusErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return nil, statusErr
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error from `ReadAll` function, package `io`: %#v", err)
+	}
+	return body, nil
+}
+
+type recordFetcher struct {
+	// fetcher - загрузчик, через который страницы загружаются из интернета
+	fetcher Fetcher
+	// dir - каталог образцов
+	dir string
+}
+
+// NewRecordFetcher создает загрузчик, который сохраняет каждую загруженную страницу в каталог образцов
+// Входные параметры: fetcher - загрузчик страниц из интернета, dir - каталог образцов
+func NewRecordFetcher(fetcher Fetcher, dir string) Fetcher {
+	return &recordFetcher{fetcher, dir}
+}
+
+// Fetch загружает веб-страницу и сохраняет ее в файл с названием FixtureName(link)
+// Входные параметры: ctx - контекст запроса пользователя, link - ссылка на веб-страницу
+func (rcf *recordFetcher) Fetch(ctx context.Context, link string) ([]byte, error) {
+	body, err := rcf.fetcher.Fetch(ctx, link)
+	if err != nil {
+		return nil, fmt.Errorf("error from `Fetch` method, package `gateway`: %#v", err)
+	}
+
+	if err = os.MkdirAll(rcf.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error from `MkdirAll` function, package `os`: %#v", err)
+	}
+	if err = os.WriteFile(filepath.Join(rcf.dir, FixtureName(link)), body, 0o644); err != nil {
+		return nil, fmt.Errorf("error from `WriteFile` function, package `os`: %#v", err)
+	}
+	return body, nil
+}
+
+type replayFetcher struct {
+	// dir - каталог образцов
+	dir string
+}
+
+// NewReplayFetcher создает загрузчик, который читает страницы из каталога образцов, не обращаясь к интернету
+// Входной параметр: dir - каталог образцов
+func NewReplayFetcher(dir string) Fetcher {
+	return &replayFetcher{dir}
+}
+
+// Fetch читает сохраненную веб-страницу из файла с названием FixtureName(link)
+// Входные параметры: ctx - контекст запроса пользователя, link - ссылка на веб-страницу
+func (rpf *replayFetcher) Fetch(ctx context.Context, link string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("error, the page %s was not read: %#v", link, err)
+	}
+
+	fileName := filepath.Join(rpf.dir, FixtureName(link))
+	body, err := os.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("error, there is no fixture %s for %s", fileName, link)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error from `ReadFile` function, package `os`: %#v", err)
+	}
+	return body, nil
+}
+
+// FixtureName возвращает название файла образца веб-страницы: читаемую часть ссылки и начало ее хеша SHA-1,
+// например, "auto.drom.ru_toyota_all_minprice=100_3f2a9c1d.html"
+// Входной параметр: link - ссылка на веб-страницу
+func FixtureName(link string) string {
+	name := strings.TrimPrefix(strings.TrimPrefix(link, "https://"), "http://")
+	name = strings.Trim(fixtureNameChars.ReplaceAllString(name, "_"), "_")
+	if len(name) > fixtureNameLength {
+		name = name[:fixtureNameLength]
+	}
+	sum := sha1.Sum([]byte(link))
+	return fmt.Sprintf("%s_%x.html", name, sum[:4])
+}
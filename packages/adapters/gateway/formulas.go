@@ -0,0 +1,38 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"vehicles/packages/domain/fuzzy"
+
+	"gopkg.in/yaml.v3"
+)
+
+type formulaFileLoader struct {
+	// fileName - путь к файлу в формате YAML или JSON с формулами коэффициентов
+	fileName string
+}
+
+// NewFormulaFileLoader создает источник формул коэффициентов, читающий файл
+// Входной параметр: fileName - путь к файлу в формате YAML или JSON
+func NewFormulaFileLoader(fileName string) fuzzy.FormulaLoader {
+	return &formulaFileLoader{fileName}
+}
+
+// LoadFormulas читает формулы коэффициентов из файла. Формулы проверяются при замене в таблице (см. FormulaTable.Update)
+func (ffl *formulaFileLoader) LoadFormulas(ctx context.Context) (fuzzy.FormulaConfig, error) {
+	data, err := os.ReadFile(ffl.fileName)
+	if err != nil {
+		return fuzzy.FormulaConfig{}, fmt.Errorf("error from `ReadFile` function, package `os`: %#v", err)
+	}
+
+	var config fuzzy.FormulaConfig
+	if err = yaml.Unmarshal(data, &config); err != nil {
+		return fuzzy.FormulaConfig{}, fmt.Errorf("error from `Unmarshal` function, package `yaml`: %#v", err)
+	}
+	if config.Version == "" {
+		return fuzzy.FormulaConfig{}, fmt.Errorf("error, there is no version in %s", ffl.fileName)
+	}
+	return config, nil
+}
@@ -0,0 +1,131 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+	"vehicles/packages/domain/models"
+	"vehicles/packages/usecases/repository"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// jobUpdateRetries - количество попыток условной записи состояния или результатов задания, которую прервало одновременное изменение
+const jobUpdateRetries = 3
+
+type jobRepository struct {
+	// rdb - клиент Redis для подключения к NoSQL БД, хранящей состояния заданий
+	rdb *redis.Client
+	// ttl - время хранения состояния задания после последнего изменения
+	ttl time.Duration
+}
+
+// NewJobRepository создает хранилище состояний заданий сбора автомобилей в Redis
+// Входные параметры: rdb - клиент Redis, ttl - время хранения состояния задания после последнего изменения
+// (0 - без ограничения)
+func NewJobRepository(rdb *redis.Client, ttl time.Duration) repository.JobRepository {
+	return &jobRepository{rdb, ttl}
+}
+
+// SaveJob сохраняет состояние нового задания сбора автомобилей, заменяя задание сессии, поставленное раньше
+// Входные параметры: ctx - контекст, job - задание
+func (jbr *jobRepository) SaveJob(ctx context.Context, job models.Job) error {
+	jobJSON, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("error from `Marshal` function, package `json`: %#v", err)
+	}
+
+	if err = jbr.rdb.Set(ctx, jobKey(job.SessionID), string(jobJSON), jbr.ttl).Err(); err != nil {
+		return fmt.Errorf("error from `Set` method, package `redis`: %#v", err)
+	}
+	return nil
+}
+
+// UpdateJob сохраняет состояние задания, только если Redis содержит задание сессии с тем же идентификатором
+// или не содержит задания сессии. Ключ отслеживается командой WATCH, поэтому задание, замененное между чтением
+// и записью, тоже не перезаписывает новое. Если задание заменено другим, возвращается false
+// Входные параметры: ctx - контекст, job - задание
+func (jbr *jobRepository) UpdateJob(ctx context.Context, job models.Job) (bool, error) {
+	jobJSON, err := json.Marshal(job)
+	if err != nil {
+		return false, fmt.Errorf("error from `Marshal` function, package `json`: %#v", err)
+	}
+
+	updated, err := watchJob(ctx, jbr.rdb, job.SessionID, job.ID, func(pipe redis.Pipeliner) {
+		pipe.Set(ctx, jobKey(job.SessionID), string(jobJSON), jbr.ttl)
+	})
+	if err != nil {
+		return false, fmt.Errorf("error from `watchJob` function, package `gateway`: %#v", err)
+	}
+	return updated, nil
+}
+
+// GetJob получает состояние задания сессии. Если задания нет, возвращается false
+// Входные параметры: ctx - контекст, sessionID - идентификатор сессии
+func (jbr *jobRepository) GetJob(ctx context.Context, sessionID string) (models.Job, bool, error) {
+	jobJSON, err := jbr.rdb.Get(ctx, jobKey(sessionID)).Result()
+	if err == redis.Nil {
+		return models.Job{}, false, nil
+	}
+	if err != nil {
+		return models.Job{}, false, fmt.Errorf("error from `Get` method, package `redis`: %#v", err)
+	}
+
+	var job models.Job
+	if err = json.Unmarshal([]byte(jobJSON), &job); err != nil {
+		return models.Job{}, false, fmt.Errorf("error from `Unmarshal` function, package `json`: %#v", err)
+	}
+	return job, true, nil
+}
+
+// watchJob выполняет запись write одной транзакцией, только если Redis содержит задание сессии с идентификатором
+// jobID или не содержит задания сессии. Ключ задания отслеживается командой WATCH, поэтому задание, замененное
+// между чтением и записью, тоже ничего не записывает. Если задание заменено другим, возвращается false
+// Входные параметры: ctx - контекст, rdb - клиент Redis, sessionID - идентификатор сессии, jobID - идентификатор
+// задания, write - запись
+func watchJob(ctx context.Context, rdb *redis.Client, sessionID, jobID string, write func(pipe redis.Pipeliner)) (bool, error) {
+	key := jobKey(sessionID)
+	for attempt := 0; attempt < jobUpdateRetries; attempt++ {
+		var written bool
+		err := rdb.Watch(ctx, func(tx *redis.Tx) error {
+			storedJSON, err := tx.Get(ctx, key).Result()
+			if err != nil && err != redis.Nil {
+				return fmt.Errorf("error from `Get` method, package `redis`: %#v", err)
+			}
+			if err == nil {
+				var stored models.Job
+				if err = json.Unmarshal([]byte(storedJSON), &stored); err != nil {
+					return fmt.Errorf("error from `Unmarshal` function, package `json`: %#v", err)
+				}
+				if stored.ID != jobID {
+					return nil
+				}
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				write(pipe)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			written = true
+			return nil
+		}, key)
+		if err == redis.TxFailedErr {
+			continue
+		}
+		if err != nil {
+			return false, fmt.Errorf("error from `Watch` method, package `redis`: %#v", err)
+		}
+		return written, nil
+	}
+	return false, fmt.Errorf("error, the job of session %s is changed concurrently %d times", sessionID, jobUpdateRetries)
+}
+
+// jobKey возвращает ключ, под которым хранится задание сессии
+// Входной параметр: sessionID - идентификатор сессии
+func jobKey(sessionID string) string {
+	return fmt.Sprintf("%s:job", sessionID)
+}
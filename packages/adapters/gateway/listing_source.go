@@ -0,0 +1,447 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"vehicles/packages/domain/models"
+	"vehicles/packages/usecases/repository"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/djimenez/iconv-go"
+)
+
+// названия интернет-порталов объявлений, которыми помечаются собранные автомобили и которые перечисляются
+// в настройке scraping.sources
+const (
+	DromSource   = "drom.ru"
+	AutoRuSource = "auto.ru"
+)
+
+// ограничение количества автомобилей в обычном поиске
+var limitValue int = 10
+
+// trimPageLimit - наибольшее количество страниц комплектации и поколения, которые просматриваются для одного автомобиля
+const trimPageLimit = 3
+
+// trimParamsSeparator отделяет в ключе кэша ссылку на страницу поколения от краткого перечня характеристик
+const trimParamsSeparator = "#"
+
+// trimCacheTimeout - наибольшее время обращения к кэшу характеристик комплектаций
+const trimCacheTimeout = 2 * time.Second
+
+// ListingSource - интернет-портал объявлений о продаже автомобилей. Портал формирует ссылки на страницы объявлений и
+// разбирает свои веб-страницы, а загружает страницы общий для всех порталов алгоритм сбора данных (см. listingRepository):
+// страница объявлений -> страница автомобиля -> страницы поколения и комплектации
+type ListingSource interface {
+	// Name возвращает название портала, которым помечаются собранные с него автомобили
+	Name() string
+
+	// Charset возвращает кодировку веб-страниц портала
+	Charset() string
+
+	// SearchLink формирует ссылку на страницу объявлений согласно параметрам обычного поиска
+	// Входной параметр: search - параметры поиска пользователя, которые он вводил на главное странице в большой форме сверху
+	SearchLink(search models.Search) string
+
+	// MakeLink формирует ссылку на страницу объявлений об автомобилях одной марки в диапазоне цен
+	// Входные параметры: minPrice  - минимальная цена, maxPrice - максимальная цена, make - название марки
+	MakeLink(minPrice, maxPrice, make string) string
+
+	// ParseListPage собирает со страницы объявлений ссылки на страницы автомобилей, их названия и цены
+	// Входные параметры: document - страница объявлений, quantity - количество автомобилей для поиска
+	ParseListPage(document *goquery.Document, quantity int) []Listing
+
+	// ParseCarPage собирает характеристики автомобиля со страницы объявления и возвращает ссылку на страницу
+	// комплектации или поколения, если она есть
+	// Входные параметры: document - страница автомобиля, car - автомобиль
+	ParseCarPage(document *goquery.Document, car *models.Car) (TrimReference, error)
+
+	// EnrichTrim собирает характеристики автомобиля со страницы комплектации или поколения. Если по странице
+	// поколения найдена подходящая комплектация, возвращается ссылка на страницу этой комплектации
+	// Входные параметры: document - страница комплектации или поколения, car - автомобиль, trim - ссылка на эту страницу
+	EnrichTrim(document *goquery.Document, car *models.Car, trim TrimReference) (TrimReference, error)
+}
+
+// Listing - объявление на странице объявлений
+type Listing struct {
+	// Link - ссылка на страницу автомобиля
+	Link string
+	// Name - название автомобиля с годом выпуска
+	Name string
+	// Price - цена без знака валюты
+	Price string
+	// Make, Model - марка и модель из ссылки на страницу автомобиля; пусто, если ссылка их не содержит
+	Make, Model string
+}
+
+// TrimReference - ссылка со страницы автомобиля на страницу комплектации или поколения
+type TrimReference struct {
+	// Link - ссылка; пустая, если страницы нет
+	Link string
+	// Generation - признак того, что ссылка ведет на страницу поколения, где подходящая комплектация
+	// выбирается по краткому перечню характеристик
+	Generation bool
+	// Params - краткий перечень характеристик автомобиля со страницы объявления (двигатель, мощность,
+	// коробка передач, привод)
+	Params map[string]string
+}
+
+// NewListingSource создает интернет-портал объявлений по названию
+// Входной параметр: name - название портала (DromSource, AutoRuSource)
+func NewListingSource(name string) (ListingSource, error) {
+	switch name {
+	case DromSource:
+		return &dromSource{}, nil
+	case AutoRuSource:
+		return &autoRuSource{}, nil
+	default:
+		return nil, fmt.Errorf("error, unknown listing source %q", name)
+	}
+}
+
+type listingRepository struct {
+	// source - интернет-портал объявлений
+	source ListingSource
+	// fetcher - загрузчик веб-страниц
+	fetcher Fetcher
+	// trims - общий кэш характеристик страниц комплектаций и поколений; nil, если кэш не используется
+	trims repository.TrimCache
+	// workers - места пула, в котором собираются данные автомобилей; пул общий для всех запросов пользователей
+	workers chan struct{}
+	// timeout - наибольшее время сбора данных по одному запросу пользователя
+	timeout time.Duration
+}
+
+// NewListingRepository создает репозиторий, собирающий данные автомобилей с интернет-портала объявлений
+// Входные параметры: source - интернет-портал объявлений, fetcher - загрузчик веб-страниц, trims - кэш характеристик
+// страниц комплектаций и поколений (nil - страницы загружаются всегда), workers - количество автомобилей, данные
+// которых собираются одновременно (0 - 1), timeout - наибольшее время сбора данных по одному запросу пользователя
+// (0 - без ограничения, кроме контекста запроса)
+func NewListingRepository(source ListingSource, fetcher Fetcher, trims repository.TrimCache, workers int,
+	timeout time.Duration) repository.ListingRepository {
+	if workers <= 0 {
+		workers = 1
+	}
+	return &listingRepository{source, fetcher, trims, make(chan struct{}, workers), timeout}
+}
+
+// Source возвращает название интернет-портала
+func (lsr *listingRepository) Source() string {
+	return lsr.source.Name()
+}
+
+// ScrapeSearchCars собирает данные автомобилей из интернета
+// Входные параметры: ctx - контекст запроса пользователя, search - параметры поиска пользователя, которые он вводил
+// на главное странице в большой форме сверху, progress - получатель сведений о ходе сбора данных
+func (lsr *listingRepository) ScrapeSearchCars(ctx context.Context, search models.Search,
+	progress repository.ScrapeProgress) ([]models.Car, error) {
+	ctx, cancel := lsr.withTimeout(ctx)
+	defer cancel()
+
+	cars, err := lsr.scrapeCars(ctx, progress, lsr.source.SearchLink(search), limitValue)
+	if err != nil {
+		return nil, fmt.Errorf("error from `scrapeCars` method, package `gateway`: %#v", err)
+	}
+	return cars, nil
+}
+
+// ScrapeSelectionCars собирает данные автомобилей из интернета. Марки обрабатываются одновременно; марка, данные
+// которой не удалось собрать, пропускается, и ошибка возвращается, только если не удалось собрать ни одной марки
+// Входные параметры: ctx - контекст запроса пользователя, minPrice  - минимальная цена, maxPrice - максимальная цена,
+// makes - срез марок, progress - получатель сведений о ходе сбора данных
+func (lsr *listingRepository) ScrapeSelectionCars(ctx context.Context, minPrice, maxPrice string, makes []models.Makes,
+	progress repository.ScrapeProgress) ([]models.Car, error) {
+	ctx, cancel := lsr.withTimeout(ctx)
+	defer cancel()
+
+	makeCars := make([][]models.Car, len(makes))
+	errs := make([]error, len(makes))
+	var wg sync.WaitGroup
+	for i, thisMake := range makes {
+		wg.Add(1)
+		go func(i int, thisMake models.Makes) {
+			defer wg.Done()
+			makeCars[i], errs[i] = lsr.scrapeCars(ctx, progress, lsr.source.MakeLink(minPrice, maxPrice, thisMake.Make),
+				thisMake.NumberOfCars)
+		}(i, thisMake)
+	}
+	wg.Wait()
+
+	var cars []models.Car
+	var lastErr error
+	for i, thisMake := range makes {
+		if errs[i] != nil {
+			fmt.Printf("error while scraping %s cars from %s: %#v\n", thisMake.Make, lsr.Source(), errs[i])
+			lastErr = errs[i]
+			continue
+		}
+		cars = append(cars, makeCars[i]...)
+	}
+	if len(cars) == 0 && lastErr != nil {
+		return nil, fmt.Errorf("error from `scrapeCars` method, package `gateway`: %#v", lastErr)
+	}
+	return cars, nil
+}
+
+// withTimeout ограничивает время сбора данных по запросу пользователя
+// Входной параметр: ctx - контекст запроса пользователя
+func (lsr *listingRepository) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if lsr.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, lsr.timeout)
+}
+
+// scrapeCars собирает данные автомобилей, объявления о которых размещены на странице объявлений. Данные автомобилей
+// собираются одновременно в пуле репозитория; автомобиль, данные которого не удалось собрать до истечения времени
+// запроса или из-за ошибки, пропускается. Ошибка возвращается, только если не удалось собрать ни одного автомобиля.
+// Идентификаторы автомобилям не присваиваются: их присваивает сценарий, объединяющий автомобили всех порталов
+// Входные параметры: ctx - контекст запроса пользователя, progress - получатель сведений о ходе сбора данных,
+// link - ссылка на страницу объявлений, quantity - количество автомобилей для поиска
+func (lsr *listingRepository) scrapeCars(ctx context.Context, progress repository.ScrapeProgress, link string,
+	quantity int) ([]models.Car, error) {
+	document, err := lsr.fetchPage(ctx, progress, link)
+	if err != nil {
+		return nil, fmt.Errorf("error from `fetchPage` method, package `gateway`: %#v", err)
+	}
+
+	listings := lsr.source.ParseListPage(document, quantity)
+	listingCars := make([]models.Car, len(listings))
+	errs := make([]error, len(listings))
+	var wg sync.WaitGroup
+	for i, listing := range listings {
+		wg.Add(1)
+		go func(i int, listing Listing) {
+			defer wg.Done()
+			select {
+			case lsr.workers <- struct{}{}:
+				defer func() { <-lsr.workers }()
+			case <-ctx.Done():
+				errs[i] = fmt.Errorf("error, the car %s was not scraped: %#v", listing.Link, ctx.Err())
+				return
+			}
+
+			car := models.NewCar()
+			car.FullName = listing.Name
+			car.Make, car.Model = listing.Make, listing.Model
+			car.Offering.Price = fmt.Sprintf("%s₽", listing.Price)
+			if errs[i] = lsr.scrapeCharacteristics(ctx, progress, &car, listing.Link); errs[i] == nil {
+				progress.CarParsed()
+			}
+			listingCars[i] = car
+		}(i, listing)
+	}
+	wg.Wait()
+
+	cars := make([]models.Car, 0, len(listings))
+	var lastErr error
+	for i, listing := range listings {
+		if errs[i] != nil {
+			fmt.Printf("error while scraping the car %s: %#v\n", listing.Link, errs[i])
+			lastErr = errs[i]
+			continue
+		}
+		cars = append(cars, listingCars[i])
+	}
+	if len(cars) == 0 && lastErr != nil {
+		return nil, fmt.Errorf("error from `scrapeCharacteristics` method, package `gateway`: %#v", lastErr)
+	}
+	return cars, nil
+}
+
+// scrapeCharacteristics собирает характеристики автомобиля с его страницы и страниц комплектации и поколения
+// Входные параметры: ctx - контекст запроса пользователя, progress - получатель сведений о ходе сбора данных,
+// car - автомобиль, link - ссылка на страницу автомобиля
+func (lsr *listingRepository) scrapeCharacteristics(ctx context.Context, progress repository.ScrapeProgress,
+	car *models.Car, link string) error {
+	document, err := lsr.fetchPage(ctx, progress, link)
+	if err != nil {
+		return fmt.Errorf("error from `fetchPage` method, package `gateway`: %#v", err)
+	}
+
+	trim, err := lsr.source.ParseCarPage(document, car)
+	if err != nil {
+		return fmt.Errorf("error from `ParseCarPage` method, package `gateway`: %#v", err)
+	}
+
+	for page := 0; trim.Link != "" && page < trimPageLimit; page++ {
+		if trim, err = lsr.enrichTrim(ctx, progress, car, trim); err != nil {
+			return fmt.Errorf("error from `enrichTrim` method, package `gateway`: %#v", err)
+		}
+	}
+	return nil
+}
+
+// enrichTrim переносит в автомобиль характеристики страницы комплектации или поколения. Характеристики берутся
+// из кэша, а если их там нет, собираются с загруженной страницы и сохраняются в кэш. Ошибки кэша не прерывают
+// сбор данных: страница в этом случае загружается
+// Входные параметры: ctx - контекст запроса пользователя, progress - получатель сведений о ходе сбора данных,
+// car - автомобиль, trim - ссылка на страницу комплектации или поколения
+func (lsr *listingRepository) enrichTrim(ctx context.Context, progress repository.ScrapeProgress, car *models.Car,
+	trim TrimReference) (TrimReference, error) {
+	key := trimCacheKey(trim)
+	if cached, ok := lsr.cachedTrim(ctx, key); ok {
+		applyTrim(car, cached)
+		return TrimReference{Link: cached.ComplectationLink}, nil
+	}
+
+	document, err := lsr.fetchPage(ctx, progress, trim.Link)
+	if err != nil {
+		return TrimReference{}, fmt.Errorf("error from `fetchPage` method, package `gateway`: %#v", err)
+	}
+
+	// характеристики собираются в новый автомобиль, чтобы в кэш попали только сведения со страницы
+	// комплектации, а не со страницы объявления
+	trimCar := models.NewCar()
+	next, err := lsr.source.EnrichTrim(document, &trimCar, trim)
+	if err != nil {
+		return TrimReference{}, fmt.Errorf("error from `EnrichTrim` method, package `gateway`: %#v", err)
+	}
+	parsed := models.Trim{TrimLevel: trimCar.TrimLevel, Specs: trimCar.Specs, Features: trimCar.Features,
+		ComplectationLink: next.Link}
+	applyTrim(car, parsed)
+
+	// страница без названия комплектации и без ссылки на нее (например, страница проверки на робота)
+	// не сохраняется, чтобы не подменять характеристики комплектации на все время хранения кэша
+	if parsed.TrimLevel != models.UndefinedStr || parsed.ComplectationLink != "" {
+		lsr.saveTrim(ctx, key, parsed)
+	}
+	return next, nil
+}
+
+// cachedTrim получает характеристики страницы из кэша
+// Входные параметры: ctx - контекст запроса пользователя, key - ключ страницы
+func (lsr *listingRepository) cachedTrim(ctx context.Context, key string) (models.Trim, bool) {
+	if lsr.trims == nil {
+		return models.Trim{}, false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, trimCacheTimeout)
+	defer cancel()
+	trim, ok, err := lsr.trims.GetTrim(ctx, key)
+	if err != nil {
+		fmt.Printf("error from `GetTrim` method, package `gateway`: %#v\n", err)
+		return models.Trim{}, false
+	}
+	return trim, ok
+}
+
+// saveTrim сохраняет характеристики страницы в кэш
+// Входные параметры: ctx - контекст запроса пользователя, key - ключ страницы, trim - характеристики
+func (lsr *listingRepository) saveTrim(ctx context.Context, key string, trim models.Trim) {
+	if lsr.trims == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, trimCacheTimeout)
+	defer cancel()
+	if err := lsr.trims.SaveTrim(ctx, key, trim); err != nil {
+		fmt.Printf("error from `SaveTrim` method, package `gateway`: %#v\n", err)
+	}
+}
+
+// trimCacheKey возвращает ключ кэша для страницы комплектации или поколения. Комплектация на странице поколения
+// выбирается по краткому перечню характеристик автомобиля, поэтому перечень входит в ключ
+// Входной параметр: trim - ссылка на страницу комплектации или поколения
+func trimCacheKey(trim TrimReference) string {
+	if !trim.Generation {
+		return trim.Link
+	}
+
+	params := url.Values{}
+	for name, value := range trim.Params {
+		params.Set(name, value)
+	}
+	return trim.Link + trimParamsSeparator + params.Encode()
+}
+
+// applyTrim переносит в автомобиль характеристики, которые есть на странице комплектации или поколения, то есть
+// отличаются от характеристик нового автомобиля. Тип кузова со страницы комплектации только дополняет страницу
+// объявления, как при разборе страницы
+// Входные параметры: car - автомобиль, trim - характеристики страницы
+func applyTrim(car *models.Car, trim models.Trim) {
+	blank := models.NewCar()
+	if trim.TrimLevel != blank.TrimLevel {
+		car.TrimLevel = trim.TrimLevel
+	}
+
+	body := car.Specs.Body
+	mergeFields(reflect.ValueOf(&car.Specs).Elem(), reflect.ValueOf(trim.Specs), reflect.ValueOf(blank.Specs))
+	mergeFields(reflect.ValueOf(&car.Features).Elem(), reflect.ValueOf(trim.Features), reflect.ValueOf(blank.Features))
+	if body != "" && body != models.UndefinedStr {
+		car.Specs.Body = body
+	}
+}
+
+// mergeFields переносит поля структуры, значения которых отличаются от значений по умолчанию; вложенные структуры
+// обходятся рекурсивно
+// Входные параметры: target - изменяемая структура, source - структура с новыми значениями, blank - структура
+// со значениями по умолчанию
+func mergeFields(target, source, blank reflect.Value) {
+	for i := 0; i < target.NumField(); i++ {
+		if target.Field(i).Kind() == reflect.Struct {
+			mergeFields(target.Field(i), source.Field(i), blank.Field(i))
+			continue
+		}
+		if source.Field(i).Interface() != blank.Field(i).Interface() {
+			target.Field(i).Set(source.Field(i))
+		}
+	}
+}
+
+// fetchPage получает веб-страницу портала и сообщает о ее загрузке
+// Входные параметры: ctx - контекст запроса пользователя, progress - получатель сведений о ходе сбора данных,
+// link - ссылка на веб-страницу
+func (lsr *listingRepository) fetchPage(ctx context.Context, progress repository.ScrapeProgress,
+	link string) (*goquery.Document, error) {
+	document, err := getWebPage(ctx, lsr.fetcher, link, lsr.source.Charset())
+	if err != nil {
+		return nil, fmt.Errorf("error from `getWebPage` function, package `gateway`: %#v", err)
+	}
+	progress.PageFetched()
+	return document, nil
+}
+
+// linkSegments возвращает части пути ссылки без пустых частей
+// Входной параметр: link - ссылка
+func linkSegments(link string) []string {
+	parsedLink, err := url.Parse(link)
+	if err != nil {
+		return nil
+	}
+	return strings.FieldsFunc(parsedLink.Path, func(r rune) bool { return r == '/' })
+}
+
+// getWebPage получает какую-либо веб-страницу
+// Входные параметры: ctx - контекст запроса пользователя, fetcher - загрузчик веб-страниц, link - ссылка
+// на веб-страницу, charset - кодировка веб-страницы
+func getWebPage(ctx context.Context, fetcher Fetcher, link, charset string) (*goquery.Document, error) {
+	body, err := fetcher.Fetch(ctx, link)
+	if err != nil {
+		return nil, fmt.Errorf("error from `Fetch` method, package `gateway`: %#v", err)
+	}
+
+	var reader io.Reader = bytes.NewReader(body)
+	if charset != "utf-8" {
+		// смена кодировки страницы, например, с windows-1251 на utf-8
+		reader, err = iconv.NewReader(reader, charset, "utf-8")
+		if err != nil {
+			return nil, fmt.Errorf("error from `NewReader` function, package `iconv`: error while converting charset from %s to utf-8: %#v",
+				charset, err)
+		}
+	}
+
+	// создание объекта структуры, представляющего HTML документ
+	document, err := goquery.NewDocumentFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error from `NewDocumentFromReader` function, package `goquery`: %#v", err)
+	}
+	return document, nil
+}
@@ -0,0 +1,330 @@
+package gateway_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"vehicles/packages/adapters/gateway"
+	"vehicles/packages/domain/models"
+)
+
+// fixturesDir - каталог сохраненных веб-страниц порталов объявлений
+const fixturesDir = "testdata/fixtures"
+
+// listingSource создает интернет-портал объявлений по названию
+func listingSource(t *testing.T, name string) gateway.ListingSource {
+	source, err := gateway.NewListingSource(name)
+	if err != nil {
+		t.Fatalf("error from `NewListingSource` function: %#v", err)
+	}
+	return source
+}
+
+// TestDromSelection разбирает сохраненные страницы drom.ru: страницу объявлений, страницы автомобилей, страницу
+// поколения и страницы комплектаций
+func TestDromSelection(t *testing.T) {
+	repo := gateway.NewListingRepository(listingSource(t, gateway.DromSource), gateway.NewReplayFetcher(fixturesDir), nil,
+		4, 0)
+	progress := &countingProgress{}
+	cars, err := repo.ScrapeSelectionCars(context.Background(), "", "3000000", []models.Makes{{Make: "toyota", NumberOfCars: 3}},
+		progress)
+	if err != nil {
+		t.Fatalf("error from `ScrapeSelectionCars` method: %#v", err)
+	}
+	if len(cars) != 3 {
+		t.Fatalf("error, scraped %d cars, expected 3", len(cars))
+	}
+	// страница объявлений, 3 страницы автомобилей, страница поколения и 2 страницы комплектаций
+	check(t, "PagesFetched", progress.pages.Load(), 7)
+	check(t, "CarsParsed", progress.cars.Load(), 3)
+
+	// комплектация по ссылке со страницы автомобиля
+	camry := cars[0]
+	check(t, "FullName", camry.FullName, "Toyota Camry, 2019")
+	check(t, "Model", camry.Model, "camry")
+	check(t, "Price", camry.Offering.Price, "2 650 000₽")
+	check(t, "Kilometerage", camry.Offering.Kilometerage, "54 000")
+	check(t, "Description", camry.Description, "Один владелец, обслуживание у официального дилера, полный комплект ключей.")
+	check(t, "Generation", camry.Generation, "8 поколение (XV70)")
+	check(t, "TrimLevel", camry.TrimLevel, "2.5 AT Prestige Safety")
+	check(t, "Drive", camry.Specs.Drive, "передний (FF)")
+	check(t, "PowerSteering", camry.Specs.SteeringWheel.PowerSteering, "Электроусилитель")
+	check(t, "Upholstery", camry.Features.Interior.Upholstery, "Кожаная")
+	check(t, "AirConditioner", camry.Features.CabinMicroclimate.AirConditioner, models.NoValue)
+	check(t, "ElectricHeatingOfBackSeats", camry.Features.ElectricOptions.ElectricHeatingOfBackSeats,
+		models.OptionValue)
+	check(t, "Year", camry.Offering.Year, 2019)
+	check(t, "Length", camry.Specs.Length, 4885)
+	check(t, "Mass", camry.Specs.Mass, 1570)
+	check(t, "MaxPower", camry.Specs.Engine.MaxPower, 181)
+	check(t, "FrontTiresRimDiameter", camry.Specs.Tires.FrontTiresRimDiameter, 18)
+	check(t, "MixedFuelConsumption", camry.Specs.MixedFuelConsumption, 7.9)
+	if len(camry.Offering.PhotoURLs) != 2 {
+		t.Errorf("PhotoURLs: %v, expected 2 photos", camry.Offering.PhotoURLs)
+	}
+
+	// комплектация, подобранная на странице поколения по двигателю, мощности, коробке передач и приводу
+	rav4 := cars[1]
+	check(t, "TrimLevel", rav4.TrimLevel, "2.0 CVT Комфорт")
+	check(t, "Drive", rav4.Specs.Drive, "полный (4WD)")
+	check(t, "BackStabilizer", rav4.Specs.Suspension.BackStabilizer, models.NoValue)
+	check(t, "Mass", rav4.Specs.Mass, 1610)
+	check(t, "Capacity", rav4.Specs.Engine.Capacity, 1986)
+
+	// объявление без ссылок на страницы комплектации и поколения
+	prado := cars[2]
+	check(t, "TrimLevel", prado.TrimLevel, models.UndefinedStr)
+	check(t, "SteeringWheelPosition", prado.Specs.SteeringWheel.SteeringWheelPosition, "Правый руль")
+	check(t, "Mass", prado.Specs.Mass, 0)
+}
+
+// TestPartialResults проверяет, что автомобиль, страница которого не загрузилась, пропускается, а если время
+// запроса истекло до загрузки страницы объявлений, возвращается ошибка
+func TestPartialResults(t *testing.T) {
+	dir := copyFixtures(t, "https://moscow.drom.ru/toyota/rav4/48222222.html")
+	repo := gateway.NewListingRepository(listingSource(t, gateway.DromSource), gateway.NewReplayFetcher(dir), nil,
+		2, 0)
+	makes := []models.Makes{{Make: "toyota", NumberOfCars: 3}}
+	cars, err := repo.ScrapeSelectionCars(context.Background(), "", "3000000", makes, &countingProgress{})
+	if err != nil {
+		t.Fatalf("error from `ScrapeSelectionCars` method: %#v", err)
+	}
+	if len(cars) != 2 || cars[0].FullName != "Toyota Camry, 2019" || cars[1].FullName != "Toyota Land Cruiser Prado, 2012" {
+		t.Fatalf("error, scraped %d cars, expected Camry and Land Cruiser Prado", len(cars))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = repo.ScrapeSelectionCars(ctx, "", "3000000", makes, &countingProgress{}); err == nil {
+		t.Errorf("error, cars were scraped after the request was cancelled")
+	}
+}
+
+// TestTrimCache проверяет, что характеристики страниц комплектаций и поколений, собранные при подборе, используются
+// обычным поиском и повторным подбором без загрузки этих страниц, а после удаления из кэша страница загружается снова
+func TestTrimCache(t *testing.T) {
+	trims := &memoryTrimCache{trims: make(map[string]models.Trim)}
+	source := listingSource(t, gateway.DromSource)
+	makes := []models.Makes{{Make: "toyota", NumberOfCars: 3}}
+	repo := gateway.NewListingRepository(source, gateway.NewReplayFetcher(fixturesDir), trims, 4, 0)
+	if _, err := repo.ScrapeSelectionCars(context.Background(), "", "3000000", makes, &countingProgress{}); err != nil {
+		t.Fatalf("error from `ScrapeSelectionCars` method: %#v", err)
+	}
+	// 2 страницы комплектаций и выбор комплектации на странице поколения
+	check(t, "cached trims", len(trims.trims), 3)
+
+	complectation := "https://www.drom.ru/catalog/toyota/camry/291045/"
+	dir := copyFixtures(t, complectation, "https://www.drom.ru/catalog/toyota/rav4/g_2018_11370/",
+		"https://www.drom.ru/catalog/toyota/rav4/292000/")
+	repo = gateway.NewListingRepository(source, gateway.NewReplayFetcher(dir), trims, 4, 0)
+	progress := &countingProgress{}
+	cars, err := repo.ScrapeSelectionCars(context.Background(), "", "3000000", makes, progress)
+	if err != nil {
+		t.Fatalf("error from `ScrapeSelectionCars` method: %#v", err)
+	}
+	if len(cars) != 3 {
+		t.Fatalf("error, scraped %d cars, expected 3", len(cars))
+	}
+	// страница объявлений и 3 страницы автомобилей
+	check(t, "PagesFetched", progress.pages.Load(), 4)
+	check(t, "TrimLevel", cars[0].TrimLevel, "2.5 AT Prestige Safety")
+	check(t, "FullName", cars[0].FullName, "Toyota Camry, 2019")
+	check(t, "Mass", cars[0].Specs.Mass, 1570)
+	check(t, "ElectricHeatingOfBackSeats", cars[0].Features.ElectricOptions.ElectricHeatingOfBackSeats,
+		models.OptionValue)
+	check(t, "TrimLevel", cars[1].TrimLevel, "2.0 CVT Комфорт")
+	check(t, "Drive", cars[1].Specs.Drive, "полный (4WD)")
+	check(t, "SteeringWheelPosition", cars[2].Specs.SteeringWheel.SteeringWheelPosition, "Правый руль")
+
+	cars, err = repo.ScrapeSearchCars(context.Background(), models.Search{Mark: "toyota", Model: "camry"}, &countingProgress{})
+	if err != nil || len(cars) != 1 {
+		t.Fatalf("error from `ScrapeSearchCars` method: %d cars, %#v", len(cars), err)
+	}
+	check(t, "TrimLevel", cars[0].TrimLevel, "2.5 AT Prestige Safety")
+
+	if err = trims.InvalidateTrim(context.Background(), complectation); err != nil {
+		t.Fatalf("error from `InvalidateTrim` method: %#v", err)
+	}
+	cars, err = repo.ScrapeSearchCars(context.Background(), models.Search{Mark: "toyota", Model: "camry"}, &countingProgress{})
+	if err == nil && len(cars) != 0 {
+		t.Errorf("error, the invalidated complectation page was not fetched again")
+	}
+}
+
+// TestDromSearch разбирает сохраненную страницу обычного поиска drom.ru
+func TestDromSearch(t *testing.T) {
+	repo := gateway.NewListingRepository(listingSource(t, gateway.DromSource), gateway.NewReplayFetcher(fixturesDir), nil,
+		4, 0)
+	cars, err := repo.ScrapeSearchCars(context.Background(), models.Search{Mark: "toyota", Model: "camry"}, &countingProgress{})
+	if err != nil {
+		t.Fatalf("error from `ScrapeSearchCars` method: %#v", err)
+	}
+	if len(cars) != 1 {
+		t.Fatalf("error, scraped %d cars, expected 1", len(cars))
+	}
+	check(t, "TrimLevel", cars[0].TrimLevel, "2.5 AT Prestige Safety")
+}
+
+// TestAutoRuSelection разбирает сохраненные страницы auto.ru: страницу объявлений, страницу автомобиля и страницу
+// технических характеристик
+func TestAutoRuSelection(t *testing.T) {
+	repo := gateway.NewListingRepository(listingSource(t, gateway.AutoRuSource), gateway.NewReplayFetcher(fixturesDir), nil,
+		4, 0)
+	cars, err := repo.ScrapeSelectionCars(context.Background(), "", "3000000", []models.Makes{{Make: "toyota", NumberOfCars: 3}},
+		&countingProgress{})
+	if err != nil {
+		t.Fatalf("error from `ScrapeSelectionCars` method: %#v", err)
+	}
+	if len(cars) != 1 {
+		t.Fatalf("error, scraped %d cars, expected 1", len(cars))
+	}
+
+	car := cars[0]
+	check(t, "FullName", car.FullName, "Toyota Camry VIII (XV70), 2018")
+	check(t, "Make", car.Make, "toyota")
+	check(t, "TrimLevel", car.TrimLevel, "2.5 AT (181 л.с.) Элеганс")
+	check(t, "Gearbox", car.Specs.Gearbox, "АКПП")
+	check(t, "ClimateControl", car.Features.CabinMicroclimate.ClimateControl, models.YesValue)
+	check(t, "Year", car.Offering.Year, 2018)
+	check(t, "Length", car.Specs.Length, 4885)
+	check(t, "Mass", car.Specs.Mass, 1570)
+}
+
+// TestRecordReplay сохраняет страницу тестового сервера в режиме записи и читает ее в режиме воспроизведения
+func TestRecordReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/toyota/all/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("<html><body>toyota</body></html>"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	link := server.URL + "/toyota/all/?maxprice=3000000"
+	recorder, err := gateway.NewFetcher(gateway.FetcherConfig{Mode: gateway.RecordFetch, FixturesDir: dir})
+	if err != nil {
+		t.Fatalf("error from `NewFetcher` function: %#v", err)
+	}
+	if _, err = recorder.Fetch(context.Background(), link); err != nil {
+		t.Fatalf("error from `Fetch` method: %#v", err)
+	}
+	if _, err = recorder.Fetch(context.Background(), server.URL+"/missing/"); err == nil {
+		t.Errorf("error, the recorder accepted the 404 page")
+	}
+	if _, err = os.Stat(filepath.Join(dir, gateway.FixtureName(link))); err != nil {
+		t.Fatalf("error, the page is not recorded: %#v", err)
+	}
+
+	server.Close()
+	replayer, err := gateway.NewFetcher(gateway.FetcherConfig{Mode: gateway.ReplayFetch, FixturesDir: dir})
+	if err != nil {
+		t.Fatalf("error from `NewFetcher` function: %#v", err)
+	}
+	body, err := replayer.Fetch(context.Background(), link)
+	if err != nil {
+		t.Fatalf("error from `Fetch` method: %#v", err)
+	}
+	if !strings.Contains(string(body), "toyota") {
+		t.Errorf("error, replayed page %q", body)
+	}
+	if _, err = replayer.Fetch(context.Background(), server.URL+"/toyota/camry/"); err == nil {
+		t.Errorf("error, the replayer returned a page that was not recorded")
+	}
+}
+
+// copyFixtures копирует сохраненные веб-страницы во временный каталог, пропуская страницы с заданными ссылками
+func copyFixtures(t *testing.T, skip ...string) string {
+	t.Helper()
+	missing := make(map[string]bool, len(skip))
+	for _, link := range skip {
+		missing[gateway.FixtureName(link)] = true
+	}
+
+	dir := t.TempDir()
+	fixtures, err := os.ReadDir(fixturesDir)
+	if err != nil {
+		t.Fatalf("error from `ReadDir` function: %#v", err)
+	}
+	for _, fixture := range fixtures {
+		if missing[fixture.Name()] {
+			continue
+		}
+		body, err := os.ReadFile(filepath.Join(fixturesDir, fixture.Name()))
+		if err != nil {
+			t.Fatalf("error from `ReadFile` function: %#v", err)
+		}
+		if err = os.WriteFile(filepath.Join(dir, fixture.Name()), body, 0o644); err != nil {
+			t.Fatalf("error from `WriteFile` function: %#v", err)
+		}
+	}
+	return dir
+}
+
+// check сравнивает значение поля автомобиля с ожидаемым
+func check[T comparable](t *testing.T, field string, actual, expected T) {
+	t.Helper()
+	if actual != expected {
+		t.Errorf("%s: %v, expected %v", field, actual, expected)
+	}
+}
+
+// countingProgress подсчитывает загруженные страницы и собранные автомобили
+type countingProgress struct {
+	pages atomic.Int64
+	cars  atomic.Int64
+}
+
+func (cnp *countingProgress) PageFetched() {
+	cnp.pages.Add(1)
+}
+
+func (cnp *countingProgress) CarParsed() {
+	cnp.cars.Add(1)
+}
+
+// memoryTrimCache хранит характеристики страниц комплектаций в памяти
+type memoryTrimCache struct {
+	mutex sync.Mutex
+	trims map[string]models.Trim
+}
+
+func (mtc *memoryTrimCache) GetTrim(ctx context.Context, link string) (models.Trim, bool, error) {
+	mtc.mutex.Lock()
+	defer mtc.mutex.Unlock()
+	trim, ok := mtc.trims[link]
+	return trim, ok, nil
+}
+
+func (mtc *memoryTrimCache) SaveTrim(ctx context.Context, link string, trim models.Trim) error {
+	mtc.mutex.Lock()
+	defer mtc.mutex.Unlock()
+	mtc.trims[link] = trim
+	return nil
+}
+
+func (mtc *memoryTrimCache) InvalidateTrim(ctx context.Context, link string) error {
+	mtc.mutex.Lock()
+	defer mtc.mutex.Unlock()
+	for key := range mtc.trims {
+		if key == link || strings.HasPrefix(key, link+"#") {
+			delete(mtc.trims, key)
+		}
+	}
+	return nil
+}
+
+func (mtc *memoryTrimCache) Clear(ctx context.Context) (int64, error) {
+	mtc.mutex.Lock()
+	defer mtc.mutex.Unlock()
+	deleted := int64(len(mtc.trims))
+	mtc.trims = make(map[string]models.Trim)
+	return deleted, nil
+}
@@ -0,0 +1,148 @@
+package gateway
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"vehicles/packages/domain/fuzzy"
+	"vehicles/packages/usecases/repository"
+
+	"github.com/lib/pq"
+	"gopkg.in/yaml.v3"
+)
+
+type membershipFileLoader struct {
+	// fileName - путь к файлу в формате YAML или JSON с описаниями функций принадлежности
+	fileName string
+}
+
+// NewMembershipFileLoader создает источник описаний функций принадлежности, читающий файл
+// Входной параметр: fileName - путь к файлу в формате YAML или JSON
+func NewMembershipFileLoader(fileName string) fuzzy.MembershipLoader {
+	return &membershipFileLoader{fileName}
+}
+
+// LoadMemberships читает описания функций принадлежности из файла
+func (mfl *membershipFileLoader) LoadMemberships(ctx context.Context) (fuzzy.MembershipConfig, error) {
+	data, err := os.ReadFile(mfl.fileName)
+	if err != nil {
+		return fuzzy.MembershipConfig{}, fmt.Errorf("error from `ReadFile` function, package `os`: %#v", err)
+	}
+
+	var config fuzzy.MembershipConfig
+	if err = yaml.Unmarshal(data, &config); err != nil {
+		return fuzzy.MembershipConfig{}, fmt.Errorf("error from `Unmarshal` function, package `yaml`: %#v", err)
+	}
+	if config.Version == "" {
+		return fuzzy.MembershipConfig{}, fmt.Errorf("error, there is no version in %s", mfl.fileName)
+	}
+	return config, nil
+}
+
+type membershipRepository struct {
+	// vehiclesDB - клиент для подключения к реляционной БД под управлением PostgreSQL,
+	// хранящей таблицу membership_functions
+	vehiclesDB *sql.DB
+}
+
+// NewMembershipRepository создает хранилище версий описаний функций принадлежности в таблице membership_functions
+// Входной параметр: vehiclesDB - клиент для подключения к БД
+func NewMembershipRepository(vehiclesDB *sql.DB) repository.MembershipRepository {
+	return &membershipRepository{vehiclesDB}
+}
+
+// LoadMemberships получает из БД описания функций принадлежности последней одобренной версии
+func (mbr *membershipRepository) LoadMemberships(ctx context.Context) (fuzzy.MembershipConfig, error) {
+	query := `
+        SELECT version, variable, term, function_type, params
+        FROM membership_functions
+        WHERE version = (SELECT MAX(version) FROM membership_functions WHERE approved)
+        ORDER BY id;
+    `
+
+	rows, err := mbr.vehiclesDB.QueryContext(ctx, query)
+	if err != nil {
+		return fuzzy.MembershipConfig{}, fmt.Errorf("error from `QueryContext` method, package `sql`: %#v", err)
+	}
+	defer rows.Close()
+
+	var config fuzzy.MembershipConfig
+	for rows.Next() {
+		var version int
+		var definition fuzzy.MembershipDefinition
+		if err := rows.Scan(&version, &definition.Variable, &definition.Term, &definition.Type,
+			pq.Array(&definition.Params)); err != nil {
+			return fuzzy.MembershipConfig{}, fmt.Errorf("error from `Scan` method, package `sql`: %#v", err)
+		}
+		config.Version = strconv.Itoa(version)
+		config.Definitions = append(config.Definitions, definition)
+	}
+
+	if err = rows.Err(); err != nil {
+		return fuzzy.MembershipConfig{}, fmt.Errorf("error from `Err` method, package `sql`: %#v", err)
+	}
+	if len(config.Definitions) == 0 {
+		return fuzzy.MembershipConfig{}, fmt.Errorf("error, there is no approved version in the table membership_functions")
+	}
+	return config, nil
+}
+
+// PublishMemberships записывает в БД новую неодобренную версию описаний функций принадлежности
+// Входные параметры: ctx - контекст, config - описания функций принадлежности
+func (mbr *membershipRepository) PublishMemberships(ctx context.Context, config fuzzy.MembershipConfig) (string, error) {
+	tx, err := mbr.vehiclesDB.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("error from `BeginTx` method, package `sql`: %#v", err)
+	}
+	defer tx.Rollback()
+
+	// таблица блокируется, чтобы две одновременные публикации не получили одну версию
+	if _, err = tx.ExecContext(ctx, `LOCK TABLE membership_functions IN EXCLUSIVE MODE;`); err != nil {
+		return "", fmt.Errorf("error from `ExecContext` method, package `sql`: %#v", err)
+	}
+
+	var version int
+	if err = tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) + 1 FROM membership_functions;`).Scan(&version); err != nil {
+		return "", fmt.Errorf("error from `Scan` method, package `sql`: %#v", err)
+	}
+
+	query := `
+        INSERT INTO membership_functions (version, variable, term, function_type, params, approved)
+        VALUES ($1, $2, $3, $4, $5, FALSE);
+    `
+	for _, definition := range config.Definitions {
+		if _, err = tx.ExecContext(ctx, query, version, definition.Variable, definition.Term, definition.Type,
+			pq.Array(definition.Params)); err != nil {
+			return "", fmt.Errorf("error from `ExecContext` method, package `sql`: %#v", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return "", fmt.Errorf("error from `Commit` method, package `sql`: %#v", err)
+	}
+	return strconv.Itoa(version), nil
+}
+
+// ApproveMemberships одобряет версию описаний функций принадлежности
+// Входные параметры: ctx - контекст, version - версия
+func (mbr *membershipRepository) ApproveMemberships(ctx context.Context, version string) error {
+	number, err := strconv.Atoi(version)
+	if err != nil {
+		return fmt.Errorf("error from `Atoi` function, package `strconv`: %#v", err)
+	}
+
+	result, err := mbr.vehiclesDB.ExecContext(ctx, `UPDATE membership_functions SET approved = TRUE WHERE version = $1;`, number)
+	if err != nil {
+		return fmt.Errorf("error from `ExecContext` method, package `sql`: %#v", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error from `RowsAffected` method, package `sql`: %#v", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("error, there is no version %s in the table membership_functions", version)
+	}
+	return nil
+}
@@ -2,7 +2,10 @@ package gateway
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"vehicles/packages/adapters"
 	"vehicles/packages/domain/models"
@@ -29,6 +32,18 @@ func (slr *selectionRepository) SetPriorities(priorities []string) {
 	slr.ctx.SetCookie("priorities", prioritiesStr, 3600, "/", "localhost", false, true)
 }
 
+// SetWeights сохраняет веса приоритетов, заданные пользователем, в cookie в виде
+// "безопасность:2,комфорт:1". Пустые веса удаляют сохраненные ранее веса
+// Входной параметр: weights - веса приоритетов (ключ - нечеткое множество)
+func (slr *selectionRepository) SetWeights(weights map[string]float64) {
+	pairs := make([]string, 0, len(weights))
+	for variable, weight := range weights {
+		pairs = append(pairs, variable+":"+strconv.FormatFloat(weight, 'g', -1, 64))
+	}
+	sort.Strings(pairs)
+	slr.ctx.SetCookie("weights", strings.Join(pairs, ","), 3600, "/", "localhost", false, true)
+}
+
 // SetPrice сохраняет диапазон цен, заданный пользователем, в cookies
 // Входные параметры: minPrice - минимальная цена, maxPrice - максимальная цена
 func (slr *selectionRepository) SetPrice(minPrice, maxPrice string) {
@@ -43,6 +58,17 @@ func (slr *selectionRepository) SetManufacturers(manufacturers []string) {
 	slr.ctx.SetCookie("manufacturers", manufacturersStr, 3600, "/", "localhost", false, true)
 }
 
+// SetConstraints сохраняет жесткие ограничения, заданные пользователем, в cookie в формате JSON
+// Входной параметр: constraints - ограничения
+func (slr *selectionRepository) SetConstraints(constraints models.Constraints) error {
+	data, err := json.Marshal(constraints)
+	if err != nil {
+		return fmt.Errorf("error from `Marshal` function, package `json`: %#v", err)
+	}
+	slr.ctx.SetCookie("constraints", string(data), 3600, "/", "localhost", false, true)
+	return nil
+}
+
 // GetSelectionParams получает параметры, заданные ранее пользователем, из cookies
 func (slr *selectionRepository) GetSelectionParams() (*models.Selection, error) {
 	slc := new(models.Selection)
@@ -52,6 +78,22 @@ func (slr *selectionRepository) GetSelectionParams() (*models.Selection, error)
 	}
 	slc.Priorities = strings.Split(prioritiesStr, ",")
 
+	// cookie с весами нет, если приоритеты сохранены до появления весов
+	if weightsStr, err := slr.ctx.Cookie("weights"); err == nil && weightsStr != "" {
+		slc.Weights = make(map[string]float64)
+		for _, pair := range strings.Split(weightsStr, ",") {
+			variable, weightStr, ok := strings.Cut(pair, ":")
+			if !ok {
+				return nil, fmt.Errorf("error, malformed weight %q", pair)
+			}
+			weight, err := strconv.ParseFloat(weightStr, 64)
+			if err != nil {
+				return nil, fmt.Errorf("error from `ParseFloat` function, package `strconv`: %#v", err)
+			}
+			slc.Weights[variable] = weight
+		}
+	}
+
 	slc.MinPrice, err = slr.ctx.Cookie("minPrice")
 	if err != nil {
 		return nil, fmt.Errorf("error from `Cookie` method, package `gin`: %#v", err)
@@ -67,5 +109,12 @@ func (slr *selectionRepository) GetSelectionParams() (*models.Selection, error)
 		return nil, fmt.Errorf("error from `Cookie` method, package `gin`: %#v", err)
 	}
 	slc.Manufacturers = strings.Split(manufacturersStr, ",")
+
+	// cookie с ограничениями нет, если пользователь прошел шаги подбора до появления ограничений
+	if constraintsStr, err := slr.ctx.Cookie("constraints"); err == nil && constraintsStr != "" {
+		if err = json.Unmarshal([]byte(constraintsStr), &slc.Constraints); err != nil {
+			return nil, fmt.Errorf("error from `Unmarshal` function, package `json`: %#v", err)
+		}
+	}
 	return slc, nil
 }
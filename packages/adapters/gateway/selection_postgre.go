@@ -3,12 +3,15 @@ package gateway
 import (
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 	"vehicles/packages/domain/models"
 
 	"github.com/lib/pq"
 )
 
-// SelectCars получает из реляционной БД под управлением PostgreSQL информацию об автомобилях
+// SelectCars получает из реляционной БД под управлением PostgreSQL информацию об автомобилях,
+// удовлетворяющих жестким ограничениям
 // Входной параметр: sln - запрос пользователя
 func (slr *selectionRepository) SelectCars(sln models.Selection) ([]models.Car, error) {
 	query := `SELECT makes.make, models.model, generations.generation, steering_wheel_positions.position, power_steering_types.power_steering, 
@@ -109,6 +112,11 @@ func (slr *selectionRepository) SelectCars(sln models.Selection) ([]models.Car,
 		whereClause = fmt.Sprintf("%s)", whereClause)
 	}
 
+	whereClause, args, err := appendConstraints(whereClause, args, sln.Constraints, time.Now().Year())
+	if err != nil {
+		return nil, fmt.Errorf("error from `appendConstraints` function, package `gateway`: %#v", err)
+	}
+
 	if whereClause != "" {
 		query = fmt.Sprintf("%s %s", query, whereClause)
 	}
@@ -157,6 +165,7 @@ func (slr *selectionRepository) SelectCars(sln models.Selection) ([]models.Car,
 
 		car.ID = index
 		car.FullName = fmt.Sprintf("%s %s, %s", make, model, strconv.Itoa(car.Offering.Year))
+		car.Make, car.Model = make, model
 		car.Offering.Price = formatPrice(car.Offering.Price)
 		cars = append(cars, car)
 		index++
@@ -167,6 +176,82 @@ func (slr *selectionRepository) SelectCars(sln models.Selection) ([]models.Car,
 	return cars, nil
 }
 
+// optionColumns - столбцы БД с наличием обязательных опций (ключ - название опции)
+var optionColumns = map[string]string{
+	models.AirConditionerOption:      "cabin_microclimate.air_conditioner",
+	models.ClimateControlOption:      "cabin_microclimate.climate_control",
+	models.RearViewCameraOption:      "safety_and_motion_control_systems.rear_view_camera",
+	models.BackParkingSensorOption:   "safety_and_motion_control_systems.back_parking_sensor",
+	models.CruiseControlOption:       "safety_and_motion_control_systems.cruise_control",
+	models.HeatedFrontSeatsOption:    "electric_options.electric_heating_of_front_seats",
+	models.HeatedSteeringWheelOption: "electric_options.electric_heating_of_steering_wheel",
+	models.RainSensorOption:          "electric_options.rain_sensor",
+}
+
+// appendConstraints добавляет к условию WHERE жесткие ограничения, заданные пользователем
+// Входные параметры: whereClause - условие WHERE, args - аргументы запроса, cns - ограничения,
+// currentYear - текущий год
+func appendConstraints(whereClause string, args []interface{}, cns models.Constraints,
+	currentYear int) (string, []interface{}, error) {
+	if err := cns.Validate(); err != nil {
+		return "", nil, fmt.Errorf("error from `Validate` method, package `models`: %#v", err)
+	}
+
+	var conditions []string
+	// условие для каждого текстового ограничения: название содержит хотя бы одно из слов
+	for _, text := range []struct {
+		column   string
+		keywords []string
+	}{
+		{"body_types.body", models.Keywords(nil, cns.Bodies)},
+		{"gearboxes.gearbox", models.Keywords(models.GearboxKeywords, cns.Gearboxes)},
+		{"drive_types.drive", models.Keywords(models.DriveKeywords, cns.Drives)},
+		{"engines.fuel_used", models.Keywords(models.FuelKeywords, cns.Fuels)},
+	} {
+		if len(text.keywords) == 0 {
+			continue
+		}
+		alternatives := make([]string, 0, len(text.keywords))
+		for _, keyword := range text.keywords {
+			args = append(args, "%"+escapeLike(keyword)+"%")
+			alternatives = append(alternatives, fmt.Sprintf("%s ILIKE $%d", text.column, len(args)))
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s)", strings.Join(alternatives, " OR ")))
+	}
+
+	if cns.MinSeats > 0 {
+		args = append(args, cns.MinSeats)
+		conditions = append(conditions, fmt.Sprintf("trim_levels.number_of_seats >= $%d", len(args)))
+	}
+	if cns.MinTrunkVolume > 0 {
+		args = append(args, cns.MinTrunkVolume)
+		conditions = append(conditions, fmt.Sprintf("trim_levels.trunk_volume >= $%d", len(args)))
+	}
+	if cns.MaxAge > 0 {
+		args = append(args, currentYear-cns.MaxAge)
+		conditions = append(conditions, fmt.Sprintf("specifications.year >= $%d", len(args)))
+	}
+	for _, option := range cns.Options {
+		args = append(args, string(models.YesValue))
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", optionColumns[option], len(args)))
+	}
+
+	for _, condition := range conditions {
+		if whereClause == "" {
+			whereClause = fmt.Sprintf("WHERE %s", condition)
+		} else {
+			whereClause = fmt.Sprintf("%s AND %s", whereClause, condition)
+		}
+	}
+	return whereClause, args, nil
+}
+
+// escapeLike экранирует символы шаблона оператора LIKE
+// Входной параметр: value - значение
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
 // formatPrice приводит цену к формату
 // Входной параметр: price - цена
 func formatPrice(price string) string {
@@ -0,0 +1,83 @@
+package gateway_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+	"vehicles/packages/adapters/gateway"
+	"vehicles/packages/domain/fuzzy"
+	"vehicles/packages/domain/models"
+)
+
+// loadDatabaseCars получает автомобили из БД vehicles по строке подключения из переменной окружения VEHICLES_DSN
+// и повторяет их, пока их не станет numberOfCars. Автомобили получают разные идентификаторы. Если переменная
+// окружения не задана, бенчмарк пропускается
+// Входной параметр: numberOfCars - количество автомобилей
+func loadDatabaseCars(b *testing.B, numberOfCars int) []models.Car {
+	dsn := os.Getenv("VEHICLES_DSN")
+	if dsn == "" {
+		b.Skip("VEHICLES_DSN is not set")
+	}
+	vehiclesDB, err := sql.Open("postgres", dsn)
+	if err != nil {
+		b.Fatalf("error from `Open` function: %#v", err)
+	}
+	defer vehiclesDB.Close()
+
+	// запрос к БД не использует контекст HTTP-запроса
+	source, err := gateway.NewSelectionRepository(nil, vehiclesDB).SelectCars(models.Selection{})
+	if err != nil {
+		b.Fatalf("error from `SelectCars` method: %#v", err)
+	}
+	if len(source) == 0 {
+		b.Fatalf("error, there are no cars in the database")
+	}
+
+	cars := make([]models.Car, numberOfCars)
+	for idx := range cars {
+		cars[idx] = source[idx%len(source)]
+		cars[idx].ID = idx
+	}
+	return cars
+}
+
+// BenchmarkRankDatabaseCars сравнивает время последовательного (workers=1) и параллельного (workers=GOMAXPROCS)
+// ранжирования 1000 и 10000 автомобилей из БД vehicles по 243 правилам, как BenchmarkRank пакета fuzzy. Метрика
+// speedup - ускорение относительно последовательного ранжирования тех же автомобилей
+func BenchmarkRankDatabaseCars(b *testing.B) {
+	rules, err := fuzzy.LoadEmbeddedRuleIndex(fuzzy.DefaultVariables(), nil)
+	if err != nil {
+		b.Fatalf("error from `LoadEmbeddedRuleIndex` function: %#v", err)
+	}
+	priorities := []string{fuzzy.Comfort, fuzzy.Economy, fuzzy.Safety, fuzzy.Dynamics, fuzzy.Handling}
+
+	for _, numberOfCars := range []int{1000, 10000} {
+		cars := loadDatabaseCars(b, numberOfCars)
+		// sequential - время одного последовательного ранжирования в наносекундах
+		var sequential float64
+		for _, workers := range []int{1, runtime.GOMAXPROCS(0)} {
+			engine := fuzzy.NewEngine(rules, nil)
+			engine.Workers = workers
+
+			b.Run(fmt.Sprintf("cars=%d/workers=%d", numberOfCars, workers), func(b *testing.B) {
+				start := time.Now()
+				for idx := 0; idx < b.N; idx++ {
+					if _, err := engine.Rank(context.Background(), cars, priorities); err != nil {
+						b.Fatalf("error from `Rank` method: %#v", err)
+					}
+				}
+				perRank := float64(time.Since(start).Nanoseconds()) / float64(b.N)
+				if workers == 1 {
+					sequential = perRank
+				}
+				if sequential > 0 {
+					b.ReportMetric(sequential/perRank, "speedup")
+				}
+			})
+		}
+	}
+}
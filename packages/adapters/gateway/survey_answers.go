@@ -0,0 +1,102 @@
+package gateway
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"vehicles/packages/domain/fuzzy"
+	"vehicles/packages/usecases/repository"
+)
+
+// surveyAnswerTerm - нечеткое множество и нечеткое подмножество, которым соответствует возможный ответ
+type surveyAnswerTerm struct {
+	variable string
+	term     string
+}
+
+// surveyAnswerTerms - соответствие возможных ответов нечетким подмножествам. Коэффициент экономичности
+// равен расходу топлива, поэтому высокий расход означает низкую экономичность. Коэффициент динамики равен
+// времени разгона, поэтому подмножества динамики совпадают с ответами
+var surveyAnswerTerms = map[string]surveyAnswerTerm{
+	"Низкий расход":    {fuzzy.Economy, fuzzy.High},
+	"Средний расход":   {fuzzy.Economy, fuzzy.Medium},
+	"Высокий расход":   {fuzzy.Economy, fuzzy.Low},
+	"Высокая динамика": {fuzzy.Dynamics, fuzzy.High},
+	"Средняя динамика": {fuzzy.Dynamics, fuzzy.Medium},
+	"Низкая динамика":  {fuzzy.Dynamics, fuzzy.Low},
+}
+
+// questionValue находит в тексте вопроса значение расхода топлива или времени разгона, например,
+// "Как Вы думаете, время разгона 20 секунд от 0 до 100 км/ч — это:"
+var questionValue = regexp.MustCompile(`(\d+(?:[.,]\d+)?) (?:л/100 км|секунд)`)
+
+type surveyAnswersRepository struct {
+	// questionsDB - клиент для подключения к реляционной БД под управлением PostgreSQL,
+	// хранящей информацию, связанную с опросом пользователей
+	questionsDB *sql.DB
+}
+
+// NewSurveyAnswersRepository создает хранилище ответов пользователей на вопросы опроса
+// Входной параметр: questionsDB - клиент для подключения к БД опроса
+func NewSurveyAnswersRepository(questionsDB *sql.DB) repository.SurveyAnswersRepository {
+	return &surveyAnswersRepository{questionsDB}
+}
+
+// LoadSurveyAnswers получает из БД количество ответов пользователей на каждый вопрос о расходе топлива
+// и времени разгона
+func (sar *surveyAnswersRepository) LoadSurveyAnswers(ctx context.Context) ([]fuzzy.SurveyAnswers, error) {
+	query := `
+        SELECT questions.id, questions.question, user_responses.answer, COUNT(*)
+        FROM user_responses
+        INNER JOIN questions ON questions.id = user_responses.question_id
+        GROUP BY questions.id, questions.question, user_responses.answer
+        ORDER BY questions.id;
+    `
+
+	rows, err := sar.questionsDB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error from `QueryContext` method, package `sql`: %#v", err)
+	}
+	defer rows.Close()
+
+	var answers []fuzzy.SurveyAnswers
+	// indexes - индексы вопросов в срезе answers (ключ - id вопроса)
+	indexes := make(map[int]int)
+	for rows.Next() {
+		var questionID, count int
+		var question, answer string
+		if err := rows.Scan(&questionID, &question, &answer, &count); err != nil {
+			return nil, fmt.Errorf("error from `Scan` method, package `sql`: %#v", err)
+		}
+
+		answerTerm, ok := surveyAnswerTerms[answer]
+		if !ok {
+			continue
+		}
+
+		idx, ok := indexes[questionID]
+		if !ok {
+			match := questionValue.FindStringSubmatch(question)
+			if match == nil {
+				return nil, fmt.Errorf("error, there is no value in the question %q", question)
+			}
+			value, err := strconv.ParseFloat(strings.Replace(match[1], ",", ".", 1), 64)
+			if err != nil {
+				return nil, fmt.Errorf("error from `ParseFloat` function, package `strconv`: %#v", err)
+			}
+
+			idx = len(answers)
+			indexes[questionID] = idx
+			answers = append(answers, fuzzy.SurveyAnswers{Variable: answerTerm.variable, X: value, Counts: make(map[string]int)})
+		}
+		answers[idx].Counts[answerTerm.term] += count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error from `Err` method, package `sql`: %#v", err)
+	}
+	return answers, nil
+}
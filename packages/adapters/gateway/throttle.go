@@ -0,0 +1,167 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+type rateLimitedFetcher struct {
+	// fetcher - загрузчик, через который отправляются запросы
+	fetcher Fetcher
+	// rate - наибольшее количество запросов в секунду к одному сайту
+	rate float64
+	// burst - вместимость корзины токенов
+	burst int
+	// mutex - защита buckets
+	mutex sync.Mutex
+	// buckets - корзины токенов по сайтам
+	buckets map[string]*tokenBucket
+}
+
+// NewRateLimitedFetcher создает загрузчик, который ограничивает частоту запросов к каждому сайту алгоритмом корзины
+// токенов: корзина вмещает burst токенов и пополняется rate токенами в секунду, каждый запрос забирает один токен
+// Входные параметры: fetcher - загрузчик веб-страниц, rate - наибольшее количество запросов в секунду к одному сайту
+// (0 - без ограничения), burst - количество запросов, которые можно отправить подряд без ожидания (0 - 1)
+func NewRateLimitedFetcher(fetcher Fetcher, rate float64, burst int) Fetcher {
+	if rate <= 0 {
+		return fetcher
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	return &rateLimitedFetcher{fetcher: fetcher, rate: rate, burst: burst, buckets: make(map[string]*tokenBucket)}
+}
+
+// Fetch дожидается токена корзины сайта и загружает веб-страницу. Ошибка загрузчика возвращается без изменений,
+// чтобы по ней можно было решить, стоит ли загружать страницу повторно
+// Входные параметры: ctx - контекст запроса пользователя, link - ссылка на веб-страницу
+func (rlf *rateLimitedFetcher) Fetch(ctx context.Context, link string) ([]byte, error) {
+	parsedLink, err := url.Parse(link)
+	if err != nil {
+		return nil, fmt.Errorf("error from `Parse` function, package `url`: %#v", err)
+	}
+
+	if err = sleep(ctx, rlf.bucket(parsedLink.Host).reserve(time.Now())); err != nil {
+		return nil, fmt.Errorf("error, the request to %s was not sent: %#v", link, err)
+	}
+	return rlf.fetcher.Fetch(ctx, link)
+}
+
+// bucket возвращает корзину токенов сайта, создавая ее при первом запросе
+// Входной параметр: host - сайт
+func (rlf *rateLimitedFetcher) bucket(host string) *tokenBucket {
+	rlf.mutex.Lock()
+	defer rlf.mutex.Unlock()
+
+	bucket, ok := rlf.buckets[host]
+	if !ok {
+		bucket = &tokenBucket{rate: rlf.rate, burst: float64(rlf.burst), tokens: float64(rlf.burst)}
+		rlf.buckets[host] = bucket
+	}
+	return bucket
+}
+
+// tokenBucket - корзина токенов одного сайта
+type tokenBucket struct {
+	// mutex - защита полей корзины
+	mutex sync.Mutex
+	// rate - скорость пополнения, токенов в секунду
+	rate float64
+	// burst - вместимость
+	burst float64
+	// tokens - количество токенов; отрицательное, если токены уже обещаны ожидающим запросам
+	tokens float64
+	// last - время последнего пополнения
+	last time.Time
+}
+
+// reserve забирает токен и возвращает время, через которое его можно использовать. Токен забирается сразу, поэтому
+// ожидающие запросы получают токены в порядке обращения
+// Входной параметр: now - текущее время
+func (tkb *tokenBucket) reserve(now time.Time) time.Duration {
+	tkb.mutex.Lock()
+	defer tkb.mutex.Unlock()
+
+	if !tkb.last.IsZero() {
+		tkb.tokens += now.Sub(tkb.last).Seconds() * tkb.rate
+		if tkb.tokens > tkb.burst {
+			tkb.tokens = tkb.burst
+		}
+	}
+	tkb.last = now
+
+	tkb.tokens--
+	if tkb.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-tkb.tokens / tkb.rate * float64(time.Second))
+}
+
+type retryFetcher struct {
+	// fetcher - загрузчик, через который отправляются запросы
+	fetcher Fetcher
+	// retries - количество повторных загрузок
+	retries int
+	// backoff - пауза перед первой повторной загрузкой
+	backoff time.Duration
+}
+
+// NewRetryFetcher создает загрузчик, который повторно загружает страницу, если сайт ответил статусом 429 или 5xx.
+// Паузы между загрузками растут экспоненциально: backoff, 2*backoff, 4*backoff...; если сайт указал паузу
+// в заголовке Retry-After, выдерживается большая из пауз
+// Входные параметры: fetcher - загрузчик веб-страниц, retries - количество повторных загрузок,
+// backoff - пауза перед первой повторной загрузкой
+func NewRetryFetcher(fetcher Fetcher, retries int, backoff time.Duration) Fetcher {
+	if retries <= 0 {
+		return fetcher
+	}
+	return &retryFetcher{fetcher, retries, backoff}
+}
+
+// Fetch загружает веб-страницу, повторяя загрузку при временных ошибках сайта
+// Входные параметры: ctx - контекст запроса пользователя, link - ссылка на веб-страницу
+func (rtf *retryFetcher) Fetch(ctx context.Context, link string) ([]byte, error) {
+	delay := rtf.backoff
+	for attempt := 0; ; attempt++ {
+		body, err := rtf.fetcher.Fetch(ctx, link)
+		if err == nil {
+			return body, nil
+		}
+
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || !statusErr.Temporary() || attempt == rtf.retries {
+			return nil, fmt.Errorf("error from `Fetch` method, package `gateway`: attempt %d: %#v", attempt+1, err)
+		}
+
+		wait := delay
+		if statusErr.RetryAfter > wait {
+			wait = statusErr.RetryAfter
+		}
+		if err = sleep(ctx, wait); err != nil {
+			return nil, fmt.Errorf("error, the page %s was not fetched again after status %d: %#v", link,
+				statusErr.StatusCode, err)
+		}
+		delay *= 2
+	}
+}
+
+// sleep ожидает заданное время или отмены контекста
+// Входные параметры: ctx - контекст запроса пользователя, delay - время ожидания
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
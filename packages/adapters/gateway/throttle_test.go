@@ -0,0 +1,76 @@
+package gateway_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+	"vehicles/packages/adapters/gateway"
+)
+
+// TestRetryFetcher проверяет, что страница загружается повторно после ответов 503 и не загружается повторно после 404
+func TestRetryFetcher(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count := atomic.AddInt32(&requests, 1)
+		switch {
+		case r.URL.Path == "/missing/":
+			http.NotFound(w, r)
+		case count <= 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+
+	fetcher := gateway.NewRetryFetcher(gateway.NewLiveFetcher(server.Client()), 3, time.Millisecond)
+	body, err := fetcher.Fetch(context.Background(), server.URL+"/page/")
+	if err != nil {
+		t.Fatalf("error from `Fetch` method: %#v", err)
+	}
+	if string(body) != "ok" || atomic.LoadInt32(&requests) != 3 {
+		t.Errorf("error, body %q after %d requests, expected \"ok\" after 3 requests", body, requests)
+	}
+
+	atomic.StoreInt32(&requests, 10)
+	if _, err = fetcher.Fetch(context.Background(), server.URL+"/missing/"); err == nil {
+		t.Fatalf("error, the 404 page was fetched")
+	}
+	if count := atomic.LoadInt32(&requests); count != 11 {
+		t.Errorf("error, the 404 page was requested %d times, expected once", count-10)
+	}
+}
+
+// TestRateLimitedFetcher проверяет, что запросы к одному сайту отправляются не чаще заданной частоты, а ожидание
+// прерывается отменой контекста
+func TestRateLimitedFetcher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	// 20 запросов в секунду: первый запрос без ожидания, каждый следующий через 50 мс
+	fetcher := gateway.NewRateLimitedFetcher(gateway.NewLiveFetcher(server.Client()), 20, 1)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := fetcher.Fetch(context.Background(), server.URL); err != nil {
+			t.Fatalf("error from `Fetch` method: %#v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Errorf("error, 4 requests took %v, expected at least 150ms", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	fetcher = gateway.NewRateLimitedFetcher(gateway.NewLiveFetcher(server.Client()), 1, 1)
+	if _, err := fetcher.Fetch(ctx, server.URL); err != nil {
+		t.Fatalf("error from `Fetch` method: %#v", err)
+	}
+	if _, err := fetcher.Fetch(ctx, server.URL); err == nil {
+		t.Errorf("error, the request was sent before the token was available")
+	}
+}
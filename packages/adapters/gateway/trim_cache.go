@@ -0,0 +1,117 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"vehicles/packages/domain/models"
+	"vehicles/packages/usecases/repository"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// trimKeyPrefix - префикс ключей кэша характеристик комплектаций
+const trimKeyPrefix = "trim:"
+
+// trimScanCount - количество ключей, которые Redis просматривает за один вызов SCAN
+const trimScanCount = 1000
+
+type trimCache struct {
+	// rdb - клиент Redis для подключения к NoSQL БД, хранящей кэш
+	rdb *redis.Client
+	// ttl - время хранения характеристик страницы
+	ttl time.Duration
+}
+
+// NewTrimCache создает кэш характеристик страниц комплектаций и поколений в Redis
+// Входные параметры: rdb - клиент Redis, ttl - время хранения характеристик страницы (0 - без ограничения)
+func NewTrimCache(rdb *redis.Client, ttl time.Duration) repository.TrimCache {
+	return &trimCache{rdb, ttl}
+}
+
+// GetTrim получает характеристики страницы из кэша. Если их нет или время хранения истекло, возвращается false
+// Входные параметры: ctx - контекст, link - ключ страницы
+func (trc *trimCache) GetTrim(ctx context.Context, link string) (models.Trim, bool, error) {
+	trimJSON, err := trc.rdb.Get(ctx, trimKeyPrefix+link).Result()
+	if err == redis.Nil {
+		return models.Trim{}, false, nil
+	}
+	if err != nil {
+		return models.Trim{}, false, fmt.Errorf("error from `Get` method, package `redis`: %#v", err)
+	}
+
+	var trim models.Trim
+	if err = json.Unmarshal([]byte(trimJSON), &trim); err != nil {
+		return models.Trim{}, false, fmt.Errorf("error from `Unmarshal` function, package `json`: %#v", err)
+	}
+	return trim, true, nil
+}
+
+// SaveTrim сохраняет характеристики страницы в кэш
+// Входные параметры: ctx - контекст, link - ключ страницы, trim - характеристики
+func (trc *trimCache) SaveTrim(ctx context.Context, link string, trim models.Trim) error {
+	trimJSON, err := json.Marshal(trim)
+	if err != nil {
+		return fmt.Errorf("error from `Marshal` function, package `json`: %#v", err)
+	}
+
+	if err = trc.rdb.Set(ctx, trimKeyPrefix+link, string(trimJSON), trc.ttl).Err(); err != nil {
+		return fmt.Errorf("error from `Set` method, package `redis`: %#v", err)
+	}
+	return nil
+}
+
+// InvalidateTrim удаляет из кэша характеристики страницы комплектации или все варианты выбора комплектации
+// на странице поколения
+// Входные параметры: ctx - контекст, link - ссылка на страницу
+func (trc *trimCache) InvalidateTrim(ctx context.Context, link string) error {
+	if err := trc.rdb.Del(ctx, trimKeyPrefix+link).Err(); err != nil {
+		return fmt.Errorf("error from `Del` method, package `redis`: %#v", err)
+	}
+	if _, err := trc.deleteKeys(ctx, trimKeyPrefix+escapePattern(link)+trimParamsSeparator+"*"); err != nil {
+		return fmt.Errorf("error from `deleteKeys` method, package `gateway`: %#v", err)
+	}
+	return nil
+}
+
+// Clear удаляет из кэша характеристики всех страниц и возвращает количество удаленных записей
+// Входной параметр: ctx - контекст
+func (trc *trimCache) Clear(ctx context.Context) (int64, error) {
+	deleted, err := trc.deleteKeys(ctx, trimKeyPrefix+"*")
+	if err != nil {
+		return deleted, fmt.Errorf("error from `deleteKeys` method, package `gateway`: %#v", err)
+	}
+	return deleted, nil
+}
+
+// deleteKeys удаляет ключи, подходящие под шаблон. Ключи перебираются командой SCAN, чтобы не блокировать Redis
+// Входные параметры: ctx - контекст, pattern - шаблон ключей
+func (trc *trimCache) deleteKeys(ctx context.Context, pattern string) (int64, error) {
+	var deleted int64
+	var cursor uint64
+	for {
+		keys, next, err := trc.rdb.Scan(ctx, cursor, pattern, trimScanCount).Result()
+		if err != nil {
+			return deleted, fmt.Errorf("error from `Scan` method, package `redis`: %#v", err)
+		}
+		if len(keys) > 0 {
+			count, err := trc.rdb.Del(ctx, keys...).Result()
+			if err != nil {
+				return deleted, fmt.Errorf("error from `Del` method, package `redis`: %#v", err)
+			}
+			deleted += count
+		}
+		if next == 0 {
+			return deleted, nil
+		}
+		cursor = next
+	}
+}
+
+// escapePattern экранирует специальные символы шаблона ключей Redis, которые встречаются в ссылках
+// Входной параметр: link - ссылка
+func escapePattern(link string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`).Replace(link)
+}
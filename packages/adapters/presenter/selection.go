@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"vehicles/packages/adapters"
+	"vehicles/packages/domain/fuzzy"
 	"vehicles/packages/domain/models"
 	usecase "vehicles/packages/usecases/usecases"
 
@@ -20,8 +21,8 @@ func NewSelectionPresenter(ctx adapters.Context) usecase.SelectionOutput {
 }
 
 // следующие методы рендерят различные веб-страницы
-func (s *selectionPresenter) ShowPriorities() {
-	s.ctx.HTML(http.StatusOK, "priorities.html", nil)
+func (s *selectionPresenter) ShowPriorities(variables []fuzzy.Variable, maxPriorities int) {
+	s.ctx.HTML(http.StatusOK, "priorities.html", gin.H{"Variables": variables, "MaxPriorities": maxPriorities})
 }
 
 func (s *selectionPresenter) ShowPrice() {
@@ -32,16 +33,28 @@ func (s *selectionPresenter) ShowManufacturers() {
 	s.ctx.HTML(http.StatusOK, "manufacturers.html", nil)
 }
 
+func (s *selectionPresenter) ShowConstraints() {
+	s.ctx.HTML(http.StatusOK, "constraints.html", nil)
+}
+
 func (s *selectionPresenter) ShowSources() {
 	s.ctx.HTML(http.StatusOK, "choice.html", nil)
 }
 
-// ShowResultOfFuzzyAlgorithm рендерит страницу, отображающую ранжированный с помощью нечеткого алгоритма список автомобилей
-// Входные параметры: sessionID - идентификатор сессии, cars - автомобили
-func (s *selectionPresenter) ShowResultOfFuzzyAlgorithm(sessionID string, cars []models.Car, choice bool) {
+// ShowResultOfFuzzyAlgorithm рендерит страницу, отображающую ранжированный с помощью нечеткого алгоритма список автомобилей.
+// Автомобили с низкой достоверностью результата помечаются
+// Входные параметры: sessionID - идентификатор сессии, cars - автомобили, explanations - объяснения результатов
+// нечеткого алгоритма в том же порядке или nil, если автомобили ранжировались по цене
+func (s *selectionPresenter) ShowResultOfFuzzyAlgorithm(sessionID string, cars []models.Car, explanations []fuzzy.Explanation,
+	choice bool) {
 	indexes := make([]int, len(cars))
+	// carExplanations - объяснение для каждого автомобиля или nil, если объяснения нет
+	carExplanations := make([]*fuzzy.Explanation, len(cars))
 	for i := range cars {
 		indexes[i] = i + 1
+		if i < len(explanations) {
+			carExplanations[i] = &explanations[i]
+		}
 	}
 
 	var Link string
@@ -51,18 +64,134 @@ func (s *selectionPresenter) ShowResultOfFuzzyAlgorithm(sessionID string, cars [
 		Link = fmt.Sprintf("http://localhost:8080/selection/internal_db?guest=%s&carID=", sessionID)
 	}
 
+	source := "internal_db"
+	if choice {
+		source = "internet"
+	}
+	// анализ чувствительности доступен, только если автомобили ранжировались нечетким алгоритмом
+	var sensitivityLink string
+	if len(explanations) > 0 {
+		sensitivityLink = fmt.Sprintf("http://localhost:8080/selection/sensitivity?guest=%s&source=%s", sessionID, source)
+	}
+
 	s.ctx.HTML(http.StatusOK, "offer_for_selection.html", gin.H{
-		"Cars": cars, "Quantity": len(cars), "SessionID": sessionID, "Indexes": indexes, "Link": Link})
+		"Cars": cars, "Quantity": len(cars), "SessionID": sessionID, "Indexes": indexes, "Link": Link,
+		"Explanations": carExplanations, "SensitivityLink": sensitivityLink,
+		"ParetoLink": fmt.Sprintf("http://localhost:8080/selection/pareto?guest=%s&source=%s", sessionID, source)})
 }
 
 // ShowSelectionCarAd рендерит страницу конкретного автомобиля
-// Входные параметры: sessionID - идентификатор сессии, car - автомобиль
-func (s *selectionPresenter) ShowSelectionCarAd(sessionID string, car models.Car, choice bool) {
+// Входные параметры: sessionID - идентификатор сессии, carID - место автомобиля в рейтинге, car - автомобиль,
+// explanation - объяснение результата нечеткого алгоритма или nil, если автомобили ранжировались по цене
+func (s *selectionPresenter) ShowSelectionCarAd(sessionID string, carID int, car models.Car, explanation *fuzzy.Explanation, choice bool) {
 	var partOfLink string
 	if choice {
 		partOfLink = fmt.Sprintf("selection/internet?guest=%s", sessionID)
 	} else {
 		partOfLink = fmt.Sprintf("selection/internal_db?guest=%s", sessionID)
 	}
-	s.ctx.HTML(http.StatusOK, "car_card.html", gin.H{"Car": car, "PartOfLink": partOfLink})
+	s.ctx.HTML(http.StatusOK, "car_card.html", gin.H{"Car": car, "PartOfLink": partOfLink, "Explanation": explanation,
+		"SessionID": sessionID, "CarID": carID})
+}
+
+// sensitivityRow - строка таблицы анализа чувствительности: автомобиль и изменение его места
+type sensitivityRow struct {
+	Car         models.Car
+	Link        string
+	Sensitivity fuzzy.CarSensitivity
+}
+
+// ShowSensitivity рендерит страницу, показывающую, как изменяется рейтинг автомобилей при изменении приоритетов
+// Входные параметры: sessionID - идентификатор сессии, cars - автомобили в порядке сохраненного рейтинга,
+// идентификатор автомобиля равен его месту, report - результат анализа чувствительности
+func (s *selectionPresenter) ShowSensitivity(sessionID string, cars []models.Car, report fuzzy.SensitivityReport, choice bool) {
+	source := "internal_db"
+	if choice {
+		source = "internet"
+	}
+
+	rows := make([]sensitivityRow, 0, len(report.Cars))
+	for _, carSensitivity := range report.Cars {
+		rows = append(rows, sensitivityRow{
+			Car:         cars[carSensitivity.CarID-1],
+			Link:        fmt.Sprintf("http://localhost:8080/selection/%s?guest=%s&carID=%d", source, sessionID, carSensitivity.CarID),
+			Sensitivity: carSensitivity,
+		})
+	}
+
+	stableTop := make([]models.Car, 0, len(report.StableTop))
+	for _, carID := range report.StableTop {
+		stableTop = append(stableTop, cars[carID-1])
+	}
+
+	s.ctx.HTML(http.StatusOK, "sensitivity.html", gin.H{"Scenarios": report.Scenarios, "TopN": report.TopN, "Rows": rows,
+		"StableTop": stableTop, "Excluded": len(report.Excluded), "ResultsLink": fmt.Sprintf("http://localhost:8080/selection/%s?guest=%s", source, sessionID)})
+}
+
+// SendSensitivity отправляет результат анализа чувствительности в формате JSON
+// Входной параметр: report - результат анализа чувствительности
+func (s *selectionPresenter) SendSensitivity(report fuzzy.SensitivityReport) {
+	s.ctx.JSON(http.StatusOK, report)
+}
+
+// paretoFront - фронт Парето на странице фронтов Парето
+type paretoFront struct {
+	Number int
+	Rows   []paretoRow
+}
+
+// paretoRow - автомобиль на странице фронтов Парето и его коэффициенты в порядке критериев
+type paretoRow struct {
+	Car    models.Car
+	Link   string
+	Values []paretoValue
+	Pareto fuzzy.ParetoCar
+}
+
+// paretoValue - коэффициент автомобиля; Known равно false, если коэффициент нельзя вычислить
+type paretoValue struct {
+	Value float64
+	Known bool
+}
+
+// ShowParetoFronts рендерит страницу, показывающую автомобили, разбитые на фронты Парето
+// Входные параметры: sessionID - идентификатор сессии, cars - автомобили в порядке сохраненного рейтинга,
+// идентификатор автомобиля равен его месту, report - фронты Парето
+func (s *selectionPresenter) ShowParetoFronts(sessionID string, cars []models.Car, report fuzzy.ParetoReport, choice bool) {
+	source := "internal_db"
+	if choice {
+		source = "internet"
+	}
+
+	fronts := make([]paretoFront, 0, len(report.Fronts))
+	for idx, front := range report.Fronts {
+		rows := make([]paretoRow, 0, len(front))
+		for _, paretoCar := range front {
+			unknown := make(map[string]bool, len(paretoCar.Unknown))
+			for _, criterion := range paretoCar.Unknown {
+				unknown[criterion] = true
+			}
+			values := make([]paretoValue, 0, len(report.Criteria))
+			for _, criterion := range report.Criteria {
+				values = append(values, paretoValue{Value: paretoCar.Coefficients[criterion], Known: !unknown[criterion]})
+			}
+
+			rows = append(rows, paretoRow{
+				Car:    cars[paretoCar.CarID-1],
+				Link:   fmt.Sprintf("http://localhost:8080/selection/%s?guest=%s&carID=%d", source, sessionID, paretoCar.CarID),
+				Values: values,
+				Pareto: paretoCar,
+			})
+		}
+		fronts = append(fronts, paretoFront{Number: idx + 1, Rows: rows})
+	}
+
+	s.ctx.HTML(http.StatusOK, "pareto.html", gin.H{"Criteria": report.Criteria, "Fronts": fronts,
+		"ResultsLink": fmt.Sprintf("http://localhost:8080/selection/%s?guest=%s", source, sessionID)})
+}
+
+// SendParetoFronts отправляет фронты Парето в формате JSON
+// Входной параметр: report - фронты Парето
+func (s *selectionPresenter) SendParetoFronts(report fuzzy.ParetoReport) {
+	s.ctx.JSON(http.StatusOK, report)
 }
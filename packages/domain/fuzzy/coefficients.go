@@ -0,0 +1,390 @@
+package fuzzy
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"vehicles/packages/domain/models"
+)
+
+const (
+	suspensionType1 = "Независимая, на двойных поперечных рычагах"
+	suspensionType2 = "Многорычажная, независимая"
+	suspensionType3 = "Пневматическая"
+	suspensionType4 = "Независимая, амортизационная стойка типа МакФерсон"
+	suspensionType5 = "Полузависимая, торсионная балка"
+	suspensionType6 = "Зависимая, пружинная"
+	suspensionType7 = "Листовая, пружинная"
+	brakesType1     = "Дисковые"
+	brakesType2     = "Дисковые вентилируемые"
+	brakesType3     = "Барабанные"
+)
+
+// CoefficientCalculator вычисляет коэффициент автомобиля, например, коэффициент комфорта
+type CoefficientCalculator interface {
+	// Calculate вычисляет коэффициент
+	// Входной параметр: car - автомобиль
+	Calculate(car models.Car) float64
+}
+
+// CoefficientFunc позволяет использовать обычную функцию в качестве CoefficientCalculator
+type CoefficientFunc func(car models.Car) float64
+
+// Calculate вызывает функцию cfn(car)
+func (cfn CoefficientFunc) Calculate(car models.Car) float64 {
+	return cfn(car)
+}
+
+// Calculators предоставляет вычислители коэффициентов нечетких множеств набора. Вычислители по умолчанию
+// (см. DefaultVariables) реализуют ConfidenceCalculator, то есть сообщают, от каких характеристик автомобиля
+// зависят коэффициенты
+func (vs *VariableSet) Calculators() map[string]CoefficientCalculator {
+	calculators := make(map[string]CoefficientCalculator, len(vs.variables))
+	for _, variable := range vs.variables {
+		calculators[variable.Name] = variable.Calculator
+	}
+	return calculators
+}
+
+// вычислители коэффициентов нечетких множеств по умолчанию. Вычислители коэффициентов управляемости, комфорта
+// и безопасности реализуют FormulaCalculator
+var (
+	handlingCalculator = newFormulaCalculator(func(car models.Car, formulas *FormulaConfig) float64 {
+		return calculateHandlingCoefficient(formulas.Handling, car.Specs.Engine.MaxPower, car.Specs.FrontTrackWidth,
+			car.Specs.BackTrackWidth, car.Specs.Drive, car.Specs.Suspension, car.Specs.Tires,
+			car.Features.SafetyAndMotionControlSystem, car.Specs.Brakes.FrontBrakes, car.Specs.Brakes.BackBrakes,
+			car.Specs.Mass, car.Specs.Wheelbase, car.Specs.Length, car.Specs.Width, car.Specs.Height,
+			car.Specs.GroundClearance, car.Specs.DragCoefficient,
+		)
+	}, []DataField{numericField(MaxPowerField, true), numericField(MassField, true), numericField(WheelbaseField, true),
+		numericField(LengthField, true), numericField(WidthField, true), numericField(HeightField, true),
+		numericField(GroundClearanceField, true), numericField(FrontTrackWidthField, true),
+		numericField(BackTrackWidthField, true), required(driveField), required(tiresField), required(frontBrakesField),
+		frontSuspensionField, backSuspensionField, backBrakesField, absField, espField})
+	comfortCalculator = newFormulaCalculator(func(car models.Car, formulas *FormulaConfig) float64 {
+		return calculateComfortCoefficient(formulas.Comfort, car.Specs.Suspension, car.Specs.Gearbox,
+			car.Features.CabinMicroclimate, car.Features.Interior, car.Features.ElectricOptions,
+			car.Features.MultimediaSystems, car.Features.Lights, car.Specs.SteeringWheel.PowerSteering,
+			car.Features.CarAlarm, car.Specs.TrunkVolume)
+	}, []DataField{frontSuspensionField, backSuspensionField, gearboxField, powerSteeringField, airConditionerField,
+		climateControlField, upholsteryField, headlightsField, numericField(TrunkVolumeField, false), carAlarmField})
+	safetyCalculator = newFormulaCalculator(func(car models.Car, formulas *FormulaConfig) float64 {
+		return calculateSafetyCoefficient(formulas.Safety, car.Specs.CrashTestEstimate,
+			car.Features.SafetyAndMotionControlSystem, car.Features.Airbags, car.Specs.Brakes)
+	}, []DataField{numericField(CrashTestEstimateField, false), absField, espField, driverAirbagField,
+		frontPassengerAirbagField, sideAirbagsField, frontBrakesField, backBrakesField})
+	practicalityCalculator = fieldCalculator{CoefficientFunc(func(car models.Car) float64 {
+		return calculatePracticalityCoefficient(car.Specs.NumberOfSeats, car.Specs.TrunkVolume, car.Specs.Length,
+			car.Specs.Width, car.Specs.Height)
+	}), []DataField{required(seatsField), numericField(TrunkVolumeField, false), numericField(LengthField, true),
+		numericField(WidthField, true), numericField(HeightField, true)}}
+	runningCostsCalculator = fieldCalculator{CoefficientFunc(func(car models.Car) float64 {
+		return calculateRunningCostsCoefficient(car.Specs.MixedFuelConsumption, car.Specs.Engine.MaxPower,
+			parsePrice(car.Offering.Price))
+	}), []DataField{numericField(MixedFuelConsumptionField, true), numericField(MaxPowerField, true), required(priceField)}}
+	offRoadCalculator = fieldCalculator{CoefficientFunc(func(car models.Car) float64 {
+		return calculateOffRoadCoefficient(car.Specs.GroundClearance, car.Specs.Drive, car.Specs.Suspension)
+	}), []DataField{numericField(GroundClearanceField, true), required(driveField), frontSuspensionField,
+		backSuspensionField}}
+)
+
+// calculateHandlingCoefficient вычисляет коэффициент управляемости
+// Входные параметры: formula - веса и таблицы коэффициента, power - мощность двигателя в л.с,
+// frontTrackWidth - ширина передней колеи в мм, backTrackWidth - ширина задней колеи в мм, drive - тип привода,
+// sps - информация о подвеске, trs - информация о шинах, smc - информация о наличии систем ABS, ESP, EBD, BAS, TCS,
+// frontBrakes - тип передних тормозов, backBrakes - тип задних тормозов, mass - масса в кг,
+// wheelbase - колесная база в мм, length - длина в мм, width - ширина в мм, height - высота в мм,
+// groundClearance - клиренс в мм, dragCoefficient - коэффициент лобового сопротивления
+func calculateHandlingCoefficient(formula HandlingFormula, power, frontTrackWidth, backTrackWidth float64, drive string,
+	sps models.Suspension, trs models.Tires, smc models.SafetyAndMotionControlSystems, frontBrakes, backBrakes string,
+	mass, wheelbase, length, width, height, groundClearance, dragCoefficient float64) float64 {
+
+	// перевод из лошадиных сил в ватты
+	var newPower = power * 735.5
+
+	// перевод из мм в метры
+	frontTrackWidth /= 1000
+	backTrackWidth /= 1000
+	frontTiresWidth := float64(trs.FrontTiresWidth) / 1000
+	backTiresWidth := float64(trs.BackTiresWidth) / 1000
+	wheelbase /= 1000
+	length /= 1000
+	width /= 1000
+	height /= 1000
+	groundClearance /= 1000
+
+	// driveTypeCoefficient - коэффициент типа привода
+	driveTypeCoefficient := formula.Drive[drive]
+
+	// коэффициент наличия переднего стабилизатора
+	var frontStabilizerCoefficient = 1.0
+
+	// коэффициент наличия заднего стабилизатора
+	var backStabilizerCoefficient = 1.0
+
+	if sps.FrontStabilizer == models.YesValue {
+		frontStabilizerCoefficient = formula.Stabilizer
+	}
+
+	if sps.BackStabilizer == models.YesValue {
+		backStabilizerCoefficient = formula.Stabilizer
+	}
+
+	// frontSuspensionCoefficient - коэффициент типа передней подвески,
+	// backSuspensionCoefficient - коэффициент типа задней подвески
+	frontSuspensionCoefficient, backSuspensionCoefficient := calculateSuspensionCoeffsForHandlingCoeff(formula,
+		sps.FrontSuspension, sps.BackSuspension)
+
+	frontTiresDiameter, backTiresDiameter := calculateTiresParamsAndTrackWidths(&frontTrackWidth, &backTrackWidth, &frontTiresWidth, &backTiresWidth,
+		&trs.FrontTiresAspectRatio, &trs.BackTiresAspectRatio, trs.FrontTiresRimDiameter, trs.BackTiresRimDiameter)
+
+	// коэффициент типа передних тормозов
+	frontBrakesCoefficient := formula.FrontBrakes[frontBrakes]
+	// коэффициент типа задних тормозов
+	backBrakesCoefficient := formula.BackBrakes[backBrakes]
+
+	// efficientFrontTrackWidth - оптимальная ширина передней колеи в метрах
+	efficientFrontTrackWidth := frontTrackWidth + 0.5*math.Abs(frontTiresWidth-backTiresWidth)/float64(trs.FrontTiresAspectRatio)
+
+	// efficientBackTrackWidth - оптимальная ширина задней колеи в метрах
+	efficientBackTrackWidth := backTrackWidth + 0.5*math.Abs(backTiresWidth-frontTiresWidth)/float64(trs.BackTiresAspectRatio)
+
+	// числитель
+	numerator := ((newPower * (efficientFrontTrackWidth + efficientBackTrackWidth) / 2) * driveTypeCoefficient *
+		(frontSuspensionCoefficient*frontStabilizerCoefficient + backSuspensionCoefficient*backStabilizerCoefficient) *
+		(frontTiresWidth*frontTiresDiameter + backTiresWidth*backTiresDiameter) * (frontBrakesCoefficient + backBrakesCoefficient))
+
+	// без размеров и массы коэффициент не вычисляется: нулевой коэффициент означает отсутствие данных,
+	// их можно восстановить по похожим автомобилям (см. Imputer)
+	if mass == 0 || wheelbase == 0 || length == 0 || width == 0 || height == 0 || groundClearance == 0 || dragCoefficient == 0 {
+		return 0
+	}
+
+	// знаменатель
+	denominator := mass * wheelbase * (length + width + height) * groundClearance * dragCoefficient
+
+	// коэффициент управляемости
+	handlingCoefficient := numerator / denominator
+
+	var sizeCoefficient float64
+	if handlingCoefficient != 0 {
+		sizeCoefficient = formula.SizeOffset
+	}
+
+	// коэффициент наличия систем безопасности
+	controlSystemsCoefficient := sumOptions(0, controlSystemOptions(smc), formula.ControlSystems)
+	handlingCoefficient += handlingCoefficient * controlSystemsCoefficient
+	handlingCoefficient = math.Abs(handlingCoefficient - sizeCoefficient)
+	return handlingCoefficient
+}
+
+// calculateSuspensionCoeffsForHandlingCoeff вычисляет коэффициент типа передней подвески и коэффициент типа задней подвески
+// Входные параметры: formula - веса и таблицы коэффициента управляемости, frontSuspension - тип передней подвески,
+// backSuspension - тип задней подвески
+func calculateSuspensionCoeffsForHandlingCoeff(formula HandlingFormula, frontSuspension, backSuspension string) (float64, float64) {
+	// коэффициент типа передней подвески
+	frontSuspensionCoefficient, ok := formula.FrontSuspension[frontSuspension]
+	if !ok {
+		frontSuspensionCoefficient = formula.UnknownSuspension
+	}
+	// коэффициент типа задней подвески
+	backSuspensionCoefficient, ok := formula.BackSuspension[backSuspension]
+	if !ok {
+		backSuspensionCoefficient = formula.UnknownSuspension
+	}
+	return frontSuspensionCoefficient, backSuspensionCoefficient
+}
+
+// calculateTiresParamsAndTrackWidths работает с параметрами шин и значениями передней колеи и задней колеи
+// Входные параметры: frontTiresWidth - ширина передних шин в метрах, backTiresWidth - ширина задних шин в метрах,
+// frontTiresAspectRatio - процентное соотношение высоты профиля передних шин к их ширине,
+// backTiresAspectRatio - процентное соотношение высоты профиля задних шин к их ширине,
+// frontTiresRimDiameter - диаметр обода  передних шин в дюймах,
+// backTiresRimDiameter - диаметр обода задних шин в дюймах
+// frontTrackWidth - ширина передней колеи в метрах, backTrackWidth - ширина задней колеи в метрах,
+func calculateTiresParamsAndTrackWidths(frontTrackWidth, backTrackWidth, frontTiresWidth, backTiresWidth *float64,
+	frontTiresAspectRatio, backTiresAspectRatio *int, frontTiresRimDiameter, backTiresRimDiameter int) (float64, float64) {
+
+	// диаметр передних шин в метрах
+	var frontTiresDiameter float64
+	// диаметр задних шин в метрах
+	var backTiresDiameter float64
+
+	if *frontTiresWidth == 0 || *backTiresWidth == 0 || *frontTrackWidth == 0 || *backTrackWidth == 0 || *frontTiresAspectRatio == 0 ||
+		*backTiresAspectRatio == 0 || frontTiresRimDiameter == 0 || backTiresRimDiameter == 0 {
+		*frontTiresAspectRatio = 1
+		*backTiresAspectRatio = 1
+	} else {
+		// высота профиля передних шин
+		frontTiresProfile := *frontTiresWidth * (float64(*frontTiresAspectRatio) / 100)
+		// высота профиля задних шин
+		backTiresProfile := *backTiresWidth * (float64(*backTiresAspectRatio) / 100)
+		// float64(ts.FrontTiresRimDiameter)*0.0254 - это перевод в метры из дюймов inches
+		frontTiresDiameter = float64(frontTiresRimDiameter)*0.0254 + 2*frontTiresProfile
+		// float64(ts.BackTiresRimDiameter)*0.0254 - это перевод в метры из дюймов inches
+		backTiresDiameter = float64(backTiresRimDiameter)*0.0254 + 2*backTiresProfile
+	}
+	return frontTiresDiameter, backTiresDiameter
+}
+
+// calculateComfortCoefficient вычисляет коэффициент комфорта
+// Входные параметры: formula - баллы коэффициента, sps - информация о подвеске, grb - информация о коробке передач,
+// cmc - информация о микроклимате салона, idn - информация об отделке салона, seo - информация об электропакете салона,
+// mts - информация о мультимедийных системах, lts - информация о фонарях, powerSteeringType - тип рулевого усилителя,
+// carAlarm - информация о наличии сигнализации, trunkVolume - объем багажника в литрах
+func calculateComfortCoefficient(formula ComfortFormula, sps models.Suspension, gearbox string,
+	cmc models.CabinMicroclimate, idn models.Interior, seo models.SetOfElectricOptions, mts models.MultimediaSystems,
+	lts models.Lights, powerSteering models.PowerSteering, carAlarm models.Availability, trunkVolume float64) float64 {
+
+	frontSuspensionCoefficient := calculateSuspensionCoeffForComfortCoeff(formula.FrontSuspension, sps.FrontSuspension,
+		sps.FrontStabilizer)
+	backSuspensionCoefficient := calculateSuspensionCoeffForComfortCoeff(formula.BackSuspension, sps.BackSuspension,
+		sps.BackStabilizer)
+
+	powerSteeringTypeCoefficient := formula.PowerSteering[string(powerSteering)]
+	gearboxCoefficient := formula.Gearbox[gearbox]
+
+	var climateCoefficient float64 = 0
+	switch {
+	case cmc.AirConditioner == models.YesValue && cmc.ClimateControl == models.YesValue:
+		climateCoefficient = formula.ClimateControl
+	case cmc.AirConditioner == models.YesValue && cmc.ClimateControl == models.NoValue:
+		climateCoefficient = formula.AirConditioner
+	}
+
+	interiorCoefficient := formula.Upholstery[idn.Upholstery]
+
+	var lightsCoefficient float64 = 0
+	if lts.Headlights != formula.BasicHeadlights {
+		lightsCoefficient += formula.Headlights
+	}
+	lightsCoefficient = sumOptions(lightsCoefficient, lightOptions(lts), formula.Options)
+
+	// коэффициент наличия электрических опций
+	electricOptionsCoefficient := sumOptions(0, electricOptions(seo), formula.Options)
+
+	var trunkVolumeCoefficient float64 = 0
+	if trunkVolume > formula.LargeTrunk {
+		trunkVolumeCoefficient = formula.Trunk
+	}
+
+	var carAlarmCoefficient float64 = 0
+	if carAlarm == models.YesValue {
+		carAlarmCoefficient = formula.Options[OptionCarAlarm]
+	}
+
+	multimediaCoefficient := sumOptions(0, multimediaOptions(mts), formula.Options)
+
+	comfortCoefficient := (frontSuspensionCoefficient + backSuspensionCoefficient + powerSteeringTypeCoefficient +
+		gearboxCoefficient + climateCoefficient + interiorCoefficient + lightsCoefficient + electricOptionsCoefficient +
+		trunkVolumeCoefficient + carAlarmCoefficient + multimediaCoefficient) * formula.Scale
+	return comfortCoefficient
+}
+
+// calculateSuspensionCoeffForComfortCoeff вычисляет коэффициент типа передней или задней подвески для коэффициента
+// комфорта. Для неизвестного типа подвески коэффициент равен нулю
+// Входные параметры: weights - баллы за типы подвески, suspension - тип подвески, stabilizer - наличие стабилизатора
+func calculateSuspensionCoeffForComfortCoeff(weights map[string]SuspensionWeight, suspension string,
+	stabilizer models.Availability) float64 {
+	weight := weights[suspension]
+	if stabilizer == models.YesValue {
+		return weight.WithStabilizer
+	}
+	return weight.WithoutStabilizer
+}
+
+// calculateSafetyCoefficient вычисляет коэффициент безопасности
+// Входные параметры: formula - баллы коэффициента, crashTestEstimate - результат краш-теста, asmc - информация
+// о наличии электронных систем безопасности и контроля движения, sab - информация о наличии подушек безопасности,
+// bkt - информация о типах тормозов
+func calculateSafetyCoefficient(formula SafetyFormula, crashTestEstimate float64, smc models.SafetyAndMotionControlSystems,
+	sab models.SetOfAirbags, bkt models.Brakes) float64 {
+	controlSystemCoefficient := sumOptions(0, controlSystemOptions(smc), formula.ControlSystems)
+	airbagsCoefficient := sumOptions(0, airbagOptions(sab), formula.Airbags)
+	frontBrakesCoefficient := formula.FrontBrakes[bkt.FrontBrakes]
+	backBrakesCoefficient := formula.BackBrakes[bkt.BackBrakes]
+
+	safetyCoefficient := crashTestEstimate*formula.CrashTest + controlSystemCoefficient + airbagsCoefficient +
+		frontBrakesCoefficient + backBrakesCoefficient
+	return safetyCoefficient
+}
+
+// calculatePracticalityCoefficient вычисляет коэффициент практичности: число мест, объем багажника в сотнях литров
+// и половину габаритного объема кузова в кубических метрах. Если число мест или габариты неизвестны, возвращает 0
+// Входные параметры: seats - число мест, trunkVolume - объем багажника в литрах, length - длина в мм,
+// width - ширина в мм, height - высота в мм
+func calculatePracticalityCoefficient(seats int, trunkVolume, length, width, height float64) float64 {
+	if seats == 0 || length == 0 || width == 0 || height == 0 {
+		return 0
+	}
+	// bodyVolume - габаритный объем кузова, м^3
+	bodyVolume := length * width * height / 1e9
+	return float64(seats) + trunkVolume/100 + bodyVolume/2
+}
+
+// parsePrice переводит цену в число. Если цена неизвестна, возвращает 0
+// Входной параметр: price - цена в рублях, например, "1500000" или "1 500 000"
+func parsePrice(price string) float64 {
+	value, err := strconv.Atoi(strings.Join(strings.Fields(price), ""))
+	if err != nil || value < 0 {
+		return 0
+	}
+	return float64(value)
+}
+
+// параметры расчета стоимости владения
+const (
+	// annualMileage - годовой пробег, км
+	annualMileage = 15000
+	// fuelPrice - цена литра топлива, руб
+	fuelPrice = 55
+	// annualDepreciation - доля цены автомобиля, которую составляют потеря стоимости и страховка за год
+	annualDepreciation = 0.1
+)
+
+// transportTaxRates - ставки транспортного налога в рублях за л.с. в зависимости от мощности двигателя:
+// ставка применяется, если мощность не больше MaxPower
+var transportTaxRates = []struct {
+	MaxPower float64
+	Rate     float64
+}{{100, 12}, {125, 25}, {150, 35}, {175, 45}, {200, 50}, {225, 65}, {250, 75}, {math.Inf(1), 150}}
+
+// calculateRunningCostsCoefficient вычисляет коэффициент стоимости владения: расходы за год в тысячах рублей на
+// топливо, транспортный налог и потерю стоимости со страховкой. Если расход топлива, мощность или цена неизвестны,
+// возвращает 0
+// Входные параметры: fuelConsumption - расход топлива в смешанном цикле в л/100 км, power - мощность двигателя
+// в л.с., price - цена в рублях
+func calculateRunningCostsCoefficient(fuelConsumption, power, price float64) float64 {
+	if fuelConsumption == 0 || power == 0 || price == 0 {
+		return 0
+	}
+	fuel := fuelConsumption * annualMileage / 100 * fuelPrice
+
+	var tax float64
+	for _, rate := range transportTaxRates {
+		if power <= rate.MaxPower {
+			tax = power * rate.Rate
+			break
+		}
+	}
+	return (fuel + tax + price*annualDepreciation) / 1000
+}
+
+// calculateOffRoadCoefficient вычисляет коэффициент проходимости: клиренс в сантиметрах и баллы за полный привод
+// и подвеску, которая лучше работает на бездорожье. Если клиренс неизвестен, возвращает 0
+// Входные параметры: groundClearance - клиренс в мм, drive - тип привода, sps - информация о подвеске
+func calculateOffRoadCoefficient(groundClearance float64, drive string, sps models.Suspension) float64 {
+	if groundClearance == 0 {
+		return 0
+	}
+	coefficient := groundClearance / 10
+	if strings.HasPrefix(drive, "Полный") {
+		coefficient += 10
+	}
+
+	// suspensionPoints - баллы за тип подвески: зависимая подвеска выдерживает большие нагрузки и сохраняет
+	// ход колес, а пневматическая позволяет поднять кузов
+	suspensionPoints := map[string]float64{suspensionType3: 3, suspensionType6: 2, suspensionType7: 2}
+	coefficient += suspensionPoints[sps.FrontSuspension] + suspensionPoints[sps.BackSuspension]
+	return coefficient
+}
@@ -0,0 +1,221 @@
+package fuzzy
+
+import (
+	"fmt"
+	"sort"
+	"vehicles/packages/domain/models"
+)
+
+// режимы учета достоверности коэффициентов при ранжировании
+const (
+	// ConfidenceFlag - автомобили с низкой достоверностью помечаются, выходное значение не изменяется
+	ConfidenceFlag = "flag"
+	// ConfidencePenalize - выходное значение умножается на достоверность, автомобили с низкой достоверностью помечаются
+	ConfidencePenalize = "penalize"
+)
+
+const (
+	// DefaultMinConfidence - достоверность, ниже которой результат помечается как ненадежный
+	DefaultMinConfidence = 0.6
+	// ImputedFieldConfidence - вклад в достоверность характеристики, значение которой восстановлено
+	// по похожим автомобилям, а не получено из описания автомобиля
+	ImputedFieldConfidence = 0.5
+)
+
+// DataField - характеристика автомобиля, от которой зависит коэффициент
+type DataField struct {
+	// Name - название характеристики (для числовых характеристик совпадает с названием столбца БД)
+	Name string
+	// Required - без этой характеристики коэффициент вычислить нельзя
+	Required bool
+	// Known - проверяет, известно ли значение характеристики
+	Known func(car models.Car) bool
+}
+
+// ConfidenceCalculator - вычислитель коэффициента, который сообщает, от каких характеристик автомобиля
+// зависит коэффициент. По ним оценивается полнота данных - достоверность коэффициента
+type ConfidenceCalculator interface {
+	CoefficientCalculator
+	// Fields возвращает характеристики автомобиля, от которых зависит коэффициент
+	Fields() []DataField
+}
+
+// fieldCalculator - вычислитель коэффициента с перечнем характеристик, от которых зависит коэффициент
+type fieldCalculator struct {
+	CoefficientFunc
+	// fields - характеристики автомобиля, от которых зависит коэффициент
+	fields []DataField
+}
+
+// Fields возвращает характеристики автомобиля, от которых зависит коэффициент
+func (fcl fieldCalculator) Fields() []DataField {
+	return fcl.fields
+}
+
+// ValidateConfidenceMode проверяет название режима учета достоверности
+// Входной параметр: mode - режим учета достоверности
+func ValidateConfidenceMode(mode string) error {
+	switch mode {
+	case ConfidenceFlag, ConfidencePenalize:
+		return nil
+	}
+	return fmt.Errorf("error, unknown confidence mode %q", mode)
+}
+
+// calculateConfidence вычисляет достоверность коэффициента - долю известных характеристик автомобиля, от которых
+// он зависит. Восстановленная характеристика учитывается с весом ImputedFieldConfidence. Если неизвестна
+// обязательная характеристика, то достоверность равна нулю. Вычислитель, не реализующий ConfidenceCalculator,
+// считается достоверным, если коэффициент не равен нулю
+// Входные параметры: calculator - вычислитель коэффициента, car - автомобиль до восстановления характеристик,
+// imputed - восстановленные характеристики, coefficient - коэффициент
+func calculateConfidence(calculator CoefficientCalculator, car models.Car, imputed map[string]bool,
+	coefficient float64) float64 {
+	withFields, ok := calculator.(ConfidenceCalculator)
+	if !ok || len(withFields.Fields()) == 0 {
+		if coefficient == 0 {
+			return 0
+		}
+		return 1
+	}
+
+	var total float64
+	for _, field := range withFields.Fields() {
+		switch {
+		case field.Known(car):
+			total++
+		case imputed[field.Name]:
+			total += ImputedFieldConfidence
+		case field.Required:
+			return 0
+		}
+	}
+	return total / float64(len(withFields.Fields()))
+}
+
+// usedImputedFields возвращает восстановленные характеристики, от которых зависит коэффициент, в алфавитном порядке
+// Входные параметры: calculator - вычислитель коэффициента, imputed - восстановленные характеристики
+func usedImputedFields(calculator CoefficientCalculator, imputed map[string]bool) []string {
+	withFields, ok := calculator.(ConfidenceCalculator)
+	if !ok || len(imputed) == 0 {
+		return nil
+	}
+	var fields []string
+	for _, field := range withFields.Fields() {
+		if imputed[field.Name] {
+			fields = append(fields, field.Name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// ruleVariables возвращает названия нечетких множеств, которые встречаются в условиях нечетких правил
+// Входной параметр: rules - нечеткие правила
+func ruleVariables(rules []Rule) map[string]bool {
+	used := make(map[string]bool)
+	for _, rule := range rules {
+		for _, condition := range rule.Conditions {
+			used[condition.Variable] = true
+		}
+	}
+	return used
+}
+
+// overallConfidence вычисляет достоверность результата - среднюю достоверность коэффициентов, которые
+// используются нечеткими правилами
+// Входные параметры: confidences - достоверности коэффициентов, used - нечеткие множества из условий правил
+func overallConfidence(confidences map[string]float64, used map[string]bool) float64 {
+	if len(used) == 0 {
+		return 1
+	}
+	var total float64
+	for variable := range used {
+		total += confidences[variable]
+	}
+	return total / float64(len(used))
+}
+
+// knownNumber проверяет, известно ли числовое значение характеристики (ноль означает отсутствие данных)
+func knownNumber(get func(car models.Car) float64) func(car models.Car) bool {
+	return func(car models.Car) bool {
+		return get(car) != 0
+	}
+}
+
+// knownString проверяет, известно ли текстовое значение характеристики
+func knownString(get func(car models.Car) string) func(car models.Car) bool {
+	return func(car models.Car) bool {
+		value := get(car)
+		return value != "" && value != models.UndefinedStr
+	}
+}
+
+// knownAvailability проверяет, известно ли наличие опции
+func knownAvailability(get func(car models.Car) models.Availability) func(car models.Car) bool {
+	return func(car models.Car) bool {
+		value := get(car)
+		return value != "" && value != models.UndefinedValue
+	}
+}
+
+// numericField возвращает описание числовой характеристики, которую можно восстановить (см. imputableFields)
+// Входные параметры: name - название характеристики, required - обязательна ли характеристика
+func numericField(name string, required bool) DataField {
+	return DataField{Name: name, Required: required, Known: knownNumber(imputableFields[name].get)}
+}
+
+// required возвращает копию описания характеристики, без которой коэффициент вычислить нельзя
+// Входной параметр: field - характеристика
+func required(field DataField) DataField {
+	field.Required = true
+	return field
+}
+
+// характеристики, от которых зависят коэффициенты по умолчанию
+var (
+	driveField           = DataField{Name: "drive", Known: knownString(func(car models.Car) string { return car.Specs.Drive })}
+	seatsField           = DataField{Name: "number_of_seats", Known: func(car models.Car) bool { return car.Specs.NumberOfSeats != 0 }}
+	priceField           = DataField{Name: "price", Known: func(car models.Car) bool { return parsePrice(car.Offering.Price) != 0 }}
+	gearboxField         = DataField{Name: "gearbox", Known: knownString(func(car models.Car) string { return car.Specs.Gearbox })}
+	frontSuspensionField = DataField{Name: "front_suspension",
+		Known: knownString(func(car models.Car) string { return car.Specs.Suspension.FrontSuspension })}
+	backSuspensionField = DataField{Name: "back_suspension",
+		Known: knownString(func(car models.Car) string { return car.Specs.Suspension.BackSuspension })}
+	frontBrakesField = DataField{Name: "front_brakes",
+		Known: knownString(func(car models.Car) string { return car.Specs.Brakes.FrontBrakes })}
+	backBrakesField = DataField{Name: "back_brakes",
+		Known: knownString(func(car models.Car) string { return car.Specs.Brakes.BackBrakes })}
+	tiresField = DataField{Name: "tires", Known: func(car models.Car) bool {
+		return car.Specs.Tires.FrontTiresWidth != 0 && car.Specs.Tires.FrontTiresRimDiameter != 0
+	}}
+	powerSteeringField = DataField{Name: "power_steering", Known: func(car models.Car) bool {
+		return car.Specs.SteeringWheel.PowerSteering != "" && car.Specs.SteeringWheel.PowerSteering != models.UndefinedPS
+	}}
+	upholsteryField = DataField{Name: "upholstery",
+		Known: knownString(func(car models.Car) string { return car.Features.Interior.Upholstery })}
+	headlightsField = DataField{Name: "headlights",
+		Known: knownString(func(car models.Car) string { return car.Features.Lights.Headlights })}
+	absField = DataField{Name: "abs_system", Known: knownAvailability(func(car models.Car) models.Availability {
+		return car.Features.SafetyAndMotionControlSystem.ABS
+	})}
+	espField = DataField{Name: "esp_system", Known: knownAvailability(func(car models.Car) models.Availability {
+		return car.Features.SafetyAndMotionControlSystem.ESP
+	})}
+	airConditionerField = DataField{Name: "air_conditioner", Known: knownAvailability(func(car models.Car) models.Availability {
+		return car.Features.CabinMicroclimate.AirConditioner
+	})}
+	climateControlField = DataField{Name: "climate_control", Known: knownAvailability(func(car models.Car) models.Availability {
+		return car.Features.CabinMicroclimate.ClimateControl
+	})}
+	carAlarmField = DataField{Name: "car_alarm", Known: knownAvailability(func(car models.Car) models.Availability {
+		return car.Features.CarAlarm
+	})}
+	driverAirbagField = DataField{Name: "driver_airbag", Known: knownAvailability(func(car models.Car) models.Availability {
+		return car.Features.Airbags.DriverAirbag
+	})}
+	frontPassengerAirbagField = DataField{Name: "front_passenger_airbag",
+		Known: knownAvailability(func(car models.Car) models.Availability { return car.Features.Airbags.FrontPassengerAirbag })}
+	sideAirbagsField = DataField{Name: "side_airbags", Known: knownAvailability(func(car models.Car) models.Availability {
+		return car.Features.Airbags.SideAirbags
+	})}
+)
@@ -0,0 +1,297 @@
+package fuzzy
+
+import (
+	"fmt"
+	"math"
+)
+
+// Defuzzifier - метод дефаззификации. Возвращает конкретное число или четкое значение
+// (в какой степени будет рекомендоваться автомобиль)
+type Defuzzifier interface {
+	// Defuzzify выполняет дефаззификацию
+	// Входные параметры: strengths - ординаты вершин треугольников, образуемых под графиками функций принадлежности
+	// нечетких подмножеств нечеткого множества "рекомендация" или глобальные максимумы этих функций,
+	// recommendations - абсциссы центральных точек оснований треугольников или центральные точки нечетких подмножеств
+	// нечеткого множества "рекомендация"
+	Defuzzify(strengths []float64, recommendations []int) float64
+}
+
+// NumericalCentroid реализует метод дефаззификации: метод центра тяжести, в котором
+// центры тяжести вычисляются численным методом трапеций. Метод не зависит от оператора импликации
+type NumericalCentroid struct {
+	// Steps - количество интервалов, на которые разбивается область под графиком интегрируемой функции
+	Steps int
+}
+
+// Defuzzify реализует метод центра тяжести
+func (nct NumericalCentroid) Defuzzify(strengths []float64, recommendations []int) float64 {
+	var centersOfMassOfTheAreaUnderTheGraph []float64
+	var areasOfTriangles []float64
+	for idx := 0; idx < len(strengths); idx++ {
+		centersOfMassOfTheAreaUnderTheGraph = append(centersOfMassOfTheAreaUnderTheGraph, nct.calculateCenterOfMassOfTheAreaUnderTheGraph(
+			strengths[idx], recommendations[idx]))
+
+		areasOfTriangles = append(areasOfTriangles, calculateAreaOfTriangle(strengths[idx]))
+	}
+
+	// расчет средневзвешенного значения, которое является значением рекомендации и выходным значением
+	// нечеткого алгоритма для одного автомобиля
+	var enumerator float64
+	for idx := range areasOfTriangles {
+		enumerator += areasOfTriangles[idx] * centersOfMassOfTheAreaUnderTheGraph[idx]
+	}
+
+	var denominator float64
+	for idx := 0; idx < len(areasOfTriangles); idx++ {
+		denominator += areasOfTriangles[idx]
+	}
+
+	result := enumerator / denominator
+	return result
+}
+
+// calculateCenterOfMassOfTheAreaUnderTheGraph вычисляет абсциссу центра тяжести площади треугольника, расположенного
+// под графиком функции принадлежности μG(r) нечеткого подмножества G множества "рекомендация"
+// Входные параметры: minValueOfMemebershipFunction - ордината вершины треугольника или
+// глобальный максимум функции μG(r), valueReсommendation - абсцисса центральной точки основания
+// треугольника или центральная точка нечеткого подмножества G нечеткого множества "рекомендация"
+func (nct NumericalCentroid) calculateCenterOfMassOfTheAreaUnderTheGraph(minValueOfMemebershipFunction float64, valueReсommendation int) float64 {
+	xValues := setX(float64(valueReсommendation), 1)
+	area := applyTrapezoidalRule(func(x float64) float64 {
+		return setY(x, xValues[0], xValues[1], xValues[2], minValueOfMemebershipFunction)
+	}, xValues[0], xValues[2], nct.Steps)
+
+	numerator := applyTrapezoidalRule(func(x float64) float64 {
+		return float64(valueReсommendation) * setY(x, xValues[0], xValues[1], xValues[2], minValueOfMemebershipFunction)
+	}, xValues[0], xValues[2], nct.Steps)
+	return numerator / area
+}
+
+// setX вычисляет абсциссы левой и правой точек основания треугольника и возвращает абсциссы точек
+// основания треугольника: левую, центральную и правую точки
+// Входные параметры: center - абсцисса центральной точки, deviation - отклонение от центральной точки
+func setX(center, deviation float64) []float64 {
+	xArg := []float64{}
+	xArg = append(xArg, center-deviation)
+	xArg = append(xArg, center)
+	xArg = append(xArg, center+deviation)
+	return xArg
+}
+
+// applyTrapezoidalRule реализует численный метод трапеций, вычисляющий определенный интеграл
+// Входные параметры: fun - интегрируемая функция, aParam - нижний предел интегрирования,
+// bParam - верхний предел интегрирования, nParam - количество интервалов, на которые
+// разбивается область под графиком интегрируемой функции
+func applyTrapezoidalRule(fun func(float64) float64, aParam, bParam float64, nParam int) float64 {
+	hParam := (bParam - aParam) / float64(nParam)
+	sParam := 0.5 * (fun(aParam) + fun(bParam))
+	for idx := 1; idx < nParam; idx++ {
+		sParam += fun(aParam + float64(idx)*hParam)
+	}
+	return hParam * sParam
+}
+
+// setY реализует кусочную функцию, графиком которой является треугольник. Эта кусочная функция является
+// функцией принадлежности μG(r) нечеткого подмножества G множества "рекомендация"
+// Входные параметры: xValue - аргумент кусочной функции, center - абсцисса центральной точки
+// основания треугольника, lefbound - абсцисса левой точки основания треугольника,
+// rightBound - абсцисса правой точки основания треугольника, yMax - ордината вершины треугольника или
+// глобальный максимум функции μG(r)
+func setY(xValue, leftBound, center, rightBound, yMax float64) float64 {
+	if xValue <= leftBound || xValue >= rightBound {
+		return 0
+	}
+	if xValue <= center && xValue >= leftBound {
+		return xValue - leftBound - (1 - yMax)
+	}
+	if leftBound <= xValue && xValue <= rightBound {
+		return rightBound - xValue - (1 - yMax)
+	}
+
+	return 0
+}
+
+// calculateAreaOfTriangle вычисляет площадь фигуры(треугольника), образуемой под графиком
+// функции принадлежности μG(r) нечеткого подмножества G множества "рекомендация"
+// Входные параметры: minValueOfMemebershipFunction - высота треугольника или
+// ордината вершины или глобальный максимум функции μG(r)
+func calculateAreaOfTriangle(minValueOfMemebershipFunction float64) float64 {
+	// baseOfTriangle - основание треугольника
+	var baseOfTriangle float64 = 2
+	area := 0.5 * baseOfTriangle * minValueOfMemebershipFunction
+	return area
+}
+
+// названия методов дефаззификации, которые можно выбрать в конфигурации или в запросе
+const (
+	NumericalCentroidMethod = "numerical_centroid"
+	CentroidMethod          = "centroid"
+	BisectorMethod          = "bisector"
+	MeanOfMaximaMethod      = "mean_of_maxima"
+	LargestOfMaximumMethod  = "largest_of_maximum"
+	WeightedAverageMethod   = "weighted_average"
+)
+
+// NewDefuzzifier создает метод дефаззификации по его названию
+// Входной параметр: name - название метода дефаззификации, например, "centroid"
+func NewDefuzzifier(name string) (Defuzzifier, error) {
+	switch name {
+	case NumericalCentroidMethod:
+		return NumericalCentroid{Steps: 10000}, nil
+	case CentroidMethod:
+		return Centroid{}, nil
+	case BisectorMethod:
+		return Bisector{}, nil
+	case MeanOfMaximaMethod:
+		return MeanOfMaxima{}, nil
+	case LargestOfMaximumMethod:
+		return LargestOfMaximum{}, nil
+	case WeightedAverageMethod:
+		return WeightedAverage{}, nil
+	}
+	return nil, fmt.Errorf("error, unknown defuzzification method %q", name)
+}
+
+// SetDefuzzifier - метод дефаззификации, которому нужна функция принадлежности μ(r) нечеткого множества
+// "рекомендация" целиком. Для таких методов нечеткий алгоритм сам строит μ(r) с выбранной импликацией
+type SetDefuzzifier interface {
+	Defuzzifier
+	// DefuzzifySet выполняет дефаззификацию
+	// Входной параметр: output - функция принадлежности нечеткого множества "рекомендация"
+	DefuzzifySet(output OutputSet) float64
+}
+
+// Centroid реализует метод центра тяжести, вычисляемый в замкнутой форме: абсцисса центра тяжести
+// фигуры под графиком функции принадлежности μ(r) нечеткого множества "рекомендация", которая является
+// объединением (максимумом) следствий правил. В отличие от NumericalCentroid, перекрытие соседних
+// треугольников учитывается один раз
+type Centroid struct{}
+
+// Defuzzify реализует метод центра тяжести с импликацией Мамдани
+func (cnt Centroid) Defuzzify(strengths []float64, recommendations []int) float64 {
+	return cnt.DefuzzifySet(NewOutputSet(strengths, recommendations, MamdaniImplication{}))
+}
+
+// DefuzzifySet реализует метод центра тяжести
+func (Centroid) DefuzzifySet(output OutputSet) float64 {
+	var area, moment float64
+	output.eachSegment(func(left, right, leftValue, rightValue float64) {
+		area += (leftValue + rightValue) / 2 * (right - left)
+		moment += (right - left) / 6 * (leftValue*(2*left+right) + rightValue*(left+2*right))
+	})
+	if area == 0 {
+		return 0
+	}
+	return moment / area
+}
+
+// Bisector реализует метод биссектрисы площади: абсцисса, которая делит площадь фигуры под графиком
+// функции принадлежности μ(r) нечеткого множества "рекомендация" на две равные части
+type Bisector struct{}
+
+// Defuzzify реализует метод биссектрисы площади с импликацией Мамдани
+func (bsc Bisector) Defuzzify(strengths []float64, recommendations []int) float64 {
+	return bsc.DefuzzifySet(NewOutputSet(strengths, recommendations, MamdaniImplication{}))
+}
+
+// DefuzzifySet реализует метод биссектрисы площади
+func (Bisector) DefuzzifySet(output OutputSet) float64 {
+	var total float64
+	output.eachSegment(func(left, right, leftValue, rightValue float64) {
+		total += (leftValue + rightValue) / 2 * (right - left)
+	})
+	if total == 0 {
+		return 0
+	}
+
+	half := total / 2
+	var accumulated float64
+	result := math.NaN()
+	output.eachSegment(func(left, right, leftValue, rightValue float64) {
+		if !math.IsNaN(result) {
+			return
+		}
+		area := (leftValue + rightValue) / 2 * (right - left)
+		if accumulated+area < half {
+			accumulated += area
+			return
+		}
+		// на отрезке функция линейна: f(t) = leftValue + slope*t, площадь от left до left+t равна
+		// leftValue*t + slope*t²/2; корень квадратного уравнения записан в форме, устойчивой при slope, близком к нулю
+		needed := half - accumulated
+		slope := (rightValue - leftValue) / (right - left)
+		denominator := leftValue + math.Sqrt(leftValue*leftValue+2*slope*needed)
+		if denominator == 0 {
+			result = left
+			return
+		}
+		result = left + 2*needed/denominator
+	})
+	return result
+}
+
+// MeanOfMaxima реализует метод среднего максимума: середина множества абсцисс, в которых функция
+// принадлежности μ(r) нечеткого множества "рекомендация" достигает максимума
+type MeanOfMaxima struct{}
+
+// Defuzzify реализует метод среднего максимума с импликацией Мамдани
+func (mom MeanOfMaxima) Defuzzify(strengths []float64, recommendations []int) float64 {
+	return mom.DefuzzifySet(NewOutputSet(strengths, recommendations, MamdaniImplication{}))
+}
+
+// DefuzzifySet реализует метод среднего максимума
+func (MeanOfMaxima) DefuzzifySet(output OutputSet) float64 {
+	intervals := output.maxima()
+	if len(intervals) == 0 {
+		return 0
+	}
+
+	var length, moment, sum float64
+	for _, interval := range intervals {
+		length += interval[1] - interval[0]
+		moment += (interval[1] - interval[0]) * (interval[0] + interval[1]) / 2
+		sum += (interval[0] + interval[1]) / 2
+	}
+	if length == 0 {
+		// максимум достигается в отдельных точках
+		return sum / float64(len(intervals))
+	}
+	return moment / length
+}
+
+// LargestOfMaximum реализует метод наибольшего из максимумов: наибольшая абсцисса, в которой функция
+// принадлежности μ(r) нечеткого множества "рекомендация" достигает максимума
+type LargestOfMaximum struct{}
+
+// Defuzzify реализует метод наибольшего из максимумов с импликацией Мамдани
+func (lom LargestOfMaximum) Defuzzify(strengths []float64, recommendations []int) float64 {
+	return lom.DefuzzifySet(NewOutputSet(strengths, recommendations, MamdaniImplication{}))
+}
+
+// DefuzzifySet реализует метод наибольшего из максимумов
+func (LargestOfMaximum) DefuzzifySet(output OutputSet) float64 {
+	intervals := output.maxima()
+	if len(intervals) == 0 {
+		return 0
+	}
+	return intervals[len(intervals)-1][1]
+}
+
+// WeightedAverage реализует метод взвешенного среднего (как в алгоритме Сугено): среднее значений
+// рекомендаций, взвешенное степенями истинности правил. Так как площадь треугольника под графиком μG(r)
+// пропорциональна степени истинности, а его центр тяжести совпадает с центральной точкой, метод дает
+// те же значения, что и NumericalCentroid, но без численного интегрирования. Метод не зависит от оператора импликации
+type WeightedAverage struct{}
+
+// Defuzzify реализует метод взвешенного среднего
+func (WeightedAverage) Defuzzify(strengths []float64, recommendations []int) float64 {
+	var enumerator, denominator float64
+	for idx := range strengths {
+		enumerator += strengths[idx] * float64(recommendations[idx])
+		denominator += strengths[idx]
+	}
+	if denominator == 0 {
+		return 0
+	}
+	return enumerator / denominator
+}
@@ -0,0 +1,154 @@
+package fuzzy_test
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"vehicles/packages/domain/fuzzy"
+	"vehicles/packages/domain/models"
+)
+
+// benchmarkPriorities - расстановка приоритетов с наибольшим количеством правил (243)
+var benchmarkPriorities = []string{fuzzy.Comfort, fuzzy.Economy, fuzzy.Safety, fuzzy.Dynamics, fuzzy.Handling}
+
+// newBenchmarkEngine создает нечеткий алгоритм, коэффициенты которого берутся из случайной, но воспроизводимой
+// таблицы, и автомобили для ранжирования
+// Входной параметр: numberOfCars - количество автомобилей
+func newBenchmarkEngine(b *testing.B, numberOfCars int) (*fuzzy.Engine, []models.Car) {
+	rules, err := fuzzy.LoadEmbeddedRuleIndex(fuzzy.DefaultVariables(), nil)
+	if err != nil {
+		b.Fatalf("error from `LoadEmbeddedRuleIndex` function: %#v", err)
+	}
+
+	// ranges - диапазоны значений коэффициентов, характерные для реальных автомобилей
+	ranges := map[string][2]float64{
+		fuzzy.Economy:  {4, 16},
+		fuzzy.Dynamics: {4, 20},
+		fuzzy.Handling: {10, 90},
+		fuzzy.Comfort:  {2, 20},
+		fuzzy.Safety:   {2, 20},
+	}
+	random := rand.New(rand.NewSource(1))
+	coefficients := make([]map[string]float64, numberOfCars)
+	cars := make([]models.Car, numberOfCars)
+	for idx := range cars {
+		cars[idx].ID = idx
+		coefficients[idx] = make(map[string]float64, len(ranges))
+		// переменные перебираются в порядке приоритетов, а не отображения, чтобы таблица не менялась между запусками
+		for _, variable := range benchmarkPriorities {
+			bounds := ranges[variable]
+			coefficients[idx][variable] = bounds[0] + random.Float64()*(bounds[1]-bounds[0])
+		}
+	}
+
+	engine := fuzzy.NewEngine(rules, nil)
+	for variable := range ranges {
+		variable := variable
+		engine.Calculators[variable] = fuzzy.CoefficientFunc(func(car models.Car) float64 {
+			return coefficients[car.ID][variable]
+		})
+	}
+	return engine, cars
+}
+
+// rankedIDs ранжирует автомобили и возвращает их идентификаторы
+func rankedIDs(b *testing.B, engine *fuzzy.Engine, cars []models.Car) []int {
+	results, err := engine.Rank(context.Background(), cars, benchmarkPriorities)
+	if err != nil {
+		b.Fatalf("error from `Rank` method: %#v", err)
+	}
+	ids := make([]int, len(results))
+	for idx, result := range results {
+		ids[idx] = result.CarID
+	}
+	return ids
+}
+
+// kendallTau вычисляет коэффициент ранговой корреляции Кендалла двух ранжирований одних и тех же автомобилей:
+// 1 - порядок совпадает, -1 - порядок обратный
+func kendallTau(first, second []int) float64 {
+	positions := make(map[int]int, len(second))
+	for idx, id := range second {
+		positions[id] = idx
+	}
+
+	var concordant, discordant float64
+	for idx := 0; idx < len(first); idx++ {
+		for jdx := idx + 1; jdx < len(first); jdx++ {
+			if positions[first[idx]] < positions[first[jdx]] {
+				concordant++
+			} else {
+				discordant++
+			}
+		}
+	}
+	return (concordant - discordant) / (concordant + discordant)
+}
+
+// BenchmarkDefuzzifiers сравнивает методы дефаззификации с методом NumericalCentroid: время ранжирования
+// 50 автомобилей по 243 правилам и совпадение порядка (метрика tau - коэффициент Кендалла,
+// top10 - доля общих автомобилей в первых десяти)
+func BenchmarkDefuzzifiers(b *testing.B) {
+	engine, cars := newBenchmarkEngine(b, 50)
+	reference := rankedIDs(b, engine, cars)
+
+	methods := []string{fuzzy.NumericalCentroidMethod, fuzzy.CentroidMethod, fuzzy.BisectorMethod,
+		fuzzy.MeanOfMaximaMethod, fuzzy.LargestOfMaximumMethod, fuzzy.WeightedAverageMethod}
+	for _, method := range methods {
+		defuzzifier, err := fuzzy.NewDefuzzifier(method)
+		if err != nil {
+			b.Fatalf("error from `NewDefuzzifier` function: %#v", err)
+		}
+		methodEngine := engine.WithDefuzzifier(defuzzifier)
+
+		b.Run(method, func(b *testing.B) {
+			var ids []int
+			for idx := 0; idx < b.N; idx++ {
+				ids = rankedIDs(b, methodEngine, cars)
+			}
+
+			top := make(map[int]bool, 10)
+			for _, id := range reference[:10] {
+				top[id] = true
+			}
+			var common float64
+			for _, id := range ids[:10] {
+				if top[id] {
+					common++
+				}
+			}
+			b.ReportMetric(kendallTau(reference, ids), "tau")
+			b.ReportMetric(common/10, "top10")
+		})
+	}
+}
+
+// BenchmarkOperators сравнивает ранжирования с разными t-нормами и операторами импликации с ранжированием
+// по умолчанию (минимум, импликация Мамдани) для метода Centroid, который учитывает импликацию
+func BenchmarkOperators(b *testing.B) {
+	engine, cars := newBenchmarkEngine(b, 50)
+	engine = engine.WithDefuzzifier(fuzzy.Centroid{})
+	reference := rankedIDs(b, engine, cars)
+
+	for _, tNormName := range []string{fuzzy.MinTNormName, fuzzy.ProductTNormName, fuzzy.LukasiewiczTNormName} {
+		for _, implicationName := range []string{fuzzy.MamdaniImplicationName, fuzzy.LarsenImplicationName} {
+			tNorm, err := fuzzy.NewTNorm(tNormName)
+			if err != nil {
+				b.Fatalf("error from `NewTNorm` function: %#v", err)
+			}
+			implication, err := fuzzy.NewImplication(implicationName)
+			if err != nil {
+				b.Fatalf("error from `NewImplication` function: %#v", err)
+			}
+			operatorsEngine := engine.WithOperators(tNorm, engine.SNorm, implication)
+
+			b.Run(tNormName+"_"+implicationName, func(b *testing.B) {
+				var ids []int
+				for idx := 0; idx < b.N; idx++ {
+					ids = rankedIDs(b, operatorsEngine, cars)
+				}
+				b.ReportMetric(kendallTau(reference, ids), "tau")
+			})
+		}
+	}
+}
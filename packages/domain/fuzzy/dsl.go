@@ -0,0 +1,358 @@
+package fuzzy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ключевые слова языка описания нечетких правил. Пример описания:
+//
+//	# комментарий
+//	PRIORITIES экономичность динамика
+//	IF экономичность IS низкий AND динамика IS низкий THEN recommendation IS 1
+//	IF экономичность IS низкий AND динамика IS средний THEN recommendation IS 2
+//	IF экономичность IS высокий OR динамика IS высокий THEN recommendation IS 3
+//
+// Условия одного правила соединяются либо только связкой AND, либо только связкой OR
+const (
+	keywordPriorities     = "PRIORITIES"
+	keywordIf             = "IF"
+	keywordAnd            = OperatorAnd
+	keywordOr             = OperatorOr
+	keywordIs             = "IS"
+	keywordThen           = "THEN"
+	keywordRecommendation = "recommendation"
+)
+
+// RuleSet - набор нечетких правил для одной расстановки приоритетов
+type RuleSet struct {
+	// Priorities - расстановка приоритетов, например, "экономичность безопасность динамика"
+	Priorities []string
+	// Rules - нечеткие правила
+	Rules []Rule
+	// Position - место набора в исходном файле, например, "rulebase/3_priorities.rules:12",
+	// используется в сообщениях об ошибках
+	Position string
+}
+
+// ParseRuleBase разбирает описание нечетких правил на языке "IF ... AND ... THEN recommendation IS n".
+// Наборы правил возвращаются в порядке описания, этот порядок определяет номера файлов rules/*_rules.txt
+// Входные параметры: name - имя источника для сообщений об ошибках, reader - описание нечетких правил
+func ParseRuleBase(name string, reader io.Reader) ([]RuleSet, error) {
+	var ruleSets []RuleSet
+	scanner := bufio.NewScanner(reader)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.Fields(line)
+		switch parts[0] {
+		case keywordPriorities:
+			if len(parts) == 1 {
+				return nil, fmt.Errorf("%s:%d: error, there are no priorities after %s", name, lineNumber, keywordPriorities)
+			}
+			ruleSets = append(ruleSets, RuleSet{Priorities: parts[1:], Position: fmt.Sprintf("%s:%d", name, lineNumber)})
+		case keywordIf:
+			if len(ruleSets) == 0 {
+				return nil, fmt.Errorf("%s:%d: error, the rule is declared before %s", name, lineNumber, keywordPriorities)
+			}
+			rule, err := parseRuleStatement(parts)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", name, lineNumber, err)
+			}
+			last := &ruleSets[len(ruleSets)-1]
+			last.Rules = append(last.Rules, rule)
+		default:
+			return nil, fmt.Errorf("%s:%d: error, unexpected keyword %q", name, lineNumber, parts[0])
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error from `Err` method, package `bufio`: %#v", err)
+	}
+	return ruleSets, nil
+}
+
+// parseRuleStatement разбирает одно нечеткое правило, например,
+// "IF экономичность IS низкий AND динамика IS высокий THEN recommendation IS 3"
+// Входной параметр: parts - слова правила
+func parseRuleStatement(parts []string) (Rule, error) {
+	// правило состоит из групп по 4 слова: "IF|AND|OR <множество> IS <подмножество>" и "THEN recommendation IS <n>"
+	if len(parts) < 8 || len(parts)%4 != 0 {
+		return Rule{}, fmt.Errorf("error, malformed rule %q", strings.Join(parts, " "))
+	}
+
+	var rule Rule
+	last := len(parts) - 4
+	// связка определяется по второму условию, остальные условия должны использовать ту же связку
+	if last > 4 && parts[4] == keywordOr {
+		rule.Operator = OperatorOr
+	}
+	for idx := 0; idx < last; idx += 4 {
+		keyword := keywordAnd
+		if rule.Operator == OperatorOr {
+			keyword = keywordOr
+		}
+		if idx == 0 {
+			keyword = keywordIf
+		}
+		if parts[idx] != keyword || parts[idx+2] != keywordIs {
+			return Rule{}, fmt.Errorf("error, expected \"%s <fuzzy set> %s <fuzzy subset>\", got %q",
+				keyword, keywordIs, strings.Join(parts[idx:idx+4], " "))
+		}
+		rule.Conditions = append(rule.Conditions, Condition{Variable: parts[idx+1], Term: parts[idx+3]})
+	}
+
+	if parts[last] != keywordThen || parts[last+1] != keywordRecommendation || parts[last+2] != keywordIs {
+		return Rule{}, fmt.Errorf("error, expected \"%s %s %s <n>\", got %q",
+			keywordThen, keywordRecommendation, keywordIs, strings.Join(parts[last:], " "))
+	}
+
+	var err error
+	rule.Recommendation, err = strconv.Atoi(parts[last+3])
+	if err != nil {
+		return Rule{}, fmt.Errorf("error from `Atoi` function, package `strconv`: %#v", err)
+	}
+	return rule, nil
+}
+
+// FormatRuleBase записывает наборы нечетких правил на языке "IF ... AND ... THEN recommendation IS n"
+// Входные параметры: writer - место записи, ruleSets - наборы нечетких правил
+func FormatRuleBase(writer io.Writer, ruleSets []RuleSet) error {
+	buffered := bufio.NewWriter(writer)
+	for idx, ruleSet := range ruleSets {
+		if idx > 0 {
+			fmt.Fprintln(buffered)
+		}
+		fmt.Fprintf(buffered, "%s %s\n", keywordPriorities, strings.Join(ruleSet.Priorities, " "))
+		for _, rule := range ruleSet.Rules {
+			fmt.Fprintln(buffered, FormatRuleStatement(rule))
+		}
+	}
+
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("error from `Flush` method, package `bufio`: %#v", err)
+	}
+	return nil
+}
+
+// FormatRuleStatement возвращает нечеткое правило на языке "IF ... AND ... THEN recommendation IS n"
+// Входной параметр: rule - нечеткое правило
+func FormatRuleStatement(rule Rule) string {
+	var builder strings.Builder
+	for idx, condition := range rule.Conditions {
+		keyword := keywordAnd
+		if rule.Operator == OperatorOr {
+			keyword = keywordOr
+		}
+		if idx == 0 {
+			keyword = keywordIf
+		}
+		fmt.Fprintf(&builder, "%s %s %s %s ", keyword, condition.Variable, keywordIs, condition.Term)
+	}
+	fmt.Fprintf(&builder, "%s %s %s %d", keywordThen, keywordRecommendation, keywordIs, rule.Recommendation)
+	return builder.String()
+}
+
+// formatRuleLine возвращает нечеткое правило в формате файлов rules/*_rules.txt, например,
+// "экономичность низкий безопасность низкий динамика высокий 3"
+// Входной параметр: rule - нечеткое правило
+func formatRuleLine(rule Rule) string {
+	parts := make([]string, 0, 2*len(rule.Conditions)+2)
+	if rule.Operator == OperatorOr {
+		parts = append(parts, OperatorOr)
+	}
+	for _, condition := range rule.Conditions {
+		parts = append(parts, condition.Variable, condition.Term)
+	}
+	parts = append(parts, strconv.Itoa(rule.Recommendation))
+	return strings.Join(parts, " ")
+}
+
+// ValidateRuleBase проверяет наборы нечетких правил и возвращает все найденные ошибки: повторяющиеся
+// расстановки приоритетов, непокрытые сочетания нечетких подмножеств, противоречивые, повторяющиеся
+// и недостижимые правила
+// Входной параметр: ruleSets - наборы нечетких правил
+func (vs *VariableSet) ValidateRuleBase(ruleSets []RuleSet) []error {
+	var errs []error
+	// positions - места наборов в исходном файле (ключ - расстановка приоритетов)
+	positions := make(map[string]string, len(ruleSets))
+	for _, ruleSet := range ruleSets {
+		key := strings.Join(ruleSet.Priorities, " ")
+		if position, ok := positions[key]; ok {
+			errs = append(errs, fmt.Errorf("%s: error, priorities %q are already declared at %s", ruleSet.Position, key, position))
+			continue
+		}
+		positions[key] = ruleSet.Position
+
+		for _, err := range vs.ValidateRuleSet(ruleSet.Priorities, ruleSet.Rules) {
+			errs = append(errs, fmt.Errorf("%s: %v", ruleSet.Position, err))
+		}
+	}
+	return errs
+}
+
+// ValidateRuleSet проверяет нечеткие правила для одной расстановки приоритетов и возвращает все найденные ошибки.
+// Правило недостижимо, если оно содержит нечеткое множество не из расстановки приоритетов или неизвестное
+// нечеткое подмножество, либо не содержит условия для одного из приоритетов. Правила противоречат друг
+// другу, если при одинаковых условиях у них разные рекомендации. Каждое сочетание нечетких подмножеств
+// должно встречаться ровно один раз в правилах со связкой "И". Правила со связкой "ИЛИ" дополняют их и
+// проверяются только на достижимость
+// Входные параметры: priorities - приоритеты, rules - нечеткие правила для этих приоритетов
+func (vs *VariableSet) ValidateRuleSet(priorities []string, rules []Rule) []error {
+	var errs []error
+	variables := make(map[string]bool, len(priorities))
+	for _, variable := range priorities {
+		if variables[variable] {
+			errs = append(errs, fmt.Errorf("error, the fuzzy set %q is repeated in priorities", variable))
+		}
+		variables[variable] = true
+	}
+
+	// combinations - номера правил (ключ - сочетание нечетких подмножеств в порядке приоритетов)
+	combinations := make(map[string]int, len(rules))
+	for idx, rule := range rules {
+		// conditions - нечеткие подмножества из условий правила (ключ - название нечеткого множества)
+		conditions := make(map[string]string, len(rule.Conditions))
+		reachable := true
+		for _, condition := range rule.Conditions {
+			if !variables[condition.Variable] {
+				errs = append(errs, fmt.Errorf("error, rule %d is unreachable: the fuzzy set %q is not in priorities",
+					idx+1, condition.Variable))
+				reachable = false
+			} else if !vs.isTerm(condition.Variable, condition.Term) {
+				errs = append(errs, fmt.Errorf("error, rule %d is unreachable: unknown fuzzy subset %q of the fuzzy set %q",
+					idx+1, condition.Term, condition.Variable))
+				reachable = false
+			} else if _, ok := conditions[condition.Variable]; ok {
+				errs = append(errs, fmt.Errorf("error, rule %d contains the fuzzy set %q twice", idx+1, condition.Variable))
+				reachable = false
+			}
+			conditions[condition.Variable] = condition.Term
+		}
+		if !reachable || rule.Operator == OperatorOr {
+			continue
+		}
+		if len(conditions) != len(variables) {
+			errs = append(errs, fmt.Errorf("error, rule %d is unreachable: it has %d conditions instead of %d",
+				idx+1, len(conditions), len(variables)))
+			continue
+		}
+
+		key := combinationKey(priorities, conditions)
+		if previous, ok := combinations[key]; ok {
+			if rules[previous].Recommendation != rule.Recommendation {
+				errs = append(errs, fmt.Errorf("error, rule %d contradicts rule %d: the combination %q leads to "+
+					"recommendations %d and %d", idx+1, previous+1, key, rules[previous].Recommendation, rule.Recommendation))
+			} else {
+				errs = append(errs, fmt.Errorf("error, rule %d repeats rule %d: the combination %q", idx+1, previous+1, key))
+			}
+			continue
+		}
+		combinations[key] = idx
+	}
+
+	for _, combination := range vs.allCombinations(priorities) {
+		key := combinationKey(priorities, combination)
+		if _, ok := combinations[key]; !ok {
+			errs = append(errs, fmt.Errorf("error, the combination %q is not covered by any rule", key))
+		}
+	}
+	return errs
+}
+
+// combinationKey возвращает сочетание нечетких подмножеств в порядке приоритетов, например, "низкий высокий"
+// Входные параметры: priorities - приоритеты, conditions - нечеткие подмножества (ключ - название нечеткого множества)
+func combinationKey(priorities []string, conditions map[string]string) string {
+	combination := make([]string, 0, len(priorities))
+	for _, variable := range priorities {
+		combination = append(combination, conditions[variable])
+	}
+	return strings.Join(combination, " ")
+}
+
+// allCombinations возвращает все сочетания нечетких подмножеств для приоритетов
+// Входной параметр: priorities - приоритеты
+func (vs *VariableSet) allCombinations(priorities []string) []map[string]string {
+	combinations := []map[string]string{{}}
+	for _, variable := range priorities {
+		scale := vs.terms(variable)
+		next := make([]map[string]string, 0, len(combinations)*len(scale))
+		for _, combination := range combinations {
+			for _, term := range scale {
+				extended := make(map[string]string, len(combination)+1)
+				for key, value := range combination {
+					extended[key] = value
+				}
+				extended[variable] = term
+				next = append(next, extended)
+			}
+		}
+		combinations = next
+	}
+	return combinations
+}
+
+// WriteRuleFiles записывает наборы нечетких правил в файл priorities.txt и файлы rules/*_rules.txt,
+// которые читает LoadRuleIndex. Лишние файлы rules/*_rules.txt, оставшиеся от прежних наборов, удаляются
+// Входные параметры: dir - каталог назначения, ruleSets - наборы нечетких правил
+func WriteRuleFiles(dir string, ruleSets []RuleSet) error {
+	rulesDir := filepath.Join(dir, "rules")
+	if err := os.MkdirAll(rulesDir, 0o755); err != nil {
+		return fmt.Errorf("error from `MkdirAll` function, package `os`: %#v", err)
+	}
+
+	priorities := make([]string, 0, len(ruleSets))
+	for idx, ruleSet := range ruleSets {
+		priorities = append(priorities, strings.Join(ruleSet.Priorities, " "))
+
+		lines := make([]string, 0, len(ruleSet.Rules))
+		for _, rule := range ruleSet.Rules {
+			lines = append(lines, formatRuleLine(rule))
+		}
+		fileName := filepath.Join(rulesDir, fmt.Sprintf("%d_rules.txt", idx+1))
+		if err := writeLines(fileName, lines); err != nil {
+			return fmt.Errorf("error from `writeLines` function, package `fuzzy`: %#v", err)
+		}
+	}
+
+	if err := writeLines(filepath.Join(dir, "priorities.txt"), priorities); err != nil {
+		return fmt.Errorf("error from `writeLines` function, package `fuzzy`: %#v", err)
+	}
+
+	stale, err := filepath.Glob(filepath.Join(rulesDir, "*_rules.txt"))
+	if err != nil {
+		return fmt.Errorf("error from `Glob` function, package `filepath`: %#v", err)
+	}
+	for _, fileName := range stale {
+		number, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(fileName), "_rules.txt"))
+		if err == nil && number >= 1 && number <= len(ruleSets) {
+			continue
+		}
+		if err = os.Remove(fileName); err != nil {
+			return fmt.Errorf("error from `Remove` function, package `os`: %#v", err)
+		}
+	}
+	return nil
+}
+
+// writeLines записывает строки в файл, каждую строку завершает символ перевода строки
+// Входные параметры: fileName - имя файла, lines - строки
+func writeLines(fileName string, lines []string) error {
+	var builder strings.Builder
+	for _, line := range lines {
+		builder.WriteString(line)
+		builder.WriteString("\n")
+	}
+	if err := os.WriteFile(fileName, []byte(builder.String()), 0o644); err != nil {
+		return fmt.Errorf("error from `WriteFile` function, package `os`: %#v", err)
+	}
+	return nil
+}
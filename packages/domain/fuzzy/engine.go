@@ -0,0 +1,358 @@
+// Пакет fuzzy реализует нечеткий алгоритм ранжирования автомобилей. Пакет не зависит от веб-фреймворка и
+// может использоваться как веб-приложением, так и пакетными задачами, утилитами командной строки и т.д.
+package fuzzy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"vehicles/packages/domain/models"
+)
+
+// названия нечетких множеств (лингвистических переменных) по умолчанию (см. DefaultVariables)
+const (
+	Economy      = "экономичность"
+	Dynamics     = "динамика"
+	Handling     = "управляемость"
+	Comfort      = "комфорт"
+	Safety       = "безопасность"
+	Practicality = "практичность"
+	RunningCosts = "стоимость_владения"
+	OffRoad      = "проходимость"
+)
+
+// названия нечетких подмножеств (термов)
+const (
+	ExtremelyLow  = "крайне_низкий"
+	VeryLow       = "очень_низкий"
+	Low           = "низкий"
+	Medium        = "средний"
+	High          = "высокий"
+	VeryHigh      = "очень_высокий"
+	ExtremelyHigh = "крайне_высокий"
+)
+
+// Result - результат нечеткого алгоритма для одного автомобиля
+type Result struct {
+	// CarID - идентификатор автомобиля
+	CarID int
+	// Value - выходное значение нечеткого алгоритма. В режиме ConfidencePenalize оно умножено на
+	// достоверность результата
+	Value float64
+	// Explanation - объяснение выходного значения нечеткого алгоритма
+	Explanation Explanation
+}
+
+// Engine - нечеткий алгоритм. Все его составные части можно заменить до начала использования
+type Engine struct {
+	// Rules - источник нечетких правил
+	Rules RuleSource
+	// Variables - нечеткие множества со шкалами. Задаются при создании (см. NewEngine), так как по ним создаются
+	// вычислители коэффициентов и функции принадлежности
+	Variables *VariableSet
+	// Calculators - вычислители коэффициентов (ключ - название нечеткого множества)
+	Calculators map[string]CoefficientCalculator
+	// Memberships - функции принадлежности, которые можно заменить новой версией во время работы
+	Memberships *MembershipTable
+	// Formulas - формулы коэффициентов, которые можно заменить новой версией во время работы. Используются
+	// вычислителями, реализующими FormulaCalculator
+	Formulas *FormulaTable
+	// Defuzzifier - метод дефаззификации
+	Defuzzifier Defuzzifier
+	// TNorm - t-норма для правил, условия которых соединены связкой "И"
+	TNorm TNorm
+	// SNorm - s-норма для правил, условия которых соединены связкой "ИЛИ"
+	SNorm SNorm
+	// Implication - оператор импликации. Используется методами дефаззификации, реализующими SetDefuzzifier
+	Implication Implication
+	// Imputer - восстанавливает неизвестные характеристики автомобиля. Если не задан, характеристики
+	// не восстанавливаются
+	Imputer Imputer
+	// ConfidenceMode - режим учета достоверности результата: ConfidenceFlag или ConfidencePenalize
+	ConfidenceMode string
+	// MinConfidence - достоверность, ниже которой результат помечается как ненадежный
+	MinConfidence float64
+	// Workers - количество горутин, одновременно оценивающих автомобили при ранжировании.
+	// Если не больше нуля, то равно runtime.GOMAXPROCS
+	Workers int
+	// Experiment - A/B-тест баз нечетких правил. Если задан, автомобили сессии ранжируются базой правил
+	// ее варианта (см. ForSession)
+	Experiment *Experiment
+	// IntervalMemberships - интервальные функции принадлежности второго типа (ключи - названия нечеткого множества
+	// и нечеткого подмножества). Если заданы, выходное значение получается понижением типа алгоритмом
+	// Карника-Менделя, а метод дефаззификации не используется. Подмножества без интервальной функции
+	// используют функцию первого типа из Memberships
+	IntervalMemberships map[string]map[string]IntervalMembership
+}
+
+// NewEngine создает нечеткий алгоритм с вычислителями коэффициентов, функциями принадлежности, формулами коэффициентов,
+// методом дефаззификации и операторами по умолчанию (минимум, максимум, импликация Мамдани).
+// Характеристики не восстанавливаются, результаты с низкой достоверностью только помечаются
+// Входные параметры: rules - источник нечетких правил, variables - нечеткие множества со шкалами
+// (nil - DefaultVariables); источник правил должен использовать те же нечеткие множества
+func NewEngine(rules RuleSource, variables *VariableSet) *Engine {
+	if variables == nil {
+		variables = DefaultVariables()
+	}
+	return &Engine{
+		Rules:          rules,
+		Variables:      variables,
+		Calculators:    variables.Calculators(),
+		Memberships:    NewMembershipTable(DefaultMembershipsVersion, variables.Memberships()),
+		Formulas:       NewFormulaTable(DefaultFormulas()),
+		Defuzzifier:    NumericalCentroid{Steps: 10000},
+		TNorm:          MinTNorm{},
+		SNorm:          MaxSNorm{},
+		Implication:    MamdaniImplication{},
+		ConfidenceMode: ConfidenceFlag,
+		MinConfidence:  DefaultMinConfidence,
+	}
+}
+
+// WithDefuzzifier возвращает копию нечеткого алгоритма с другим методом дефаззификации. Копия использует
+// те же нечеткие правила, вычислители коэффициентов и функции принадлежности
+// Входной параметр: defuzzifier - метод дефаззификации
+func (eng *Engine) WithDefuzzifier(defuzzifier Defuzzifier) *Engine {
+	copied := *eng
+	copied.Defuzzifier = defuzzifier
+	return &copied
+}
+
+// WithOperators возвращает копию нечеткого алгоритма с другими t-нормой, s-нормой и оператором импликации.
+// Копия использует те же нечеткие правила, вычислители коэффициентов, функции принадлежности и метод дефаззификации
+// Входные параметры: tNorm - t-норма, sNorm - s-норма, implication - оператор импликации
+func (eng *Engine) WithOperators(tNorm TNorm, sNorm SNorm, implication Implication) *Engine {
+	copied := *eng
+	copied.TNorm, copied.SNorm, copied.Implication = tNorm, sNorm, implication
+	return &copied
+}
+
+// WithConfidenceMode возвращает копию нечеткого алгоритма с другим режимом учета достоверности результата
+// Входной параметр: mode - режим учета достоверности: ConfidenceFlag или ConfidencePenalize
+func (eng *Engine) WithConfidenceMode(mode string) *Engine {
+	copied := *eng
+	copied.ConfidenceMode = mode
+	return &copied
+}
+
+// Score выполняет нечеткий алгоритм (получает выходное значение нечеткого алгоритма) для одного автомобиля
+// Входные параметры: car - автомобиль, priorities - приоритеты, расставленные пользователем
+func (eng *Engine) Score(car models.Car, priorities []string) (Result, error) {
+	rules, err := eng.Rules.Rules(priorities)
+	if err != nil {
+		return Result{}, fmt.Errorf("error from `Rules` method, package `fuzzy`: %#v", err)
+	}
+	if len(rules) == 0 {
+		return Result{}, fmt.Errorf("error, there are no rules for priorities %q", strings.Join(priorities, " "))
+	}
+	return eng.scoreRules(car, rules, eng.pinVersions())
+}
+
+// engineVersions - версии функций принадлежности и формул коэффициентов, закрепленные на время ранжирования
+type engineVersions struct {
+	memberships *membershipVersion
+	formulas    *FormulaConfig
+}
+
+// pinVersions закрепляет текущие версии функций принадлежности и формул коэффициентов, чтобы все автомобили
+// одного рейтинга были оценены одними версиями, даже если во время ранжирования они были заменены
+func (eng *Engine) pinVersions() engineVersions {
+	versions := engineVersions{memberships: eng.Memberships.current.Load(), formulas: &defaultFormulas}
+	if eng.Formulas != nil {
+		versions.formulas = eng.Formulas.Formulas()
+	}
+	return versions
+}
+
+// scoreRules выполняет нечеткий алгоритм для одного автомобиля по заданным нечетким правилам
+// Входные параметры: car - автомобиль, rules - нечеткие правила, versions - закрепленные версии функций
+// принадлежности и формул коэффициентов
+func (eng *Engine) scoreRules(car models.Car, rules []Rule, versions engineVersions) (Result, error) {
+	memberships := versions.memberships.functions
+	coefficients, confidences, imputedFields := eng.calculateCoefficients(car, versions.formulas)
+
+	// strengths - степени истинности правил или ординаты вершин треугольников,
+	// которые образуются под графиками функций принадлежности нечеткого множества "рекомендация"
+	strengths, err := eng.ruleStrengths(rules, coefficients, confidences, memberships)
+	if err != nil {
+		return Result{}, fmt.Errorf("error from `ruleStrengths` method, package `fuzzy`: %#v", err)
+	}
+	// recommendations - значения, которые определяют, насколько сильно будет рекомендоваться автомобиль
+	recommendations := make([]int, 0, len(rules))
+	for _, rule := range rules {
+		recommendations = append(recommendations, rule.Recommendation)
+	}
+
+	var value, lowerValue, upperValue float64
+	if eng.IntervalMemberships != nil {
+		lowerValue, upperValue, err = eng.reduceType(rules, coefficients, confidences, memberships, recommendations)
+		if err != nil {
+			return Result{}, fmt.Errorf("error from `reduceType` method, package `fuzzy`: %#v", err)
+		}
+		value = (lowerValue + upperValue) / 2
+	} else if defuzzifier, ok := eng.Defuzzifier.(SetDefuzzifier); ok {
+		value = defuzzifier.DefuzzifySet(NewOutputSet(strengths, recommendations, eng.Implication))
+	} else {
+		value = eng.Defuzzifier.Defuzzify(strengths, recommendations)
+	}
+
+	explanation := explain(eng.Variables, coefficients, confidences, imputedFields, memberships, rules, strengths, value)
+	explanation.Confidence = overallConfidence(confidences, ruleVariables(rules))
+	explanation.LowConfidence = explanation.Confidence < eng.MinConfidence
+	explanation.MembershipsVersion, explanation.FormulasVersion = versions.memberships.version, versions.formulas.Version
+	if eng.IntervalMemberships != nil {
+		explainIntervals(&explanation, eng.IntervalMemberships, lowerValue, upperValue)
+	}
+	if eng.ConfidenceMode == ConfidencePenalize {
+		value *= explanation.Confidence
+		explanation.LowerValue *= explanation.Confidence
+		explanation.UpperValue *= explanation.Confidence
+	}
+	return Result{CarID: car.ID, Value: value, Explanation: explanation}, nil
+}
+
+// ruleStrengths вычисляет степени истинности нечетких правил по коэффициентам автомобиля
+// Входные параметры: rules - нечеткие правила, coefficients - коэффициенты автомобиля, confidences - достоверности
+// коэффициентов, memberships - функции принадлежности
+func (eng *Engine) ruleStrengths(rules []Rule, coefficients, confidences map[string]float64,
+	memberships map[string]map[string]MembershipFunction) ([]float64, error) {
+	strengths := make([]float64, 0, len(rules))
+	for _, rule := range rules {
+		values, err := evaluateConditions(rule.Conditions, coefficients, confidences, memberships)
+		if err != nil {
+			return nil, fmt.Errorf("error from `evaluateConditions` function, package `fuzzy`: %#v", err)
+		}
+		strengths = append(strengths, eng.combine(rule.Operator, values))
+	}
+	return strengths, nil
+}
+
+// reduceType вычисляет нижние и верхние степени истинности нечетких правил по интервальным функциям
+// принадлежности и понижает тип алгоритмом Карника-Менделя. Возвращает концы отрезка выходного значения
+// Входные параметры: rules - нечеткие правила, coefficients - коэффициенты автомобиля, confidences - достоверности
+// коэффициентов, memberships - функции принадлежности первого типа, recommendations - рекомендации правил
+func (eng *Engine) reduceType(rules []Rule, coefficients, confidences map[string]float64,
+	memberships map[string]map[string]MembershipFunction, recommendations []int) (float64, float64, error) {
+	lowerMemberships, upperMemberships := intervalBounds(memberships, eng.IntervalMemberships)
+	lowerStrengths, err := eng.ruleStrengths(rules, coefficients, confidences, lowerMemberships)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error from `ruleStrengths` method, package `fuzzy`: %#v", err)
+	}
+	upperStrengths, err := eng.ruleStrengths(rules, coefficients, confidences, upperMemberships)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error from `ruleStrengths` method, package `fuzzy`: %#v", err)
+	}
+	lowerValue, upperValue := KarnikMendel(lowerStrengths, upperStrengths, recommendations)
+	return lowerValue, upperValue, nil
+}
+
+// calculateCoefficients вычисляет коэффициенты автомобиля, например, коэффициент комфорта и т.д., по характеристикам,
+// восстановленным Imputer, и достоверности коэффициентов по исходным характеристикам. Возвращает коэффициенты,
+// их достоверности и восстановленные характеристики, от которых зависит каждый коэффициент (ключ - название
+// нечеткого множества). Вычислители, реализующие FormulaCalculator, используют заданную версию формул
+// Входные параметры: car - автомобиль, formulas - версия формул коэффициентов
+func (eng *Engine) calculateCoefficients(car models.Car, formulas *FormulaConfig) (map[string]float64,
+	map[string]float64, map[string][]string) {
+	// completed - автомобиль с восстановленными характеристиками, imputed - восстановленные характеристики
+	completed, imputed := car, map[string]bool{}
+	if eng.Imputer != nil {
+		completed, imputed = eng.Imputer.Impute(car)
+	}
+
+	coefficients := make(map[string]float64, len(eng.Calculators))
+	confidences := make(map[string]float64, len(eng.Calculators))
+	imputedFields := make(map[string][]string)
+	for variable, calculator := range eng.Calculators {
+		if withFormulas, ok := calculator.(FormulaCalculator); ok {
+			coefficients[variable] = withFormulas.CalculateWithFormulas(completed, formulas)
+		} else {
+			coefficients[variable] = calculator.Calculate(completed)
+		}
+		confidences[variable] = calculateConfidence(calculator, car, imputed, coefficients[variable])
+		if fields := usedImputedFields(calculator, imputed); len(fields) > 0 {
+			imputedFields[variable] = fields
+		}
+	}
+	return coefficients, confidences, imputedFields
+}
+
+// Rank получает выходное значение нечеткого алгоритма для каждого автомобиля и ранжирует автомобили
+// по убыванию выходного значения нечеткого алгоритма. Если для части автомобилей возникли ошибки или контекст
+// был отменен, то возвращаются результаты для остальных автомобилей и ошибка типа *RankError
+// Входные параметры: ctx - контекст, cars - автомобили, priorities - приоритеты, расставленные пользователем
+func (eng *Engine) Rank(ctx context.Context, cars []models.Car, priorities []string) ([]Result, error) {
+	rules, err := eng.Rules.Rules(priorities)
+	if err != nil {
+		return nil, fmt.Errorf("error from `Rules` method, package `fuzzy`: %#v", err)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("error, there are no rules for priorities %q", strings.Join(priorities, " "))
+	}
+	return eng.rankRules(ctx, cars, rules)
+}
+
+// RankWeighted ранжирует автомобили так же, как Rank, но по нечетким правилам, построенным по весам
+// приоритетов (см. WeightedRules), а не по готовому файлу с правилами
+// Входные параметры: ctx - контекст, cars - автомобили, weights - веса приоритетов (ключ - название нечеткого множества)
+func (eng *Engine) RankWeighted(ctx context.Context, cars []models.Car, weights map[string]float64) ([]Result, error) {
+	rules, err := eng.Variables.WeightedRules(weights)
+	if err != nil {
+		return nil, fmt.Errorf("error from `WeightedRules` method, package `fuzzy`: %#v", err)
+	}
+	return eng.rankRules(ctx, cars, rules)
+}
+
+// evaluateConditions вычисляет значения функций принадлежности для условий "ЕСЛИ" одного нечеткого правила.
+// Если достоверность коэффициента равна нулю, то есть данных для его вычисления нет, то условие пропускается
+// Входные параметры: conditions - условия правила, coefficients - коэффициенты автомобиля,
+// confidences - достоверности коэффициентов, memberships - функции принадлежности
+func evaluateConditions(conditions []Condition, coefficients, confidences map[string]float64,
+	memberships map[string]map[string]MembershipFunction) ([]float64, error) {
+	values := make([]float64, 0, len(conditions))
+	for _, condition := range conditions {
+		coefficient, ok := coefficients[condition.Variable]
+		if !ok {
+			return nil, fmt.Errorf("error, there is no coefficient calculator for the fuzzy set %q", condition.Variable)
+		}
+		if confidences[condition.Variable] == 0 {
+			continue
+		}
+
+		function, ok := memberships[condition.Variable][condition.Term]
+		if !ok {
+			return nil, fmt.Errorf("error, there is no membership function for the fuzzy subset %q of the fuzzy set %q",
+				condition.Term, condition.Variable)
+		}
+		values = append(values, clamp(function.Value(coefficient)))
+	}
+	return values, nil
+}
+
+// clamp ограничивает значение функции принадлежности отрезком [0, 1]
+func clamp(value float64) float64 {
+	if value < 0 {
+		return 0
+	} else if value > 1 {
+		return 1
+	}
+	return value
+}
+
+// combine вычисляет степень истинности правила по значениям функций принадлежности для его условий:
+// t-нормой для связки "И" и s-нормой для связки "ИЛИ"
+// Входные параметры: operator - связка условий, values - значения функций принадлежности
+func (eng *Engine) combine(operator string, values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	strength := values[0]
+	for _, value := range values[1:] {
+		if operator == OperatorOr {
+			strength = eng.SNorm.Apply(strength, value)
+		} else {
+			strength = eng.TNorm.Apply(strength, value)
+		}
+	}
+	return strength
+}
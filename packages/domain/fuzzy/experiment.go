@@ -0,0 +1,77 @@
+package fuzzy
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// варианты A/B-теста баз нечетких правил
+const (
+	// ControlVariant - текущая база правил
+	ControlVariant = "control"
+	// CandidateVariant - новая версия базы правил, например, обученная по отзывам пользователей
+	CandidateVariant = "candidate"
+)
+
+// Experiment - A/B-тест двух баз нечетких правил. Сессия всегда попадает в один и тот же вариант,
+// поэтому рейтинг и отзывы о нем относятся к одной базе правил
+type Experiment struct {
+	// Name - название эксперимента, например, версия новой базы правил. Входит в хеш сессии, поэтому разные
+	// эксперименты распределяют сессии независимо
+	Name string
+	// Control - текущая база правил
+	Control RuleSource
+	// Candidate - новая версия базы правил
+	Candidate RuleSource
+	// Share - доля сессий от 0 до 1, которые ранжируются новой версией
+	Share float64
+}
+
+// NewExperiment создает A/B-тест двух баз нечетких правил
+// Входные параметры: name - название эксперимента, control - текущая база правил, candidate - новая версия,
+// share - доля сессий, которые ранжируются новой версией
+func NewExperiment(name string, control, candidate RuleSource, share float64) (*Experiment, error) {
+	if name == "" {
+		return nil, fmt.Errorf("error, the experiment has no name")
+	}
+	if control == nil || candidate == nil {
+		return nil, fmt.Errorf("error, the experiment %q has no control or candidate rules", name)
+	}
+	if share < 0 || share > 1 {
+		return nil, fmt.Errorf("error, the share %v of the experiment %q is not between 0 and 1", share, name)
+	}
+	return &Experiment{Name: name, Control: control, Candidate: candidate, Share: share}, nil
+}
+
+// Variant возвращает вариант, в который попадает сессия: ControlVariant или CandidateVariant
+// Входной параметр: sessionID - идентификатор сессии
+func (exp *Experiment) Variant(sessionID string) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(exp.Name + "/" + sessionID))
+	if float64(hash.Sum32()%10000) < exp.Share*10000 {
+		return CandidateVariant
+	}
+	return ControlVariant
+}
+
+// Rules возвращает базу правил варианта
+// Входной параметр: variant - вариант: ControlVariant или CandidateVariant
+func (exp *Experiment) Rules(variant string) RuleSource {
+	if variant == CandidateVariant {
+		return exp.Candidate
+	}
+	return exp.Control
+}
+
+// ForSession возвращает нечеткий алгоритм, ранжирующий автомобили сессии, и вариант A/B-теста. Если эксперимент
+// не задан, возвращается сам нечеткий алгоритм и пустой вариант, иначе - копия с базой правил варианта сессии
+// Входной параметр: sessionID - идентификатор сессии
+func (eng *Engine) ForSession(sessionID string) (*Engine, string) {
+	if eng.Experiment == nil {
+		return eng, ""
+	}
+	variant := eng.Experiment.Variant(sessionID)
+	copied := *eng
+	copied.Rules = eng.Experiment.Rules(variant)
+	return &copied, variant
+}
@@ -0,0 +1,128 @@
+package fuzzy
+
+import "sort"
+
+// NumberOfFiredRules - количество правил с наибольшей степенью истинности, которые попадают в объяснение
+const NumberOfFiredRules = 5
+
+// Explanation - объяснение выходного значения нечеткого алгоритма для одного автомобиля
+type Explanation struct {
+	// Variables - коэффициенты и значения функций принадлежности для каждого нечеткого множества
+	Variables []VariableExplanation
+	// FiredRules - правила с наибольшей степенью истинности, упорядоченные по её убыванию
+	FiredRules []FiredRule
+	// Value - выходное значение нечеткого алгоритма, полученное дефаззификацией
+	Value float64
+	// Confidence - достоверность результата: средняя достоверность коэффициентов, используемых правилами
+	Confidence float64
+	// LowConfidence - достоверность результата ниже порога, заданного нечетким алгоритмом
+	LowConfidence bool
+	// Interval - выходное значение получено по интервальным функциям принадлежности второго типа,
+	// и Value - середина отрезка [LowerValue, UpperValue]
+	Interval bool
+	// LowerValue, UpperValue - концы отрезка выходного значения, полученного понижением типа
+	// алгоритмом Карника-Менделя: полоса неопределенности из-за расхождения мнений пользователей
+	LowerValue, UpperValue float64
+	// MembershipsVersion, FormulasVersion - версии функций принадлежности и формул коэффициентов, которыми
+	// оценен автомобиль. Все автомобили одного рейтинга оцениваются одними версиями
+	MembershipsVersion, FormulasVersion string
+}
+
+// VariableExplanation - коэффициент автомобиля и степени его принадлежности нечетким подмножествам
+// одного нечеткого множества, например, "комфорт"
+type VariableExplanation struct {
+	// Variable - название нечеткого множества
+	Variable string
+	// Coefficient - значение коэффициента
+	Coefficient float64
+	// Memberships - значения функций принадлежности нечетких подмножеств от худшего к лучшему.
+	// Срез пуст, если данных для вычисления коэффициента нет и нечеткое множество не участвовало в вычислениях
+	Memberships []TermMembership
+	// Confidence - достоверность коэффициента, доля известных характеристик от 0 до 1
+	Confidence float64
+	// Imputed - характеристики, восстановленные по похожим автомобилям, в алфавитном порядке
+	Imputed []string
+}
+
+// TermMembership - значение функции принадлежности одного нечеткого подмножества
+type TermMembership struct {
+	// Term - название нечеткого подмножества
+	Term string
+	// Degree - значение функции принадлежности
+	Degree float64
+	// Lower, Upper - значения нижней и верхней функций принадлежности, если для нечеткого подмножества задана
+	// интервальная функция принадлежности второго типа, иначе равны Degree
+	Lower, Upper float64
+}
+
+// FiredRule - нечеткое правило и его степень истинности
+type FiredRule struct {
+	// Rule - нечеткое правило
+	Rule Rule
+	// Strength - степень истинности правила
+	Strength float64
+}
+
+// explain составляет объяснение выходного значения нечеткого алгоритма
+// Входные параметры: variableSet - нечеткие множества со шкалами, coefficients - коэффициенты автомобиля,
+// confidences - достоверности коэффициентов, imputed - восстановленные характеристики (ключ - название нечеткого множества), memberships - функции принадлежности, rules - нечеткие правила,
+// strengths - степени истинности правил, value - выходное значение нечеткого алгоритма
+func explain(variableSet *VariableSet, coefficients, confidences map[string]float64, imputed map[string][]string,
+	memberships map[string]map[string]MembershipFunction, rules []Rule, strengths []float64, value float64) Explanation {
+	variables := make([]string, 0, len(coefficients))
+	for variable := range coefficients {
+		variables = append(variables, variable)
+	}
+	sort.Strings(variables)
+
+	explanation := Explanation{Variables: make([]VariableExplanation, 0, len(variables)), Value: value}
+	for _, variable := range variables {
+		varExplanation := VariableExplanation{Variable: variable, Coefficient: coefficients[variable],
+			Confidence: confidences[variable], Imputed: imputed[variable]}
+		if varExplanation.Confidence != 0 {
+			for _, term := range variableSet.terms(variable) {
+				if function, ok := memberships[variable][term]; ok {
+					varExplanation.Memberships = append(varExplanation.Memberships,
+						newTermMembership(term, clamp(function.Value(varExplanation.Coefficient))))
+				}
+			}
+		}
+		explanation.Variables = append(explanation.Variables, varExplanation)
+	}
+
+	fired := make([]FiredRule, 0, len(rules))
+	for idx, rule := range rules {
+		if strengths[idx] > 0 {
+			fired = append(fired, FiredRule{Rule: rule, Strength: strengths[idx]})
+		}
+	}
+	sort.SliceStable(fired, func(idx, jdx int) bool {
+		return fired[idx].Strength > fired[jdx].Strength
+	})
+	if len(fired) > NumberOfFiredRules {
+		fired = fired[:NumberOfFiredRules]
+	}
+	explanation.FiredRules = fired
+	return explanation
+}
+
+// newTermMembership создает значение функции принадлежности первого типа
+// Входные параметры: term - название нечеткого подмножества, degree - значение функции принадлежности
+func newTermMembership(term string, degree float64) TermMembership {
+	return TermMembership{Term: term, Degree: degree, Lower: degree, Upper: degree}
+}
+
+// explainIntervals дополняет объяснение отрезком выходного значения и значениями нижних и верхних
+// функций принадлежности второго типа
+// Входные параметры: explanation - объяснение, intervals - интервальные функции принадлежности,
+// lowerValue, upperValue - концы отрезка выходного значения
+func explainIntervals(explanation *Explanation, intervals map[string]map[string]IntervalMembership, lowerValue, upperValue float64) {
+	explanation.Interval, explanation.LowerValue, explanation.UpperValue = true, lowerValue, upperValue
+	for _, variable := range explanation.Variables {
+		for idx, membership := range variable.Memberships {
+			if interval, ok := intervals[variable.Variable][membership.Term]; ok {
+				variable.Memberships[idx].Lower, variable.Memberships[idx].Upper = interval.Bounds(variable.Coefficient)
+			}
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package fuzzy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// действия пользователя с автомобилем из рейтинга
+const (
+	// FeedbackOpen - пользователь открыл страницу автомобиля
+	FeedbackOpen = "open"
+	// FeedbackFavourite - пользователь добавил автомобиль в избранное
+	FeedbackFavourite = "favourite"
+	// FeedbackDismiss - пользователь отметил, что автомобиль ему не подходит
+	FeedbackDismiss = "dismiss"
+)
+
+const (
+	// DefaultLearningRate - доля шкалы рекомендаций, на которую может сдвинуться рекомендация правила
+	DefaultLearningRate = 0.2
+	// DefaultPriorSupport - суммарная степень истинности правила, при которой сдвиг рекомендации достигает
+	// половины наибольшего
+	DefaultPriorSupport = 5.0
+)
+
+// DefaultFeedbackRewards - оценки действий пользователя: положительные для автомобилей, которые ему понравились,
+// отрицательные для отвергнутых
+var DefaultFeedbackRewards = map[string]float64{
+	FeedbackOpen:      0.5,
+	FeedbackFavourite: 1,
+	FeedbackDismiss:   -1,
+}
+
+// ValidateFeedbackAction проверяет действие пользователя
+// Входной параметр: action - действие: FeedbackOpen, FeedbackFavourite или FeedbackDismiss
+func ValidateFeedbackAction(action string) error {
+	if _, ok := DefaultFeedbackRewards[action]; !ok {
+		return fmt.Errorf("error, unknown feedback action %q", action)
+	}
+	return nil
+}
+
+// FeedbackEvent - действие пользователя с автомобилем из рейтинга. Событие хранит коэффициенты автомобиля,
+// чтобы степени истинности правил можно было вычислить позже, без самого автомобиля
+type FeedbackEvent struct {
+	// SessionID - идентификатор сессии
+	SessionID string
+	// Priorities - приоритеты, по которым ранжировались автомобили
+	Priorities []string
+	// Experiment, Variant - A/B-тест и его вариант, базой правил которого ранжировались автомобили;
+	// пусто, если эксперимента нет
+	Experiment string
+	Variant    string
+	// Rank - место автомобиля в рейтинге, начиная с 1
+	Rank int
+	// Action - действие: FeedbackOpen, FeedbackFavourite или FeedbackDismiss
+	Action string
+	// Coefficients - коэффициенты автомобиля (ключ - название нечеткого множества)
+	Coefficients map[string]float64
+	// Confidences - достоверности коэффициентов (ключ - название нечеткого множества)
+	Confidences map[string]float64
+	// MembershipsVersion, FormulasVersion - версии функций принадлежности и формул коэффициентов,
+	// которыми оценен автомобиль
+	MembershipsVersion, FormulasVersion string
+	// CreatedAt - время действия
+	CreatedAt time.Time
+}
+
+// NewFeedbackEvent создает событие по объяснению результата нечеткого алгоритма для автомобиля
+// Входные параметры: sessionID - идентификатор сессии, priorities - приоритеты, experiment - название A/B-теста,
+// variant - вариант A/B-теста, rank - место автомобиля в рейтинге, action - действие, explanation - объяснение
+func NewFeedbackEvent(sessionID string, priorities []string, experiment, variant string, rank int, action string,
+	explanation Explanation) (FeedbackEvent, error) {
+	if err := ValidateFeedbackAction(action); err != nil {
+		return FeedbackEvent{}, fmt.Errorf("error from `ValidateFeedbackAction` function, package `fuzzy`: %#v", err)
+	}
+	if rank < 1 {
+		return FeedbackEvent{}, fmt.Errorf("error, the rank %d is not positive", rank)
+	}
+
+	event := FeedbackEvent{SessionID: sessionID, Priorities: priorities, Experiment: experiment, Variant: variant,
+		Rank: rank, Action: action, Coefficients: make(map[string]float64, len(explanation.Variables)),
+		Confidences: make(map[string]float64, len(explanation.Variables)), MembershipsVersion: explanation.MembershipsVersion,
+		FormulasVersion: explanation.FormulasVersion, CreatedAt: time.Now()}
+	for _, variable := range explanation.Variables {
+		event.Coefficients[variable.Variable] = variable.Coefficient
+		event.Confidences[variable.Variable] = variable.Confidence
+	}
+	return event, nil
+}
+
+// ConsequentLearner подбирает рекомендации (заключения) нечетких правил по отзывам пользователей: рекомендация
+// правила растет, если автомобили, для которых оно истинно, открывают и добавляют в избранное, и уменьшается,
+// если их отвергают. Сдвиг пропорционален средней оценке действий, взвешенной степенями истинности правила
+type ConsequentLearner struct {
+	// Engine - нечеткий алгоритм, функциями принадлежности и операторами которого вычисляются степени
+	// истинности правил, а базой правил - правила для расстановок, которых нет в обучаемой базе
+	Engine *Engine
+	// Rewards - оценки действий пользователя от -1 до 1
+	Rewards map[string]float64
+	// LearningRate - доля шкалы рекомендаций, на которую может сдвинуться рекомендация правила
+	LearningRate float64
+	// PriorSupport - суммарная степень истинности, при которой сдвиг достигает половины наибольшего.
+	// Защищает от изменения правил по нескольким случайным отзывам
+	PriorSupport float64
+}
+
+// NewConsequentLearner создает обучение рекомендаций нечетких правил с параметрами по умолчанию
+// Входной параметр: engine - нечеткий алгоритм
+func NewConsequentLearner(engine *Engine) *ConsequentLearner {
+	return &ConsequentLearner{Engine: engine, Rewards: DefaultFeedbackRewards, LearningRate: DefaultLearningRate,
+		PriorSupport: DefaultPriorSupport}
+}
+
+// RuleChange - изменение рекомендации одного нечеткого правила
+type RuleChange struct {
+	// Priorities - расстановка приоритетов
+	Priorities []string
+	// Rule - номер правила в наборе, начиная с 1
+	Rule int
+	// Before, After - рекомендация до и после обучения
+	Before, After int
+	// Support - суммарная степень истинности правила по всем отзывам
+	Support float64
+	// Reward - средняя оценка отзывов, взвешенная степенями истинности правила
+	Reward float64
+}
+
+// LearningReport - результат обучения рекомендаций нечетких правил
+type LearningReport struct {
+	// Events - количество отзывов
+	Events int
+	// UsedEvents - количество отзывов, по которым вычислены степени истинности правил
+	UsedEvents int
+	// Changes - изменения рекомендаций в порядке наборов правил и номеров правил
+	Changes []RuleChange
+}
+
+// Learn строит новую версию базы нечетких правил по отзывам пользователей. Наборы правил без отзывов
+// копируются без изменений; для расстановок приоритетов из отзывов, которых нет в базе, правила берутся
+// из нечеткого алгоритма и добавляются в конец. Исходные наборы не изменяются
+// Входные параметры: ruleSets - текущая база правил, events - отзывы пользователей
+func (lrn *ConsequentLearner) Learn(ruleSets []RuleSet, events []FeedbackEvent) ([]RuleSet, LearningReport, error) {
+	report := LearningReport{Events: len(events)}
+
+	learned := make([]RuleSet, 0, len(ruleSets))
+	// indexes - индексы наборов правил (ключ - расстановка приоритетов)
+	indexes := make(map[string]int, len(ruleSets))
+	for _, ruleSet := range ruleSets {
+		indexes[strings.Join(ruleSet.Priorities, " ")] = len(learned)
+		ruleSet.Rules = append([]Rule(nil), ruleSet.Rules...)
+		learned = append(learned, ruleSet)
+	}
+
+	// grouped - отзывы по расстановкам приоритетов в порядке первого появления
+	grouped := make(map[string][]FeedbackEvent)
+	var keys []string
+	for _, event := range events {
+		if _, ok := lrn.Rewards[event.Action]; !ok || len(event.Priorities) == 0 {
+			continue
+		}
+		key := strings.Join(event.Priorities, " ")
+		if _, ok := grouped[key]; !ok {
+			keys = append(keys, key)
+		}
+		grouped[key] = append(grouped[key], event)
+	}
+
+	memberships := lrn.Engine.Memberships.Functions()
+	for _, key := range keys {
+		idx, ok := indexes[key]
+		if !ok {
+			priorities := grouped[key][0].Priorities
+			rules, err := lrn.Engine.Rules.Rules(priorities)
+			if err != nil {
+				return nil, LearningReport{}, fmt.Errorf("error from `Rules` method, package `fuzzy`: %#v", err)
+			}
+			idx = len(learned)
+			learned = append(learned, RuleSet{Priorities: priorities, Rules: append([]Rule(nil), rules...),
+				Position: "feedback"})
+		}
+
+		changes, used := lrn.learnRuleSet(&learned[idx], grouped[key], memberships)
+		report.UsedEvents += used
+		report.Changes = append(report.Changes, changes...)
+	}
+	return learned, report, nil
+}
+
+// learnRuleSet сдвигает рекомендации правил одного набора по отзывам и возвращает изменения и количество
+// использованных отзывов. Отзывы, коэффициентов которых недостаточно для правил, пропускаются
+// Входные параметры: ruleSet - набор правил, events - отзывы для его расстановки приоритетов,
+// memberships - функции принадлежности
+func (lrn *ConsequentLearner) learnRuleSet(ruleSet *RuleSet, events []FeedbackEvent,
+	memberships map[string]map[string]MembershipFunction) ([]RuleChange, int) {
+	support := make([]float64, len(ruleSet.Rules))
+	reward := make([]float64, len(ruleSet.Rules))
+	var used int
+	for _, event := range events {
+		strengths, err := lrn.Engine.ruleStrengths(ruleSet.Rules, event.Coefficients, event.Confidences, memberships)
+		if err != nil {
+			continue
+		}
+		used++
+		for idx, strength := range strengths {
+			support[idx] += strength
+			reward[idx] += strength * lrn.Rewards[event.Action]
+		}
+	}
+
+	// maxRecommendation - наибольшая рекомендация набора, граница шкалы рекомендаций
+	maxRecommendation := 1
+	for _, rule := range ruleSet.Rules {
+		maxRecommendation = maxInt(maxRecommendation, rule.Recommendation)
+	}
+
+	var changes []RuleChange
+	for idx, rule := range ruleSet.Rules {
+		if support[idx] == 0 {
+			continue
+		}
+		mean := reward[idx] / support[idx]
+		shift := lrn.LearningRate * float64(maxRecommendation-1) * mean * support[idx] / (support[idx] + lrn.PriorSupport)
+		recommendation := minInt(maxRecommendation, maxInt(1, rule.Recommendation+int(math.Round(shift))))
+		if recommendation == rule.Recommendation {
+			continue
+		}
+		changes = append(changes, RuleChange{Priorities: ruleSet.Priorities, Rule: idx + 1, Before: rule.Recommendation,
+			After: recommendation, Support: support[idx], Reward: mean})
+		ruleSet.Rules[idx].Recommendation = recommendation
+	}
+	return changes, used
+}
+
+// VariantStats - показатели одного варианта A/B-теста по отзывам пользователей
+type VariantStats struct {
+	// Experiment, Variant - A/B-тест и вариант; пусто для отзывов без эксперимента
+	Experiment string
+	Variant    string
+	// Sessions - количество сессий с отзывами
+	Sessions int
+	// Opens, Favourites, Dismisses - количество действий каждого вида
+	Opens, Favourites, Dismisses int
+	// FavouritesPerSession, DismissesPerSession - среднее количество добавлений в избранное и отказов на сессию
+	FavouritesPerSession, DismissesPerSession float64
+	// MeanReciprocalRank - среднее значение 1/место для открытых и добавленных в избранное автомобилей:
+	// чем ближе к 1, тем выше в рейтинге автомобили, которые нравятся пользователям
+	MeanReciprocalRank float64
+}
+
+// CompareVariants вычисляет показатели вариантов A/B-тестов по отзывам пользователей. Варианты упорядочены
+// по названию эксперимента и варианта
+// Входной параметр: events - отзывы пользователей
+func CompareVariants(events []FeedbackEvent) []VariantStats {
+	type variantKey struct{ experiment, variant string }
+	stats := make(map[variantKey]*VariantStats)
+	sessions := make(map[variantKey]map[string]bool)
+	// positives - количество открытых и добавленных в избранное автомобилей
+	positives := make(map[variantKey]int)
+	for _, event := range events {
+		key := variantKey{event.Experiment, event.Variant}
+		if stats[key] == nil {
+			stats[key] = &VariantStats{Experiment: event.Experiment, Variant: event.Variant}
+			sessions[key] = make(map[string]bool)
+		}
+		variant := stats[key]
+		sessions[key][event.SessionID] = true
+
+		switch event.Action {
+		case FeedbackOpen:
+			variant.Opens++
+		case FeedbackFavourite:
+			variant.Favourites++
+		case FeedbackDismiss:
+			variant.Dismisses++
+			continue
+		default:
+			continue
+		}
+		if event.Rank > 0 {
+			variant.MeanReciprocalRank += 1 / float64(event.Rank)
+			positives[key]++
+		}
+	}
+
+	result := make([]VariantStats, 0, len(stats))
+	for key, variant := range stats {
+		variant.Sessions = len(sessions[key])
+		variant.FavouritesPerSession = float64(variant.Favourites) / float64(variant.Sessions)
+		variant.DismissesPerSession = float64(variant.Dismisses) / float64(variant.Sessions)
+		if positives[key] > 0 {
+			variant.MeanReciprocalRank /= float64(positives[key])
+		}
+		result = append(result, *variant)
+	}
+	sort.Slice(result, func(idx, jdx int) bool {
+		if result[idx].Experiment != result[jdx].Experiment {
+			return result[idx].Experiment < result[jdx].Experiment
+		}
+		return result[idx].Variant < result[jdx].Variant
+	})
+	return result
+}
@@ -0,0 +1,398 @@
+package fuzzy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// параметры метода Левенберга-Марквардта
+const (
+	// maxFitIterations - максимальное количество итераций
+	maxFitIterations = 500
+	// fitTolerance - относительное изменение суммы квадратов невязок, при котором итерации прекращаются
+	fitTolerance = 1e-12
+	// jacobianStep - шаг численного дифференцирования
+	jacobianStep = 1e-7
+)
+
+// SurveyAnswers - ответы пользователей на один вопрос опроса, например,
+// "Как Вы думаете, расход топлива в смешанном цикле 5 л/100 км — это:"
+type SurveyAnswers struct {
+	// Variable - название нечеткого множества, к которому относится вопрос
+	Variable string
+	// X - значение коэффициента, о котором спрашивается в вопросе
+	X float64
+	// Counts - количество ответов (ключ - название нечеткого подмножества)
+	Counts map[string]int
+}
+
+// CurvePoint - точка эмпирической функции принадлежности
+type CurvePoint struct {
+	// X - значение коэффициента
+	X float64
+	// Degree - доля пользователей, отнесших значение коэффициента к нечеткому подмножеству
+	Degree float64
+}
+
+// FitResult - функция принадлежности, подобранная по ответам пользователей
+type FitResult struct {
+	// Definition - описание подобранной функции принадлежности
+	Definition MembershipDefinition
+	// Points - точки эмпирической функции принадлежности
+	Points []CurvePoint
+	// RMSE - среднеквадратичное отклонение подобранной функции от точек
+	RMSE float64
+}
+
+// EmpiricalCurves строит эмпирические функции принадлежности: для каждого вопроса степень принадлежности
+// значения коэффициента нечеткому подмножеству равна доле пользователей, выбравших это подмножество
+// Входной параметр: answers - ответы пользователей
+func EmpiricalCurves(answers []SurveyAnswers) map[string]map[string][]CurvePoint {
+	curves := make(map[string]map[string][]CurvePoint)
+	for _, question := range answers {
+		var total int
+		for _, count := range question.Counts {
+			total += count
+		}
+		if total == 0 {
+			continue
+		}
+
+		if curves[question.Variable] == nil {
+			curves[question.Variable] = make(map[string][]CurvePoint)
+		}
+		for _, term := range terms {
+			curves[question.Variable][term] = append(curves[question.Variable][term],
+				CurvePoint{X: question.X, Degree: float64(question.Counts[term]) / float64(total)})
+		}
+	}
+
+	for _, variableCurves := range curves {
+		for _, points := range variableCurves {
+			sort.Slice(points, func(idx, jdx int) bool {
+				return points[idx].X < points[jdx].X
+			})
+		}
+	}
+	return curves
+}
+
+// FitMemberships подбирает методом Левенберга-Марквардта параметры функций принадлежности по ответам
+// пользователей. Тип функции берется из текущих функций принадлежности, а ее параметры используются как
+// начальное приближение. Если текущей функции нет или ее тип не подходит, то для подмножества "средний"
+// подбирается функция Гаусса, а для остальных - сигмоида
+// Входные параметры: answers - ответы пользователей, current - текущие функции принадлежности
+func FitMemberships(answers []SurveyAnswers, current map[string]map[string]MembershipFunction) ([]FitResult, error) {
+	curves := EmpiricalCurves(answers)
+	variables := make([]string, 0, len(curves))
+	for variable := range curves {
+		variables = append(variables, variable)
+	}
+	sort.Strings(variables)
+
+	var results []FitResult
+	for _, variable := range variables {
+		for _, term := range terms {
+			points := curves[variable][term]
+			result, err := fitCurve(variable, term, points, current[variable][term])
+			if err != nil {
+				return nil, fmt.Errorf("error from `fitCurve` function, package `fuzzy`, fuzzy subset %q of the fuzzy set %q: %#v",
+					term, variable, err)
+			}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// fitCurve подбирает параметры одной функции принадлежности
+// Входные параметры: variable - название нечеткого множества, term - название нечеткого подмножества,
+// points - точки эмпирической функции принадлежности, initial - текущая функция принадлежности или nil
+func fitCurve(variable, term string, points []CurvePoint, initial MembershipFunction) (FitResult, error) {
+	var functionType string
+	var params []float64
+	switch function := initial.(type) {
+	case Sigmoid:
+		functionType, params = SigmoidType, []float64{function.L, function.K, function.X0}
+	case Gaussian:
+		functionType, params = GaussianType, []float64{function.Amp, function.Cen, function.Wid}
+	default:
+		functionType, params = initialGuess(term, points)
+	}
+	if len(points) < len(params) {
+		return FitResult{}, fmt.Errorf("error, %d points are not enough to fit %d parameters", len(points), len(params))
+	}
+
+	model := func(params []float64, x float64) float64 {
+		if functionType == GaussianType {
+			return Gaussian{params[0], params[1], params[2]}.Value(x)
+		}
+		return Sigmoid{params[0], params[1], params[2]}.Value(x)
+	}
+
+	params, err := levenbergMarquardt(model, params, points)
+	if err != nil {
+		return FitResult{}, fmt.Errorf("error from `levenbergMarquardt` function, package `fuzzy`: %#v", err)
+	}
+
+	definition := MembershipDefinition{Variable: variable, Term: term, Type: functionType, Params: params}
+	if _, err = NewMembershipFunction(definition); err != nil {
+		return FitResult{}, fmt.Errorf("error from `NewMembershipFunction` function, package `fuzzy`: %#v", err)
+	}
+	return FitResult{Definition: definition, Points: points, RMSE: math.Sqrt(sumOfSquares(model, params, points) / float64(len(points)))}, nil
+}
+
+// initialGuess возвращает тип функции принадлежности и начальное приближение ее параметров по точкам
+// эмпирической функции принадлежности
+// Входные параметры: term - название нечеткого подмножества, points - точки, упорядоченные по возрастанию абсцисс
+func initialGuess(term string, points []CurvePoint) (string, []float64) {
+	if len(points) == 0 {
+		return SigmoidType, []float64{1, 1, 0}
+	}
+
+	first, last := points[0], points[len(points)-1]
+	if term == Medium {
+		peak := points[0]
+		for _, point := range points {
+			if point.Degree > peak.Degree {
+				peak = point
+			}
+		}
+		width := (last.X - first.X) / 4
+		if width == 0 {
+			width = 1
+		}
+		return GaussianType, []float64{peak.Degree * math.Sqrt(2*math.Pi) * width, peak.X, width}
+	}
+
+	steepness := 1.0
+	if first.Degree > last.Degree {
+		steepness = -1
+	}
+	return SigmoidType, []float64{1, steepness, (first.X + last.X) / 2}
+}
+
+// levenbergMarquardt подбирает параметры модели, минимизируя сумму квадратов невязок в точках
+// Входные параметры: model - модель, initial - начальное приближение параметров, points - точки
+func levenbergMarquardt(model func(params []float64, x float64) float64, initial []float64, points []CurvePoint) ([]float64, error) {
+	params := append([]float64(nil), initial...)
+	lambda := 1e-3
+	cost := sumOfSquares(model, params, points)
+	if math.IsNaN(cost) || math.IsInf(cost, 0) {
+		return nil, fmt.Errorf("error, the model can't be evaluated at the initial parameters %v", initial)
+	}
+	for iteration := 0; iteration < maxFitIterations; iteration++ {
+		jacobian, residuals := linearize(model, params, points)
+
+		// normal - матрица JᵀJ, gradient - вектор Jᵀr
+		normal := make([][]float64, len(params))
+		gradient := make([]float64, len(params))
+		for row := range params {
+			normal[row] = make([]float64, len(params))
+			for col := range params {
+				for idx := range points {
+					normal[row][col] += jacobian[idx][row] * jacobian[idx][col]
+				}
+			}
+			for idx := range points {
+				gradient[row] += jacobian[idx][row] * residuals[idx]
+			}
+		}
+
+		improved := false
+		for !improved && lambda < 1e12 {
+			damped := make([][]float64, len(params))
+			for row := range params {
+				damped[row] = append([]float64(nil), normal[row]...)
+				damped[row][row] += lambda * math.Max(normal[row][row], 1e-12)
+			}
+
+			step, err := solveLinearSystem(damped, gradient)
+			if err != nil {
+				lambda *= 10
+				continue
+			}
+
+			candidate := make([]float64, len(params))
+			for idx := range params {
+				candidate[idx] = params[idx] + step[idx]
+			}
+			candidateCost := sumOfSquares(model, candidate, points)
+			if !math.IsNaN(candidateCost) && candidateCost < cost {
+				converged := cost-candidateCost <= fitTolerance*cost
+				params, cost, improved = candidate, candidateCost, true
+				lambda = math.Max(lambda/10, 1e-12)
+				if converged {
+					return params, nil
+				}
+			} else {
+				lambda *= 10
+			}
+		}
+		if !improved {
+			// сумма квадратов невязок больше не уменьшается: найден минимум
+			return params, nil
+		}
+	}
+	return params, nil
+}
+
+// linearize вычисляет численно матрицу Якоби модели и невязки в точках
+// Входные параметры: model - модель, params - параметры модели, points - точки
+func linearize(model func(params []float64, x float64) float64, params []float64, points []CurvePoint) ([][]float64, []float64) {
+	jacobian := make([][]float64, len(points))
+	residuals := make([]float64, len(points))
+	shifted := append([]float64(nil), params...)
+	for idx, point := range points {
+		value := model(params, point.X)
+		residuals[idx] = point.Degree - value
+		jacobian[idx] = make([]float64, len(params))
+		for param := range params {
+			step := jacobianStep * math.Max(math.Abs(params[param]), 1)
+			shifted[param] = params[param] + step
+			jacobian[idx][param] = (model(shifted, point.X) - value) / step
+			shifted[param] = params[param]
+		}
+	}
+	return jacobian, residuals
+}
+
+// sumOfSquares вычисляет сумму квадратов невязок модели в точках
+// Входные параметры: model - модель, params - параметры модели, points - точки
+func sumOfSquares(model func(params []float64, x float64) float64, params []float64, points []CurvePoint) float64 {
+	var sum float64
+	for _, point := range points {
+		sum += math.Pow(point.Degree-model(params, point.X), 2)
+	}
+	return sum
+}
+
+// solveLinearSystem решает систему линейных уравнений методом Гаусса с выбором главного элемента
+// Входные параметры: matrix - матрица системы (изменяется), vector - вектор правой части
+func solveLinearSystem(matrix [][]float64, vector []float64) ([]float64, error) {
+	size := len(vector)
+	solution := append([]float64(nil), vector...)
+	for col := 0; col < size; col++ {
+		pivot := col
+		for row := col + 1; row < size; row++ {
+			if math.Abs(matrix[row][col]) > math.Abs(matrix[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(matrix[pivot][col]) < 1e-300 {
+			return nil, fmt.Errorf("error, the linear system is singular")
+		}
+		matrix[col], matrix[pivot] = matrix[pivot], matrix[col]
+		solution[col], solution[pivot] = solution[pivot], solution[col]
+
+		for row := col + 1; row < size; row++ {
+			factor := matrix[row][col] / matrix[col][col]
+			for idx := col; idx < size; idx++ {
+				matrix[row][idx] -= factor * matrix[col][idx]
+			}
+			solution[row] -= factor * solution[col]
+		}
+	}
+
+	for row := size - 1; row >= 0; row-- {
+		for idx := row + 1; idx < size; idx++ {
+			solution[row] -= matrix[row][idx] * solution[idx]
+		}
+		solution[row] /= matrix[row][row]
+	}
+	return solution, nil
+}
+
+// DefinitionOf возвращает описание функции принадлежности
+// Входные параметры: variable - название нечеткого множества, term - название нечеткого подмножества,
+// function - функция принадлежности
+func DefinitionOf(variable, term string, function MembershipFunction) (MembershipDefinition, error) {
+	definition := MembershipDefinition{Variable: variable, Term: term}
+	switch function := function.(type) {
+	case Sigmoid:
+		definition.Type, definition.Params = SigmoidType, []float64{function.L, function.K, function.X0}
+	case Gaussian:
+		definition.Type, definition.Params = GaussianType, []float64{function.Amp, function.Cen, function.Wid}
+	case Triangular:
+		definition.Type, definition.Params = TriangularType, []float64{function.A, function.B, function.C}
+	case Trapezoidal:
+		definition.Type, definition.Params = TrapezoidalType, []float64{function.A, function.B, function.C, function.D}
+	default:
+		return MembershipDefinition{}, fmt.Errorf("error, the membership function %T can't be described", function)
+	}
+	return definition, nil
+}
+
+// CandidateConfig составляет новую версию описаний функций принадлежности: подобранные функции заменяют
+// текущие, остальные текущие функции сохраняются без изменений
+// Входные параметры: version - новая версия, current - текущие функции принадлежности,
+// results - подобранные функции принадлежности
+func CandidateConfig(version string, current map[string]map[string]MembershipFunction, results []FitResult) (MembershipConfig, error) {
+	fitted := make(map[string]map[string]MembershipDefinition)
+	for _, result := range results {
+		if fitted[result.Definition.Variable] == nil {
+			fitted[result.Definition.Variable] = make(map[string]MembershipDefinition)
+		}
+		fitted[result.Definition.Variable][result.Definition.Term] = result.Definition
+	}
+
+	variables := make([]string, 0, len(current))
+	for variable := range current {
+		variables = append(variables, variable)
+	}
+	for variable := range fitted {
+		if _, ok := current[variable]; !ok {
+			variables = append(variables, variable)
+		}
+	}
+	sort.Strings(variables)
+
+	config := MembershipConfig{Version: version}
+	for _, variable := range variables {
+		for _, term := range orderedTerms(current[variable], fitted[variable]) {
+			if definition, ok := fitted[variable][term]; ok {
+				config.Definitions = append(config.Definitions, definition)
+				continue
+			}
+			function, ok := current[variable][term]
+			if !ok {
+				continue
+			}
+			definition, err := DefinitionOf(variable, term, function)
+			if err != nil {
+				return MembershipConfig{}, fmt.Errorf("error from `DefinitionOf` function, package `fuzzy`: %#v", err)
+			}
+			config.Definitions = append(config.Definitions, definition)
+		}
+	}
+	return config, nil
+}
+
+// orderedTerms возвращает названия нечетких подмножеств из текущих и подобранных функций принадлежности:
+// сначала "низкий", "средний", "высокий", затем остальные в алфавитном порядке
+// Входные параметры: current - текущие функции принадлежности, fitted - подобранные функции принадлежности
+func orderedTerms(current map[string]MembershipFunction, fitted map[string]MembershipDefinition) []string {
+	ordered := make([]string, 0, len(current)+len(fitted))
+	seen := make(map[string]bool, len(current)+len(fitted))
+	for _, term := range terms {
+		ordered = append(ordered, term)
+		seen[term] = true
+	}
+
+	var others []string
+	for term := range current {
+		if !seen[term] {
+			others = append(others, term)
+			seen[term] = true
+		}
+	}
+	for term := range fitted {
+		if !seen[term] {
+			others = append(others, term)
+			seen[term] = true
+		}
+	}
+	sort.Strings(others)
+	return append(ordered, others...)
+}
@@ -0,0 +1,491 @@
+package fuzzy
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+	"vehicles/packages/domain/models"
+)
+
+// DefaultFormulasVersion - версия встроенных формул коэффициентов
+const DefaultFormulasVersion = "default"
+
+// FormulaConfig - версия весов и таблиц, по которым вычисляются коэффициенты управляемости, комфорта и безопасности.
+// Ключи таблиц - значения характеристик автомобиля, например, тип подвески, или названия опций "есть/нет"
+// (см. FormulaOptions)
+type FormulaConfig struct {
+	// Version - версия формул. Формулы заменяются, только если версия изменилась
+	Version string `json:"version" yaml:"version"`
+	// Handling - формула коэффициента управляемости
+	Handling HandlingFormula `json:"handling" yaml:"handling"`
+	// Comfort - формула коэффициента комфорта
+	Comfort ComfortFormula `json:"comfort" yaml:"comfort"`
+	// Safety - формула коэффициента безопасности
+	Safety SafetyFormula `json:"safety" yaml:"safety"`
+}
+
+// HandlingFormula - веса и таблицы коэффициента управляемости
+type HandlingFormula struct {
+	// Drive - коэффициенты типов привода. Коэффициент неизвестного типа равен нулю
+	Drive map[string]float64 `json:"drive" yaml:"drive"`
+	// FrontSuspension, BackSuspension - коэффициенты типов передней и задней подвески
+	FrontSuspension map[string]float64 `json:"front_suspension" yaml:"front_suspension"`
+	BackSuspension  map[string]float64 `json:"back_suspension" yaml:"back_suspension"`
+	// UnknownSuspension - коэффициент типа подвески, которого нет в таблице
+	UnknownSuspension float64 `json:"unknown_suspension" yaml:"unknown_suspension"`
+	// Stabilizer - множитель коэффициента типа подвески при наличии стабилизатора
+	Stabilizer float64 `json:"stabilizer" yaml:"stabilizer"`
+	// FrontBrakes, BackBrakes - коэффициенты типов передних и задних тормозов. Коэффициент неизвестного типа
+	// равен нулю
+	FrontBrakes map[string]float64 `json:"front_brakes" yaml:"front_brakes"`
+	BackBrakes  map[string]float64 `json:"back_brakes" yaml:"back_brakes"`
+	// ControlSystems - доли, на которые электронные системы контроля движения увеличивают коэффициент
+	ControlSystems map[string]float64 `json:"control_systems" yaml:"control_systems"`
+	// SizeOffset - величина, модуль разности с которой дает коэффициент управляемости
+	SizeOffset float64 `json:"size_offset" yaml:"size_offset"`
+}
+
+// SuspensionWeight - баллы комфорта за тип подвески со стабилизатором и без него
+type SuspensionWeight struct {
+	WithStabilizer    float64 `json:"with_stabilizer" yaml:"with_stabilizer"`
+	WithoutStabilizer float64 `json:"without_stabilizer" yaml:"without_stabilizer"`
+}
+
+// ComfortFormula - баллы коэффициента комфорта. Коэффициент - сумма баллов, умноженная на Scale
+type ComfortFormula struct {
+	// FrontSuspension, BackSuspension - баллы за типы передней и задней подвески
+	FrontSuspension map[string]SuspensionWeight `json:"front_suspension" yaml:"front_suspension"`
+	BackSuspension  map[string]SuspensionWeight `json:"back_suspension" yaml:"back_suspension"`
+	// PowerSteering - баллы за типы рулевого усилителя
+	PowerSteering map[string]float64 `json:"power_steering" yaml:"power_steering"`
+	// Gearbox - баллы за типы коробки передач
+	Gearbox map[string]float64 `json:"gearbox" yaml:"gearbox"`
+	// AirConditioner - баллы за кондиционер без климат-контроля, ClimateControl - за кондиционер с климат-контролем
+	AirConditioner float64 `json:"air_conditioner" yaml:"air_conditioner"`
+	ClimateControl float64 `json:"climate_control" yaml:"climate_control"`
+	// Upholstery - баллы за материалы отделки салона
+	Upholstery map[string]float64 `json:"upholstery" yaml:"upholstery"`
+	// Headlights - баллы за фары любого типа, кроме BasicHeadlights
+	Headlights      float64 `json:"headlights" yaml:"headlights"`
+	BasicHeadlights string  `json:"basic_headlights" yaml:"basic_headlights"`
+	// Options - баллы за опции освещения, электропакета, мультимедиа и сигнализацию
+	Options map[string]float64 `json:"options" yaml:"options"`
+	// Trunk - баллы за багажник объемом больше LargeTrunk литров
+	Trunk      float64 `json:"trunk" yaml:"trunk"`
+	LargeTrunk float64 `json:"large_trunk" yaml:"large_trunk"`
+	// Scale - множитель суммы баллов
+	Scale float64 `json:"scale" yaml:"scale"`
+}
+
+// SafetyFormula - баллы коэффициента безопасности. Коэффициент - сумма баллов
+type SafetyFormula struct {
+	// CrashTest - множитель оценки краш-теста
+	CrashTest float64 `json:"crash_test" yaml:"crash_test"`
+	// ControlSystems - баллы за электронные системы безопасности и контроля движения
+	ControlSystems map[string]float64 `json:"control_systems" yaml:"control_systems"`
+	// Airbags - баллы за подушки безопасности
+	Airbags map[string]float64 `json:"airbags" yaml:"airbags"`
+	// FrontBrakes, BackBrakes - баллы за типы передних и задних тормозов
+	FrontBrakes map[string]float64 `json:"front_brakes" yaml:"front_brakes"`
+	BackBrakes  map[string]float64 `json:"back_brakes" yaml:"back_brakes"`
+}
+
+// FormulaLoader загружает актуальную версию формул коэффициентов, например, из файла
+type FormulaLoader interface {
+	// LoadFormulas загружает актуальную версию формул коэффициентов
+	// Входной параметр: ctx - контекст
+	LoadFormulas(ctx context.Context) (FormulaConfig, error)
+}
+
+// FormulaCalculator - вычислитель коэффициента, веса которого задаются версией формул коэффициентов.
+// Нечеткий алгоритм передает ему версию, закрепленную на время ранжирования, а Calculate использует
+// встроенные формулы
+type FormulaCalculator interface {
+	CoefficientCalculator
+	// CalculateWithFormulas вычисляет коэффициент по заданной версии формул
+	// Входные параметры: car - автомобиль, formulas - версия формул коэффициентов
+	CalculateWithFormulas(car models.Car, formulas *FormulaConfig) float64
+}
+
+// formulaCalculator - вычислитель коэффициента по версии формул с перечнем характеристик,
+// от которых зависит коэффициент
+type formulaCalculator struct {
+	fieldCalculator
+	formula func(car models.Car, formulas *FormulaConfig) float64
+}
+
+// newFormulaCalculator создает вычислитель коэффициента по версии формул
+// Входные параметры: formula - формула коэффициента, fields - характеристики автомобиля, от которых зависит коэффициент
+func newFormulaCalculator(formula func(car models.Car, formulas *FormulaConfig) float64,
+	fields []DataField) formulaCalculator {
+	return formulaCalculator{fieldCalculator{CoefficientFunc(func(car models.Car) float64 {
+		return formula(car, &defaultFormulas)
+	}), fields}, formula}
+}
+
+// CalculateWithFormulas вычисляет коэффициент по заданной версии формул
+func (fcl formulaCalculator) CalculateWithFormulas(car models.Car, formulas *FormulaConfig) float64 {
+	return fcl.formula(car, formulas)
+}
+
+// defaultFormulas - встроенные формулы, которые используют вычислители коэффициентов вне нечеткого алгоритма
+var defaultFormulas = DefaultFormulas()
+
+// DefaultFormulas возвращает встроенные формулы коэффициентов управляемости, комфорта и безопасности
+func DefaultFormulas() FormulaConfig {
+	// comfortPoint - балл комфорта за одну опцию
+	const comfortPoint = 0.2962962962962963
+
+	options := map[string]float64{OptionLEDRunningLights: 0.1, OptionLEDTailLights: 0.1,
+		OptionCarAlarm: 0.5925925925925926}
+	for _, name := range []string{OptionFrontFogLights, OptionBackFogLights, OptionLightSensor, OptionOnBoardComputer,
+		OptionMP3Support, OptionHandsFreeSupport} {
+		options[name] = comfortPoint
+	}
+	for _, option := range electricOptions(models.SetOfElectricOptions{}) {
+		options[option.name] = comfortPoint
+	}
+
+	return FormulaConfig{
+		Version: DefaultFormulasVersion,
+		Handling: HandlingFormula{
+			Drive: map[string]float64{"Передний(FF)": 0.9, "Передний": 0.9, "Полный (4WD)": 1, "Полный": 1,
+				"Задний(FR)": 0.7, "Задний": 0.7},
+			FrontSuspension: map[string]float64{suspensionType1: 1.9, suspensionType2: 1.8, suspensionType3: 1.7,
+				suspensionType4: 1.6, suspensionType5: 1.5, suspensionType6: 1.4, suspensionType7: 1.3},
+			BackSuspension: map[string]float64{suspensionType2: 1.9, suspensionType1: 1.8, suspensionType3: 1.7,
+				suspensionType4: 1.6, suspensionType5: 1.4, suspensionType6: 1.3, suspensionType7: 1.2},
+			UnknownSuspension: 1,
+			Stabilizer:        1.2,
+			FrontBrakes:       map[string]float64{brakesType1: 0.7, brakesType2: 0.7, brakesType3: 0.5},
+			BackBrakes:        map[string]float64{brakesType1: 0.6, brakesType2: 0.6, brakesType3: 0.4},
+			ControlSystems: map[string]float64{OptionABS: 0.064, OptionESP: 0.07, OptionEBD: 0.056, OptionBAS: 0.059,
+				OptionTCS: 0.051},
+			SizeOffset: 30,
+		},
+		Comfort: ComfortFormula{
+			FrontSuspension: map[string]SuspensionWeight{suspensionType1: {3.6, 2.8}, suspensionType2: {3.8, 3},
+				suspensionType3: {4, 3.2}, suspensionType4: {3.4, 2.6}, suspensionType5: {2.8, 2},
+				suspensionType6: {2.4, 1.6}, suspensionType7: {1.8, 1}},
+			BackSuspension: map[string]SuspensionWeight{suspensionType1: {3.8, 3}, suspensionType2: {3.6, 2.8},
+				suspensionType3: {4, 3.2}, suspensionType4: {3.4, 2.6}, suspensionType5: {2.8, 2},
+				suspensionType6: {2.4, 1.6}, suspensionType7: {1.8, 1}},
+			PowerSteering: map[string]float64{string(models.ElectricPS): 2, string(models.HydraulicPS): 2,
+				string(models.ElectrohydraulicPS): 2},
+			Gearbox:         map[string]float64{"АКПП 6": 4, "АКПП 5": 4, "Вариатор": 4},
+			AirConditioner:  2,
+			ClimateControl:  3,
+			Upholstery:      map[string]float64{"Кожаная": comfortPoint},
+			Headlights:      0.8888888888888888,
+			BasicHeadlights: "Галогенные",
+			Options:         options,
+			Trunk:           0.8888888888888888,
+			LargeTrunk:      500,
+			Scale:           0.8,
+		},
+		Safety: SafetyFormula{
+			CrashTest: 1,
+			ControlSystems: map[string]float64{OptionABS: 3, OptionESP: 1, OptionEBD: 1, OptionBAS: 1,
+				OptionTCS: 1},
+			Airbags: map[string]float64{OptionDriverAirbag: 1, OptionFrontPassengerAirbag: 1, OptionSideAirbags: 1,
+				OptionCurtainAirbags: 1},
+			FrontBrakes: map[string]float64{brakesType1: 2, brakesType2: 2},
+			BackBrakes:  map[string]float64{brakesType1: 2, brakesType2: 2},
+		},
+	}
+}
+
+// ValidateFormulas проверяет версию формул коэффициентов: версия задана, все таблицы заполнены, веса конечны
+// и неотрицательны, множители Stabilizer и Scale положительны, а опции известны (см. FormulaOptions)
+// Входной параметр: config - версия формул коэффициентов
+func ValidateFormulas(config FormulaConfig) error {
+	if config.Version == "" {
+		return fmt.Errorf("error, the coefficient formulas have no version")
+	}
+
+	handling, comfort, safety := config.Handling, config.Comfort, config.Safety
+	tables := []struct {
+		name    string
+		weights map[string]float64
+		options []string
+	}{
+		{"handling.drive", handling.Drive, nil},
+		{"handling.front_suspension", handling.FrontSuspension, nil},
+		{"handling.back_suspension", handling.BackSuspension, nil},
+		{"handling.front_brakes", handling.FrontBrakes, nil},
+		{"handling.back_brakes", handling.BackBrakes, nil},
+		{"handling.control_systems", handling.ControlSystems, optionNames(controlSystemOptions)},
+		{"comfort.front_suspension.with_stabilizer", stabilizerWeights(comfort.FrontSuspension, true), nil},
+		{"comfort.front_suspension.without_stabilizer", stabilizerWeights(comfort.FrontSuspension, false), nil},
+		{"comfort.back_suspension.with_stabilizer", stabilizerWeights(comfort.BackSuspension, true), nil},
+		{"comfort.back_suspension.without_stabilizer", stabilizerWeights(comfort.BackSuspension, false), nil},
+		{"comfort.power_steering", comfort.PowerSteering, nil},
+		{"comfort.gearbox", comfort.Gearbox, nil},
+		{"comfort.upholstery", comfort.Upholstery, nil},
+		{"comfort.options", comfort.Options, FormulaOptions()[ComfortOptions]},
+		{"safety.control_systems", safety.ControlSystems, optionNames(controlSystemOptions)},
+		{"safety.airbags", safety.Airbags, optionNames(airbagOptions)},
+		{"safety.front_brakes", safety.FrontBrakes, nil},
+		{"safety.back_brakes", safety.BackBrakes, nil},
+	}
+	for _, table := range tables {
+		if len(table.weights) == 0 {
+			return fmt.Errorf("error, the table %s of version %q is empty", table.name, config.Version)
+		}
+		for key, weight := range table.weights {
+			if key == "" {
+				return fmt.Errorf("error, the table %s of version %q has an empty key", table.name, config.Version)
+			}
+			if table.options != nil && !containsString(table.options, key) {
+				return fmt.Errorf("error, unknown option %q in the table %s of version %q", key, table.name,
+					config.Version)
+			}
+			if err := checkWeight(table.name+"."+key, weight); err != nil {
+				return fmt.Errorf("error from `checkWeight` function, package `fuzzy`, version %q: %#v", config.Version, err)
+			}
+		}
+	}
+
+	for name, weight := range map[string]float64{
+		"handling.unknown_suspension": handling.UnknownSuspension, "handling.size_offset": handling.SizeOffset,
+		"comfort.air_conditioner": comfort.AirConditioner, "comfort.climate_control": comfort.ClimateControl,
+		"comfort.headlights": comfort.Headlights, "comfort.trunk": comfort.Trunk,
+		"comfort.large_trunk": comfort.LargeTrunk, "safety.crash_test": safety.CrashTest,
+	} {
+		if err := checkWeight(name, weight); err != nil {
+			return fmt.Errorf("error from `checkWeight` function, package `fuzzy`, version %q: %#v", config.Version, err)
+		}
+	}
+	if !(handling.Stabilizer > 0) || math.IsInf(handling.Stabilizer, 0) {
+		return fmt.Errorf("error, handling.stabilizer of version %q must be positive, got %v", config.Version,
+			handling.Stabilizer)
+	}
+	if !(comfort.Scale > 0) || math.IsInf(comfort.Scale, 0) {
+		return fmt.Errorf("error, comfort.scale of version %q must be positive, got %v", config.Version, comfort.Scale)
+	}
+	return nil
+}
+
+// checkWeight проверяет, что вес конечен и неотрицателен
+// Входные параметры: name - название веса, weight - вес
+func checkWeight(name string, weight float64) error {
+	if math.IsNaN(weight) || math.IsInf(weight, 0) || weight < 0 {
+		return fmt.Errorf("error, %s must be a finite non-negative number, got %v", name, weight)
+	}
+	return nil
+}
+
+// stabilizerWeights возвращает баллы комфорта за типы подвески со стабилизатором или без него
+// Входные параметры: weights - баллы за типы подвески, withStabilizer - баллы со стабилизатором
+func stabilizerWeights(weights map[string]SuspensionWeight, withStabilizer bool) map[string]float64 {
+	selected := make(map[string]float64, len(weights))
+	for suspension, weight := range weights {
+		selected[suspension] = weight.WithoutStabilizer
+		if withStabilizer {
+			selected[suspension] = weight.WithStabilizer
+		}
+	}
+	return selected
+}
+
+// FormulaTable хранит текущую версию формул коэффициентов. Версию можно заменить во время работы нечеткого
+// алгоритма: каждое ранжирование использует одну версию от начала до конца
+type FormulaTable struct {
+	current atomic.Pointer[FormulaConfig]
+}
+
+// NewFormulaTable создает таблицу формул коэффициентов. Формулы из внешних источников должны быть проверены
+// функцией ValidateFormulas
+// Входной параметр: config - версия формул коэффициентов
+func NewFormulaTable(config FormulaConfig) *FormulaTable {
+	table := &FormulaTable{}
+	table.current.Store(&config)
+	return table
+}
+
+// Version возвращает текущую версию формул коэффициентов
+func (tbl *FormulaTable) Version() string {
+	return tbl.current.Load().Version
+}
+
+// Formulas возвращает текущую версию формул коэффициентов. Возвращаемая версия не должна изменяться
+func (tbl *FormulaTable) Formulas() *FormulaConfig {
+	return tbl.current.Load()
+}
+
+// Update заменяет формулы коэффициентов новой версией после ее проверки (см. ValidateFormulas)
+// Входной параметр: config - версия формул коэффициентов
+func (tbl *FormulaTable) Update(config FormulaConfig) error {
+	if err := ValidateFormulas(config); err != nil {
+		return fmt.Errorf("error from `ValidateFormulas` function, package `fuzzy`: %#v", err)
+	}
+	tbl.current.Store(&config)
+	return nil
+}
+
+// WatchFormulas периодически загружает формулы коэффициентов и заменяет ими формулы в таблице, если версия
+// изменилась. Ошибки загрузки записываются в журнал, при этом продолжает использоваться прежняя версия.
+// Функция завершается при отмене контекста
+// Входные параметры: ctx - контекст, table - таблица формул коэффициентов, loader - источник формул,
+// interval - период проверки
+func WatchFormulas(ctx context.Context, table *FormulaTable, loader FormulaLoader, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ReloadFormulas(ctx, table, loader); err != nil {
+				log.Printf("coefficient formulas are not reloaded: %v", err)
+			}
+		}
+	}
+}
+
+// ReloadFormulas загружает формулы коэффициентов и заменяет ими формулы в таблице, если версия изменилась
+// Входные параметры: ctx - контекст, table - таблица формул коэффициентов, loader - источник формул
+func ReloadFormulas(ctx context.Context, table *FormulaTable, loader FormulaLoader) error {
+	config, err := loader.LoadFormulas(ctx)
+	if err != nil {
+		return fmt.Errorf("error from `LoadFormulas` method, package `fuzzy`: %#v", err)
+	}
+	if config.Version == table.Version() {
+		return nil
+	}
+
+	if err = table.Update(config); err != nil {
+		return fmt.Errorf("error from `Update` method, package `fuzzy`: %#v", err)
+	}
+	log.Printf("coefficient formulas are reloaded, version %q", config.Version)
+	return nil
+}
+
+// названия опций "есть/нет" в таблицах формул коэффициентов
+const (
+	OptionABS                  = "abs"
+	OptionESP                  = "esp"
+	OptionEBD                  = "ebd"
+	OptionBAS                  = "bas"
+	OptionTCS                  = "tcs"
+	OptionDriverAirbag         = "driver_airbag"
+	OptionFrontPassengerAirbag = "front_passenger_airbag"
+	OptionSideAirbags          = "side_airbags"
+	OptionCurtainAirbags       = "curtain_airbags"
+	OptionLEDRunningLights     = "led_running_lights"
+	OptionLEDTailLights        = "led_tail_lights"
+	OptionFrontFogLights       = "front_fog_lights"
+	OptionBackFogLights        = "back_fog_lights"
+	OptionLightSensor          = "light_sensor"
+	OptionOnBoardComputer      = "on_board_computer"
+	OptionMP3Support           = "mp3_support"
+	OptionHandsFreeSupport     = "hands_free_support"
+	OptionCarAlarm             = "car_alarm"
+)
+
+// группы опций "есть/нет" (см. FormulaOptions)
+const (
+	ControlSystemOptions = "control_systems"
+	AirbagOptions        = "airbags"
+	ComfortOptions       = "options"
+)
+
+// FormulaOptions возвращает названия опций "есть/нет", которые можно указать в таблицах формул коэффициентов:
+// control_systems - электронные системы, airbags - подушки безопасности, options - опции коэффициента комфорта
+// (ключ - группа опций)
+func FormulaOptions() map[string][]string {
+	comfort := optionNames(lightOptions)
+	comfort = append(comfort, optionNames(electricOptions)...)
+	comfort = append(comfort, optionNames(multimediaOptions)...)
+	comfort = append(comfort, OptionCarAlarm)
+	sort.Strings(comfort)
+	return map[string][]string{
+		ControlSystemOptions: optionNames(controlSystemOptions),
+		AirbagOptions:        optionNames(airbagOptions),
+		ComfortOptions:       comfort,
+	}
+}
+
+// availabilityOption - опция "есть/нет" автомобиля, за наличие которой формула начисляет баллы
+type availabilityOption struct {
+	name  string
+	value models.Availability
+}
+
+// optionNames возвращает названия опций группы
+// Входной параметр: options - функция, перечисляющая опции группы
+func optionNames[T any](options func(T) []availabilityOption) []string {
+	var zero T
+	listed := options(zero)
+	names := make([]string, 0, len(listed))
+	for _, option := range listed {
+		names = append(names, option.name)
+	}
+	return names
+}
+
+// sumOptions прибавляет к сумме веса имеющихся опций в порядке их перечисления, чтобы сумма не зависела
+// от порядка обхода карты
+// Входные параметры: sum - сумма, options - опции автомобиля, weights - веса опций
+func sumOptions(sum float64, options []availabilityOption, weights map[string]float64) float64 {
+	for _, option := range options {
+		if option.value == models.YesValue {
+			sum += weights[option.name]
+		}
+	}
+	return sum
+}
+
+// controlSystemOptions перечисляет электронные системы безопасности и контроля движения
+// Входной параметр: smc - информация о наличии систем
+func controlSystemOptions(smc models.SafetyAndMotionControlSystems) []availabilityOption {
+	return []availabilityOption{{OptionABS, smc.ABS}, {OptionESP, smc.ESP}, {OptionEBD, smc.EBD}, {OptionBAS, smc.BAS},
+		{OptionTCS, smc.TCS}}
+}
+
+// airbagOptions перечисляет подушки безопасности
+// Входной параметр: sab - информация о наличии подушек безопасности
+func airbagOptions(sab models.SetOfAirbags) []availabilityOption {
+	return []availabilityOption{{OptionDriverAirbag, sab.DriverAirbag},
+		{OptionFrontPassengerAirbag, sab.FrontPassengerAirbag}, {OptionSideAirbags, sab.SideAirbags},
+		{OptionCurtainAirbags, sab.CurtainAirbags}}
+}
+
+// lightOptions перечисляет опции освещения
+// Входной параметр: lts - информация о фонарях
+func lightOptions(lts models.Lights) []availabilityOption {
+	return []availabilityOption{{OptionLEDRunningLights, lts.LEDRunningLights},
+		{OptionLEDTailLights, lts.LEDTailLights}, {OptionFrontFogLights, lts.FrontFogLights},
+		{OptionBackFogLights, lts.BackFogLights}, {OptionLightSensor, lts.LightSensor}}
+}
+
+// electricOptions перечисляет опции электропакета салона
+// Входной параметр: seo - информация об электропакете салона
+func electricOptions(seo models.SetOfElectricOptions) []availabilityOption {
+	return []availabilityOption{
+		{"electric_front_side_windows_lifts", seo.ElectricFrontSideWindowsLifts},
+		{"electric_back_side_windows_lifts", seo.ElectricBackSideWindowsLifts},
+		{"electric_heating_of_front_seats", seo.ElectricHeatingOfFrontSeats},
+		{"electric_heating_of_back_seats", seo.ElectricHeatingOfBackSeats},
+		{"electric_heating_of_steering_wheel", seo.ElectricHeatingOfSteeringWheel},
+		{"electric_heating_of_windshield", seo.ElectricHeatingOfWindshield},
+		{"electric_heating_of_rear_window", seo.ElectricHeatingOfRearWindow},
+		{"electric_heating_of_side_mirrors", seo.ElectricHeatingOfSideMirrors},
+		{"electric_drive_of_driver_seat", seo.ElectricDriveOfDriverSeat},
+		{"electric_drive_of_front_seats", seo.ElectricDriveOfFrontSeats},
+		{"electric_drive_of_side_mirrors", seo.ElectricDriveOfSideMirrors},
+		{"electric_trunk_opener", seo.ElectricTrunkOpener},
+		{"rain_sensor", seo.RainSensor},
+	}
+}
+
+// multimediaOptions перечисляет мультимедийные системы
+// Входной параметр: mts - информация о мультимедийных системах
+func multimediaOptions(mts models.MultimediaSystems) []availabilityOption {
+	return []availabilityOption{{OptionOnBoardComputer, mts.OnBoardComputer}, {OptionMP3Support, mts.MP3Support},
+		{OptionHandsFreeSupport, mts.HandsFreeSupport}}
+}
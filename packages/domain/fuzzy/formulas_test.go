@@ -0,0 +1,94 @@
+package fuzzy_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"vehicles/packages/domain/fuzzy"
+)
+
+// TestValidateFormulas проверяет, что встроенные формулы проходят проверку, а формулы с ошибками - нет
+func TestValidateFormulas(t *testing.T) {
+	if err := fuzzy.ValidateFormulas(fuzzy.DefaultFormulas()); err != nil {
+		t.Fatalf("error from `ValidateFormulas` function for the default formulas: %#v", err)
+	}
+
+	for name, spoil := range map[string]func(config *fuzzy.FormulaConfig){
+		"no version":      func(config *fuzzy.FormulaConfig) { config.Version = "" },
+		"empty table":     func(config *fuzzy.FormulaConfig) { config.Handling.Drive = nil },
+		"unknown option":  func(config *fuzzy.FormulaConfig) { config.Safety.Airbags["knee_airbag"] = 1 },
+		"negative weight": func(config *fuzzy.FormulaConfig) { config.Comfort.Gearbox["АКПП 6"] = -4 },
+		"infinite weight": func(config *fuzzy.FormulaConfig) { config.Safety.CrashTest = math.Inf(1) },
+		"zero scale":      func(config *fuzzy.FormulaConfig) { config.Comfort.Scale = 0 },
+		"negative suspension": func(config *fuzzy.FormulaConfig) {
+			config.Comfort.BackSuspension["Пневматическая"] = fuzzy.SuspensionWeight{WithStabilizer: 4, WithoutStabilizer: -1}
+		},
+	} {
+		config := fuzzy.DefaultFormulas()
+		spoil(&config)
+		if err := fuzzy.ValidateFormulas(config); err == nil {
+			t.Errorf("error, the formulas with %s pass validation", name)
+		}
+	}
+}
+
+// TestFormulasVersion проверяет, что рейтинг вычисляется по новой версии формул и в объяснениях записаны
+// версии, которыми оценены автомобили
+func TestFormulasVersion(t *testing.T) {
+	cars, err := fuzzy.ReadGoldenCars(goldenCarsFile)
+	if err != nil {
+		t.Fatalf("error from `ReadGoldenCars` function: %#v", err)
+	}
+	rules, err := fuzzy.LoadEmbeddedRuleIndex(fuzzy.DefaultVariables(), nil)
+	if err != nil {
+		t.Fatalf("error from `LoadEmbeddedRuleIndex` function: %#v", err)
+	}
+	engine := fuzzy.NewEngine(rules, nil)
+	priorities := []string{fuzzy.Handling}
+
+	base, err := engine.Rank(context.Background(), cars, priorities)
+	if err != nil {
+		t.Fatalf("error from `Rank` method: %#v", err)
+	}
+	config := fuzzy.DefaultFormulas()
+	config.Version = "2"
+	config.Handling.SizeOffset = 20
+	if err = engine.Formulas.Update(config); err != nil {
+		t.Fatalf("error from `Update` method: %#v", err)
+	}
+	candidate, err := engine.Rank(context.Background(), cars, priorities)
+	if err != nil {
+		t.Fatalf("error from `Rank` method: %#v", err)
+	}
+
+	handling := func(explanation fuzzy.Explanation) float64 {
+		for _, variable := range explanation.Variables {
+			if variable.Variable == fuzzy.Handling {
+				return variable.Coefficient
+			}
+		}
+		return math.NaN()
+	}
+	baseHandling := make(map[int]float64, len(base))
+	for _, result := range base {
+		baseHandling[result.CarID] = handling(result.Explanation)
+		if result.Explanation.FormulasVersion != fuzzy.DefaultFormulasVersion {
+			t.Errorf("error, car %d is scored by formulas %q, expected %q", result.CarID,
+				result.Explanation.FormulasVersion, fuzzy.DefaultFormulasVersion)
+		}
+	}
+
+	var changed int
+	for _, result := range candidate {
+		if result.Explanation.FormulasVersion != "2" || result.Explanation.MembershipsVersion != fuzzy.DefaultMembershipsVersion {
+			t.Errorf("error, car %d is scored by formulas %q and memberships %q, expected %q and %q", result.CarID,
+				result.Explanation.FormulasVersion, result.Explanation.MembershipsVersion, "2", fuzzy.DefaultMembershipsVersion)
+		}
+		if handling(result.Explanation) != baseHandling[result.CarID] {
+			changed++
+		}
+	}
+	if changed == 0 {
+		t.Errorf("error, the handling coefficients do not depend on the formulas version")
+	}
+}
@@ -0,0 +1,120 @@
+package fuzzy
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// названия шаблонов правил
+const (
+	// LexicographicTemplateName - каждый приоритет важнее всех следующих за ним вместе взятых
+	LexicographicTemplateName = "lexicographic"
+	// LinearTemplateName - веса приоритетов убывают линейно
+	LinearTemplateName = "linear"
+)
+
+// RuleTemplate - монотонный шаблон нечетких правил. Шаблон задает веса приоритетов, а правила строятся функцией
+// WeightedRules, поэтому рекомендация не убывает при переходе любого условия к лучшему нечеткому подмножеству
+type RuleTemplate interface {
+	// Name возвращает название шаблона
+	Name() string
+	// Weights возвращает веса приоритетов (ключ - название нечеткого множества)
+	// Входные параметры: variables - нечеткие множества со шкалами, priorities - приоритеты, расставленные пользователем
+	Weights(variables *VariableSet, priorities []string) map[string]float64
+}
+
+// LexicographicTemplate - шаблон, в котором каждый приоритет важнее всех следующих за ним вместе взятых
+// (см. PriorityWeights). Для шкал из 3 нечетких подмножеств строит те же правила, что и файлы rules/*_rules.txt
+type LexicographicTemplate struct{}
+
+// Name возвращает название шаблона
+func (LexicographicTemplate) Name() string {
+	return LexicographicTemplateName
+}
+
+// Weights возвращает веса приоритетов, равносильные расстановке приоритетов
+func (LexicographicTemplate) Weights(variables *VariableSet, priorities []string) map[string]float64 {
+	return variables.PriorityWeights(priorities)
+}
+
+// LinearTemplate - шаблон, в котором вес приоритета на месте i из k равен k-i: первый приоритет важнее
+// остальных, но хорошие значения нескольких следующих приоритетов могут перевесить его
+type LinearTemplate struct{}
+
+// Name возвращает название шаблона
+func (LinearTemplate) Name() string {
+	return LinearTemplateName
+}
+
+// Weights возвращает линейно убывающие веса приоритетов
+func (LinearTemplate) Weights(_ *VariableSet, priorities []string) map[string]float64 {
+	weights := make(map[string]float64, len(priorities))
+	for idx, priority := range priorities {
+		weights[priority] = float64(len(priorities) - idx)
+	}
+	return weights
+}
+
+// NewRuleTemplate создает шаблон нечетких правил по названию
+// Входной параметр: name - название шаблона: lexicographic, linear
+func NewRuleTemplate(name string) (RuleTemplate, error) {
+	switch name {
+	case LexicographicTemplateName:
+		return LexicographicTemplate{}, nil
+	case LinearTemplateName:
+		return LinearTemplate{}, nil
+	}
+	return nil, fmt.Errorf("error, unknown rule template %q", name)
+}
+
+// GenerateRuleSet строит набор нечетких правил для расстановки приоритетов по шаблону и проверяет его так же,
+// как наборы из файлов (см. ValidateRuleSet): каждое сочетание нечетких подмножеств покрыто ровно одним правилом
+// Входные параметры: priorities - приоритеты, расставленные пользователем, template - шаблон правил
+func (vs *VariableSet) GenerateRuleSet(priorities []string, template RuleTemplate) (RuleSet, error) {
+	if err := vs.ValidatePriorities(priorities); err != nil {
+		return RuleSet{}, fmt.Errorf("error from `ValidatePriorities` method, package `fuzzy`: %#v", err)
+	}
+
+	rules, err := vs.WeightedRules(template.Weights(vs, priorities))
+	if err != nil {
+		return RuleSet{}, fmt.Errorf("error from `WeightedRules` method, package `fuzzy`: %#v", err)
+	}
+	if errs := vs.ValidateRuleSet(priorities, rules); len(errs) > 0 {
+		return RuleSet{}, fmt.Errorf("error from `ValidateRuleSet` method, package `fuzzy`: %v (%d errors in total)",
+			errs[0], len(errs))
+	}
+	return RuleSet{Priorities: priorities, Rules: rules, Position: template.Name()}, nil
+}
+
+type ruleGenerator struct {
+	template RuleTemplate
+	// variables - нечеткие множества, по шкалам которых строятся правила
+	variables *VariableSet
+	// rules содержит в качестве ключей - расстановку приоритетов, а в качестве значений - построенные правила
+	rules sync.Map
+}
+
+// NewRuleGenerator создает источник нечетких правил, который строит правила по шаблону вместо чтения файлов
+// с правилами. Построенные правила хранятся в памяти
+// Входные параметры: template - шаблон правил, variables - нечеткие множества, по шкалам которых строятся правила
+func NewRuleGenerator(template RuleTemplate, variables *VariableSet) RuleSource {
+	return &ruleGenerator{template: template, variables: variables}
+}
+
+// Rules возвращает нечеткие правила, построенные по шаблону для расстановки приоритетов.
+// Возвращаемый срез общий для всех вызовов и не должен изменяться
+// Входной параметр: priorities - приоритеты, расставленные пользователем
+func (gen *ruleGenerator) Rules(priorities []string) ([]Rule, error) {
+	prioritiesStr := strings.Join(priorities, " ")
+	if rules, ok := gen.rules.Load(prioritiesStr); ok {
+		return rules.([]Rule), nil
+	}
+
+	ruleSet, err := gen.variables.GenerateRuleSet(priorities, gen.template)
+	if err != nil {
+		return nil, fmt.Errorf("error from `GenerateRuleSet` method, package `fuzzy`: %#v", err)
+	}
+	rules, _ := gen.rules.LoadOrStore(prioritiesStr, ruleSet.Rules)
+	return rules.([]Rule), nil
+}
@@ -0,0 +1,55 @@
+package fuzzy_test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"vehicles/packages/domain/fuzzy"
+)
+
+// TestLexicographicTemplate проверяет, что для каждой расстановки приоритетов из встроенных файлов с правилами
+// лексикографический шаблон строит правила с теми же рекомендациями для каждого сочетания нечетких подмножеств
+func TestLexicographicTemplate(t *testing.T) {
+	ruleSets, err := fuzzy.ReadEmbeddedRuleFiles()
+	if err != nil {
+		t.Fatalf("error from `ReadEmbeddedRuleFiles` function: %#v", err)
+	}
+	if len(ruleSets) == 0 {
+		t.Fatalf("error, there are no embedded rule files")
+	}
+
+	variables := fuzzy.DefaultVariables()
+	for _, ruleSet := range ruleSets {
+		generated, err := variables.GenerateRuleSet(ruleSet.Priorities, fuzzy.LexicographicTemplate{})
+		if err != nil {
+			t.Errorf("%s: error from `GenerateRuleSet` method: %#v", ruleSet.Position, err)
+			continue
+		}
+		if len(generated.Rules) != len(ruleSet.Rules) {
+			t.Errorf("%s: %d rules are generated, expected %d", ruleSet.Position, len(generated.Rules),
+				len(ruleSet.Rules))
+			continue
+		}
+
+		recommendations := make(map[string]int, len(generated.Rules))
+		for _, rule := range generated.Rules {
+			recommendations[conditionsKey(rule)] = rule.Recommendation
+		}
+		for _, rule := range ruleSet.Rules {
+			if recommendation, ok := recommendations[conditionsKey(rule)]; !ok || recommendation != rule.Recommendation {
+				t.Errorf("%s: the rule %q is generated with the recommendation %d", ruleSet.Position,
+					fuzzy.FormatRuleStatement(rule), recommendation)
+			}
+		}
+	}
+}
+
+// conditionsKey возвращает условия правила в виде строки, не зависящей от порядка условий
+func conditionsKey(rule fuzzy.Rule) string {
+	conditions := make([]string, 0, len(rule.Conditions))
+	for _, condition := range rule.Conditions {
+		conditions = append(conditions, condition.Variable+"="+condition.Term)
+	}
+	sort.Strings(conditions)
+	return strings.Join(conditions, " ")
+}
@@ -0,0 +1,405 @@
+package fuzzy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"vehicles/packages/domain/models"
+)
+
+// DefaultGoldenTolerance - допустимое отклонение коэффициентов, значений функций принадлежности и выходных
+// значений нечеткого алгоритма от эталона. Оно покрывает различия округления между платформами
+const DefaultGoldenTolerance = 1e-6
+
+// Snapshot - эталон результатов одной версии нечеткого алгоритма на фиксированном наборе автомобилей:
+// коэффициенты и значения функций принадлежности каждого автомобиля и рейтинги для набора расстановок приоритетов
+type Snapshot struct {
+	// Version - описание версии нечеткого алгоритма: источник правил, метод дефаззификации и т.д.
+	Version string
+	// Cars - коэффициенты и значения функций принадлежности автомобилей
+	Cars []CarSnapshot
+	// Rankings - рейтинги автомобилей для каждой расстановки приоритетов
+	Rankings []RankingSnapshot
+}
+
+// CarSnapshot - коэффициенты и значения функций принадлежности одного автомобиля
+type CarSnapshot struct {
+	// CarID - идентификатор автомобиля
+	CarID int
+	// FullName - название автомобиля
+	FullName string
+	// Variables - коэффициенты, значения функций принадлежности и достоверности в алфавитном порядке
+	// нечетких множеств
+	Variables []VariableExplanation
+}
+
+// RankingSnapshot - рейтинг автомобилей для одной расстановки приоритетов
+type RankingSnapshot struct {
+	// Priorities - приоритеты, расставленные пользователем
+	Priorities []string
+	// Cars - автомобили в порядке убывания выходного значения нечеткого алгоритма
+	Cars []RankedCar
+}
+
+// RankedCar - место автомобиля в рейтинге
+type RankedCar struct {
+	// CarID - идентификатор автомобиля
+	CarID int
+	// Value - выходное значение нечеткого алгоритма. Равно NaN, если ни одно правило не сработало и метод
+	// дефаззификации делит на ноль; в файле эталона такое значение записывается как null
+	Value float64
+}
+
+// rankedCarJSON - место автомобиля в рейтинге в файле эталона
+type rankedCarJSON struct {
+	CarID int
+	Value *float64
+}
+
+// MarshalJSON записывает место автомобиля в рейтинге, заменяя NaN на null
+func (rcr RankedCar) MarshalJSON() ([]byte, error) {
+	encoded := rankedCarJSON{CarID: rcr.CarID}
+	if !math.IsNaN(rcr.Value) {
+		encoded.Value = &rcr.Value
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON читает место автомобиля в рейтинге, заменяя null на NaN
+func (rcr *RankedCar) UnmarshalJSON(data []byte) error {
+	var decoded rankedCarJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	rcr.CarID, rcr.Value = decoded.CarID, math.NaN()
+	if decoded.Value != nil {
+		rcr.Value = *decoded.Value
+	}
+	return nil
+}
+
+// GoldenOrderings возвращает расстановки приоритетов для эталона: все расстановки от одного до трех нечетких
+// множеств "экономичность", "динамика", "управляемость", "комфорт", "безопасность" и циклические сдвиги
+// расстановок из четырех и пяти этих нечетких множеств
+func GoldenOrderings() [][]string {
+	variables := []string{Economy, Dynamics, Handling, Comfort, Safety}
+
+	var orderings [][]string
+	var arrange func(prefix []string, length int)
+	arrange = func(prefix []string, length int) {
+		if len(prefix) == length {
+			orderings = append(orderings, append([]string(nil), prefix...))
+			return
+		}
+		for _, variable := range variables {
+			if !containsString(prefix, variable) {
+				arrange(append(prefix, variable), length)
+			}
+		}
+	}
+	for length := 1; length <= 3; length++ {
+		arrange(nil, length)
+	}
+
+	for _, length := range []int{4, 5} {
+		for shift := range variables {
+			ordering := make([]string, 0, length)
+			for idx := 0; idx < length; idx++ {
+				ordering = append(ordering, variables[(shift+idx)%len(variables)])
+			}
+			orderings = append(orderings, ordering)
+		}
+	}
+	return orderings
+}
+
+// containsString проверяет, содержит ли срез строку
+func containsString(values []string, value string) bool {
+	for _, known := range values {
+		if known == value {
+			return true
+		}
+	}
+	return false
+}
+
+// TakeSnapshot получает эталон результатов нечеткого алгоритма: вычисляет коэффициенты и значения функций
+// принадлежности каждого автомобиля и ранжирует автомобили для каждой расстановки приоритетов
+// Входные параметры: ctx - контекст, eng - нечеткий алгоритм, version - описание версии нечеткого алгоритма,
+// cars - автомобили, orderings - расстановки приоритетов
+func TakeSnapshot(ctx context.Context, eng *Engine, version string, cars []models.Car, orderings [][]string) (Snapshot, error) {
+	snapshot := Snapshot{Version: version, Cars: make([]CarSnapshot, 0, len(cars)),
+		Rankings: make([]RankingSnapshot, 0, len(orderings))}
+
+	versions := eng.pinVersions()
+	for _, car := range cars {
+		coefficients, confidences, imputedFields := eng.calculateCoefficients(car, versions.formulas)
+		explanation := explain(eng.Variables, coefficients, confidences, imputedFields, versions.memberships.functions, nil, nil, 0)
+		if eng.IntervalMemberships != nil {
+			explainIntervals(&explanation, eng.IntervalMemberships, 0, 0)
+		}
+		snapshot.Cars = append(snapshot.Cars, CarSnapshot{CarID: car.ID, FullName: car.FullName,
+			Variables: explanation.Variables})
+	}
+
+	for _, priorities := range orderings {
+		results, err := eng.Rank(ctx, cars, priorities)
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("error from `Rank` method, package `fuzzy`, priorities %q: %#v",
+				strings.Join(priorities, " "), err)
+		}
+		ranking := RankingSnapshot{Priorities: priorities, Cars: make([]RankedCar, 0, len(results))}
+		for _, result := range results {
+			ranking.Cars = append(ranking.Cars, RankedCar{CarID: result.CarID, Value: result.Value})
+		}
+		snapshot.Rankings = append(snapshot.Rankings, ranking)
+	}
+	return snapshot, nil
+}
+
+// SnapshotDiff - различия двух эталонов
+type SnapshotDiff struct {
+	// Coefficients - изменившиеся коэффициенты
+	Coefficients []CoefficientChange
+	// Memberships - изменившиеся значения функций принадлежности
+	Memberships []MembershipChange
+	// Rankings - рейтинги, в которых изменились места или выходные значения автомобилей
+	Rankings []RankingDiff
+	// Missing - автомобили и расстановки приоритетов, которые есть только в одном из эталонов
+	Missing []string
+}
+
+// CoefficientChange - изменение коэффициента автомобиля
+type CoefficientChange struct {
+	// CarID - идентификатор автомобиля
+	CarID int
+	// FullName - название автомобиля
+	FullName string
+	// Variable - название нечеткого множества
+	Variable string
+	// Base, Candidate - коэффициент в исходном и новом эталонах
+	Base, Candidate float64
+}
+
+// MembershipChange - изменение значения функции принадлежности для коэффициента автомобиля
+type MembershipChange struct {
+	// CarID - идентификатор автомобиля
+	CarID int
+	// FullName - название автомобиля
+	FullName string
+	// Variable - название нечеткого множества
+	Variable string
+	// Term - название нечеткого подмножества
+	Term string
+	// Base, Candidate - значение функции принадлежности в исходном и новом эталонах
+	Base, Candidate float64
+}
+
+// RankingDiff - различия рейтингов для одной расстановки приоритетов
+type RankingDiff struct {
+	// Priorities - приоритеты, расставленные пользователем
+	Priorities []string
+	// Tau - коэффициент ранговой корреляции Кендалла: 1 - порядок совпадает, -1 - порядок обратный
+	Tau float64
+	// Changes - автомобили, у которых изменилось место или выходное значение, в порядке исходного рейтинга.
+	// Место 0 означает, что автомобиля нет в рейтинге
+	Changes []RankChange
+}
+
+// RankChange - изменение места автомобиля в рейтинге
+type RankChange struct {
+	// CarID - идентификатор автомобиля
+	CarID int
+	// FullName - название автомобиля
+	FullName string
+	// BaseRank, CandidateRank - место в исходном и новом рейтингах, начиная с 1
+	BaseRank, CandidateRank int
+	// BaseValue, CandidateValue - выходное значение нечеткого алгоритма в исходном и новом рейтингах
+	BaseValue, CandidateValue float64
+}
+
+// Empty проверяет, что эталоны совпадают
+func (sdf SnapshotDiff) Empty() bool {
+	return len(sdf.Coefficients) == 0 && len(sdf.Memberships) == 0 && len(sdf.Rankings) == 0 && len(sdf.Missing) == 0
+}
+
+// CompareSnapshots сравнивает два эталона. Коэффициенты, значения функций принадлежности и выходные значения
+// считаются изменившимися, если отличаются больше, чем на tolerance; места в рейтинге сравниваются точно
+// Входные параметры: base - исходный эталон, candidate - новый эталон, tolerance - допустимое отклонение
+func CompareSnapshots(base, candidate Snapshot, tolerance float64) SnapshotDiff {
+	var diff SnapshotDiff
+	changed := func(first, second float64) bool {
+		return math.Abs(first-second) > tolerance || math.IsNaN(first) != math.IsNaN(second)
+	}
+
+	candidateCars := make(map[int]CarSnapshot, len(candidate.Cars))
+	for _, car := range candidate.Cars {
+		candidateCars[car.CarID] = car
+	}
+	names := make(map[int]string, len(base.Cars))
+	for _, baseCar := range base.Cars {
+		names[baseCar.CarID] = baseCar.FullName
+		candidateCar, ok := candidateCars[baseCar.CarID]
+		if !ok {
+			diff.Missing = append(diff.Missing, fmt.Sprintf("car %d %q is missing in the candidate", baseCar.CarID, baseCar.FullName))
+			continue
+		}
+		delete(candidateCars, baseCar.CarID)
+
+		candidateVariables := make(map[string]VariableExplanation, len(candidateCar.Variables))
+		for _, variable := range candidateCar.Variables {
+			candidateVariables[variable.Variable] = variable
+		}
+		for _, baseVariable := range baseCar.Variables {
+			candidateVariable, ok := candidateVariables[baseVariable.Variable]
+			if !ok {
+				diff.Missing = append(diff.Missing, fmt.Sprintf("fuzzy set %q of the car %d is missing in the candidate",
+					baseVariable.Variable, baseCar.CarID))
+				continue
+			}
+			if changed(baseVariable.Coefficient, candidateVariable.Coefficient) {
+				diff.Coefficients = append(diff.Coefficients, CoefficientChange{CarID: baseCar.CarID, FullName: baseCar.FullName,
+					Variable: baseVariable.Variable, Base: baseVariable.Coefficient, Candidate: candidateVariable.Coefficient})
+			}
+
+			candidateDegrees := make(map[string]float64, len(candidateVariable.Memberships))
+			for _, membership := range candidateVariable.Memberships {
+				candidateDegrees[membership.Term] = membership.Degree
+			}
+			for _, membership := range baseVariable.Memberships {
+				// если коэффициент нельзя вычислить, значений функций принадлежности нет
+				candidateDegree := candidateDegrees[membership.Term]
+				if changed(membership.Degree, candidateDegree) {
+					diff.Memberships = append(diff.Memberships, MembershipChange{CarID: baseCar.CarID, FullName: baseCar.FullName,
+						Variable: baseVariable.Variable, Term: membership.Term, Base: membership.Degree, Candidate: candidateDegree})
+				}
+			}
+		}
+	}
+	for _, car := range candidate.Cars {
+		if _, ok := candidateCars[car.CarID]; ok {
+			names[car.CarID] = car.FullName
+			diff.Missing = append(diff.Missing, fmt.Sprintf("car %d %q is missing in the base", car.CarID, car.FullName))
+		}
+	}
+
+	candidateRankings := make(map[string]RankingSnapshot, len(candidate.Rankings))
+	for _, ranking := range candidate.Rankings {
+		candidateRankings[strings.Join(ranking.Priorities, " ")] = ranking
+	}
+	for _, baseRanking := range base.Rankings {
+		key := strings.Join(baseRanking.Priorities, " ")
+		candidateRanking, ok := candidateRankings[key]
+		if !ok {
+			diff.Missing = append(diff.Missing, fmt.Sprintf("ranking for priorities %q is missing in the candidate", key))
+			continue
+		}
+		delete(candidateRankings, key)
+		if rankingDiff, ok := compareRankings(baseRanking, candidateRanking, names, changed); ok {
+			diff.Rankings = append(diff.Rankings, rankingDiff)
+		}
+	}
+	missingRankings := make([]string, 0, len(candidateRankings))
+	for key := range candidateRankings {
+		missingRankings = append(missingRankings, fmt.Sprintf("ranking for priorities %q is missing in the base", key))
+	}
+	sort.Strings(missingRankings)
+	diff.Missing = append(diff.Missing, missingRankings...)
+	return diff
+}
+
+// compareRankings сравнивает рейтинги для одной расстановки приоритетов. Возвращает false, если рейтинги совпадают
+// Входные параметры: base, candidate - исходный и новый рейтинги, names - названия автомобилей (ключ - идентификатор),
+// changed - проверяет, что выходные значения отличаются больше допустимого
+func compareRankings(base, candidate RankingSnapshot, names map[int]string,
+	changed func(first, second float64) bool) (RankingDiff, bool) {
+	candidatePlaces := make(map[int]int, len(candidate.Cars))
+	for idx, car := range candidate.Cars {
+		candidatePlaces[car.CarID] = idx
+	}
+
+	rankingDiff := RankingDiff{Priorities: base.Priorities, Tau: 1}
+	var concordant, discordant float64
+	for idx, car := range base.Cars {
+		place, ok := candidatePlaces[car.CarID]
+		if !ok {
+			rankingDiff.Changes = append(rankingDiff.Changes, RankChange{CarID: car.CarID, FullName: names[car.CarID],
+				BaseRank: idx + 1, BaseValue: car.Value})
+			continue
+		}
+		candidateCar := candidate.Cars[place]
+		if place != idx || changed(car.Value, candidateCar.Value) {
+			rankingDiff.Changes = append(rankingDiff.Changes, RankChange{CarID: car.CarID, FullName: names[car.CarID],
+				BaseRank: idx + 1, CandidateRank: place + 1, BaseValue: car.Value, CandidateValue: candidateCar.Value})
+		}
+
+		for _, next := range base.Cars[idx+1:] {
+			if nextPlace, ok := candidatePlaces[next.CarID]; ok {
+				if place < nextPlace {
+					concordant++
+				} else {
+					discordant++
+				}
+			}
+		}
+	}
+	basePlaces := make(map[int]bool, len(base.Cars))
+	for _, car := range base.Cars {
+		basePlaces[car.CarID] = true
+	}
+	for idx, car := range candidate.Cars {
+		if !basePlaces[car.CarID] {
+			rankingDiff.Changes = append(rankingDiff.Changes, RankChange{CarID: car.CarID, FullName: names[car.CarID],
+				CandidateRank: idx + 1, CandidateValue: car.Value})
+		}
+	}
+	if concordant+discordant > 0 {
+		rankingDiff.Tau = (concordant - discordant) / (concordant + discordant)
+	}
+	return rankingDiff, len(rankingDiff.Changes) > 0
+}
+
+// ReadGoldenCars читает автомобили из файла JSON
+// Входной параметр: fileName - путь к файлу
+func ReadGoldenCars(fileName string) ([]models.Car, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("error from `ReadFile` function, package `os`: %#v", err)
+	}
+	var cars []models.Car
+	if err = json.Unmarshal(data, &cars); err != nil {
+		return nil, fmt.Errorf("error from `Unmarshal` function, package `json`, file %q: %#v", fileName, err)
+	}
+	return cars, nil
+}
+
+// ReadSnapshot читает эталон из файла JSON
+// Входной параметр: fileName - путь к файлу
+func ReadSnapshot(fileName string) (Snapshot, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("error from `ReadFile` function, package `os`: %#v", err)
+	}
+	var snapshot Snapshot
+	if err = json.Unmarshal(data, &snapshot); err != nil {
+		return Snapshot{}, fmt.Errorf("error from `Unmarshal` function, package `json`, file %q: %#v", fileName, err)
+	}
+	return snapshot, nil
+}
+
+// WriteSnapshot записывает эталон в файл JSON
+// Входные параметры: fileName - путь к файлу, snapshot - эталон
+func WriteSnapshot(fileName string, snapshot Snapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error from `MarshalIndent` function, package `json`: %#v", err)
+	}
+	if err = os.WriteFile(fileName, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("error from `WriteFile` function, package `os`: %#v", err)
+	}
+	return nil
+}
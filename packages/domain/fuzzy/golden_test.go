@@ -0,0 +1,104 @@
+package fuzzy_test
+
+import (
+	"context"
+	"flag"
+	"testing"
+	"vehicles/packages/domain/fuzzy"
+)
+
+// файлы эталона: автомобили (12 автомобилей из sql_scripts/vehicles.sql и объявления с неизвестными
+// характеристиками) и результаты нечеткого алгоритма для них
+const (
+	goldenCarsFile     = "testdata/golden/cars.json"
+	goldenSnapshotFile = "testdata/golden/snapshot.json"
+)
+
+// updateGolden перезаписывает эталон: go test ./packages/domain/fuzzy -run TestGolden -update
+var updateGolden = flag.Bool("update", false, "rewrite "+goldenSnapshotFile)
+
+// goldenSnapshot получает результаты нечеткого алгоритма по умолчанию со встроенными правилами для автомобилей эталона
+func goldenSnapshot(t *testing.T) fuzzy.Snapshot {
+	cars, err := fuzzy.ReadGoldenCars(goldenCarsFile)
+	if err != nil {
+		t.Fatalf("error from `ReadGoldenCars` function: %#v", err)
+	}
+	rules, err := fuzzy.LoadEmbeddedRuleIndex(fuzzy.DefaultVariables(), nil)
+	if err != nil {
+		t.Fatalf("error from `LoadEmbeddedRuleIndex` function: %#v", err)
+	}
+
+	snapshot, err := fuzzy.TakeSnapshot(context.Background(), fuzzy.NewEngine(rules, nil), "embedded rules, default engine",
+		cars, fuzzy.GoldenOrderings())
+	if err != nil {
+		t.Fatalf("error from `TakeSnapshot` function: %#v", err)
+	}
+	return snapshot
+}
+
+// TestGolden сравнивает коэффициенты, значения функций принадлежности и рейтинги автомобилей с эталоном.
+// Если изменение намеренное, эталон перезаписывается флагом -update, а различия просматриваются утилитой fuzzydiff
+func TestGolden(t *testing.T) {
+	actual := goldenSnapshot(t)
+	if *updateGolden {
+		if err := fuzzy.WriteSnapshot(goldenSnapshotFile, actual); err != nil {
+			t.Fatalf("error from `WriteSnapshot` function: %#v", err)
+		}
+		return
+	}
+
+	expected, err := fuzzy.ReadSnapshot(goldenSnapshotFile)
+	if err != nil {
+		t.Fatalf("error from `ReadSnapshot` function: %#v", err)
+	}
+	diff := fuzzy.CompareSnapshots(expected, actual, fuzzy.DefaultGoldenTolerance)
+	for _, change := range diff.Coefficients {
+		t.Errorf("%s, %s: coefficient %v, expected %v", change.FullName, change.Variable, change.Candidate, change.Base)
+	}
+	for _, change := range diff.Memberships {
+		t.Errorf("%s, %s %s: membership %v, expected %v", change.FullName, change.Variable, change.Term,
+			change.Candidate, change.Base)
+	}
+	for _, ranking := range diff.Rankings {
+		for _, change := range ranking.Changes {
+			t.Errorf("priorities %q, %s: rank %d (%v), expected %d (%v)", ranking.Priorities, change.FullName,
+				change.CandidateRank, change.CandidateValue, change.BaseRank, change.BaseValue)
+		}
+	}
+	for _, missing := range diff.Missing {
+		t.Errorf("%s", missing)
+	}
+}
+
+// TestCompareSnapshots проверяет, что сравнение эталонов находит измененный коэффициент и переставленные автомобили
+func TestCompareSnapshots(t *testing.T) {
+	base, err := fuzzy.ReadSnapshot(goldenSnapshotFile)
+	if err != nil {
+		t.Fatalf("error from `ReadSnapshot` function: %#v", err)
+	}
+	if diff := fuzzy.CompareSnapshots(base, base, 0); !diff.Empty() {
+		t.Fatalf("error, the snapshot differs from itself: %+v", diff)
+	}
+
+	candidate, err := fuzzy.ReadSnapshot(goldenSnapshotFile)
+	if err != nil {
+		t.Fatalf("error from `ReadSnapshot` function: %#v", err)
+	}
+	candidate.Cars[0].Variables[0].Coefficient += 1
+	ranking := candidate.Rankings[0].Cars
+	ranking[0], ranking[1] = ranking[1], ranking[0]
+
+	diff := fuzzy.CompareSnapshots(base, candidate, fuzzy.DefaultGoldenTolerance)
+	if len(diff.Coefficients) != 1 || diff.Coefficients[0].CarID != base.Cars[0].CarID {
+		t.Errorf("error, expected one changed coefficient of the car %d, got %+v", base.Cars[0].CarID, diff.Coefficients)
+	}
+	if len(diff.Rankings) != 1 || len(diff.Rankings[0].Changes) != 2 {
+		t.Fatalf("error, expected two swapped cars in one ranking, got %+v", diff.Rankings)
+	}
+	if change := diff.Rankings[0].Changes[0]; change.BaseRank != 1 || change.CandidateRank != 2 {
+		t.Errorf("error, expected the first car to move to the second place, got %+v", change)
+	}
+	if tau := diff.Rankings[0].Tau; tau >= 1 {
+		t.Errorf("error, expected Kendall tau below 1 for swapped cars, got %v", tau)
+	}
+}
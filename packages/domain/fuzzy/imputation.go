@@ -0,0 +1,192 @@
+package fuzzy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"vehicles/packages/domain/models"
+)
+
+// названия числовых характеристик, которые можно восстановить (совпадают с названиями столбцов БД)
+const (
+	MixedFuelConsumptionField = "mixed_fuel_consumption"
+	Acceleration0To100Field   = "acceleration_0_to_100"
+	MaxPowerField             = "max_power"
+	MassField                 = "mass"
+	WheelbaseField            = "wheelbase"
+	LengthField               = "length"
+	WidthField                = "width"
+	HeightField               = "height"
+	GroundClearanceField      = "ground_clearance"
+	FrontTrackWidthField      = "front_track_width"
+	BackTrackWidthField       = "back_track_width"
+	TrunkVolumeField          = "trunk_volume"
+	CrashTestEstimateField    = "crash_test_estimate"
+)
+
+// accessor - чтение и запись числовой характеристики автомобиля
+type accessor struct {
+	get func(car models.Car) float64
+	set func(car *models.Car, value float64)
+}
+
+// imputableFields - числовые характеристики, которые можно восстановить (ключ - название характеристики)
+var imputableFields = map[string]accessor{
+	MixedFuelConsumptionField: {func(car models.Car) float64 { return car.Specs.MixedFuelConsumption },
+		func(car *models.Car, value float64) { car.Specs.MixedFuelConsumption = value }},
+	Acceleration0To100Field: {func(car models.Car) float64 { return car.Specs.Acceleration0To100 },
+		func(car *models.Car, value float64) { car.Specs.Acceleration0To100 = value }},
+	MaxPowerField: {func(car models.Car) float64 { return car.Specs.Engine.MaxPower },
+		func(car *models.Car, value float64) { car.Specs.Engine.MaxPower = value }},
+	MassField: {func(car models.Car) float64 { return car.Specs.Mass },
+		func(car *models.Car, value float64) { car.Specs.Mass = value }},
+	WheelbaseField: {func(car models.Car) float64 { return car.Specs.Wheelbase },
+		func(car *models.Car, value float64) { car.Specs.Wheelbase = value }},
+	LengthField: {func(car models.Car) float64 { return car.Specs.Length },
+		func(car *models.Car, value float64) { car.Specs.Length = value }},
+	WidthField: {func(car models.Car) float64 { return car.Specs.Width },
+		func(car *models.Car, value float64) { car.Specs.Width = value }},
+	HeightField: {func(car models.Car) float64 { return car.Specs.Height },
+		func(car *models.Car, value float64) { car.Specs.Height = value }},
+	GroundClearanceField: {func(car models.Car) float64 { return car.Specs.GroundClearance },
+		func(car *models.Car, value float64) { car.Specs.GroundClearance = value }},
+	FrontTrackWidthField: {func(car models.Car) float64 { return car.Specs.FrontTrackWidth },
+		func(car *models.Car, value float64) { car.Specs.FrontTrackWidth = value }},
+	BackTrackWidthField: {func(car models.Car) float64 { return car.Specs.BackTrackWidth },
+		func(car *models.Car, value float64) { car.Specs.BackTrackWidth = value }},
+	TrunkVolumeField: {func(car models.Car) float64 { return car.Specs.TrunkVolume },
+		func(car *models.Car, value float64) { car.Specs.TrunkVolume = value }},
+	CrashTestEstimateField: {func(car models.Car) float64 { return car.Specs.CrashTestEstimate },
+		func(car *models.Car, value float64) { car.Specs.CrashTestEstimate = value }},
+}
+
+// Imputer восстанавливает неизвестные характеристики автомобиля перед вычислением коэффициентов
+type Imputer interface {
+	// Impute возвращает автомобиль с восстановленными характеристиками и названия восстановленных характеристик
+	// Входной параметр: car - автомобиль
+	Impute(car models.Car) (models.Car, map[string]bool)
+}
+
+// CarSampleLoader - источник автомобилей с известными характеристиками, по которым восстанавливаются
+// характеристики других автомобилей
+type CarSampleLoader interface {
+	// LoadCarSamples получает автомобили с заполненными маркой, моделью, поколением, типом кузова и числовыми
+	// характеристиками
+	// Входной параметр: ctx - контекст
+	LoadCarSamples(ctx context.Context) ([]models.Car, error)
+}
+
+// MedianImputer восстанавливает неизвестную числовую характеристику медианой ее значений у автомобилей того же
+// поколения той же марки и модели, а если таких нет - того же типа кузова. Названия поколений вроде "1 поколение"
+// повторяются у разных моделей, поэтому поколение без марки и модели не образует группу
+type MedianImputer struct {
+	// byGeneration - медианы характеристик (ключ - марка, модель и поколение, затем название характеристики)
+	byGeneration map[string]map[string]float64
+	// byBody - медианы характеристик (ключ - тип кузова, затем название характеристики)
+	byBody map[string]map[string]float64
+}
+
+// NewMedianImputer вычисляет медианы характеристик по поколениям и типам кузова
+// Входной параметр: samples - автомобили, например, все комплектации из БД vehicles
+func NewMedianImputer(samples []models.Car) *MedianImputer {
+	generations := make(map[string]map[string][]float64)
+	bodies := make(map[string]map[string][]float64)
+	for _, car := range samples {
+		for name, field := range imputableFields {
+			value := field.get(car)
+			if value == 0 {
+				continue
+			}
+			if key := generationKey(car); key != "" {
+				appendSample(generations, key, name, value)
+			}
+			if key := groupKey(car.Specs.Body); key != "" {
+				appendSample(bodies, key, name, value)
+			}
+		}
+	}
+	return &MedianImputer{byGeneration: medians(generations), byBody: medians(bodies)}
+}
+
+// Impute заменяет нулевые числовые характеристики медианами характеристик похожих автомобилей
+// Входной параметр: car - автомобиль
+func (mim *MedianImputer) Impute(car models.Car) (models.Car, map[string]bool) {
+	imputed := make(map[string]bool)
+	for name, field := range imputableFields {
+		if field.get(car) != 0 {
+			continue
+		}
+		value, ok := mim.byGeneration[generationKey(car)][name]
+		if !ok {
+			value, ok = mim.byBody[groupKey(car.Specs.Body)][name]
+		}
+		if ok {
+			field.set(&car, value)
+			imputed[name] = true
+		}
+	}
+	return car, imputed
+}
+
+// LoadMedianImputer получает автомобили из источника и вычисляет по ним медианы характеристик
+// Входные параметры: ctx - контекст, loader - источник автомобилей
+func LoadMedianImputer(ctx context.Context, loader CarSampleLoader) (*MedianImputer, error) {
+	samples, err := loader.LoadCarSamples(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error from `LoadCarSamples` method, package `fuzzy`: %#v", err)
+	}
+	return NewMedianImputer(samples), nil
+}
+
+// groupKey приводит название поколения или типа кузова к ключу группы. Неизвестное название не образует группу
+func groupKey(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == strings.ToLower(models.UndefinedStr) {
+		return ""
+	}
+	return name
+}
+
+// generationKey возвращает ключ группы поколения: марку, модель и поколение. Марка и модель приводятся к одному
+// виду для БД vehicles ("Land Cruiser Prado") и ссылок порталов ("land_cruiser_prado"). Автомобиль с неизвестной
+// маркой, моделью или поколением не образует группу
+// Входной параметр: car - автомобиль
+func generationKey(car models.Car) string {
+	carMake, model, generation := nameKey(car.Make), nameKey(car.Model), groupKey(car.Generation)
+	if carMake == "" || model == "" || generation == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s|%s|%s", carMake, model, generation)
+}
+
+// nameKey приводит название марки или модели к ключу: подчеркивания и дефисы заменяются пробелами
+func nameKey(name string) string {
+	return strings.Join(strings.Fields(strings.NewReplacer("_", " ", "-", " ").Replace(groupKey(name))), " ")
+}
+
+// appendSample добавляет значение характеристики в группу
+func appendSample(groups map[string]map[string][]float64, key, name string, value float64) {
+	if groups[key] == nil {
+		groups[key] = make(map[string][]float64)
+	}
+	groups[key][name] = append(groups[key][name], value)
+}
+
+// medians вычисляет медиану значений каждой характеристики в каждой группе
+func medians(groups map[string]map[string][]float64) map[string]map[string]float64 {
+	result := make(map[string]map[string]float64, len(groups))
+	for key, fields := range groups {
+		result[key] = make(map[string]float64, len(fields))
+		for name, values := range fields {
+			sort.Float64s(values)
+			middle := len(values) / 2
+			if len(values)%2 == 1 {
+				result[key][name] = values[middle]
+			} else {
+				result[key][name] = (values[middle-1] + values[middle]) / 2
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,42 @@
+package fuzzy_test
+
+import (
+	"testing"
+	"vehicles/packages/domain/fuzzy"
+	"vehicles/packages/domain/models"
+)
+
+// TestMedianImputerGroups проверяет, что медианы поколения вычисляются по автомобилям той же марки и модели,
+// а не по всем автомобилям с тем же названием поколения, и что без марки и модели используются медианы типа кузова
+func TestMedianImputerGroups(t *testing.T) {
+	sample := func(carMake, model, generation, body string, mass float64) models.Car {
+		car := models.Car{Make: carMake, Model: model, Generation: generation}
+		car.Specs.Body = body
+		car.Specs.Mass = mass
+		return car
+	}
+	imputer := fuzzy.NewMedianImputer([]models.Car{
+		sample("Lada", "Granta", "1 поколение", "седан", 1100),
+		sample("Lada", "Granta", "1 поколение", "седан", 1160),
+		sample("Toyota", "Land Cruiser Prado", "1 поколение", "внедорожник", 2100),
+		sample("Toyota", "Camry", "8 поколение (XV70)", "седан", 1570),
+	})
+
+	tests := []struct {
+		name     string
+		car      models.Car
+		expected float64
+	}{
+		{"модель из ссылки портала", sample("toyota", "land_cruiser_prado", "1 поколение", "седан", 0), 2100},
+		{"модель из БД", sample("Lada", "Granta", "1 поколение", "внедорожник", 0), 1130},
+		{"неизвестная модель", sample("", "", "1 поколение", "седан", 0), 1160},
+		{"другая модель с тем же поколением", sample("Kia", "Rio", "1 поколение", "внедорожник", 0), 2100},
+	}
+	for _, test := range tests {
+		car, imputed := imputer.Impute(test.car)
+		if !imputed[fuzzy.MassField] || car.Specs.Mass != test.expected {
+			t.Errorf("%s: mass %v (imputed %v), expected %v", test.name, car.Specs.Mass, imputed[fuzzy.MassField],
+				test.expected)
+		}
+	}
+}
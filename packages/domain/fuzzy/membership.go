@@ -0,0 +1,101 @@
+package fuzzy
+
+import "math"
+
+// MembershipFunction - функция принадлежности нечеткого подмножества
+type MembershipFunction interface {
+	// Value вычисляет значение функции принадлежности
+	// Входной параметр: x - значение коэффициента, например, коэффициента комфорта
+	Value(x float64) float64
+}
+
+// Sigmoid - функция `сигмоида`
+// Аппроксимирует точки, абсциссами которых являются значения коэффициентов, например, "управляемости" и т.д.,
+// а ординатами - значения принадлежности точек нечетким подмножествам, например, "Высокая безопасность"
+// нечеткого множества "Безопасность" и т.д.
+type Sigmoid struct {
+	// L - максимальное значение функции
+	L float64
+	// K - крутизна
+	K float64
+	// X0 - абсцисса точки перегиба
+	X0 float64
+}
+
+// Value вычисляет значение функции `сигмоида`
+func (sgm Sigmoid) Value(x float64) float64 {
+	return sgm.L / (1 + math.Exp(-sgm.K*(x-sgm.X0)))
+}
+
+// Gaussian - функция Гаусса
+// Аппроксимирует точки, абсциссами которых являются значения коэффициентов, например, "управляемости" и т.д.,
+// а ординатами - значения принадлежности точек нечетким подмножествам "низкий", "средний", "высокий" нечетких
+// множеств "экономичность", "динамика", "управляемость", "комфорт", "безопасность"
+type Gaussian struct {
+	// Amp - амплитуда
+	Amp float64
+	// Cen - абсцисса центра
+	Cen float64
+	// Wid - ширина
+	Wid float64
+}
+
+// Value вычисляет значение функции Гаусса
+func (gsn Gaussian) Value(x float64) float64 {
+	return gsn.Amp / (math.Sqrt(2*math.Pi) * gsn.Wid) * math.Exp(-math.Pow(x-gsn.Cen, 2)/(2*math.Pow(gsn.Wid, 2)))
+}
+
+// Memberships предоставляет функции принадлежности, соответствующие нечетким подмножествам нечетких множеств набора,
+// например, "низкий", "средний" и "высокий" для шкал по умолчанию
+func (vs *VariableSet) Memberships() map[string]map[string]MembershipFunction {
+	memberships := make(map[string]map[string]MembershipFunction, len(vs.variables))
+	for _, variable := range vs.variables {
+		memberships[variable.Name] = make(map[string]MembershipFunction, len(variable.Memberships))
+		for term, function := range variable.Memberships {
+			memberships[variable.Name][term] = function
+		}
+	}
+	return memberships
+}
+
+// Triangular - треугольная функция принадлежности
+type Triangular struct {
+	// A - абсцисса левой точки основания треугольника
+	A float64
+	// B - абсцисса вершины треугольника
+	B float64
+	// C - абсцисса правой точки основания треугольника
+	C float64
+}
+
+// Value вычисляет значение треугольной функции принадлежности
+func (trg Triangular) Value(x float64) float64 {
+	return Trapezoidal{trg.A, trg.B, trg.B, trg.C}.Value(x)
+}
+
+// Trapezoidal - трапециевидная функция принадлежности
+type Trapezoidal struct {
+	// A - абсцисса левой точки нижнего основания трапеции
+	A float64
+	// B - абсцисса левой точки верхнего основания трапеции
+	B float64
+	// C - абсцисса правой точки верхнего основания трапеции
+	C float64
+	// D - абсцисса правой точки нижнего основания трапеции
+	D float64
+}
+
+// Value вычисляет значение трапециевидной функции принадлежности
+func (trp Trapezoidal) Value(x float64) float64 {
+	switch {
+	case x < trp.A || x > trp.D:
+		return 0
+	case x < trp.B:
+		return (x - trp.A) / (trp.B - trp.A)
+	case x <= trp.C:
+		return 1
+	case x < trp.D:
+		return (trp.D - x) / (trp.D - trp.C)
+	}
+	return 0
+}
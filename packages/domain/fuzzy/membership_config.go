@@ -0,0 +1,203 @@
+package fuzzy
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// типы функций принадлежности, допустимые в описании функций принадлежности
+const (
+	SigmoidType     = "sigmoid"
+	GaussianType    = "gaussian"
+	TriangularType  = "triangular"
+	TrapezoidalType = "trapezoidal"
+)
+
+// DefaultMembershipsVersion - версия встроенных функций принадлежности
+const DefaultMembershipsVersion = "default"
+
+// MembershipDefinition - описание функции принадлежности одного нечеткого подмножества
+type MembershipDefinition struct {
+	// Variable - название нечеткого множества, например, "комфорт"
+	Variable string `json:"variable" yaml:"variable"`
+	// Term - название нечеткого подмножества, например, "высокий"
+	Term string `json:"term" yaml:"term"`
+	// Type - тип функции принадлежности: sigmoid, gaussian, triangular или trapezoidal
+	Type string `json:"type" yaml:"type"`
+	// Params - параметры функции принадлежности в порядке полей структуры, реализующей функцию:
+	// sigmoid - L, K, X0; gaussian - Amp, Cen, Wid; triangular - A, B, C; trapezoidal - A, B, C, D
+	Params []float64 `json:"params" yaml:"params"`
+}
+
+// MembershipConfig - версия описаний функций принадлежности
+type MembershipConfig struct {
+	// Version - версия описаний. Функции принадлежности заменяются, только если версия изменилась
+	Version string `json:"version" yaml:"version"`
+	// Definitions - описания функций принадлежности
+	Definitions []MembershipDefinition `json:"memberships" yaml:"memberships"`
+}
+
+// MembershipLoader загружает актуальную версию описаний функций принадлежности,
+// например, из файла или таблицы БД
+type MembershipLoader interface {
+	// LoadMemberships загружает актуальную версию описаний функций принадлежности
+	// Входной параметр: ctx - контекст
+	LoadMemberships(ctx context.Context) (MembershipConfig, error)
+}
+
+// NewMembershipFunction создает функцию принадлежности по ее описанию
+// Входной параметр: definition - описание функции принадлежности
+func NewMembershipFunction(definition MembershipDefinition) (MembershipFunction, error) {
+	params := definition.Params
+	expected := map[string]int{SigmoidType: 3, GaussianType: 3, TriangularType: 3, TrapezoidalType: 4}
+	count, ok := expected[definition.Type]
+	if !ok {
+		return nil, fmt.Errorf("error, unknown membership function type %q", definition.Type)
+	}
+	if len(params) != count {
+		return nil, fmt.Errorf("error, membership function type %q expects %d parameters, got %d",
+			definition.Type, count, len(params))
+	}
+
+	switch definition.Type {
+	case SigmoidType:
+		return Sigmoid{params[0], params[1], params[2]}, nil
+	case GaussianType:
+		if params[2] == 0 {
+			return nil, fmt.Errorf("error, the width of the gaussian membership function must not be zero")
+		}
+		return Gaussian{params[0], params[1], params[2]}, nil
+	case TriangularType:
+		if params[0] > params[1] || params[1] > params[2] {
+			return nil, fmt.Errorf("error, the triangular membership function expects A <= B <= C, got %v", params)
+		}
+		return Triangular{params[0], params[1], params[2]}, nil
+	default:
+		if params[0] > params[1] || params[1] > params[2] || params[2] > params[3] {
+			return nil, fmt.Errorf("error, the trapezoidal membership function expects A <= B <= C <= D, got %v", params)
+		}
+		return Trapezoidal{params[0], params[1], params[2], params[3]}, nil
+	}
+}
+
+// BuildMemberships создает функции принадлежности по их описаниям
+// Входной параметр: config - версия описаний функций принадлежности
+func BuildMemberships(config MembershipConfig) (map[string]map[string]MembershipFunction, error) {
+	memberships := make(map[string]map[string]MembershipFunction)
+	for idx, definition := range config.Definitions {
+		if definition.Variable == "" || definition.Term == "" {
+			return nil, fmt.Errorf("error, definition %d has no fuzzy set or fuzzy subset", idx+1)
+		}
+		if _, ok := memberships[definition.Variable][definition.Term]; ok {
+			return nil, fmt.Errorf("error, the fuzzy subset %q of the fuzzy set %q is defined twice",
+				definition.Term, definition.Variable)
+		}
+
+		function, err := NewMembershipFunction(definition)
+		if err != nil {
+			return nil, fmt.Errorf("error from `NewMembershipFunction` function, package `fuzzy`, definition %d: %#v", idx+1, err)
+		}
+		if memberships[definition.Variable] == nil {
+			memberships[definition.Variable] = make(map[string]MembershipFunction)
+		}
+		memberships[definition.Variable][definition.Term] = function
+	}
+	return memberships, nil
+}
+
+// MembershipTable хранит текущую версию функций принадлежности. Версию можно заменить во время работы
+// нечеткого алгоритма: каждое ранжирование использует одну версию от начала до конца
+type MembershipTable struct {
+	current atomic.Pointer[membershipVersion]
+}
+
+// membershipVersion - версия функций принадлежности
+type membershipVersion struct {
+	version string
+	// functions - функции принадлежности (ключ 1-го уровня - название нечеткого множества,
+	// ключ 2-го уровня - название нечеткого подмножества)
+	functions map[string]map[string]MembershipFunction
+}
+
+// NewMembershipTable создает таблицу функций принадлежности
+// Входные параметры: version - версия, functions - функции принадлежности (ключ 1-го уровня -
+// название нечеткого множества, ключ 2-го уровня - название нечеткого подмножества)
+func NewMembershipTable(version string, functions map[string]map[string]MembershipFunction) *MembershipTable {
+	table := &MembershipTable{}
+	table.current.Store(&membershipVersion{version: version, functions: functions})
+	return table
+}
+
+// Version возвращает текущую версию функций принадлежности
+func (tbl *MembershipTable) Version() string {
+	return tbl.current.Load().version
+}
+
+// Functions возвращает текущую версию функций принадлежности. Возвращаемая карта не должна изменяться
+func (tbl *MembershipTable) Functions() map[string]map[string]MembershipFunction {
+	return tbl.current.Load().functions
+}
+
+// Update заменяет функции принадлежности новой версией. Новая версия должна содержать функции для всех
+// нечетких подмножеств текущей версии, иначе нечеткий алгоритм не сможет вычислить часть правил
+// Входной параметр: config - версия описаний функций принадлежности
+func (tbl *MembershipTable) Update(config MembershipConfig) error {
+	functions, err := BuildMemberships(config)
+	if err != nil {
+		return fmt.Errorf("error from `BuildMemberships` function, package `fuzzy`: %#v", err)
+	}
+
+	for variable, current := range tbl.Functions() {
+		for term := range current {
+			if _, ok := functions[variable][term]; !ok {
+				return fmt.Errorf("error, the fuzzy subset %q of the fuzzy set %q is missing in version %q",
+					term, variable, config.Version)
+			}
+		}
+	}
+
+	tbl.current.Store(&membershipVersion{version: config.Version, functions: functions})
+	return nil
+}
+
+// WatchMemberships периодически загружает описания функций принадлежности и заменяет ими функции
+// принадлежности в таблице, если версия изменилась. Ошибки загрузки записываются в журнал, при этом
+// продолжает использоваться прежняя версия. Функция завершается при отмене контекста
+// Входные параметры: ctx - контекст, table - таблица функций принадлежности,
+// loader - источник описаний, interval - период проверки
+func WatchMemberships(ctx context.Context, table *MembershipTable, loader MembershipLoader, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ReloadMemberships(ctx, table, loader); err != nil {
+				log.Printf("membership functions are not reloaded: %v", err)
+			}
+		}
+	}
+}
+
+// ReloadMemberships загружает описания функций принадлежности и заменяет ими функции принадлежности
+// в таблице, если версия изменилась
+// Входные параметры: ctx - контекст, table - таблица функций принадлежности, loader - источник описаний
+func ReloadMemberships(ctx context.Context, table *MembershipTable, loader MembershipLoader) error {
+	config, err := loader.LoadMemberships(ctx)
+	if err != nil {
+		return fmt.Errorf("error from `LoadMemberships` method, package `fuzzy`: %#v", err)
+	}
+	if config.Version == table.Version() {
+		return nil
+	}
+
+	if err = table.Update(config); err != nil {
+		return fmt.Errorf("error from `Update` method, package `fuzzy`: %#v", err)
+	}
+	log.Printf("membership functions are reloaded, version %q", config.Version)
+	return nil
+}
@@ -0,0 +1,167 @@
+package fuzzy
+
+import (
+	"fmt"
+	"math"
+)
+
+// названия t-норм
+const (
+	MinTNormName         = "min"
+	ProductTNormName     = "product"
+	LukasiewiczTNormName = "lukasiewicz"
+)
+
+// названия s-норм
+const (
+	MaxSNormName              = "max"
+	ProbabilisticSumSNormName = "probabilistic_sum"
+	BoundedSumSNormName       = "bounded_sum"
+)
+
+// названия операторов импликации
+const (
+	MamdaniImplicationName = "mamdani"
+	LarsenImplicationName  = "larsen"
+)
+
+// TNorm - t-норма, которая вычисляет степень истинности правила, условия которого соединены связкой "И"
+type TNorm interface {
+	// Apply применяет t-норму к двум значениям функций принадлежности
+	// Входные параметры: a, b - значения функций принадлежности
+	Apply(a, b float64) float64
+}
+
+// SNorm - s-норма, которая вычисляет степень истинности правила, условия которого соединены связкой "ИЛИ"
+type SNorm interface {
+	// Apply применяет s-норму к двум значениям функций принадлежности
+	// Входные параметры: a, b - значения функций принадлежности
+	Apply(a, b float64) float64
+}
+
+// Implication - оператор импликации, который строит следствие правила: функцию принадлежности μG(r)
+// нечеткого множества "рекомендация", измененную степенью истинности правила
+type Implication interface {
+	// Apply вычисляет значение следствия правила
+	// Входные параметры: strength - степень истинности правила, membership - значение функции принадлежности μG(r)
+	Apply(strength, membership float64) float64
+	// Saturation возвращает значение функции принадлежности μG(r), начиная с которого следствие правила
+	// перестает расти (1, если следствие растет вместе с μG(r) до самой вершины)
+	// Входной параметр: strength - степень истинности правила
+	Saturation(strength float64) float64
+}
+
+// MinTNorm - t-норма минимума (используется по умолчанию)
+type MinTNorm struct{}
+
+// Apply вычисляет минимум
+func (MinTNorm) Apply(a, b float64) float64 {
+	return math.Min(a, b)
+}
+
+// ProductTNorm - алгебраическое произведение
+type ProductTNorm struct{}
+
+// Apply вычисляет произведение
+func (ProductTNorm) Apply(a, b float64) float64 {
+	return a * b
+}
+
+// LukasiewiczTNorm - t-норма Лукасевича (ограниченная разность)
+type LukasiewiczTNorm struct{}
+
+// Apply вычисляет ограниченную разность
+func (LukasiewiczTNorm) Apply(a, b float64) float64 {
+	return math.Max(0, a+b-1)
+}
+
+// MaxSNorm - s-норма максимума (используется по умолчанию)
+type MaxSNorm struct{}
+
+// Apply вычисляет максимум
+func (MaxSNorm) Apply(a, b float64) float64 {
+	return math.Max(a, b)
+}
+
+// ProbabilisticSumSNorm - вероятностная сумма
+type ProbabilisticSumSNorm struct{}
+
+// Apply вычисляет вероятностную сумму
+func (ProbabilisticSumSNorm) Apply(a, b float64) float64 {
+	return a + b - a*b
+}
+
+// BoundedSumSNorm - ограниченная сумма (s-норма Лукасевича)
+type BoundedSumSNorm struct{}
+
+// Apply вычисляет ограниченную сумму
+func (BoundedSumSNorm) Apply(a, b float64) float64 {
+	return math.Min(1, a+b)
+}
+
+// MamdaniImplication - импликация Мамдани: функция принадлежности μG(r) усекается на уровне степени
+// истинности правила (используется по умолчанию)
+type MamdaniImplication struct{}
+
+// Apply вычисляет минимум степени истинности правила и значения функции принадлежности
+func (MamdaniImplication) Apply(strength, membership float64) float64 {
+	return math.Min(strength, membership)
+}
+
+// Saturation возвращает степень истинности правила: выше нее следствие усечено
+func (MamdaniImplication) Saturation(strength float64) float64 {
+	return strength
+}
+
+// LarsenImplication - импликация Ларсена: функция принадлежности μG(r) масштабируется степенью истинности правила
+type LarsenImplication struct{}
+
+// Apply вычисляет произведение степени истинности правила и значения функции принадлежности
+func (LarsenImplication) Apply(strength, membership float64) float64 {
+	return strength * membership
+}
+
+// Saturation возвращает 1: масштабированное следствие растет до самой вершины
+func (LarsenImplication) Saturation(strength float64) float64 {
+	return 1
+}
+
+// NewTNorm создает t-норму по ее названию
+// Входной параметр: name - название t-нормы
+func NewTNorm(name string) (TNorm, error) {
+	switch name {
+	case MinTNormName:
+		return MinTNorm{}, nil
+	case ProductTNormName:
+		return ProductTNorm{}, nil
+	case LukasiewiczTNormName:
+		return LukasiewiczTNorm{}, nil
+	}
+	return nil, fmt.Errorf("error, unknown t-norm %q", name)
+}
+
+// NewSNorm создает s-норму по ее названию
+// Входной параметр: name - название s-нормы
+func NewSNorm(name string) (SNorm, error) {
+	switch name {
+	case MaxSNormName:
+		return MaxSNorm{}, nil
+	case ProbabilisticSumSNormName:
+		return ProbabilisticSumSNorm{}, nil
+	case BoundedSumSNormName:
+		return BoundedSumSNorm{}, nil
+	}
+	return nil, fmt.Errorf("error, unknown s-norm %q", name)
+}
+
+// NewImplication создает оператор импликации по его названию
+// Входной параметр: name - название оператора импликации
+func NewImplication(name string) (Implication, error) {
+	switch name {
+	case MamdaniImplicationName:
+		return MamdaniImplication{}, nil
+	case LarsenImplicationName:
+		return LarsenImplication{}, nil
+	}
+	return nil, fmt.Errorf("error, unknown implication operator %q", name)
+}
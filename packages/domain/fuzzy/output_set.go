@@ -0,0 +1,178 @@
+package fuzzy
+
+import (
+	"math"
+	"sort"
+)
+
+// consequent - следствие одного или нескольких правил с одинаковой рекомендацией: функция принадлежности μG(r)
+// с основанием [center-1, center+1], измененная оператором импликации
+type consequent struct {
+	// center - абсцисса центральной точки основания треугольника
+	center float64
+	// strength - наибольшая степень истинности правил с этой рекомендацией
+	strength float64
+}
+
+// piece - отрезок, на котором следствие правила линейно
+type piece struct {
+	left, right, leftValue, rightValue float64
+}
+
+// OutputSet - функция принадлежности μ(r) нечеткого множества "рекомендация": максимум следствий правил
+type OutputSet struct {
+	// consequents - следствия с ненулевой степенью истинности, упорядоченные по возрастанию абсцисс центральных точек
+	consequents []consequent
+	// implication - оператор импликации
+	implication Implication
+}
+
+// NewOutputSet строит функцию принадлежности μ(r) нечеткого множества "рекомендация". Следствия правил с
+// одинаковой рекомендацией объединяются заранее: оператор импликации не убывает по степени истинности,
+// поэтому максимум следствий равен следствию с наибольшей степенью истинности
+// Входные параметры: strengths - степени истинности правил, recommendations - значения рекомендаций,
+// implication - оператор импликации
+func NewOutputSet(strengths []float64, recommendations []int, implication Implication) OutputSet {
+	// indexes - индексы следствий в срезе consequents (ключ - значение рекомендации)
+	indexes := make(map[int]int)
+	consequents := make([]consequent, 0, len(strengths))
+	for idx, strength := range strengths {
+		if strength <= 0 {
+			continue
+		}
+		strength = math.Min(strength, 1)
+		if jdx, ok := indexes[recommendations[idx]]; ok {
+			consequents[jdx].strength = math.Max(consequents[jdx].strength, strength)
+			continue
+		}
+		indexes[recommendations[idx]] = len(consequents)
+		consequents = append(consequents, consequent{center: float64(recommendations[idx]), strength: strength})
+	}
+	sort.Slice(consequents, func(idx, jdx int) bool {
+		return consequents[idx].center < consequents[jdx].center
+	})
+	return OutputSet{consequents: consequents, implication: implication}
+}
+
+// peak возвращает наибольшее значение следствия правила
+// Входной параметр: csq - следствие правила
+func (out OutputSet) peak(csq consequent) float64 {
+	return out.implication.Apply(csq.strength, 1)
+}
+
+// pieces разбивает следствие правила на отрезки, на которых оно линейно: возрастающая сторона,
+// горизонтальная часть (если следствие усечено) и убывающая сторона
+// Входной параметр: csq - следствие правила
+func (out OutputSet) pieces(csq consequent) []piece {
+	saturation := clamp(out.implication.Saturation(csq.strength))
+	top := out.implication.Apply(csq.strength, saturation)
+	pieces := []piece{{csq.center - 1, csq.center - 1 + saturation, 0, top}}
+	if saturation < 1 {
+		pieces = append(pieces, piece{csq.center - 1 + saturation, csq.center + 1 - saturation, top, top})
+	}
+	return append(pieces, piece{csq.center + 1 - saturation, csq.center + 1, top, 0})
+}
+
+// value вычисляет значение функции принадлежности μ(r)
+// Входной параметр: x - абсцисса
+func (out OutputSet) value(x float64) float64 {
+	// учитываются только следствия, основания которых содержат x
+	first := sort.Search(len(out.consequents), func(idx int) bool {
+		return out.consequents[idx].center > x-1
+	})
+	var value float64
+	for idx := first; idx < len(out.consequents) && out.consequents[idx].center < x+1; idx++ {
+		csq := out.consequents[idx]
+		value = math.Max(value, out.implication.Apply(csq.strength, 1-math.Abs(x-csq.center)))
+	}
+	return value
+}
+
+// breakpoints возвращает упорядоченные абсциссы, между которыми функция принадлежности μ(r) линейна:
+// концы линейных отрезков следствий и точки пересечения отрезков соседних следствий
+func (out OutputSet) breakpoints() []float64 {
+	pieces := make([][]piece, len(out.consequents))
+	for idx, csq := range out.consequents {
+		pieces[idx] = out.pieces(csq)
+	}
+
+	var points []float64
+	for idx, csq := range out.consequents {
+		for _, pce := range pieces[idx] {
+			points = append(points, pce.left, pce.right)
+		}
+		for jdx := idx + 1; jdx < len(out.consequents) && out.consequents[jdx].center-csq.center < 2; jdx++ {
+			for _, other := range pieces[jdx] {
+				for _, pce := range pieces[idx] {
+					if point, ok := intersect(pce, other); ok {
+						points = append(points, point)
+					}
+				}
+			}
+		}
+	}
+	sort.Float64s(points)
+
+	unique := points[:0]
+	for _, point := range points {
+		if len(unique) == 0 || point-unique[len(unique)-1] > 1e-12 {
+			unique = append(unique, point)
+		}
+	}
+	return unique
+}
+
+// intersect находит абсциссу точки пересечения двух линейных отрезков
+// Входные параметры: first, second - линейные отрезки
+func intersect(first, second piece) (float64, bool) {
+	left, right := math.Max(first.left, second.left), math.Min(first.right, second.right)
+	if left >= right {
+		return 0, false
+	}
+	firstSlope := (first.rightValue - first.leftValue) / (first.right - first.left)
+	secondSlope := (second.rightValue - second.leftValue) / (second.right - second.left)
+	if firstSlope == secondSlope {
+		return 0, false
+	}
+	// first.leftValue + firstSlope*(x-first.left) = second.leftValue + secondSlope*(x-second.left)
+	x := (second.leftValue - first.leftValue + firstSlope*first.left - secondSlope*second.left) / (firstSlope - secondSlope)
+	if x <= left || x >= right {
+		return 0, false
+	}
+	return x, true
+}
+
+// eachSegment вызывает функцию для каждого отрезка, на котором функция принадлежности μ(r) линейна
+// Входной параметр: visit - функция, получающая концы отрезка и значения μ(r) в них
+func (out OutputSet) eachSegment(visit func(left, right, leftValue, rightValue float64)) {
+	points := out.breakpoints()
+	for idx := 0; idx+1 < len(points); idx++ {
+		visit(points[idx], points[idx+1], out.value(points[idx]), out.value(points[idx+1]))
+	}
+}
+
+// maxima возвращает упорядоченные отрезки, на которых функция принадлежности μ(r) достигает максимума
+func (out OutputSet) maxima() [][2]float64 {
+	var height float64
+	for _, csq := range out.consequents {
+		height = math.Max(height, out.peak(csq))
+	}
+	if height == 0 {
+		return nil
+	}
+
+	var intervals [][2]float64
+	for _, csq := range out.consequents {
+		if out.peak(csq) < height {
+			continue
+		}
+		saturation := clamp(out.implication.Saturation(csq.strength))
+		interval := [2]float64{csq.center - 1 + saturation, csq.center + 1 - saturation}
+		if last := len(intervals) - 1; last >= 0 && interval[0] <= intervals[last][1] {
+			intervals[last][1] = math.Max(intervals[last][1], interval[1])
+			continue
+		}
+		intervals = append(intervals, interval)
+	}
+	return intervals
+}
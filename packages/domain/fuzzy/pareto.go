@@ -0,0 +1,155 @@
+package fuzzy
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"vehicles/packages/domain/models"
+)
+
+// ParetoCar - автомобиль на одном из фронтов Парето
+type ParetoCar struct {
+	// CarID - идентификатор автомобиля
+	CarID int
+	// Front - номер фронта, начиная с 1. Первый фронт содержит автомобили, которые не уступают ни одному
+	// другому автомобилю сразу по всем критериям
+	Front int
+	// Coefficients - коэффициенты автомобиля (ключ - название нечеткого множества)
+	Coefficients map[string]float64
+	// Unknown - критерии, коэффициенты которых нельзя вычислить из-за неизвестных характеристик. По таким
+	// критериям автомобиль считается худшим
+	Unknown []string
+	// DominatedBy - количество автомобилей, которые лучше этого автомобиля по всем критериям
+	DominatedBy int
+}
+
+// ParetoReport - автомобили, разбитые на последовательные фронты Парето
+type ParetoReport struct {
+	// Criteria - нечеткие множества, по коэффициентам которых сравниваются автомобили
+	Criteria []string
+	// Fronts - фронты Парето; автомобили внутри фронта в порядке их следования во входном срезе
+	Fronts [][]ParetoCar
+}
+
+// ParetoCriteria возвращает критерии по умолчанию: все нечеткие множества нечеткого алгоритма
+func (eng *Engine) ParetoCriteria() []string {
+	return eng.Variables.Names()
+}
+
+// ParetoFronts разбивает автомобили на фронты Парето по коэффициентам, не сводя их к одному числу. Первый фронт
+// содержит недоминируемые автомобили, второй - недоминируемые после удаления первого фронта и т.д. Автомобиль
+// доминирует другой, если он не хуже по всем критериям и лучше хотя бы по одному
+// Входные параметры: ctx - контекст, cars - автомобили, criteria - нечеткие множества, по коэффициентам которых
+// сравниваются автомобили; если не заданы, используются ParetoCriteria
+func (eng *Engine) ParetoFronts(ctx context.Context, cars []models.Car, criteria []string) (ParetoReport, error) {
+	if len(criteria) == 0 {
+		criteria = eng.ParetoCriteria()
+	}
+	seen := make(map[string]bool, len(criteria))
+	// lowerIsBetter - критерии, для которых меньший коэффициент лучше, например, расход топлива
+	lowerIsBetter := make(map[string]bool, len(criteria))
+	for _, criterion := range criteria {
+		variable, ok := eng.Variables.lookup(criterion)
+		if _, calculated := eng.Calculators[criterion]; !ok || !calculated {
+			return ParetoReport{}, fmt.Errorf("error, unknown fuzzy set %q", criterion)
+		}
+		lowerIsBetter[criterion] = variable.LowerIsBetter
+		if seen[criterion] {
+			return ParetoReport{}, fmt.Errorf("error, the fuzzy set %q is repeated", criterion)
+		}
+		seen[criterion] = true
+	}
+
+	formulas := eng.pinVersions().formulas
+	paretoCars := make([]ParetoCar, len(cars))
+	// scores - значения критериев, приведенные к виду "больше - лучше"
+	scores := make([][]float64, len(cars))
+	for idx, car := range cars {
+		if err := ctx.Err(); err != nil {
+			return ParetoReport{}, fmt.Errorf("error, the Pareto fronts are interrupted: %#v", err)
+		}
+		coefficients, confidences, _ := eng.calculateCoefficients(car, formulas)
+		paretoCars[idx] = ParetoCar{CarID: car.ID, Coefficients: make(map[string]float64, len(criteria))}
+		scores[idx] = make([]float64, len(criteria))
+		for jdx, criterion := range criteria {
+			paretoCars[idx].Coefficients[criterion] = coefficients[criterion]
+			switch {
+			case confidences[criterion] == 0:
+				paretoCars[idx].Unknown = append(paretoCars[idx].Unknown, criterion)
+				scores[idx][jdx] = math.Inf(-1)
+			case lowerIsBetter[criterion]:
+				scores[idx][jdx] = -coefficients[criterion]
+			default:
+				scores[idx][jdx] = coefficients[criterion]
+			}
+		}
+	}
+
+	// dominated - индексы автомобилей, которые доминирует каждый автомобиль
+	dominated := make([][]int, len(cars))
+	for idx := range cars {
+		if err := ctx.Err(); err != nil {
+			return ParetoReport{}, fmt.Errorf("error, the Pareto fronts are interrupted: %#v", err)
+		}
+		for jdx := idx + 1; jdx < len(cars); jdx++ {
+			switch {
+			case dominates(scores[idx], scores[jdx]):
+				dominated[idx] = append(dominated[idx], jdx)
+				paretoCars[jdx].DominatedBy++
+			case dominates(scores[jdx], scores[idx]):
+				dominated[jdx] = append(dominated[jdx], idx)
+				paretoCars[idx].DominatedBy++
+			}
+		}
+	}
+
+	// remaining - количество еще не распределенных автомобилей, доминирующих каждый автомобиль
+	remaining := make([]int, len(cars))
+	var front []int
+	for idx := range paretoCars {
+		remaining[idx] = paretoCars[idx].DominatedBy
+		if remaining[idx] == 0 {
+			front = append(front, idx)
+		}
+	}
+
+	report := ParetoReport{Criteria: criteria}
+	for len(front) > 0 {
+		// next - индексы автомобилей следующего фронта в порядке следования во входном срезе
+		next := make([]bool, len(cars))
+		current := make([]ParetoCar, 0, len(front))
+		for _, idx := range front {
+			paretoCars[idx].Front = len(report.Fronts) + 1
+			current = append(current, paretoCars[idx])
+			for _, jdx := range dominated[idx] {
+				if remaining[jdx]--; remaining[jdx] == 0 {
+					next[jdx] = true
+				}
+			}
+		}
+		report.Fronts = append(report.Fronts, current)
+
+		front = front[:0]
+		for idx, ok := range next {
+			if ok {
+				front = append(front, idx)
+			}
+		}
+	}
+	return report, nil
+}
+
+// dominates проверяет, что первый автомобиль не хуже второго по всем критериям и лучше хотя бы по одному
+// Входные параметры: first, second - значения критериев автомобилей, больше - лучше
+func dominates(first, second []float64) bool {
+	var better bool
+	for idx := range first {
+		if first[idx] < second[idx] {
+			return false
+		}
+		if first[idx] > second[idx] {
+			better = true
+		}
+	}
+	return better
+}
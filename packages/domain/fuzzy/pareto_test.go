@@ -0,0 +1,80 @@
+package fuzzy_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"vehicles/packages/domain/fuzzy"
+	"vehicles/packages/domain/models"
+)
+
+// TestParetoFronts проверяет фронты Парето, вычисленные вручную по расходу топлива (меньше - лучше) и комфорту:
+// равные автомобили не доминируют друг друга, а автомобиль с неизвестным коэффициентом считается худшим по этому
+// критерию, но может оставаться на первом фронте благодаря другому критерию
+func TestParetoFronts(t *testing.T) {
+	// coefficients - расход топлива и комфорт автомобилей; 0 - коэффициент нельзя вычислить
+	coefficients := map[int][2]float64{
+		1: {5, 10},
+		2: {5, 10},
+		3: {4, 5},
+		4: {8, 12},
+		5: {6, 8},
+		6: {0, 20},
+		7: {9, 0},
+		8: {7, 6},
+	}
+	coefficient := func(idx int) fuzzy.CoefficientFunc {
+		return func(car models.Car) float64 {
+			return coefficients[car.ID][idx]
+		}
+	}
+	engine := fuzzy.NewEngine(nil, nil)
+	engine.Calculators = map[string]fuzzy.CoefficientCalculator{fuzzy.Economy: coefficient(0),
+		fuzzy.Comfort: coefficient(1)}
+	cars := make([]models.Car, 0, len(coefficients))
+	for id := 1; id <= len(coefficients); id++ {
+		cars = append(cars, models.Car{ID: id})
+	}
+
+	report, err := engine.ParetoFronts(context.Background(), cars, []string{fuzzy.Economy, fuzzy.Comfort})
+	if err != nil {
+		t.Fatalf("error from `ParetoFronts` method: %#v", err)
+	}
+
+	// автомобиль 5 уступает равным автомобилям 1 и 2, автомобиль 8 - им и автомобилю 5, а автомобиль 7
+	// с неизвестным комфортом - всем автомобилям, кроме автомобиля 6 с неизвестным расходом топлива, поэтому
+	// он остается один на последнем фронте
+	type expectedCar struct {
+		id          int
+		dominatedBy int
+		unknown     []string
+	}
+	expected := [][]expectedCar{
+		{{1, 0, nil}, {2, 0, nil}, {3, 0, nil}, {4, 0, nil}, {6, 0, []string{fuzzy.Economy}}},
+		{{5, 2, nil}},
+		{{8, 3, nil}},
+		{{7, 6, []string{fuzzy.Comfort}}},
+	}
+	if len(report.Fronts) != len(expected) {
+		t.Fatalf("error, %d fronts, expected %d: %+v", len(report.Fronts), len(expected), report.Fronts)
+	}
+	for frontIdx, front := range report.Fronts {
+		if len(front) != len(expected[frontIdx]) {
+			t.Errorf("error, front %d is %+v, expected %+v", frontIdx+1, front, expected[frontIdx])
+			continue
+		}
+		for idx, car := range front {
+			want := expected[frontIdx][idx]
+			if car.CarID != want.id || car.Front != frontIdx+1 || car.DominatedBy != want.dominatedBy ||
+				!reflect.DeepEqual(car.Unknown, want.unknown) {
+				t.Errorf("error, front %d, car %+v, expected %+v", frontIdx+1, car, want)
+			}
+		}
+	}
+
+	for _, criteria := range [][]string{{"неизвестное"}, {fuzzy.Economy, fuzzy.Economy}, {fuzzy.Safety}} {
+		if _, err = engine.ParetoFronts(context.Background(), cars, criteria); err == nil {
+			t.Errorf("error, expected an error for the criteria %q", criteria)
+		}
+	}
+}
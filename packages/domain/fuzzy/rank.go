@@ -0,0 +1,128 @@
+package fuzzy
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"vehicles/packages/domain/models"
+)
+
+// CarError - ошибка нечеткого алгоритма для одного автомобиля
+type CarError struct {
+	// CarID - идентификатор автомобиля
+	CarID int
+	// Err - ошибка
+	Err error
+}
+
+// RankError - ошибки ранжирования. Ранжирование не прерывается из-за ошибки для одного автомобиля,
+// поэтому вместе с RankError возвращаются результаты для остальных автомобилей
+type RankError struct {
+	// Cars - ошибки для отдельных автомобилей в порядке их следования во входном срезе
+	Cars []CarError
+	// Skipped - количество автомобилей, которые не были оценены из-за отмены контекста
+	Skipped int
+	// Cause - ошибка контекста, если ранжирование было прервано, иначе nil
+	Cause error
+}
+
+// Error перечисляет ошибки ранжирования
+func (rke *RankError) Error() string {
+	messages := make([]string, 0, len(rke.Cars)+1)
+	if rke.Cause != nil {
+		messages = append(messages, fmt.Sprintf("ranking is interrupted, %d cars are skipped: %v", rke.Skipped, rke.Cause))
+	}
+	for _, carErr := range rke.Cars {
+		messages = append(messages, fmt.Sprintf("car %d: %v", carErr.CarID, carErr.Err))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap возвращает ошибку контекста, чтобы ее можно было проверить функцией errors.Is
+func (rke *RankError) Unwrap() error {
+	return rke.Cause
+}
+
+// workers возвращает количество горутин, оценивающих автомобили одновременно
+// Входной параметр: numberOfCars - количество автомобилей
+func (eng *Engine) workers(numberOfCars int) int {
+	workers := eng.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > numberOfCars {
+		workers = numberOfCars
+	}
+	return workers
+}
+
+// rankRules ранжирует автомобили по заданным нечетким правилам. Автомобили оцениваются одновременно
+// не более чем Workers горутинами. После отмены контекста новые автомобили не оцениваются. Возвращаются
+// результаты для всех оцененных автомобилей и, если были ошибки или отмена, ошибка типа *RankError
+// Входные параметры: ctx - контекст, cars - автомобили, rules - нечеткие правила
+func (eng *Engine) rankRules(ctx context.Context, cars []models.Car, rules []Rule) ([]Result, error) {
+	results := make([]Result, len(cars))
+	errs := make([]error, len(cars))
+	// scored - автомобиль оценен, успешно или с ошибкой
+	scored := make([]bool, len(cars))
+
+	// versions - версии функций принадлежности и формул коэффициентов, которыми оценивается весь рейтинг
+	versions := eng.pinVersions()
+	// jobs - индексы автомобилей, которые нужно оценить
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < eng.workers(len(cars)); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				// контекст мог быть отменен, пока индекс ждал в канале
+				if ctx.Err() != nil {
+					continue
+				}
+				results[idx], errs[idx] = eng.scoreRules(cars[idx], rules, versions)
+				scored[idx] = true
+			}
+		}()
+	}
+
+dispatch:
+	for idx := range cars {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobs <- idx:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	ranked := make([]Result, 0, len(cars))
+	rankErr := &RankError{}
+	for idx := range cars {
+		switch {
+		case !scored[idx]:
+			rankErr.Skipped++
+		case errs[idx] != nil:
+			rankErr.Cars = append(rankErr.Cars, CarError{CarID: cars[idx].ID,
+				Err: fmt.Errorf("error from `scoreRules` method, package `fuzzy`: %#v", errs[idx])})
+		default:
+			ranked = append(ranked, results[idx])
+		}
+	}
+	// порядок равных результатов такой же, как при последовательной оценке
+	sort.SliceStable(ranked, func(idx, jdx int) bool {
+		return ranked[idx].Value > ranked[jdx].Value
+	})
+
+	if rankErr.Skipped > 0 {
+		rankErr.Cause = ctx.Err()
+	}
+	if len(rankErr.Cars) > 0 || rankErr.Cause != nil {
+		return ranked, rankErr
+	}
+	return ranked, nil
+}
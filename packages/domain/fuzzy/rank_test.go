@@ -0,0 +1,237 @@
+package fuzzy_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"testing"
+	"time"
+	"vehicles/packages/domain/fuzzy"
+	"vehicles/packages/domain/models"
+)
+
+// randomCars создает автомобили со случайными характеристиками, достаточными для всех коэффициентов по умолчанию
+// Входной параметр: numberOfCars - количество автомобилей
+func randomCars(numberOfCars int) []models.Car {
+	random := rand.New(rand.NewSource(1))
+	between := func(low, high float64) float64 {
+		return low + random.Float64()*(high-low)
+	}
+	pick := func(values ...string) string {
+		return values[random.Intn(len(values))]
+	}
+	availability := func() models.Availability {
+		if random.Intn(2) == 0 {
+			return models.NoValue
+		}
+		return models.YesValue
+	}
+
+	cars := make([]models.Car, numberOfCars)
+	for idx := range cars {
+		car := models.NewCar()
+		car.Specs.MixedFuelConsumption = between(4, 16)
+		car.Specs.Acceleration0To100 = between(4, 20)
+		car.Specs.Engine.MaxPower = between(70, 400)
+		car.Specs.Mass = between(900, 2500)
+		car.Specs.Wheelbase = between(2300, 3100)
+		car.Specs.Length = between(3700, 5200)
+		car.Specs.Width = between(1600, 2000)
+		car.Specs.Height = between(1300, 1900)
+		car.Specs.GroundClearance = between(120, 220)
+		car.Specs.FrontTrackWidth = between(1400, 1700)
+		car.Specs.BackTrackWidth = between(1400, 1700)
+		car.Specs.TrunkVolume = between(200, 700)
+		car.Specs.CrashTestEstimate = between(0, 5)
+		car.Specs.Tires = models.Tires{FrontTiresWidth: 205, BackTiresWidth: 205, FrontTiresAspectRatio: 55,
+			BackTiresAspectRatio: 55, FrontTiresRimDiameter: 16, BackTiresRimDiameter: 16}
+		car.Specs.Drive = pick("Передний", "Задний", "Полный")
+		car.Specs.Gearbox = pick("АКПП 6", "МКПП 5", "Вариатор")
+		car.Specs.Suspension.FrontSuspension = pick("Независимая, амортизационная стойка типа МакФерсон",
+			"Независимая, на двойных поперечных рычагах")
+		car.Specs.Suspension.BackSuspension = pick("Полузависимая, торсионная балка", "Многорычажная, независимая")
+		car.Specs.Brakes.FrontBrakes = pick("Дисковые", "Дисковые вентилируемые")
+		car.Specs.Brakes.BackBrakes = pick("Дисковые", "Барабанные")
+		car.Features.SafetyAndMotionControlSystem.ABS = availability()
+		car.Features.SafetyAndMotionControlSystem.ESP = availability()
+		car.Features.Airbags.DriverAirbag = availability()
+		car.Features.Airbags.FrontPassengerAirbag = availability()
+		car.Features.CabinMicroclimate.AirConditioner = availability()
+		cars[idx] = car
+	}
+	return cars
+}
+
+// rankEngine создает нечеткий алгоритм со встроенными правилами, оценивающий автомобили в 4 горутинах
+func rankEngine(t *testing.T) *fuzzy.Engine {
+	rules, err := fuzzy.LoadEmbeddedRuleIndex(fuzzy.DefaultVariables(), nil)
+	if err != nil {
+		t.Fatalf("error from `LoadEmbeddedRuleIndex` function: %#v", err)
+	}
+	engine := fuzzy.NewEngine(rules, nil)
+	engine.Workers = 4
+	return engine
+}
+
+// TestRankCarError проверяет, что ошибка для одного автомобиля возвращается в RankError.Cars, а остальные
+// автомобили ранжируются
+func TestRankCarError(t *testing.T) {
+	engine := rankEngine(t)
+	cars := randomCars(20)
+	for idx := range cars {
+		cars[idx].ID = idx + 1
+	}
+	// правила дополнены условием для нечеткого множества без функций принадлежности. Условие проверяется, только
+	// если достоверность коэффициента больше нуля, а коэффициент не равен нулю только у автомобиля 7
+	engine.Rules = extraConditionRules{engine.Rules}
+	engine.Calculators[extraVariable] = fuzzy.CoefficientFunc(func(car models.Car) float64 {
+		if car.ID == 7 {
+			return 1
+		}
+		return 0
+	})
+
+	results, err := engine.Rank(context.Background(), cars, benchmarkPriorities)
+	var rankErr *fuzzy.RankError
+	if !errors.As(err, &rankErr) {
+		t.Fatalf("error, %#v is not *RankError", err)
+	}
+	if len(rankErr.Cars) != 1 || rankErr.Cars[0].CarID != 7 || rankErr.Skipped != 0 || rankErr.Cause != nil {
+		t.Fatalf("error, unexpected ranking errors: %v", rankErr)
+	}
+	if len(results) != len(cars)-1 {
+		t.Fatalf("error, %d cars are ranked, expected %d", len(results), len(cars)-1)
+	}
+	for idx, result := range results {
+		if result.CarID == 7 {
+			t.Errorf("error, the failed car is ranked")
+		}
+		if idx > 0 && result.Value > results[idx-1].Value {
+			t.Errorf("error, the car %d is ranked above a car with a lower value", results[idx-1].CarID)
+		}
+	}
+}
+
+// extraVariable - нечеткое множество, для которого нет функций принадлежности
+const extraVariable = "тест"
+
+// extraConditionRules добавляет к каждому правилу условие для нечеткого множества extraVariable
+type extraConditionRules struct {
+	fuzzy.RuleSource
+}
+
+func (ecr extraConditionRules) Rules(priorities []string) ([]fuzzy.Rule, error) {
+	rules, err := ecr.RuleSource.Rules(priorities)
+	if err != nil {
+		return nil, err
+	}
+	extended := make([]fuzzy.Rule, len(rules))
+	for idx, rule := range rules {
+		extended[idx] = rule
+		extended[idx].Conditions = append(append([]fuzzy.Condition{}, rule.Conditions...),
+			fuzzy.Condition{Variable: extraVariable, Term: "высокая"})
+	}
+	return extended, nil
+}
+
+// TestRankCancelled проверяет, что после отмены контекста автомобили не оцениваются, а ошибка содержит
+// количество пропущенных автомобилей и ошибку контекста
+func TestRankCancelled(t *testing.T) {
+	engine := rankEngine(t)
+	cars := randomCars(100)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := engine.Rank(ctx, cars, benchmarkPriorities)
+	var rankErr *fuzzy.RankError
+	if !errors.As(err, &rankErr) {
+		t.Fatalf("error, %#v is not *RankError", err)
+	}
+	if rankErr.Skipped == 0 {
+		t.Errorf("error, no cars are skipped after the context was cancelled")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error, %v is not context.Canceled", err)
+	}
+}
+
+// TestRankOrder проверяет, что параллельное ранжирование упорядочивает автомобили так же, как последовательное,
+// а автомобили с равными выходными значениями остаются в порядке входного среза
+func TestRankOrder(t *testing.T) {
+	cars := randomCars(15)
+	// каждый автомобиль повторяется дважды подряд, поэтому у соседних автомобилей равные выходные значения
+	twins := make([]models.Car, 0, 2*len(cars))
+	for _, car := range cars {
+		twins = append(twins, car, car)
+	}
+	for idx := range twins {
+		twins[idx].ID = idx + 1
+	}
+
+	engine := rankEngine(t)
+	parallel, err := engine.Rank(context.Background(), twins, benchmarkPriorities)
+	if err != nil {
+		t.Fatalf("error from `Rank` method: %#v", err)
+	}
+	engine.Workers = 1
+	sequential, err := engine.Rank(context.Background(), twins, benchmarkPriorities)
+	if err != nil {
+		t.Fatalf("error from `Rank` method: %#v", err)
+	}
+
+	if len(parallel) != len(sequential) {
+		t.Fatalf("error, %d cars are ranked in parallel, %d sequentially", len(parallel), len(sequential))
+	}
+	for idx := range parallel {
+		if parallel[idx].CarID != sequential[idx].CarID {
+			t.Fatalf("error, place %d: car %d in parallel, car %d sequentially", idx+1, parallel[idx].CarID,
+				sequential[idx].CarID)
+		}
+		if idx > 0 && parallel[idx].Value == parallel[idx-1].Value && parallel[idx].CarID < parallel[idx-1].CarID {
+			t.Errorf("error, the car %d with an equal value is ranked above the car %d", parallel[idx-1].CarID,
+				parallel[idx].CarID)
+		}
+	}
+}
+
+// BenchmarkRank сравнивает время последовательного (workers=1) и параллельного (workers=GOMAXPROCS)
+// ранжирования 1000 и 10000 автомобилей по 243 правилам методом дефаззификации по умолчанию. Метрика
+// speedup - ускорение относительно последовательного ранжирования тех же автомобилей. Автомобили создаются
+// случайно; тот же бенчмарк на автомобилях из БД vehicles - BenchmarkRankDatabaseCars пакета gateway
+func BenchmarkRank(b *testing.B) {
+	rules, err := fuzzy.LoadEmbeddedRuleIndex(fuzzy.DefaultVariables(), nil)
+	if err != nil {
+		b.Fatalf("error from `LoadEmbeddedRuleIndex` function: %#v", err)
+	}
+
+	for _, numberOfCars := range []int{1000, 10000} {
+		cars := randomCars(numberOfCars)
+		for idx := range cars {
+			cars[idx].ID = idx
+		}
+		// sequential - время одного последовательного ранжирования в наносекундах
+		var sequential float64
+		for _, workers := range []int{1, runtime.GOMAXPROCS(0)} {
+			engine := fuzzy.NewEngine(rules, nil)
+			engine.Workers = workers
+
+			b.Run(fmt.Sprintf("cars=%d/workers=%d", numberOfCars, workers), func(b *testing.B) {
+				start := time.Now()
+				for idx := 0; idx < b.N; idx++ {
+					if _, err := engine.Rank(context.Background(), cars, benchmarkPriorities); err != nil {
+						b.Fatalf("error from `Rank` method: %#v", err)
+					}
+				}
+				perRank := float64(time.Since(start).Nanoseconds()) / float64(b.N)
+				if workers == 1 {
+					sequential = perRank
+				}
+				if sequential > 0 {
+					b.ReportMetric(sequential/perRank, "speedup")
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package fuzzy
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Condition - условие "ЕСЛИ" нечеткого правила, например, "экономичность низкий"
+type Condition struct {
+	// Variable - название нечеткого множества
+	Variable string
+	// Term - название нечеткого подмножества
+	Term string
+}
+
+// связки условий "ЕСЛИ" нечеткого правила
+const (
+	// OperatorAnd - условия соединены связкой "И", степень истинности правила вычисляется t-нормой
+	OperatorAnd = "AND"
+	// OperatorOr - условия соединены связкой "ИЛИ", степень истинности правила вычисляется s-нормой
+	OperatorOr = "OR"
+)
+
+// Rule - нечеткое правило
+type Rule struct {
+	// Conditions - условия "ЕСЛИ"
+	Conditions []Condition
+	// Operator - связка условий: OperatorAnd (пустое значение означает то же самое) или OperatorOr
+	Operator string
+	// Recommendation - значение, которое определяет, насколько сильно будет рекомендоваться автомобиль,
+	// например 1,2,3, и т.д. Это значение принадлежит нечеткому множеству "рекомендация"
+	Recommendation int
+}
+
+// RuleSource предоставляет нечеткие правила для расстановки приоритетов
+type RuleSource interface {
+	// Rules возвращает нечеткие правила, соответствующие приоритетам
+	// Входной параметр: priorities - приоритеты, расставленные пользователем
+	Rules(priorities []string) ([]Rule, error)
+}
+
+// файлы priorities.txt и rules/*_rules.txt генерируются из описания правил в каталоге rulebase
+//go:generate go run ../../../cmd/rulec build -out . rulebase
+
+// embeddedRules - файл priorities.txt, содержащий все возможные расстановки приоритетов, и файлы с нечеткими правилами,
+// встроенные в исполняемый файл
+//
+//go:embed priorities.txt rules/*_rules.txt
+var embeddedRules embed.FS
+
+// terms - шкала из трех нечетких подмножеств, которую используют файлы с нечеткими правилами и нечеткие
+// множества по умолчанию (см. TermScale)
+var terms = []string{Low, Medium, High}
+
+type ruleIndex struct {
+	// rules содержит в качестве ключей - расстановку приоритетов, например,
+	// "экономичность безопасность динамика", а в качестве значений - нечеткие правила для этой расстановки
+	rules map[string][]Rule
+	// known - нечеткие множества, которые встречаются в расстановках приоритетов файлов
+	known map[string]bool
+	// variables - нечеткие множества, шкалы которых сравниваются со шкалой файлов
+	variables *VariableSet
+	// generator строит правила для расстановок, которых нет в файлах (nil - такие расстановки не поддерживаются)
+	generator RuleSource
+}
+
+// LoadEmbeddedRuleIndex загружает в память нечеткие правила, встроенные в исполняемый файл
+// Входные параметры: variables - нечеткие множества со шкалами, generator - источник правил для расстановок,
+// которых нет в файлах (nil - без него, см. LoadRuleIndex)
+func LoadEmbeddedRuleIndex(variables *VariableSet, generator RuleSource) (RuleSource, error) {
+	return LoadRuleIndex(embeddedRules, variables, generator)
+}
+
+// ReadEmbeddedRuleFiles читает нечеткие правила, встроенные в исполняемый файл, без проверки правил
+func ReadEmbeddedRuleFiles() ([]RuleSet, error) {
+	return ReadRuleFiles(embeddedRules)
+}
+
+// LoadRuleIndex один раз читает файл priorities.txt и все файлы с нечеткими правилами, проверяет их
+// и хранит правила в памяти. Правила для расстановок с нечеткими множествами, которых нет в файлах, или с измененной
+// шкалой берутся у generator, только если он задан, например, NewRuleGenerator(LexicographicTemplate{})
+// Входные параметры: fsys - файловая система, содержащая файл priorities.txt и каталог rules,
+// например, os.DirFS(dir), variables - нечеткие множества со шкалами, generator - источник правил для расстановок,
+// которых нет в файлах (nil - без него)
+func LoadRuleIndex(fsys fs.FS, variables *VariableSet, generator RuleSource) (RuleSource, error) {
+	ruleSets, err := ReadRuleFiles(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("error from `ReadRuleFiles` function, package `fuzzy`: %#v", err)
+	}
+
+	// файлы описаны шкалой из 3 нечетких подмножеств, поэтому проверяются по шкалам по умолчанию
+	if errs := DefaultVariables().ValidateRuleBase(ruleSets); len(errs) > 0 {
+		return nil, fmt.Errorf("error from `ValidateRuleBase` method, package `fuzzy`: %v (%d errors in total)",
+			errs[0], len(errs))
+	}
+
+	rdx := &ruleIndex{rules: make(map[string][]Rule, len(ruleSets)), known: make(map[string]bool),
+		variables: variables, generator: generator}
+	for _, ruleSet := range ruleSets {
+		rdx.rules[strings.Join(ruleSet.Priorities, " ")] = ruleSet.Rules
+		for _, variable := range ruleSet.Priorities {
+			rdx.known[variable] = true
+		}
+	}
+	return rdx, nil
+}
+
+// ReadRuleFiles читает файл priorities.txt и все файлы с нечеткими правилами без проверки правил
+// Входной параметр: fsys - файловая система, содержащая файл priorities.txt и каталог rules
+func ReadRuleFiles(fsys fs.FS) ([]RuleSet, error) {
+	// файл priorities.txt содержит все возможные расстановки приоритетов.
+	// Набор этих расстановок является "размещением" (термин комбинаторики)
+	lines, err := readLines(fsys, "priorities.txt")
+	if err != nil {
+		return nil, fmt.Errorf("error from `readLines` function, package `fuzzy`: %#v", err)
+	}
+
+	ruleSets := make([]RuleSet, 0, len(lines))
+	for idx, line := range lines {
+		fileName := path.Join("rules", fmt.Sprintf("%d_rules.txt", idx+1))
+		ruleLines, err := readLines(fsys, fileName)
+		if err != nil {
+			return nil, fmt.Errorf("error from `readLines` function, package `fuzzy`: %#v", err)
+		}
+
+		rules := make([]Rule, 0, len(ruleLines))
+		for _, ruleLine := range ruleLines {
+			rule, err := ParseRule(ruleLine)
+			if err != nil {
+				return nil, fmt.Errorf("error from `ParseRule` function, package `fuzzy`, file %s: %#v", fileName, err)
+			}
+			rules = append(rules, rule)
+		}
+		ruleSets = append(ruleSets, RuleSet{Priorities: strings.Fields(line), Rules: rules, Position: fileName})
+	}
+	return ruleSets, nil
+}
+
+// Rules возвращает из памяти нечеткие правила, соответствующие приоритетам.
+// Возвращаемый срез общий для всех вызовов и не должен изменяться. Если расстановка содержит нечеткое множество,
+// которого нет в файлах (например, добавленное в набор позже, см. NewVariableSet), или шкала одного из приоритетов
+// изменена (см. VariableSet.WithTermScale), то правила берутся у источника generator, а без него возвращается ошибка.
+// Расстановка из нечетких множеств файлов со шкалой из 3 нечетких подмножеств, для которой нет файла, - ошибка
+// базы правил, поэтому для нее всегда возвращается ошибка
+// Входной параметр: priorities - приоритеты, расставленные пользователем
+func (rdx *ruleIndex) Rules(priorities []string) ([]Rule, error) {
+	prioritiesStr := strings.Join(priorities, " ")
+	standard := rdx.variables.hasStandardScale(priorities)
+	if rules, ok := rdx.rules[prioritiesStr]; ok && standard {
+		return rules, nil
+	}
+	if standard && rdx.knownVariables(priorities) {
+		return nil, fmt.Errorf("error, there is no rule set for priorities %q in the rule base", prioritiesStr)
+	}
+	if rdx.generator == nil {
+		return nil, fmt.Errorf("error, there is no rule set for priorities %q and generated rules are disabled",
+			prioritiesStr)
+	}
+
+	rules, err := rdx.generator.Rules(priorities)
+	if err != nil {
+		return nil, fmt.Errorf("error, there is no rule set for priorities %q: %v", prioritiesStr, err)
+	}
+	return rules, nil
+}
+
+// knownVariables проверяет, что все нечеткие множества встречаются в расстановках приоритетов файлов
+// Входной параметр: priorities - приоритеты, расставленные пользователем
+func (rdx *ruleIndex) knownVariables(priorities []string) bool {
+	for _, variable := range priorities {
+		if !rdx.known[variable] {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseRule разбирает строку нечеткого правила, например,
+// "экономичность низкий безопасность низкий динамика высокий 3". Правило, условия которого соединены
+// связкой "ИЛИ", начинается со слова OR: "OR экономичность высокий динамика высокий 3"
+// Входной параметр: line - строка нечеткого правила
+func ParseRule(line string) (Rule, error) {
+	parts := strings.Fields(line)
+	var operator string
+	if len(parts) > 0 && parts[0] == OperatorOr {
+		operator, parts = OperatorOr, parts[1:]
+	}
+	if len(parts) < 3 || len(parts)%2 == 0 {
+		return Rule{}, fmt.Errorf("error, malformed rule %q", line)
+	}
+
+	rule := Rule{Conditions: make([]Condition, 0, len(parts)/2), Operator: operator}
+	for idx := 0; idx+1 < len(parts); idx += 2 {
+		rule.Conditions = append(rule.Conditions, Condition{Variable: parts[idx], Term: parts[idx+1]})
+	}
+
+	var err error
+	rule.Recommendation, err = strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return Rule{}, fmt.Errorf("error from `Atoi` function, package `strconv`: %#v", err)
+	}
+	return rule, nil
+}
+
+// readLines читает непустые строки файла
+// Входные параметры: fsys - файловая система, fileName - имя файла
+func readLines(fsys fs.FS, fileName string) ([]string, error) {
+	file, err := fsys.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("error from `Open` method, package `fs`: %#v", err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error from `Err` method, package `bufio`: %#v", err)
+	}
+	return lines, nil
+}
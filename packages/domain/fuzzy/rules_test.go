@@ -0,0 +1,52 @@
+package fuzzy_test
+
+import (
+	"testing"
+	"testing/fstest"
+	"vehicles/packages/domain/fuzzy"
+)
+
+// TestRuleIndexMissingRules проверяет, что правила для расстановки с нечетким множеством, которого нет в файлах,
+// строятся только при заданном источнике generator, а расстановка из нечетких множеств файлов без своего файла
+// всегда считается ошибкой базы правил
+func TestRuleIndexMissingRules(t *testing.T) {
+	fsys := fstest.MapFS{
+		"priorities.txt": {Data: []byte(fuzzy.Economy + "\n" + fuzzy.Dynamics + "\n")},
+		"rules/1_rules.txt": {Data: []byte(fuzzy.Economy + " низкий 1\n" + fuzzy.Economy + " средний 2\n" +
+			fuzzy.Economy + " высокий 3\n")},
+		"rules/2_rules.txt": {Data: []byte(fuzzy.Dynamics + " низкий 1\n" + fuzzy.Dynamics + " средний 2\n" +
+			fuzzy.Dynamics + " высокий 3\n")},
+	}
+	variables := fuzzy.DefaultVariables()
+	withoutGenerator, err := fuzzy.LoadRuleIndex(fsys, variables, nil)
+	if err != nil {
+		t.Fatalf("error from `LoadRuleIndex` function: %#v", err)
+	}
+	withGenerator, err := fuzzy.LoadRuleIndex(fsys, variables,
+		fuzzy.NewRuleGenerator(fuzzy.LexicographicTemplate{}, variables))
+	if err != nil {
+		t.Fatalf("error from `LoadRuleIndex` function: %#v", err)
+	}
+
+	tests := []struct {
+		name       string
+		rules      fuzzy.RuleSource
+		priorities []string
+		ok         bool
+	}{
+		{"расстановка из файла", withoutGenerator, []string{fuzzy.Economy}, true},
+		{"нечеткое множество не из файлов без генератора", withoutGenerator, []string{fuzzy.Practicality}, false},
+		{"нечеткое множество не из файлов с генератором", withGenerator, []string{fuzzy.Practicality}, true},
+		{"нет файла для расстановки без генератора", withoutGenerator, []string{fuzzy.Economy, fuzzy.Dynamics}, false},
+		{"нет файла для расстановки с генератором", withGenerator, []string{fuzzy.Economy, fuzzy.Dynamics}, false},
+	}
+	for _, test := range tests {
+		rules, err := test.rules.Rules(test.priorities)
+		if test.ok && (err != nil || len(rules) == 0) {
+			t.Errorf("%s: %d rules, error %v", test.name, len(rules), err)
+		}
+		if !test.ok && err == nil {
+			t.Errorf("%s: expected an error, got %d rules", test.name, len(rules))
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package fuzzy
+
+import (
+	"fmt"
+	"math"
+)
+
+// TermScale возвращает шкалу из 3, 5 или 7 нечетких подмножеств от худшего к лучшему, например,
+// "очень_низкий", "низкий", "средний", "высокий", "очень_высокий" для 5 термов
+// Входной параметр: numberOfTerms - количество нечетких подмножеств
+func TermScale(numberOfTerms int) ([]string, error) {
+	switch numberOfTerms {
+	case 3:
+		return []string{Low, Medium, High}, nil
+	case 5:
+		return []string{VeryLow, Low, Medium, High, VeryHigh}, nil
+	case 7:
+		return []string{ExtremelyLow, VeryLow, Low, Medium, High, VeryHigh, ExtremelyHigh}, nil
+	}
+	return nil, fmt.Errorf("error, there is no scale of %d fuzzy subsets, expected 3, 5 or 7", numberOfTerms)
+}
+
+// UniformMemberships строит функции принадлежности, равномерно разбивающие диапазон коэффициента: крайние
+// нечеткие подмножества - трапеции, открытые наружу, остальные - треугольники. В каждой точке сумма значений
+// функций принадлежности равна 1
+// Входные параметры: terms - нечеткие подмножества от худшего к лучшему, min, max - диапазон коэффициента,
+// lowerIsBetter - меньший коэффициент лучше; тогда лучшее подмножество соответствует началу диапазона
+func UniformMemberships(terms []string, min, max float64, lowerIsBetter bool) (map[string]MembershipFunction, error) {
+	if len(terms) < 2 {
+		return nil, fmt.Errorf("error, the scale has less than 2 fuzzy subsets")
+	}
+	if !(min < max) {
+		return nil, fmt.Errorf("error, the range [%v, %v] of the coefficient is empty", min, max)
+	}
+
+	step := (max - min) / float64(len(terms)-1)
+	// centers - вершины функций принадлежности по возрастанию коэффициента
+	centers := make([]float64, len(terms))
+	for idx := range centers {
+		centers[idx] = min + step*float64(idx)
+	}
+	last := len(terms) - 1
+
+	memberships := make(map[string]MembershipFunction, len(terms))
+	for idx, term := range terms {
+		position := idx
+		if lowerIsBetter {
+			position = last - idx
+		}
+		switch position {
+		case 0:
+			memberships[term] = Trapezoidal{math.Inf(-1), math.Inf(-1), centers[0], centers[1]}
+		case last:
+			memberships[term] = Trapezoidal{centers[last-1], centers[last], math.Inf(1), math.Inf(1)}
+		default:
+			memberships[term] = Triangular{centers[position-1], centers[position], centers[position+1]}
+		}
+	}
+	return memberships, nil
+}
+
+// WithTermScale возвращает копию набора, в которой шкала нечеткого множества заменена шкалой из 3, 5 или 7
+// нечетких подмножеств (см. TermScale) с функциями принадлежности UniformMemberships на диапазоне Variable.Range.
+// Правила для расстановок приоритетов с этим нечетким множеством строятся генератором правил (см. GenerateRuleSet),
+// так как файлы с правилами описаны шкалой из 3 нечетких подмножеств. Исходный набор не изменяется
+// Входные параметры: name - название нечеткого множества, numberOfTerms - количество нечетких подмножеств
+func (vs *VariableSet) WithTermScale(name string, numberOfTerms int) (*VariableSet, error) {
+	for idx, variable := range vs.variables {
+		if variable.Name != name {
+			continue
+		}
+		if len(variable.Terms) == numberOfTerms {
+			return vs, nil
+		}
+
+		scale, err := TermScale(numberOfTerms)
+		if err != nil {
+			return nil, fmt.Errorf("error from `TermScale` function, package `fuzzy`: %#v", err)
+		}
+		memberships, err := UniformMemberships(scale, variable.Range[0], variable.Range[1], variable.LowerIsBetter)
+		if err != nil {
+			return nil, fmt.Errorf("error from `UniformMemberships` function, package `fuzzy`, fuzzy set %q: %#v", name, err)
+		}
+		copied := &VariableSet{variables: vs.Variables()}
+		copied.variables[idx].Terms, copied.variables[idx].Memberships = scale, memberships
+		return copied, nil
+	}
+	return nil, fmt.Errorf("error, unknown fuzzy set %q", name)
+}
+
+// terms возвращает нечеткие подмножества нечеткого множества от худшего к лучшему или шкалу
+// из 3 нечетких подмножеств, если нечеткого множества нет в наборе
+// Входной параметр: name - название нечеткого множества
+func (vs *VariableSet) terms(name string) []string {
+	if variable, ok := vs.lookup(name); ok {
+		return variable.Terms
+	}
+	return terms
+}
+
+// hasStandardScale проверяет, что все нечеткие множества используют шкалу из 3 нечетких подмножеств,
+// которой описаны файлы с нечеткими правилами
+// Входной параметр: variables - названия нечетких множеств
+func (vs *VariableSet) hasStandardScale(variables []string) bool {
+	for _, variable := range variables {
+		scale := vs.terms(variable)
+		if len(scale) != len(terms) {
+			return false
+		}
+		for idx, term := range terms {
+			if scale[idx] != term {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isTerm проверяет, является ли строка названием нечеткого подмножества нечеткого множества
+// Входные параметры: variable - название нечеткого множества, term - название нечеткого подмножества
+func (vs *VariableSet) isTerm(variable, term string) bool {
+	for _, known := range vs.terms(variable) {
+		if known == term {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,228 @@
+package fuzzy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"vehicles/packages/domain/models"
+)
+
+const (
+	// DefaultSensitivityDelta - доля, на которую по умолчанию уменьшается и увеличивается вес каждого приоритета
+	DefaultSensitivityDelta = 0.25
+	// DefaultSensitivityTopN - количество первых мест рейтинга, устойчивость которых проверяется по умолчанию
+	DefaultSensitivityTopN = 5
+)
+
+// Scenario - сценарий анализа чувствительности: веса приоритетов, измененные относительно исходных
+type Scenario struct {
+	// Name - описание изменения, например, "динамика -25%"
+	Name string
+	// Variables - нечеткие множества, веса которых изменены; пусто для исходных весов
+	Variables []string
+	// Weights - веса приоритетов (ключ - название нечеткого множества)
+	Weights map[string]float64
+}
+
+// CarSensitivity - изменение места одного автомобиля в рейтинге по сценариям
+type CarSensitivity struct {
+	// CarID - идентификатор автомобиля
+	CarID int
+	// BaseRank - место при исходных весах, начиная с 1
+	BaseRank int
+	// Ranks - места в каждом сценарии в порядке SensitivityReport.Scenarios
+	Ranks []int
+	// MinRank, MaxRank - лучшее и худшее место по всем сценариям
+	MinRank, MaxRank int
+	// Volatility - стандартное отклонение места по всем сценариям
+	Volatility float64
+	// TopCount - количество сценариев, в которых автомобиль попадает в первые TopN мест
+	TopCount int
+	// AlwaysInTop - автомобиль попадает в первые TopN мест во всех сценариях
+	AlwaysInTop bool
+	// InfluentialVariable - нечеткое множество, изменение веса которого сильнее всего сдвигает автомобиль;
+	// пусто, если место не изменяется
+	InfluentialVariable string
+	// Influence - средний сдвиг места при изменении веса InfluentialVariable
+	Influence float64
+}
+
+// SensitivityReport - результат анализа чувствительности рейтинга к приоритетам
+type SensitivityReport struct {
+	// Scenarios - сценарии; первый сценарий содержит исходные веса
+	Scenarios []Scenario
+	// TopN - количество первых мест рейтинга, устойчивость которых проверяется
+	TopN int
+	// Cars - автомобили, упорядоченные по месту при исходных весах
+	Cars []CarSensitivity
+	// StableTop - идентификаторы автомобилей, которые попадают в первые TopN мест во всех сценариях
+	StableTop []int
+	// Excluded - идентификаторы автомобилей, которые не удалось оценить хотя бы в одном сценарии; они не входят
+	// в Cars, потому что их места нельзя сравнить по всем сценариям
+	Excluded []int
+}
+
+// SensitivityScenarios строит сценарии анализа чувствительности: исходные веса, уменьшение и увеличение веса
+// каждого приоритета на долю delta и перестановки соседних по важности приоритетов
+// Входные параметры: weights - исходные веса приоритетов (ключ - название нечеткого множества),
+// delta - доля изменения веса, от 0 до 1
+func (vs *VariableSet) SensitivityScenarios(weights map[string]float64, delta float64) ([]Scenario, error) {
+	priorities, err := vs.WeightedPriorities(weights)
+	if err != nil {
+		return nil, fmt.Errorf("error from `WeightedPriorities` method, package `fuzzy`: %#v", err)
+	}
+	if delta <= 0 || delta >= 1 {
+		return nil, fmt.Errorf("error, the weight change %v is not between 0 and 1", delta)
+	}
+
+	// changed возвращает копию исходных весов с измененными весами
+	changed := func(update map[string]float64) map[string]float64 {
+		copied := make(map[string]float64, len(priorities))
+		for _, variable := range priorities {
+			copied[variable] = weights[variable]
+		}
+		for variable, weight := range update {
+			copied[variable] = weight
+		}
+		return copied
+	}
+
+	scenarios := []Scenario{{Name: "исходные приоритеты", Weights: changed(nil)}}
+	percent := math.Round(delta * 100)
+	for _, variable := range priorities {
+		scenarios = append(scenarios,
+			Scenario{Name: fmt.Sprintf("%s -%g%%", variable, percent), Variables: []string{variable},
+				Weights: changed(map[string]float64{variable: weights[variable] * (1 - delta)})},
+			Scenario{Name: fmt.Sprintf("%s +%g%%", variable, percent), Variables: []string{variable},
+				Weights: changed(map[string]float64{variable: weights[variable] * (1 + delta)})})
+	}
+	for idx := 0; idx+1 < len(priorities); idx++ {
+		first, second := priorities[idx], priorities[idx+1]
+		scenarios = append(scenarios, Scenario{Name: fmt.Sprintf("%s <-> %s", first, second), Variables: []string{first, second},
+			Weights: changed(map[string]float64{first: weights[second], second: weights[first]})})
+	}
+	return scenarios, nil
+}
+
+// Sensitivity ранжирует одни и те же автомобили в каждом сценарии и сравнивает места автомобилей
+// Входные параметры: ctx - контекст, cars - автомобили, scenarios - сценарии (первый содержит исходные веса),
+// topN - количество первых мест рейтинга, устойчивость которых проверяется
+func (eng *Engine) Sensitivity(ctx context.Context, cars []models.Car, scenarios []Scenario,
+	topN int) (SensitivityReport, error) {
+	if len(scenarios) == 0 {
+		return SensitivityReport{}, fmt.Errorf("error, there are no scenarios")
+	}
+	if topN <= 0 {
+		return SensitivityReport{}, fmt.Errorf("error, the number of top places %d is not positive", topN)
+	}
+
+	// ranks - места автомобилей в каждом сценарии (ключ - идентификатор автомобиля, индекс - номер сценария;
+	// 0, если в сценарии автомобиль не оценен)
+	ranks := make(map[int][]int, len(cars))
+	for _, car := range cars {
+		ranks[car.ID] = make([]int, len(scenarios))
+	}
+	for scenarioIdx, scenario := range scenarios {
+		results, err := eng.RankWeighted(ctx, cars, scenario.Weights)
+		// ошибки для отдельных автомобилей не мешают сравнить места остальных
+		var rankErr *RankError
+		if err != nil && !(errors.As(err, &rankErr) && rankErr.Cause == nil) {
+			return SensitivityReport{}, fmt.Errorf("error from `RankWeighted` method, package `fuzzy`: %#v", err)
+		}
+		for idx, result := range results {
+			ranks[result.CarID][scenarioIdx] = idx + 1
+		}
+	}
+
+	report := SensitivityReport{Scenarios: scenarios, TopN: topN, Cars: make([]CarSensitivity, 0, len(ranks))}
+	for _, car := range cars {
+		carRanks := ranks[car.ID]
+		if !rankedInAll(carRanks) {
+			report.Excluded = append(report.Excluded, car.ID)
+			continue
+		}
+		report.Cars = append(report.Cars, carSensitivity(car.ID, carRanks, scenarios, topN))
+	}
+	sort.Slice(report.Cars, func(idx, jdx int) bool {
+		return report.Cars[idx].BaseRank < report.Cars[jdx].BaseRank
+	})
+	for _, car := range report.Cars {
+		if car.AlwaysInTop {
+			report.StableTop = append(report.StableTop, car.CarID)
+		}
+	}
+	return report, nil
+}
+
+// rankedInAll проверяет, что автомобиль оценен во всех сценариях
+// Входной параметр: ranks - места автомобиля в каждом сценарии
+func rankedInAll(ranks []int) bool {
+	for _, rank := range ranks {
+		if rank == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// carSensitivity вычисляет показатели устойчивости места одного автомобиля
+// Входные параметры: carID - идентификатор автомобиля, ranks - места в каждом сценарии, scenarios - сценарии,
+// topN - количество первых мест рейтинга
+func carSensitivity(carID int, ranks []int, scenarios []Scenario, topN int) CarSensitivity {
+	car := CarSensitivity{CarID: carID, BaseRank: ranks[0], Ranks: ranks, MinRank: ranks[0], MaxRank: ranks[0]}
+
+	var sum float64
+	for _, rank := range ranks {
+		car.MinRank = minInt(car.MinRank, rank)
+		car.MaxRank = maxInt(car.MaxRank, rank)
+		sum += float64(rank)
+		if rank <= topN {
+			car.TopCount++
+		}
+	}
+	car.AlwaysInTop = car.TopCount == len(ranks)
+
+	mean := sum / float64(len(ranks))
+	var squares float64
+	for _, rank := range ranks {
+		squares += (float64(rank) - mean) * (float64(rank) - mean)
+	}
+	car.Volatility = math.Sqrt(squares / float64(len(ranks)))
+
+	// shifts - суммарный сдвиг места и количество сценариев, изменяющих вес нечеткого множества
+	shifts := make(map[string][2]float64)
+	var order []string
+	for idx, scenario := range scenarios {
+		for _, variable := range scenario.Variables {
+			if _, ok := shifts[variable]; !ok {
+				order = append(order, variable)
+			}
+			shift := shifts[variable]
+			shifts[variable] = [2]float64{shift[0] + math.Abs(float64(ranks[idx]-car.BaseRank)), shift[1] + 1}
+		}
+	}
+	for _, variable := range order {
+		if influence := shifts[variable][0] / shifts[variable][1]; influence > car.Influence {
+			car.InfluentialVariable, car.Influence = variable, influence
+		}
+	}
+	return car
+}
+
+// minInt возвращает меньшее из двух целых чисел
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// maxInt возвращает большее из двух целых чисел
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
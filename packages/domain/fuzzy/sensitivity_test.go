@@ -0,0 +1,187 @@
+package fuzzy_test
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"vehicles/packages/domain/fuzzy"
+	"vehicles/packages/domain/models"
+)
+
+// TestSensitivityScenarios проверяет веса сценариев: исходные веса, уменьшение и увеличение веса каждого
+// приоритета с ненулевым весом и перестановки соседних по важности приоритетов
+func TestSensitivityScenarios(t *testing.T) {
+	tests := []struct {
+		name      string
+		weights   map[string]float64
+		delta     float64
+		scenarios []fuzzy.Scenario
+	}{
+		{
+			name:    "два приоритета",
+			weights: map[string]float64{fuzzy.Economy: 2, fuzzy.Dynamics: 1},
+			delta:   0.25,
+			scenarios: []fuzzy.Scenario{
+				{Name: "исходные приоритеты", Weights: map[string]float64{fuzzy.Economy: 2, fuzzy.Dynamics: 1}},
+				{Name: fuzzy.Economy + " -25%", Variables: []string{fuzzy.Economy},
+					Weights: map[string]float64{fuzzy.Economy: 1.5, fuzzy.Dynamics: 1}},
+				{Name: fuzzy.Economy + " +25%", Variables: []string{fuzzy.Economy},
+					Weights: map[string]float64{fuzzy.Economy: 2.5, fuzzy.Dynamics: 1}},
+				{Name: fuzzy.Dynamics + " -25%", Variables: []string{fuzzy.Dynamics},
+					Weights: map[string]float64{fuzzy.Economy: 2, fuzzy.Dynamics: 0.75}},
+				{Name: fuzzy.Dynamics + " +25%", Variables: []string{fuzzy.Dynamics},
+					Weights: map[string]float64{fuzzy.Economy: 2, fuzzy.Dynamics: 1.25}},
+				{Name: fuzzy.Economy + " <-> " + fuzzy.Dynamics, Variables: []string{fuzzy.Economy, fuzzy.Dynamics},
+					Weights: map[string]float64{fuzzy.Economy: 1, fuzzy.Dynamics: 2}},
+			},
+		},
+		{
+			name:    "нулевой вес не изменяется и не входит в сценарии",
+			weights: map[string]float64{fuzzy.Economy: 1, fuzzy.Dynamics: 0, fuzzy.Comfort: 3},
+			delta:   0.5,
+			scenarios: []fuzzy.Scenario{
+				{Name: "исходные приоритеты", Weights: map[string]float64{fuzzy.Comfort: 3, fuzzy.Economy: 1}},
+				{Name: fuzzy.Comfort + " -50%", Variables: []string{fuzzy.Comfort},
+					Weights: map[string]float64{fuzzy.Comfort: 1.5, fuzzy.Economy: 1}},
+				{Name: fuzzy.Comfort + " +50%", Variables: []string{fuzzy.Comfort},
+					Weights: map[string]float64{fuzzy.Comfort: 4.5, fuzzy.Economy: 1}},
+				{Name: fuzzy.Economy + " -50%", Variables: []string{fuzzy.Economy},
+					Weights: map[string]float64{fuzzy.Comfort: 3, fuzzy.Economy: 0.5}},
+				{Name: fuzzy.Economy + " +50%", Variables: []string{fuzzy.Economy},
+					Weights: map[string]float64{fuzzy.Comfort: 3, fuzzy.Economy: 1.5}},
+				{Name: fuzzy.Comfort + " <-> " + fuzzy.Economy, Variables: []string{fuzzy.Comfort, fuzzy.Economy},
+					Weights: map[string]float64{fuzzy.Comfort: 1, fuzzy.Economy: 3}},
+			},
+		},
+		{
+			name:    "один приоритет",
+			weights: map[string]float64{fuzzy.Safety: 1},
+			delta:   0.1,
+			scenarios: []fuzzy.Scenario{
+				{Name: "исходные приоритеты", Weights: map[string]float64{fuzzy.Safety: 1}},
+				{Name: fuzzy.Safety + " -10%", Variables: []string{fuzzy.Safety},
+					Weights: map[string]float64{fuzzy.Safety: 0.9}},
+				{Name: fuzzy.Safety + " +10%", Variables: []string{fuzzy.Safety},
+					Weights: map[string]float64{fuzzy.Safety: 1.1}},
+			},
+		},
+		{name: "нулевая доля изменения", weights: map[string]float64{fuzzy.Economy: 1}, delta: 0},
+		{name: "доля изменения не меньше 1", weights: map[string]float64{fuzzy.Economy: 1}, delta: 1},
+		{name: "неизвестное нечеткое множество", weights: map[string]float64{"неизвестное": 1}, delta: 0.25},
+		{name: "все веса нулевые", weights: map[string]float64{fuzzy.Economy: 0}, delta: 0.25},
+	}
+
+	variables := fuzzy.DefaultVariables()
+	for _, test := range tests {
+		scenarios, err := variables.SensitivityScenarios(test.weights, test.delta)
+		if test.scenarios == nil {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %d scenarios", test.name, len(scenarios))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error from `SensitivityScenarios` method: %#v", test.name, err)
+			continue
+		}
+		if len(scenarios) != len(test.scenarios) {
+			t.Errorf("%s: %d scenarios, expected %d", test.name, len(scenarios), len(test.scenarios))
+			continue
+		}
+		for idx, scenario := range scenarios {
+			expected := test.scenarios[idx]
+			if scenario.Name != expected.Name || !reflect.DeepEqual(scenario.Variables, expected.Variables) ||
+				!equalWeights(scenario.Weights, expected.Weights) {
+				t.Errorf("%s: scenario %d is %+v, expected %+v", test.name, idx, scenario, expected)
+			}
+		}
+	}
+}
+
+// equalWeights сравнивает веса приоритетов с точностью до ошибки округления
+func equalWeights(first, second map[string]float64) bool {
+	if len(first) != len(second) {
+		return false
+	}
+	for variable, weight := range first {
+		if expected, ok := second[variable]; !ok || math.Abs(weight-expected) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+// TestSensitivity проверяет места автомобилей по сценариям, показатели их устойчивости и исключение автомобиля,
+// который не удалось оценить в одном из сценариев. Коэффициенты 1, 2, 3 попадают в ядра нечетких подмножеств
+// "низкий", "средний", "высокий", поэтому при взвешенном среднем выходное значение равно рекомендации
+// единственного сработавшего правила или среднему рекомендаций правил, условия которых пропущены
+func TestSensitivity(t *testing.T) {
+	// levels - коэффициенты экономичности, динамики и комфорта автомобилей; коэффициент комфорта 0 означает,
+	// что данных нет, и условие пропускается, а для ненулевого не хватает функций принадлежности
+	levels := map[int][3]float64{1: {3, 1, 0}, 2: {1, 3, 0}, 3: {2, 2, 0}, 4: {1, 1, 1}}
+	coefficient := func(idx int) fuzzy.CoefficientFunc {
+		return func(car models.Car) float64 {
+			return levels[car.ID][idx]
+		}
+	}
+	crisp := map[string]fuzzy.MembershipFunction{
+		fuzzy.Low:    fuzzy.Triangular{A: 0, B: 1, C: 2},
+		fuzzy.Medium: fuzzy.Triangular{A: 1, B: 2, C: 3},
+		fuzzy.High:   fuzzy.Triangular{A: 2, B: 3, C: 4},
+	}
+
+	engine := fuzzy.NewEngine(nil, nil)
+	engine.Defuzzifier = fuzzy.WeightedAverage{}
+	engine.Calculators = map[string]fuzzy.CoefficientCalculator{fuzzy.Economy: coefficient(0),
+		fuzzy.Dynamics: coefficient(1), fuzzy.Comfort: coefficient(2)}
+	engine.Memberships = fuzzy.NewMembershipTable("test", map[string]map[string]fuzzy.MembershipFunction{
+		fuzzy.Economy: crisp, fuzzy.Dynamics: crisp})
+	cars := make([]models.Car, 0, len(levels))
+	for id := 1; id <= len(levels); id++ {
+		cars = append(cars, models.Car{ID: id})
+	}
+
+	// рекомендации при весах 2 и 1 (9 правил): автомобиль 1 - 6, автомобиль 2 - 4, автомобиль 3 - 5, автомобиль 4,
+	// который занимает последнее место во всех сценариях, где его удалось оценить, - 1;
+	// при весах 1 и 2: 4, 6, 5; при весах 2, 1, 1 (27 правил) и пропущенном комфорте - средние
+	// рекомендаций трех правил: (14+17+21)/3, (8+11+14)/3, (11+14+17)/3
+	scenarios := []fuzzy.Scenario{
+		{Name: "исходные приоритеты", Weights: map[string]float64{fuzzy.Economy: 2, fuzzy.Dynamics: 1}},
+		{Name: fuzzy.Economy + " <-> " + fuzzy.Dynamics, Variables: []string{fuzzy.Economy, fuzzy.Dynamics},
+			Weights: map[string]float64{fuzzy.Economy: 1, fuzzy.Dynamics: 2}},
+		{Name: fuzzy.Comfort, Variables: []string{fuzzy.Comfort},
+			Weights: map[string]float64{fuzzy.Economy: 2, fuzzy.Dynamics: 1, fuzzy.Comfort: 1}},
+	}
+	report, err := engine.Sensitivity(context.Background(), cars, scenarios, 2)
+	if err != nil {
+		t.Fatalf("error from `Sensitivity` method: %#v", err)
+	}
+
+	expected := []fuzzy.CarSensitivity{
+		{CarID: 1, BaseRank: 1, Ranks: []int{1, 3, 1}, MinRank: 1, MaxRank: 3, Volatility: math.Sqrt(8) / 3,
+			TopCount: 2, InfluentialVariable: fuzzy.Economy, Influence: 2},
+		{CarID: 3, BaseRank: 2, Ranks: []int{2, 2, 2}, MinRank: 2, MaxRank: 2, TopCount: 3, AlwaysInTop: true},
+		{CarID: 2, BaseRank: 3, Ranks: []int{3, 1, 3}, MinRank: 1, MaxRank: 3, Volatility: math.Sqrt(8) / 3,
+			TopCount: 1, InfluentialVariable: fuzzy.Economy, Influence: 2},
+	}
+	if len(report.Cars) != len(expected) {
+		t.Fatalf("error, %d cars in the report, expected %d", len(report.Cars), len(expected))
+	}
+	for idx, car := range report.Cars {
+		want := expected[idx]
+		if math.Abs(car.Volatility-want.Volatility) > 1e-9 {
+			t.Errorf("error, the volatility of the car %d is %v, expected %v", car.CarID, car.Volatility, want.Volatility)
+		}
+		car.Volatility = want.Volatility
+		if !reflect.DeepEqual(car, want) {
+			t.Errorf("error, place %d is %+v, expected %+v", idx+1, car, want)
+		}
+	}
+	if !reflect.DeepEqual(report.StableTop, []int{3}) {
+		t.Errorf("error, the stable top is %v, expected [3]", report.StableTop)
+	}
+	if !reflect.DeepEqual(report.Excluded, []int{4}) {
+		t.Errorf("error, the excluded cars are %v, expected [4]", report.Excluded)
+	}
+}
@@ -0,0 +1,207 @@
+package fuzzy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// DefaultFootprintSpread - множитель стандартного отклонения ответов пользователей, на который нижняя и верхняя
+// функции принадлежности отстоят от доли пользователей, выбравших нечеткое подмножество
+const DefaultFootprintSpread = 0.5
+
+// maxKarnikMendelIterations - максимальное количество итераций алгоритма Карника-Менделя. Алгоритм сходится
+// не более чем за количество правил итераций, ограничение защищает от зацикливания из-за ошибок округления
+const maxKarnikMendelIterations = 1000
+
+// IntervalMembership - интервальная функция принадлежности второго типа: степень принадлежности значения
+// коэффициента нечеткому подмножеству - отрезок между нижней и верхней функциями принадлежности.
+// Область между ними (след неопределенности) отражает расхождение мнений пользователей
+type IntervalMembership struct {
+	// Lower - нижняя функция принадлежности
+	Lower MembershipFunction
+	// Upper - верхняя функция принадлежности
+	Upper MembershipFunction
+}
+
+// Bounds вычисляет нижнюю и верхнюю степени принадлежности, ограниченные отрезком [0, 1]. Если подобранные
+// функции пересекаются, то в точке пересечения они меняются ролями, поэтому нижняя степень не больше верхней
+// Входной параметр: x - значение коэффициента
+func (im IntervalMembership) Bounds(x float64) (float64, float64) {
+	lower, upper := clamp(im.Lower.Value(x)), clamp(im.Upper.Value(x))
+	if lower > upper {
+		return upper, lower
+	}
+	return lower, upper
+}
+
+// intervalBound - нижняя или верхняя функция принадлежности интервальной функции как функция первого типа
+type intervalBound struct {
+	interval IntervalMembership
+	upper    bool
+}
+
+// Value вычисляет нижнюю или верхнюю степень принадлежности
+func (ib intervalBound) Value(x float64) float64 {
+	lower, upper := ib.interval.Bounds(x)
+	if ib.upper {
+		return upper
+	}
+	return lower
+}
+
+// IntervalFitResult - интервальная функция принадлежности, подобранная по ответам пользователей
+type IntervalFitResult struct {
+	// Lower - нижняя функция принадлежности и точки, по которым она подобрана
+	Lower FitResult
+	// Upper - верхняя функция принадлежности и точки, по которым она подобрана
+	Upper FitResult
+}
+
+// FootprintPoints строит точки нижней и верхней функций принадлежности по точкам эмпирической функции
+// принадлежности. Ответ каждого пользователя - 1, если он выбрал нечеткое подмножество, и 0 иначе, поэтому
+// при доле p стандартное отклонение ответов равно √(p(1-p)): оно равно нулю, когда все пользователи согласны,
+// и максимально, когда мнения разделились поровну
+// Входные параметры: points - точки эмпирической функции принадлежности, spread - множитель стандартного отклонения
+func FootprintPoints(points []CurvePoint, spread float64) ([]CurvePoint, []CurvePoint) {
+	lower := make([]CurvePoint, 0, len(points))
+	upper := make([]CurvePoint, 0, len(points))
+	for _, point := range points {
+		deviation := spread * math.Sqrt(point.Degree*(1-point.Degree))
+		lower = append(lower, CurvePoint{X: point.X, Degree: clamp(point.Degree - deviation)})
+		upper = append(upper, CurvePoint{X: point.X, Degree: clamp(point.Degree + deviation)})
+	}
+	return lower, upper
+}
+
+// FitIntervalMemberships подбирает методом Левенберга-Марквардта нижние и верхние функции принадлежности
+// по ответам пользователей (см. FootprintPoints). Тип функций и начальное приближение выбираются так же,
+// как в FitMemberships
+// Входные параметры: answers - ответы пользователей, current - текущие функции принадлежности,
+// spread - множитель стандартного отклонения ответов
+func FitIntervalMemberships(answers []SurveyAnswers, current map[string]map[string]MembershipFunction,
+	spread float64) ([]IntervalFitResult, error) {
+	if spread < 0 {
+		return nil, fmt.Errorf("error, the footprint spread %v is negative", spread)
+	}
+
+	curves := EmpiricalCurves(answers)
+	variables := make([]string, 0, len(curves))
+	for variable := range curves {
+		variables = append(variables, variable)
+	}
+	sort.Strings(variables)
+
+	var results []IntervalFitResult
+	for _, variable := range variables {
+		for _, term := range terms {
+			lowerPoints, upperPoints := FootprintPoints(curves[variable][term], spread)
+			lower, err := fitCurve(variable, term, lowerPoints, current[variable][term])
+			if err != nil {
+				return nil, fmt.Errorf("error from `fitCurve` function, package `fuzzy`, lower function of the fuzzy subset %q of the fuzzy set %q: %#v",
+					term, variable, err)
+			}
+			upper, err := fitCurve(variable, term, upperPoints, current[variable][term])
+			if err != nil {
+				return nil, fmt.Errorf("error from `fitCurve` function, package `fuzzy`, upper function of the fuzzy subset %q of the fuzzy set %q: %#v",
+					term, variable, err)
+			}
+			results = append(results, IntervalFitResult{Lower: lower, Upper: upper})
+		}
+	}
+	return results, nil
+}
+
+// BuildIntervalMemberships создает интервальные функции принадлежности по подобранным нижним и верхним функциям
+// Входной параметр: results - подобранные функции принадлежности
+func BuildIntervalMemberships(results []IntervalFitResult) (map[string]map[string]IntervalMembership, error) {
+	intervals := make(map[string]map[string]IntervalMembership)
+	for _, result := range results {
+		lower, err := NewMembershipFunction(result.Lower.Definition)
+		if err != nil {
+			return nil, fmt.Errorf("error from `NewMembershipFunction` function, package `fuzzy`: %#v", err)
+		}
+		upper, err := NewMembershipFunction(result.Upper.Definition)
+		if err != nil {
+			return nil, fmt.Errorf("error from `NewMembershipFunction` function, package `fuzzy`: %#v", err)
+		}
+
+		variable, term := result.Lower.Definition.Variable, result.Lower.Definition.Term
+		if intervals[variable] == nil {
+			intervals[variable] = make(map[string]IntervalMembership)
+		}
+		intervals[variable][term] = IntervalMembership{Lower: lower, Upper: upper}
+	}
+	return intervals, nil
+}
+
+// intervalBounds возвращает нижние и верхние функции принадлежности всех нечетких подмножеств. Для подмножеств
+// без интервальной функции нижняя и верхняя функции совпадают с функцией первого типа
+// Входные параметры: memberships - функции принадлежности первого типа, intervals - интервальные функции
+func intervalBounds(memberships map[string]map[string]MembershipFunction,
+	intervals map[string]map[string]IntervalMembership) (map[string]map[string]MembershipFunction, map[string]map[string]MembershipFunction) {
+	lower := make(map[string]map[string]MembershipFunction, len(memberships))
+	upper := make(map[string]map[string]MembershipFunction, len(memberships))
+	for variable, functions := range memberships {
+		lower[variable] = make(map[string]MembershipFunction, len(functions))
+		upper[variable] = make(map[string]MembershipFunction, len(functions))
+		for term, function := range functions {
+			lower[variable][term], upper[variable][term] = function, function
+			if interval, ok := intervals[variable][term]; ok {
+				lower[variable][term] = intervalBound{interval: interval}
+				upper[variable][term] = intervalBound{interval: interval, upper: true}
+			}
+		}
+	}
+	return lower, upper
+}
+
+// KarnikMendel выполняет понижение типа методом центра множеств алгоритмом Карника-Менделя: находит наименьшее
+// и наибольшее взвешенное среднее рекомендаций, когда степень истинности каждого правила пробегает отрезок
+// от нижней до верхней. Левый конец получается, когда правила с рекомендацией не выше текущего среднего берутся
+// с верхней степенью истинности, а остальные - с нижней; правый - наоборот. Если ни одно правило не сработало,
+// возвращаются нули
+// Входные параметры: lower, upper - нижние и верхние степени истинности правил, recommendations - рекомендации правил
+func KarnikMendel(lower, upper []float64, recommendations []int) (float64, float64) {
+	return karnikMendelEndpoint(lower, upper, recommendations, true),
+		karnikMendelEndpoint(lower, upper, recommendations, false)
+}
+
+// karnikMendelEndpoint вычисляет левый или правый конец отрезка, полученного понижением типа
+// Входные параметры: lower, upper - нижние и верхние степени истинности правил, recommendations - рекомендации правил,
+// left - вычисляется левый конец
+func karnikMendelEndpoint(lower, upper []float64, recommendations []int, left bool) float64 {
+	// начальное приближение - взвешенное среднее по серединам отрезков степеней истинности
+	var enumerator, denominator float64
+	for idx := range recommendations {
+		strength := (lower[idx] + upper[idx]) / 2
+		enumerator += strength * float64(recommendations[idx])
+		denominator += strength
+	}
+	if denominator == 0 {
+		return 0
+	}
+	value := enumerator / denominator
+
+	for iteration := 0; iteration < maxKarnikMendelIterations; iteration++ {
+		enumerator, denominator = 0, 0
+		for idx, recommendation := range recommendations {
+			strength := lower[idx]
+			if (float64(recommendation) <= value) == left {
+				strength = upper[idx]
+			}
+			enumerator += strength * float64(recommendation)
+			denominator += strength
+		}
+		if denominator == 0 {
+			return value
+		}
+
+		next := enumerator / denominator
+		if math.Abs(next-value) < 1e-12 {
+			return next
+		}
+		value = next
+	}
+	return value
+}
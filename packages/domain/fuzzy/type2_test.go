@@ -0,0 +1,124 @@
+package fuzzy_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"vehicles/packages/domain/fuzzy"
+)
+
+// TestKarnikMendel проверяет концы отрезка, полученного понижением типа, для симметричных следов неопределенности,
+// концы которых известны аналитически, и для совпадающих нижних и верхних степеней истинности, при которых отрезок
+// вырождается в точку - взвешенное среднее первого типа
+func TestKarnikMendel(t *testing.T) {
+	tests := []struct {
+		name            string
+		lower, upper    []float64
+		recommendations []int
+		left, right     float64
+	}{
+		{
+			// левый конец: (0.75*1 + 0.25*3) / 1, правый: (0.25*1 + 0.75*3) / 1
+			name:  "два правила с одинаковыми отрезками",
+			lower: []float64{0.25, 0.25}, upper: []float64{0.75, 0.75}, recommendations: []int{1, 3},
+			left: 1.5, right: 2.5,
+		},
+		{
+			// левый конец: (0.4*1 + 0.6*2 + 0.2*3) / (0.4 + 0.6 + 0.2) = 11/6, правый симметричен относительно 2
+			name:  "три правила, след неопределенности симметричен относительно 2",
+			lower: []float64{0.2, 0.6, 0.2}, upper: []float64{0.4, 0.8, 0.4}, recommendations: []int{1, 2, 3},
+			left: 11.0 / 6, right: 13.0 / 6,
+		},
+		{
+			// (0.3*1 + 0.7*5 + 0.1*9) / (0.3 + 0.7 + 0.1)
+			name:  "нижние степени истинности равны верхним",
+			lower: []float64{0.3, 0.7, 0.1}, upper: []float64{0.3, 0.7, 0.1}, recommendations: []int{1, 5, 9},
+			left: 4.7 / 1.1, right: 4.7 / 1.1,
+		},
+		{
+			name:  "ни одно правило не сработало",
+			lower: []float64{0, 0}, upper: []float64{0, 0}, recommendations: []int{1, 9},
+		},
+	}
+	for _, test := range tests {
+		left, right := fuzzy.KarnikMendel(test.lower, test.upper, test.recommendations)
+		if math.Abs(left-test.left) > 1e-9 || math.Abs(right-test.right) > 1e-9 {
+			t.Errorf("%s: [%v, %v], expected [%v, %v]", test.name, left, right, test.left, test.right)
+		}
+	}
+
+	strengths, recommendations := []float64{0.3, 0.7, 0.1}, []int{1, 5, 9}
+	centroid := fuzzy.WeightedAverage{}.Defuzzify(strengths, recommendations)
+	if left, right := fuzzy.KarnikMendel(strengths, strengths, recommendations); left != right ||
+		math.Abs(left-centroid) > 1e-9 {
+		t.Errorf("error, [%v, %v] does not collapse to the type-1 centroid %v", left, right, centroid)
+	}
+}
+
+// TestIntervalMembershipsDegenerate проверяет, что нечеткий алгоритм с интервальными функциями принадлежности,
+// нижние и верхние функции которых совпадают с функциями первого типа, оценивает автомобили так же, как нечеткий
+// алгоритм первого типа с методом взвешенного среднего
+func TestIntervalMembershipsDegenerate(t *testing.T) {
+	typeOne := rankEngine(t).WithDefuzzifier(fuzzy.WeightedAverage{})
+	typeTwo := rankEngine(t)
+	typeTwo.IntervalMemberships = make(map[string]map[string]fuzzy.IntervalMembership)
+	for variable, functions := range typeTwo.Memberships.Functions() {
+		typeTwo.IntervalMemberships[variable] = make(map[string]fuzzy.IntervalMembership, len(functions))
+		for term, function := range functions {
+			typeTwo.IntervalMemberships[variable][term] = fuzzy.IntervalMembership{Lower: function, Upper: function}
+		}
+	}
+
+	cars := randomCars(10)
+	for idx := range cars {
+		cars[idx].ID = idx + 1
+	}
+	expected, err := typeOne.Rank(context.Background(), cars, benchmarkPriorities)
+	if err != nil {
+		t.Fatalf("error from `Rank` method: %#v", err)
+	}
+	results, err := typeTwo.Rank(context.Background(), cars, benchmarkPriorities)
+	if err != nil {
+		t.Fatalf("error from `Rank` method: %#v", err)
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("error, %d cars are ranked, expected %d", len(results), len(expected))
+	}
+	for idx, result := range results {
+		explanation := result.Explanation
+		if result.CarID != expected[idx].CarID || math.Abs(result.Value-expected[idx].Value) > 1e-9 ||
+			math.Abs(explanation.LowerValue-explanation.UpperValue) > 1e-9 {
+			t.Errorf("error, place %d: car %d with %v in [%v, %v], expected car %d with %v", idx+1, result.CarID,
+				result.Value, explanation.LowerValue, explanation.UpperValue, expected[idx].CarID, expected[idx].Value)
+		}
+	}
+}
+
+// TestFootprintPoints проверяет, что нижняя и верхняя точки отстоят от доли пользователей на spread стандартных
+// отклонений ответов, совпадают с ней, когда все пользователи согласны, и не выходят за отрезок [0, 1]
+func TestFootprintPoints(t *testing.T) {
+	points := []fuzzy.CurvePoint{{X: 1, Degree: 0}, {X: 2, Degree: 1}, {X: 3, Degree: 0.5}, {X: 4, Degree: 0.1}}
+	tests := []struct {
+		spread       float64
+		lower, upper []float64
+	}{
+		// √(0.5*0.5) = 0.5, √(0.1*0.9) = 0.3
+		{spread: 0.5, lower: []float64{0, 1, 0.25, 0}, upper: []float64{0, 1, 0.75, 0.25}},
+		{spread: 0, lower: []float64{0, 1, 0.5, 0.1}, upper: []float64{0, 1, 0.5, 0.1}},
+		{spread: 2, lower: []float64{0, 1, 0, 0}, upper: []float64{0, 1, 1, 0.7}},
+	}
+	for _, test := range tests {
+		lower, upper := fuzzy.FootprintPoints(points, test.spread)
+		if len(lower) != len(points) || len(upper) != len(points) {
+			t.Fatalf("spread %v: %d lower and %d upper points, expected %d", test.spread, len(lower), len(upper),
+				len(points))
+		}
+		for idx, point := range points {
+			if lower[idx].X != point.X || upper[idx].X != point.X ||
+				math.Abs(lower[idx].Degree-test.lower[idx]) > 1e-9 || math.Abs(upper[idx].Degree-test.upper[idx]) > 1e-9 {
+				t.Errorf("spread %v, x = %v: [%v, %v], expected [%v, %v]", test.spread, point.X, lower[idx].Degree,
+					upper[idx].Degree, test.lower[idx], test.upper[idx])
+			}
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package fuzzy
+
+import (
+	"fmt"
+	"strings"
+	"vehicles/packages/domain/models"
+)
+
+const (
+	// MaxPriorities - наибольшее количество приоритетов в одной расстановке. Количество нечетких правил
+	// растет как 3^k, поэтому автомобили ранжируются не более чем по MaxPriorities нечетким множествам
+	MaxPriorities = 5
+	// MaxRules - наибольшее количество нечетких правил для одной расстановки приоритетов: произведение
+	// количеств нечетких подмножеств приоритетов, например, 5^5 для пяти приоритетов со шкалой из 5 термов
+	MaxRules = 3125
+)
+
+// Variable - лингвистическая переменная (нечеткое множество), по которой ранжируются автомобили.
+// Нечеткие подмножества оценивают автомобиль: "высокий" всегда означает лучшее значение, например,
+// низкий расход топлива для экономичности и низкие расходы для стоимости владения
+type Variable struct {
+	// Name - название нечеткого множества. Используется в нечетких правилах, поэтому не содержит пробелов
+	Name string
+	// Title - название для страницы расстановки приоритетов, например, "Стоимость владения"
+	Title string
+	// Calculator - вычислитель коэффициента
+	Calculator CoefficientCalculator
+	// Terms - нечеткие подмножества от худшего к лучшему (см. TermScale). Если не заданы, используются
+	// "низкий", "средний", "высокий"
+	Terms []string
+	// Memberships - функции принадлежности нечетких подмножеств Terms
+	Memberships map[string]MembershipFunction
+	// LowerIsBetter - меньший коэффициент лучше, например, расход топлива или время разгона
+	LowerIsBetter bool
+	// Range - типичные наименьшее и наибольшее значения коэффициента. По ним WithTermScale строит функции
+	// принадлежности шкал из 5 и 7 термов
+	Range [2]float64
+}
+
+// VariableSet - нечеткие множества, по которым ранжируются автомобили, в порядке добавления. Этот порядок сохраняется
+// при равных весах приоритетов и на странице расстановки приоритетов. Набор не изменяется после создания,
+// поэтому его можно использовать из нескольких горутин; набор с другими шкалами создается методом WithTermScale.
+// Набор создается один раз при запуске программы и передается нечеткому алгоритму (см. NewEngine)
+// и источникам правил
+type VariableSet struct {
+	variables []Variable
+}
+
+// NewVariableSet проверяет нечеткие множества и создает из них набор: нечеткий алгоритм с этим набором вычисляет
+// их коэффициенты, правила для расстановок приоритетов с ними строятся автоматически (см. WeightedRules),
+// а страница расстановки приоритетов предлагает их пользователю
+// Входной параметр: variables - нечеткие множества
+func NewVariableSet(variables []Variable) (*VariableSet, error) {
+	set := &VariableSet{variables: make([]Variable, 0, len(variables))}
+	for _, variable := range variables {
+		if variable.Name == "" || strings.ContainsAny(variable.Name, " \t\n,") {
+			return nil, fmt.Errorf("error, the fuzzy set name %q is empty or contains spaces or commas", variable.Name)
+		}
+		if set.isVariable(variable.Name) {
+			return nil, fmt.Errorf("error, the fuzzy set %q is repeated", variable.Name)
+		}
+		if variable.Calculator == nil {
+			return nil, fmt.Errorf("error, the fuzzy set %q has no coefficient calculator", variable.Name)
+		}
+		if len(variable.Terms) == 0 {
+			variable.Terms = terms
+		}
+		if len(variable.Terms) < 2 {
+			return nil, fmt.Errorf("error, the fuzzy set %q has less than 2 fuzzy subsets", variable.Name)
+		}
+		for _, term := range variable.Terms {
+			if variable.Memberships[term] == nil {
+				return nil, fmt.Errorf("error, the fuzzy subset %q of the fuzzy set %q has no membership function",
+					term, variable.Name)
+			}
+		}
+		if variable.Title == "" {
+			variable.Title = variable.Name
+		}
+		set.variables = append(set.variables, variable)
+	}
+	return set, nil
+}
+
+// DefaultVariables создает набор нечетких множеств по умолчанию (см. defaultVariables) со шкалами
+// из 3 нечетких подмножеств
+func DefaultVariables() *VariableSet {
+	set, err := NewVariableSet(defaultVariables())
+	if err != nil {
+		panic(err)
+	}
+	return set
+}
+
+// Variables возвращает нечеткие множества набора в порядке добавления
+func (vs *VariableSet) Variables() []Variable {
+	return append([]Variable(nil), vs.variables...)
+}
+
+// Names возвращает названия нечетких множеств набора в порядке добавления
+func (vs *VariableSet) Names() []string {
+	names := make([]string, 0, len(vs.variables))
+	for _, variable := range vs.variables {
+		names = append(names, variable.Name)
+	}
+	return names
+}
+
+// lookup ищет нечеткое множество набора по названию
+// Входной параметр: name - название нечеткого множества
+func (vs *VariableSet) lookup(name string) (Variable, bool) {
+	for _, variable := range vs.variables {
+		if variable.Name == name {
+			return variable, true
+		}
+	}
+	return Variable{}, false
+}
+
+// isVariable проверяет, является ли строка названием нечеткого множества набора
+func (vs *VariableSet) isVariable(name string) bool {
+	_, ok := vs.lookup(name)
+	return ok
+}
+
+// ValidatePriorities проверяет расстановку приоритетов: приоритеты - нечеткие множества набора,
+// не повторяются, их не больше MaxPriorities, а нечетких правил для них не больше MaxRules
+// Входной параметр: priorities - приоритеты, расставленные пользователем
+func (vs *VariableSet) ValidatePriorities(priorities []string) error {
+	if len(priorities) == 0 || len(priorities) > MaxPriorities {
+		return fmt.Errorf("error, the number of priorities %d is not between 1 and %d", len(priorities), MaxPriorities)
+	}
+	seen := make(map[string]bool, len(priorities))
+	numberOfRules := 1
+	for _, priority := range priorities {
+		if !vs.isVariable(priority) {
+			return fmt.Errorf("error, unknown fuzzy set %q", priority)
+		}
+		if seen[priority] {
+			return fmt.Errorf("error, the fuzzy set %q is repeated", priority)
+		}
+		seen[priority] = true
+		numberOfRules *= len(vs.terms(priority))
+	}
+	if numberOfRules > MaxRules {
+		return fmt.Errorf("error, priorities %q require %d rules, at most %d are allowed",
+			strings.Join(priorities, " "), numberOfRules, MaxRules)
+	}
+	return nil
+}
+
+// defaultVariables возвращает нечеткие множества по умолчанию. Значения параметров функций принадлежности первых
+// пяти нечетких множеств были получены путем аппроксимации точек методом Левенберга-Марквардта с помощью пакета
+// "Lmfit" ЯП "Python", функции принадлежности остальных заданы по границам типичных значений коэффициентов
+func defaultVariables() []Variable {
+	return []Variable{
+		{
+			Name:  Economy,
+			Title: "Экономичность",
+			// коэффициент экономичности или расход топлива в смешанном цикле в литрах на 100 км
+			Calculator: fieldCalculator{CoefficientFunc(func(car models.Car) float64 {
+				return car.Specs.MixedFuelConsumption
+			}), []DataField{numericField(MixedFuelConsumptionField, true)}},
+			Memberships: map[string]MembershipFunction{
+				Low:    Sigmoid{1.043723139993038, 0.5194913435480255, 11.165188013054621},
+				Medium: Gaussian{2.2900397063026374, 9.43414665796981, 2.4138470099365112},
+				High:   Sigmoid{1.949834151590793, -0.3804532441502327, 5.188639378787266},
+			},
+			LowerIsBetter: true,
+			Range:         [2]float64{4, 16},
+		},
+		{
+			Name:  Dynamics,
+			Title: "Динамика",
+			// коэффициент динамики или время разгона в секундах до 100 км/ч
+			Calculator: fieldCalculator{CoefficientFunc(func(car models.Car) float64 {
+				return car.Specs.Acceleration0To100
+			}), []DataField{numericField(Acceleration0To100Field, true)}},
+			Memberships: map[string]MembershipFunction{
+				Low:    Sigmoid{1.0231319819933777, 0.5016231903133455, 13.44547910618538},
+				Medium: Gaussian{4.59765854168931, 10.810654375352698, 3.529577571232097},
+				High:   Sigmoid{1.1836613715914706, -0.3792245799359442, 7.418367289135995},
+			},
+			LowerIsBetter: true,
+			Range:         [2]float64{4, 20},
+		},
+		{
+			Name:       Handling,
+			Title:      "Управляемость",
+			Calculator: handlingCalculator,
+			Memberships: map[string]MembershipFunction{
+				Low:    Sigmoid{1.2027418825694678, -0.07501884950616336, 22.4088071782321},
+				Medium: Gaussian{31.124751770295614, 44.305695848946904, 19.042293165507264},
+				High:   Sigmoid{1.3245201627428753, 0.07214432798281176, 69.8908258450921},
+			},
+			Range: [2]float64{10, 90},
+		},
+		{
+			Name:       Comfort,
+			Title:      "Комфорт",
+			Calculator: comfortCalculator,
+			Memberships: map[string]MembershipFunction{
+				Low:    Sigmoid{1.1834768835495801, -0.2870468773928149, 5.7724209993240825},
+				Medium: Gaussian{5.0422852289029185, 10.10928688292464, 4.210219040980836},
+				High:   Sigmoid{1.2492396969207602, 0.27009941927484593, 14.702080359730674},
+			},
+			Range: [2]float64{2, 20},
+		},
+		{
+			Name:       Safety,
+			Title:      "Безопасность",
+			Calculator: safetyCalculator,
+			Memberships: map[string]MembershipFunction{
+				Low:    Sigmoid{1.3490219429573107, -0.21934076866027877, 4.73473258614666},
+				Medium: Gaussian{5.450821590257078, 10.048764235757659, 4.185552288427339},
+				High:   Sigmoid{1.2799644032509998, 0.3119397892443973, 15.65152657451626},
+			},
+			Range: [2]float64{2, 20},
+		},
+		{
+			Name:       Practicality,
+			Title:      "Практичность",
+			Calculator: practicalityCalculator,
+			Memberships: map[string]MembershipFunction{
+				Low:    Trapezoidal{0, 0, 11, 15},
+				Medium: Triangular{11, 15.5, 20},
+				High:   Trapezoidal{16, 20, 100, 100},
+			},
+			Range: [2]float64{9, 22},
+		},
+		{
+			Name:       RunningCosts,
+			Title:      "Стоимость владения",
+			Calculator: runningCostsCalculator,
+			Memberships: map[string]MembershipFunction{
+				Low:    Trapezoidal{250, 400, 100000, 100000},
+				Medium: Triangular{120, 220, 400},
+				High:   Trapezoidal{0, 0, 120, 220},
+			},
+			LowerIsBetter: true,
+			Range:         [2]float64{80, 450},
+		},
+		{
+			Name:       OffRoad,
+			Title:      "Проходимость",
+			Calculator: offRoadCalculator,
+			Memberships: map[string]MembershipFunction{
+				Low:    Trapezoidal{0, 0, 15, 20},
+				Medium: Triangular{15, 22, 29},
+				High:   Trapezoidal{24, 30, 100, 100},
+			},
+			Range: [2]float64{12, 32},
+		},
+	}
+}
@@ -0,0 +1,36 @@
+package fuzzy_test
+
+import (
+	"testing"
+	"vehicles/packages/domain/fuzzy"
+)
+
+// TestWithTermScale проверяет, что шкала изменяется только в копии набора нечетких множеств, а нечеткий
+// алгоритм с копией получает функции принадлежности всех нечетких подмножеств новой шкалы
+func TestWithTermScale(t *testing.T) {
+	variables := fuzzy.DefaultVariables()
+	scaled, err := variables.WithTermScale(fuzzy.Economy, 5)
+	if err != nil {
+		t.Fatalf("error from `WithTermScale` method: %#v", err)
+	}
+
+	if err = variables.ValidatePriorities([]string{fuzzy.Economy}); err != nil {
+		t.Fatalf("error from `ValidatePriorities` method: %#v", err)
+	}
+	if rules, err := variables.WeightedRules(map[string]float64{fuzzy.Economy: 1}); err != nil || len(rules) != 3 {
+		t.Errorf("default scale: %d rules, error %v, expected 3 rules", len(rules), err)
+	}
+	if rules, err := scaled.WeightedRules(map[string]float64{fuzzy.Economy: 1}); err != nil || len(rules) != 5 {
+		t.Errorf("scale of 5 terms: %d rules, error %v, expected 5 rules", len(rules), err)
+	}
+
+	memberships := fuzzy.NewEngine(nil, scaled).Memberships.Functions()[fuzzy.Economy]
+	for _, term := range []string{fuzzy.VeryLow, fuzzy.Low, fuzzy.Medium, fuzzy.High, fuzzy.VeryHigh} {
+		if memberships[term] == nil {
+			t.Errorf("there is no membership function of the fuzzy subset %q", term)
+		}
+	}
+	if _, err = variables.WithTermScale("неизвестное", 5); err == nil {
+		t.Errorf("expected an error for an unknown fuzzy set")
+	}
+}
@@ -0,0 +1,130 @@
+package fuzzy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ValidateWeights проверяет веса приоритетов: веса задаются только для нечетких множеств набора,
+// не могут быть отрицательными, хотя бы один вес должен быть больше нуля, ненулевых весов не больше MaxPriorities,
+// а нечетких правил для них не больше MaxRules
+// Входной параметр: weights - веса приоритетов (ключ - название нечеткого множества)
+func (vs *VariableSet) ValidateWeights(weights map[string]float64) error {
+	var total float64
+	var nonZero int
+	numberOfRules := 1
+	for variable, weight := range weights {
+		if !vs.isVariable(variable) {
+			return fmt.Errorf("error, unknown fuzzy set %q", variable)
+		}
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return fmt.Errorf("error, the weight %v of the fuzzy set %q is not a non-negative number", weight, variable)
+		}
+		total += weight
+		if weight > 0 {
+			nonZero++
+			numberOfRules *= len(vs.terms(variable))
+		}
+	}
+	if total == 0 {
+		return fmt.Errorf("error, all weights are zero")
+	}
+	if nonZero > MaxPriorities {
+		return fmt.Errorf("error, %d weights are not zero, at most %d are allowed", nonZero, MaxPriorities)
+	}
+	if numberOfRules > MaxRules {
+		return fmt.Errorf("error, the weights require %d rules, at most %d are allowed", numberOfRules, MaxRules)
+	}
+	return nil
+}
+
+// WeightedPriorities возвращает нечеткие множества с ненулевыми весами в порядке убывания весов
+// Входной параметр: weights - веса приоритетов (ключ - название нечеткого множества)
+func (vs *VariableSet) WeightedPriorities(weights map[string]float64) ([]string, error) {
+	if err := vs.ValidateWeights(weights); err != nil {
+		return nil, fmt.Errorf("error from `ValidateWeights` method, package `fuzzy`: %#v", err)
+	}
+
+	priorities := make([]string, 0, len(weights))
+	for _, variable := range vs.Names() {
+		if weights[variable] > 0 {
+			priorities = append(priorities, variable)
+		}
+	}
+	sort.SliceStable(priorities, func(idx, jdx int) bool {
+		return weights[priorities[idx]] > weights[priorities[jdx]]
+	})
+	return priorities, nil
+}
+
+// PriorityWeights возвращает веса, равносильные расстановке приоритетов: каждый приоритет важнее всех следующих
+// за ним вместе взятых. Для шкал из 3 нечетких подмножеств вес приоритета на месте i из k равен 3^(k-1-i),
+// и WeightedRules строит с такими весами те же правила, что и файлы rules/*_rules.txt. В общем случае вес
+// равен (n_i-1)*n_(i+1)*...*n_k/(n_k-1), где n_i - количество нечетких подмножеств приоритета на месте i
+// Входной параметр: priorities - приоритеты, расставленные пользователем
+func (vs *VariableSet) PriorityWeights(priorities []string) map[string]float64 {
+	weights := make(map[string]float64, len(priorities))
+	if len(priorities) == 0 {
+		return weights
+	}
+	// radix - количество сочетаний нечетких подмножеств приоритетов, следующих за текущим
+	radix := 1.0
+	last := float64(len(vs.terms(priorities[len(priorities)-1])) - 1)
+	for idx := len(priorities) - 1; idx >= 0; idx-- {
+		numberOfTerms := float64(len(vs.terms(priorities[idx])))
+		weights[priorities[idx]] = radix * (numberOfTerms - 1) / last
+		radix *= numberOfTerms
+	}
+	return weights
+}
+
+// WeightedRules строит нечеткие правила по весам приоритетов вместо выбора готового файла с правилами.
+// Правила содержат все сочетания нечетких подмножеств для нечетких множеств с ненулевыми весами.
+// Рекомендация правила пропорциональна взвешенной сумме долей номеров нечетких подмножеств в шкале
+// нечеткого множества (для шкалы из 3 термов: "низкий" - 0, "средний" - 1/2, "высокий" - 1) и, как и в файлах
+// с правилами, изменяется от 1 до количества правил, то есть до 3^k для k нечетких множеств со шкалой из 3 термов.
+// Поэтому рекомендация не убывает при переходе любого условия к лучшему нечеткому подмножеству
+// Входной параметр: weights - веса приоритетов (ключ - название нечеткого множества)
+func (vs *VariableSet) WeightedRules(weights map[string]float64) ([]Rule, error) {
+	priorities, err := vs.WeightedPriorities(weights)
+	if err != nil {
+		return nil, fmt.Errorf("error from `WeightedPriorities` method, package `fuzzy`: %#v", err)
+	}
+
+	// scales - нечеткие подмножества приоритетов от худшего к лучшему
+	scales := make([][]string, len(priorities))
+	// maxSum - взвешенная сумма, если все условия содержат лучшее нечеткое подмножество
+	var maxSum float64
+	// maxRecommendation - наибольшая рекомендация, произведение количеств нечетких подмножеств
+	maxRecommendation := 1
+	for idx, variable := range priorities {
+		scales[idx] = vs.terms(variable)
+		maxSum += weights[variable]
+		maxRecommendation *= len(scales[idx])
+	}
+
+	rules := make([]Rule, 0, maxRecommendation)
+	// levels - номера нечетких подмножеств в текущем сочетании, перебираются как разряды числа в смешанной
+	// системе счисления, чтобы порядок правил совпадал с порядком в файлах с правилами
+	levels := make([]int, len(priorities))
+	for count := 0; count < maxRecommendation; count++ {
+		rule := Rule{Conditions: make([]Condition, 0, len(priorities))}
+		var sum float64
+		for idx, variable := range priorities {
+			rule.Conditions = append(rule.Conditions, Condition{Variable: variable, Term: scales[idx][levels[idx]]})
+			sum += weights[variable] * float64(levels[idx]) / float64(len(scales[idx])-1)
+		}
+		rule.Recommendation = 1 + int(math.Round(float64(maxRecommendation-1)*sum/maxSum))
+		rules = append(rules, rule)
+
+		for idx := len(levels) - 1; idx >= 0; idx-- {
+			levels[idx]++
+			if levels[idx] < len(scales[idx]) {
+				break
+			}
+			levels[idx] = 0
+		}
+	}
+	return rules, nil
+}
@@ -8,6 +8,10 @@ type Car struct {
 	ID int
 	// FullName - название
 	FullName string
+	// Make - марка; пусто, если неизвестна
+	Make string
+	// Model - модель; пусто, если неизвестна
+	Model string
 	// Description - описание
 	Description string
 	// Generation - название поколения
@@ -20,6 +24,8 @@ type Car struct {
 	Features Features
 	// Offering - сведения для покупателя
 	Offering Offering
+	// Source - интернет-портал объявлений, с которого собраны данные автомобиля; пусто для автомобилей из БД
+	Source string
 }
 
 // Specifications - технические характеристики
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Constraints - жесткие ограничения, заданные пользователем. Автомобили, которые им не удовлетворяют,
+// не ранжируются нечетким алгоритмом. Пустое ограничение не проверяется
+type Constraints struct {
+	// Bodies - допустимые типы кузова, например, "Седан", "Универсал"
+	Bodies []string `json:"bodies"`
+	// Gearboxes - допустимые типы трансмиссии (ключи GearboxKeywords)
+	Gearboxes []string `json:"gearboxes"`
+	// Drives - допустимые типы привода (ключи DriveKeywords)
+	Drives []string `json:"drives"`
+	// Fuels - допустимые виды топлива (ключи FuelKeywords)
+	Fuels []string `json:"fuels"`
+	// MinSeats - минимальное число мест
+	MinSeats int `json:"minSeats"`
+	// MinTrunkVolume - минимальный объем багажника, литры
+	MinTrunkVolume float64 `json:"minTrunkVolume"`
+	// MaxAge - максимальный возраст автомобиля, лет
+	MaxAge int `json:"maxAge"`
+	// Options - обязательные опции (ключи Options)
+	Options []string `json:"options"`
+}
+
+// GearboxKeywords - слова, по которым в названии трансмиссии определяется ее тип. Названия различаются
+// в БД ("АКПП 6") и на сайте ("автоматическая"), поэтому для каждого типа задано несколько слов
+var GearboxKeywords = map[string][]string{
+	"automatic": {"АКПП", "автомат"},
+	"manual":    {"МКПП", "механ"},
+	"robot":     {"РКПП", "робот"},
+	"cvt":       {"вариатор", "CVT"},
+}
+
+// DriveKeywords - слова, по которым в названии привода определяется его тип
+var DriveKeywords = map[string][]string{
+	"front": {"Передний"},
+	"rear":  {"Задний"},
+	"all":   {"Полный"},
+}
+
+// FuelKeywords - слова, по которым в названии топлива определяется его вид
+var FuelKeywords = map[string][]string{
+	"petrol":   {"Бензин"},
+	"diesel":   {"Дизель"},
+	"hybrid":   {"Гибрид"},
+	"electric": {"Электр"},
+}
+
+// названия обязательных опций
+const (
+	AirConditionerOption      = "air_conditioner"
+	ClimateControlOption      = "climate_control"
+	RearViewCameraOption      = "rear_view_camera"
+	BackParkingSensorOption   = "back_parking_sensor"
+	CruiseControlOption       = "cruise_control"
+	HeatedFrontSeatsOption    = "heated_front_seats"
+	HeatedSteeringWheelOption = "heated_steering_wheel"
+	RainSensorOption          = "rain_sensor"
+)
+
+// Options - наличие обязательных опций у автомобиля (ключ - название опции)
+var Options = map[string]func(car Car) Availability{
+	AirConditionerOption:      func(car Car) Availability { return car.Features.CabinMicroclimate.AirConditioner },
+	ClimateControlOption:      func(car Car) Availability { return car.Features.CabinMicroclimate.ClimateControl },
+	RearViewCameraOption:      func(car Car) Availability { return car.Features.SafetyAndMotionControlSystem.RearViewCamera },
+	BackParkingSensorOption:   func(car Car) Availability { return car.Features.SafetyAndMotionControlSystem.BackParkingSensor },
+	CruiseControlOption:       func(car Car) Availability { return car.Features.SafetyAndMotionControlSystem.CruiseControl },
+	HeatedFrontSeatsOption:    func(car Car) Availability { return car.Features.ElectricOptions.ElectricHeatingOfFrontSeats },
+	HeatedSteeringWheelOption: func(car Car) Availability { return car.Features.ElectricOptions.ElectricHeatingOfSteeringWheel },
+	RainSensorOption:          func(car Car) Availability { return car.Features.ElectricOptions.RainSensor },
+}
+
+// Validate проверяет, что ограничения используют известные типы трансмиссии, привода, топлива и опции,
+// а числовые ограничения не отрицательны
+func (cns Constraints) Validate() error {
+	for _, check := range []struct {
+		name   string
+		values []string
+		table  map[string][]string
+	}{{"gearbox", cns.Gearboxes, GearboxKeywords}, {"drive", cns.Drives, DriveKeywords}, {"fuel", cns.Fuels, FuelKeywords}} {
+		for _, value := range check.values {
+			if _, ok := check.table[value]; !ok {
+				return fmt.Errorf("error, unknown %s type %q", check.name, value)
+			}
+		}
+	}
+	for _, option := range cns.Options {
+		if _, ok := Options[option]; !ok {
+			return fmt.Errorf("error, unknown option %q", option)
+		}
+	}
+	if cns.MinSeats < 0 || cns.MinTrunkVolume < 0 || cns.MaxAge < 0 {
+		return fmt.Errorf("error, numeric constraints must not be negative")
+	}
+	return nil
+}
+
+// Keywords возвращает слова для поиска в названиях по выбранным пользователем ключам. Если таблица
+// не задана, то ключи сами являются словами для поиска (так задаются типы кузова)
+// Входные параметры: table - слова для каждого ключа, keys - ключи, выбранные пользователем
+func Keywords(table map[string][]string, keys []string) []string {
+	if table == nil {
+		return keys
+	}
+	var keywords []string
+	for _, key := range keys {
+		keywords = append(keywords, table[key]...)
+	}
+	return keywords
+}
+
+// Match проверяет, удовлетворяет ли автомобиль ограничениям. Неизвестное значение характеристики
+// не удовлетворяет ограничению, как и значение NULL в запросе к БД
+// Входные параметры: car - автомобиль, currentYear - текущий год
+func (cns Constraints) Match(car Car, currentYear int) bool {
+	switch {
+	case !containsAny(car.Specs.Body, Keywords(nil, cns.Bodies)),
+		!containsAny(car.Specs.Gearbox, Keywords(GearboxKeywords, cns.Gearboxes)),
+		!containsAny(car.Specs.Drive, Keywords(DriveKeywords, cns.Drives)),
+		!containsAny(car.Specs.Engine.FuelUsed, Keywords(FuelKeywords, cns.Fuels)),
+		cns.MinSeats > 0 && car.Specs.NumberOfSeats < cns.MinSeats,
+		cns.MinTrunkVolume > 0 && car.Specs.TrunkVolume < cns.MinTrunkVolume,
+		cns.MaxAge > 0 && car.Offering.Year < currentYear-cns.MaxAge:
+		return false
+	}
+
+	for _, option := range cns.Options {
+		if availability, ok := Options[option]; !ok || availability(car) != YesValue {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterCars возвращает автомобили, удовлетворяющие ограничениям
+// Входные параметры: cars - автомобили, cns - ограничения, currentYear - текущий год
+func FilterCars(cars []Car, cns Constraints, currentYear int) []Car {
+	filtered := make([]Car, 0, len(cars))
+	for _, car := range cars {
+		if cns.Match(car, currentYear) {
+			filtered = append(filtered, car)
+		}
+	}
+	return filtered
+}
+
+// containsAny проверяет без учета регистра, содержит ли значение хотя бы одно из слов. Если слов нет,
+// то ограничение не задано и значение подходит
+// Входные параметры: value - значение характеристики, keywords - слова
+func containsAny(value string, keywords []string) bool {
+	if len(keywords) == 0 {
+		return true
+	}
+	value = strings.ToLower(value)
+	for _, keyword := range keywords {
+		if strings.Contains(value, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
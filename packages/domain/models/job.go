@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// состояния задания сбора и ранжирования автомобилей
+const (
+	// JobQueued - задание ожидает свободного места в очереди
+	JobQueued = "queued"
+	// JobRunning - данные автомобилей собираются
+	JobRunning = "running"
+	// JobDone - автомобили собраны и сохранены, страницу результатов можно показывать
+	JobDone = "done"
+	// JobFailed - задание завершилось ошибкой
+	JobFailed = "failed"
+	// JobCancelled - задание отменено пользователем
+	JobCancelled = "cancelled"
+)
+
+// Job - задание сбора автомобилей из интернета (и их ранжирования при подборе), выполняемое в фоне.
+// У каждой сессии не больше одного задания каждого вида
+type Job struct {
+	// ID - идентификатор задания. Каждое задание, поставленное в очередь, получает новый идентификатор, по которому
+	// хранилище отличает его от заданий сессии, поставленных раньше
+	ID string `json:"id"`
+	// SessionID - идентификатор сессии
+	SessionID string `json:"sessionID"`
+	// Status - состояние (JobQueued, JobRunning, JobDone, JobFailed, JobCancelled)
+	Status string `json:"status"`
+	// PagesFetched - количество загруженных веб-страниц
+	PagesFetched int64 `json:"pagesFetched"`
+	// CarsParsed - количество автомобилей, данные которых собраны
+	CarsParsed int64 `json:"carsParsed"`
+	// Error - описание ошибки, если задание завершилось ошибкой
+	Error string `json:"error,omitempty"`
+	// CreatedAt - время постановки в очередь
+	CreatedAt time.Time `json:"createdAt"`
+	// UpdatedAt - время последнего изменения
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Finished сообщает, что задание завершено: успешно, с ошибкой или отменой
+func (j Job) Finished() bool {
+	return j.Status == JobDone || j.Status == JobFailed || j.Status == JobCancelled
+}
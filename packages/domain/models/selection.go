@@ -4,12 +4,17 @@ type Selection struct {
 	// Priorities - приоритеты или нечеткие множества, например,
 	// "Экономичность", "Комфорт", "Управляемость", "Динамика", "Безопасность"
 	Priorities []string
+	// Weights - веса приоритетов (ключ - нечеткое множество), например, "безопасность" 2, "комфорт" 1.
+	// Если веса заданы, то Priorities содержит нечеткие множества в порядке убывания весов
+	Weights map[string]float64
 	// MinPrice - нижний предел цены
 	MinPrice string
 	// MaxPrice - верхний предел цены
 	MaxPrice string
 	// Manufacturers - страны-производители
 	Manufacturers []string
+	// Constraints - жесткие ограничения: тип кузова, трансмиссия, обязательные опции и т.д.
+	Constraints Constraints
 }
 
 type Makes struct {
@@ -0,0 +1,16 @@
+package models
+
+// Trim - характеристики автомобиля, собранные со страницы комплектации или поколения. Они одинаковы для всех
+// объявлений об автомобилях этой комплектации, поэтому хранятся в общем кэше и переносятся в автомобили
+// без загрузки страницы. Характеристики, которых на странице нет, имеют значения нового автомобиля (см. NewCar)
+type Trim struct {
+	// TrimLevel - название комплектации
+	TrimLevel string `json:"trimLevel"`
+	// Specs - технические характеристики
+	Specs Specifications `json:"specs"`
+	// Features - опции
+	Features Features `json:"features"`
+	// ComplectationLink - ссылка на страницу подходящей комплектации, если характеристики собраны со страницы
+	// поколения
+	ComplectationLink string `json:"complectationLink"`
+}
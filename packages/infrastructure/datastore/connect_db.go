@@ -35,6 +35,16 @@ func CreateNewSelectionRDB() *redis.Client {
 	return rdb
 }
 
+// CreateNewTrimCacheRDB создает клиент Redis для кэша характеристик комплектаций, общего для обычного поиска и подбора
+func CreateNewTrimCacheRDB() *redis.Client {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     "localhost:6379",
+		Password: "",
+		DB:       2,
+	})
+	return rdb
+}
+
 func CreateNewDBForSurvey() (*sql.DB, error) {
 	connStr := fmt.Sprintf("%s%s%s%s%s%s%s%s", user, viper.GetString("postgre.user"),
 		password, viper.GetString("postgre.password"), dbname, viper.GetString("postgre.dbname"),
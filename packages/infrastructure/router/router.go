@@ -3,17 +3,27 @@ package router
 import (
 	"database/sql"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
+	"vehicles/packages/domain/fuzzy"
+	"vehicles/packages/domain/models"
 	"vehicles/packages/registry"
+	"vehicles/packages/usecases/repository"
+	usecase "vehicles/packages/usecases/usecases"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 )
 
-func MakeNewRouter(router *gin.Engine, redisSearchDB *redis.Client, redisSelectionDB *redis.Client, surveyDB *sql.DB, vehiclesDB *sql.DB) *gin.Engine {
+// jobEventsInterval - период, с которым состояние задания проверяется для отправки браузеру
+const jobEventsInterval = 500 * time.Millisecond
+
+func MakeNewRouter(router *gin.Engine, redisSearchDB *redis.Client, redisSelectionDB *redis.Client, surveyDB *sql.DB, vehiclesDB *sql.DB,
+	engine *fuzzy.Engine, listingRepos []repository.ListingRepository, searchJobs, selectionJobs usecase.JobQueue) *gin.Engine {
 	router.GET("main", func(ctx *gin.Context) {
-		registry.NewSearchController(ctx, redisSearchDB, surveyDB).DisplayMainPage()
+		registry.NewSearchController(ctx, redisSearchDB, surveyDB, listingRepos, searchJobs).DisplayMainPage()
 	})
 
 	router.POST("main", func(ctx *gin.Context) {
@@ -25,14 +35,18 @@ func MakeNewRouter(router *gin.Engine, redisSearchDB *redis.Client, redisSelecti
 				fmt.Printf("error from `AbortWithError` method, package `gin`: %#v", err)
 			}
 		}
-		err = registry.NewSearchController(ctx, redisSearchDB, surveyDB).GetSeachCars()
+		// параметры поиска читаются из тела запроса до постановки задания в очередь, а автомобили собираются в фоне
+		// после ответа на запрос, ход сбора отдается по адресу /jobs/search
+		err = registry.NewSearchController(ctx, redisSearchDB, surveyDB, listingRepos, searchJobs).GetSeachCars()
 		if err != nil {
 			fmt.Printf("error from `GetSeachCars` method, package `controller`: %#v", err)
 			errAbort := ctx.AbortWithError(http.StatusInternalServerError, fmt.Errorf("internal Server Error"))
 			if errAbort != nil {
 				fmt.Printf("error from `AbortWithError` method, package `gin`: %#v", err)
 			}
+			return
 		}
+		ctx.JSON(http.StatusAccepted, gin.H{"message": "Сбор автомобилей начат"})
 	})
 
 	router.GET("search", func(ctx *gin.Context) {
@@ -43,7 +57,7 @@ func MakeNewRouter(router *gin.Engine, redisSearchDB *redis.Client, redisSelecti
 			if err != nil {
 				fmt.Printf("error from `Atoi` function, package `strconv`: %#v", err)
 			}
-			err = registry.NewSearchController(ctx, redisSearchDB, surveyDB).DisplaySearchCarAd(sessionID, carID)
+			err = registry.NewSearchController(ctx, redisSearchDB, surveyDB, listingRepos, searchJobs).DisplaySearchCarAd(sessionID, carID)
 			if err != nil {
 				fmt.Printf("error from `DisplaySearchCarAd` method, package `controller`: %#v", err)
 				errAbort := ctx.AbortWithError(http.StatusInternalServerError, fmt.Errorf("internal Server Error"))
@@ -52,7 +66,7 @@ func MakeNewRouter(router *gin.Engine, redisSearchDB *redis.Client, redisSelecti
 				}
 			}
 		} else {
-			err := registry.NewSearchController(ctx, redisSearchDB, surveyDB).TransferSearchCarsData(sessionID)
+			err := registry.NewSearchController(ctx, redisSearchDB, surveyDB, listingRepos, searchJobs).TransferSearchCarsData(sessionID)
 			if err != nil {
 				fmt.Printf("error from `TransferSearchCarsData` method, package `controller`: %#v", err)
 				errAbort := ctx.AbortWithError(http.StatusInternalServerError, fmt.Errorf("internal Server Error"))
@@ -74,20 +88,22 @@ func MakeNewRouter(router *gin.Engine, redisSearchDB *redis.Client, redisSelecti
 		}
 	})
 
-	ServeSelection(router, redisSelectionDB, vehiclesDB)
+	ServeSelection(router, redisSelectionDB, vehiclesDB, engine, listingRepos, selectionJobs)
+	ServeJobs(router, map[string]usecase.JobQueue{"search": searchJobs, "selection": selectionJobs})
 
 	return router
 }
 
-func ServeSelection(router *gin.Engine, redisSelectionDB *redis.Client, vehiclesDB *sql.DB) {
+func ServeSelection(router *gin.Engine, redisSelectionDB *redis.Client, vehiclesDB *sql.DB, engine *fuzzy.Engine,
+	listingRepos []repository.ListingRepository, selectionJobs usecase.JobQueue) {
 	selection := router.Group("/selection")
 	{
 		selection.GET("priorities", func(ctx *gin.Context) {
-			registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB).ChoosePriorities()
+			registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB, engine, listingRepos, selectionJobs).ChoosePriorities()
 		})
 
 		selection.POST("priorities", func(ctx *gin.Context) {
-			err := registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB).PutPriorities()
+			err := registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB, engine, listingRepos, selectionJobs).PutPriorities()
 			if err != nil {
 				fmt.Printf("error from `PutPriorities` method, package `controller`: %#v", err)
 				errAbort := ctx.AbortWithError(http.StatusInternalServerError, fmt.Errorf("internal Server Error"))
@@ -99,11 +115,11 @@ func ServeSelection(router *gin.Engine, redisSelectionDB *redis.Client, vehicles
 		})
 
 		selection.GET("price", func(ctx *gin.Context) {
-			registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB).ChoosePrice()
+			registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB, engine, listingRepos, selectionJobs).ChoosePrice()
 		})
 
 		selection.POST("price", func(ctx *gin.Context) {
-			err := registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB).PutPrice()
+			err := registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB, engine, listingRepos, selectionJobs).PutPrice()
 			if err != nil {
 				fmt.Printf("error from `PutPrice` method, package `controller`: %#v", err)
 				errAbort := ctx.AbortWithError(http.StatusInternalServerError, fmt.Errorf("internal Server Error"))
@@ -114,11 +130,11 @@ func ServeSelection(router *gin.Engine, redisSelectionDB *redis.Client, vehicles
 		})
 
 		selection.GET("manufacturers", func(ctx *gin.Context) {
-			registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB).ChooseManufacturers()
+			registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB, engine, listingRepos, selectionJobs).ChooseManufacturers()
 		})
 
 		selection.POST("manufacturers", func(ctx *gin.Context) {
-			err := registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB).PutManufacturers()
+			err := registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB, engine, listingRepos, selectionJobs).PutManufacturers()
 			if err != nil {
 				fmt.Printf("error from `PutManufacturers` method, package `controller`: %#v", err)
 				errAbort := ctx.AbortWithError(http.StatusInternalServerError, fmt.Errorf("internal Server Error"))
@@ -128,27 +144,65 @@ func ServeSelection(router *gin.Engine, redisSelectionDB *redis.Client, vehicles
 			}
 		})
 
+		selection.GET("constraints", func(ctx *gin.Context) {
+			registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB, engine, listingRepos, selectionJobs).ChooseConstraints()
+		})
+
+		selection.POST("constraints", func(ctx *gin.Context) {
+			err := registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB, engine, listingRepos, selectionJobs).PutConstraints()
+			if err != nil {
+				fmt.Printf("error from `PutConstraints` method, package `controller`: %#v", err)
+				errAbort := ctx.AbortWithError(http.StatusBadRequest, fmt.Errorf("bad Request"))
+				if errAbort != nil {
+					fmt.Printf("error from `AbortWithError` method, package `gin`: %#v", err)
+				}
+			}
+		})
+
 		selection.GET("choice", func(ctx *gin.Context) {
-			registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB).ChooseSource()
+			registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB, engine, listingRepos, selectionJobs).ChooseSource()
 		})
 
 		selection.POST("internet", func(ctx *gin.Context) {
-			err := registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB).GetSelectionFromInternetCars()
+			requestEngine, err := engineForRequest(ctx, engine)
+			if err != nil {
+				fmt.Printf("error from `engineForRequest` function, package `router`: %#v", err)
+				errAbort := ctx.AbortWithError(http.StatusBadRequest, fmt.Errorf("bad Request"))
+				if errAbort != nil {
+					fmt.Printf("error from `AbortWithError` method, package `gin`: %#v", err)
+				}
+				return
+			}
+			// параметры подбора читаются из тела запроса и cookies до постановки задания в очередь, а автомобили
+			// собираются и ранжируются в фоне после ответа на запрос, ход сбора отдается по адресу /jobs/selection
+			err = registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB, requestEngine, listingRepos,
+				selectionJobs).GetSelectionFromInternetCars()
 			if err != nil {
 				fmt.Printf("error from `GetSelectionFromInternetCars` method, package `controller`: %#v", err)
 				errAbort := ctx.AbortWithError(http.StatusInternalServerError, fmt.Errorf("internal Server Error"))
 				if errAbort != nil {
 					fmt.Printf("error from `AbortWithError` method, package `gin`: %#v", err)
 				}
+				return
 			}
+			ctx.JSON(http.StatusAccepted, gin.H{"message": "Подбор автомобилей начат"})
 		})
 
 		selection.GET("internet", func(ctx *gin.Context) {
-			ServeSelectionCarList(ctx, redisSelectionDB, vehiclesDB, true)
+			ServeSelectionCarList(ctx, redisSelectionDB, vehiclesDB, engine, listingRepos, selectionJobs, true)
 		})
 
 		selection.POST("internal_db", func(ctx *gin.Context) {
-			err := registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB).GetSelectionFromDBCars()
+			requestEngine, err := engineForRequest(ctx, engine)
+			if err != nil {
+				fmt.Printf("error from `engineForRequest` function, package `router`: %#v", err)
+				errAbort := ctx.AbortWithError(http.StatusBadRequest, fmt.Errorf("bad Request"))
+				if errAbort != nil {
+					fmt.Printf("error from `AbortWithError` method, package `gin`: %#v", err)
+				}
+				return
+			}
+			err = registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB, requestEngine, listingRepos, selectionJobs).GetSelectionFromDBCars()
 			if err != nil {
 				fmt.Printf("error from `GetSelectionFromDBCars` method, package `controller`: %#v", err)
 				errAbort := ctx.AbortWithError(http.StatusInternalServerError, fmt.Errorf("internal Server Error"))
@@ -159,12 +213,90 @@ func ServeSelection(router *gin.Engine, redisSelectionDB *redis.Client, vehicles
 		})
 
 		selection.GET("internal_db", func(ctx *gin.Context) {
-			ServeSelectionCarList(ctx, redisSelectionDB, vehiclesDB, false)
+			ServeSelectionCarList(ctx, redisSelectionDB, vehiclesDB, engine, listingRepos, selectionJobs, false)
+		})
+
+		selection.POST("feedback", func(ctx *gin.Context) {
+			err := registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB, engine, listingRepos, selectionJobs).PutFeedback()
+			if err != nil {
+				fmt.Printf("error from `PutFeedback` method, package `controller`: %#v", err)
+				errAbort := ctx.AbortWithError(http.StatusBadRequest, fmt.Errorf("bad Request"))
+				if errAbort != nil {
+					fmt.Printf("error from `AbortWithError` method, package `gin`: %#v", err)
+				}
+				return
+			}
+			ctx.JSON(http.StatusOK, gin.H{"message": "Отзыв сохранен"})
+		})
+
+		selection.GET("sensitivity", func(ctx *gin.Context) {
+			requestEngine, err := engineForRequest(ctx, engine)
+			if err != nil {
+				fmt.Printf("error from `engineForRequest` function, package `router`: %#v", err)
+				errAbort := ctx.AbortWithError(http.StatusBadRequest, fmt.Errorf("bad Request"))
+				if errAbort != nil {
+					fmt.Printf("error from `AbortWithError` method, package `gin`: %#v", err)
+				}
+				return
+			}
+			err = registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB, requestEngine, listingRepos, selectionJobs).
+				ShowSensitivity(ctx.Query("guest"), ctx.Query("source") == "internet")
+			if err != nil {
+				fmt.Printf("error from `ShowSensitivity` method, package `controller`: %#v", err)
+				errAbort := ctx.AbortWithError(http.StatusInternalServerError, fmt.Errorf("internal Server Error"))
+				if errAbort != nil {
+					fmt.Printf("error from `AbortWithError` method, package `gin`: %#v", err)
+				}
+			}
+		})
+
+		selection.POST("sensitivity", func(ctx *gin.Context) {
+			requestEngine, err := engineForRequest(ctx, engine)
+			if err != nil {
+				fmt.Printf("error from `engineForRequest` function, package `router`: %#v", err)
+				errAbort := ctx.AbortWithError(http.StatusBadRequest, fmt.Errorf("bad Request"))
+				if errAbort != nil {
+					fmt.Printf("error from `AbortWithError` method, package `gin`: %#v", err)
+				}
+				return
+			}
+			err = registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB, requestEngine, listingRepos, selectionJobs).GetSensitivity()
+			if err != nil {
+				fmt.Printf("error from `GetSensitivity` method, package `controller`: %#v", err)
+				errAbort := ctx.AbortWithError(http.StatusBadRequest, fmt.Errorf("bad Request"))
+				if errAbort != nil {
+					fmt.Printf("error from `AbortWithError` method, package `gin`: %#v", err)
+				}
+			}
+		})
+
+		selection.GET("pareto", func(ctx *gin.Context) {
+			err := registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB, engine, listingRepos, selectionJobs).
+				ShowParetoFronts(ctx.Query("guest"), ctx.Query("source") == "internet")
+			if err != nil {
+				fmt.Printf("error from `ShowParetoFronts` method, package `controller`: %#v", err)
+				errAbort := ctx.AbortWithError(http.StatusInternalServerError, fmt.Errorf("internal Server Error"))
+				if errAbort != nil {
+					fmt.Printf("error from `AbortWithError` method, package `gin`: %#v", err)
+				}
+			}
+		})
+
+		selection.POST("pareto", func(ctx *gin.Context) {
+			err := registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB, engine, listingRepos, selectionJobs).GetParetoFronts()
+			if err != nil {
+				fmt.Printf("error from `GetParetoFronts` method, package `controller`: %#v", err)
+				errAbort := ctx.AbortWithError(http.StatusBadRequest, fmt.Errorf("bad Request"))
+				if errAbort != nil {
+					fmt.Printf("error from `AbortWithError` method, package `gin`: %#v", err)
+				}
+			}
 		})
 	}
 }
 
-func ServeSelectionCarList(ctx *gin.Context, redisSelectionDB *redis.Client, vehiclesDB *sql.DB, choice bool) {
+func ServeSelectionCarList(ctx *gin.Context, redisSelectionDB *redis.Client, vehiclesDB *sql.DB, engine *fuzzy.Engine,
+	listingRepos []repository.ListingRepository, selectionJobs usecase.JobQueue, choice bool) {
 	sessionID := ctx.Query("guest")
 	thisCarID := ctx.Query("carID")
 	if thisCarID != "" {
@@ -172,7 +304,8 @@ func ServeSelectionCarList(ctx *gin.Context, redisSelectionDB *redis.Client, veh
 		if err != nil {
 			fmt.Printf("error from `Atoi` function, package `strconv`: %#v", err)
 		}
-		err = registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB).DisplaySelectionCarAd(sessionID, carID, choice)
+		err = registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB, engine, listingRepos, selectionJobs).
+			DisplaySelectionCarAd(sessionID, carID, choice)
 		if err != nil {
 			fmt.Printf("error from `DisplaySelectionCarAd` method, package `controller`: %#v", err)
 			errAbort := ctx.AbortWithError(http.StatusInternalServerError, fmt.Errorf("internal Server Error"))
@@ -182,7 +315,8 @@ func ServeSelectionCarList(ctx *gin.Context, redisSelectionDB *redis.Client, veh
 		}
 
 	} else {
-		err := registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB).TransferSelectionCarsData(sessionID, choice)
+		err := registry.NewSelectionController(ctx, redisSelectionDB, vehiclesDB, engine, listingRepos, selectionJobs).
+			TransferSelectionCarsData(sessionID, choice)
 		if err != nil {
 			fmt.Printf("error from `TransferSelectionCarsData` method, package `controller`: %#v", err)
 			errAbort := ctx.AbortWithError(http.StatusInternalServerError, fmt.Errorf("internal Server Error"))
@@ -192,3 +326,135 @@ func ServeSelectionCarList(ctx *gin.Context, redisSelectionDB *redis.Client, veh
 		}
 	}
 }
+
+// ServeJobs обслуживает задания сбора автомобилей, выполняемые в фоне: /jobs/search - задания обычного поиска,
+// /jobs/selection - задания подбора из интернета. Сессия задается параметром guest
+func ServeJobs(router *gin.Engine, jobQueues map[string]usecase.JobQueue) {
+	jobs := router.Group("/jobs")
+	{
+		// состояние задания для опроса браузером
+		jobs.GET(":kind", func(ctx *gin.Context) {
+			queue, ok := jobQueues[ctx.Param("kind")]
+			if !ok {
+				ctx.AbortWithStatus(http.StatusNotFound)
+				return
+			}
+			job, ok, err := registry.NewJobController(ctx, queue).ShowJob(ctx.Query("guest"))
+			if err != nil {
+				fmt.Printf("error from `ShowJob` method, package `controller`: %#v\n", err)
+				errAbort := ctx.AbortWithError(http.StatusInternalServerError, fmt.Errorf("internal Server Error"))
+				if errAbort != nil {
+					fmt.Printf("error from `AbortWithError` method, package `gin`: %#v\n", errAbort)
+				}
+				return
+			}
+			if !ok {
+				ctx.JSON(http.StatusNotFound, gin.H{"message": "Задание не найдено"})
+				return
+			}
+			ctx.JSON(http.StatusOK, job)
+		})
+
+		// состояние задания в виде Server-Sent Events: событие progress отправляется при каждом изменении
+		// состояния, поток закрывается после завершения задания
+		jobs.GET(":kind/events", func(ctx *gin.Context) {
+			queue, ok := jobQueues[ctx.Param("kind")]
+			if !ok {
+				ctx.AbortWithStatus(http.StatusNotFound)
+				return
+			}
+			sessionID := ctx.Query("guest")
+			jobController := registry.NewJobController(ctx, queue)
+
+			ticker := time.NewTicker(jobEventsInterval)
+			defer ticker.Stop()
+			var sent models.Job
+			ctx.Stream(func(w io.Writer) bool {
+				job, ok, err := jobController.ShowJob(sessionID)
+				if err != nil {
+					fmt.Printf("error from `ShowJob` method, package `controller`: %#v\n", err)
+					ctx.SSEvent("failure", gin.H{"message": "Не удалось получить состояние задания"})
+					return false
+				}
+				if !ok {
+					ctx.SSEvent("failure", gin.H{"message": "Задание не найдено"})
+					return false
+				}
+				if job.Status != sent.Status || job.PagesFetched != sent.PagesFetched || job.CarsParsed != sent.CarsParsed {
+					ctx.SSEvent("progress", job)
+					sent = job
+				}
+				if job.Finished() {
+					return false
+				}
+
+				select {
+				case <-ctx.Request.Context().Done():
+					return false
+				case <-ticker.C:
+					return true
+				}
+			})
+		})
+
+		jobs.DELETE(":kind", func(ctx *gin.Context) {
+			queue, ok := jobQueues[ctx.Param("kind")]
+			if !ok {
+				ctx.AbortWithStatus(http.StatusNotFound)
+				return
+			}
+			err := registry.NewJobController(ctx, queue).CancelJob(ctx.Query("guest"))
+			if err != nil {
+				fmt.Printf("error from `CancelJob` method, package `controller`: %#v\n", err)
+				errAbort := ctx.AbortWithError(http.StatusBadRequest, fmt.Errorf("bad Request"))
+				if errAbort != nil {
+					fmt.Printf("error from `AbortWithError` method, package `gin`: %#v\n", errAbort)
+				}
+				return
+			}
+			ctx.JSON(http.StatusOK, gin.H{"message": "Задание отменено"})
+		})
+	}
+}
+
+// engineForRequest возвращает нечеткий алгоритм с методом дефаззификации, режимом учета достоверности, t-нормой,
+// s-нормой и оператором импликации, выбранными параметрами запроса defuzzifier, confidence, tnorm, snorm
+// и implication. Не заданные параметры берутся из нечеткого алгоритма по умолчанию
+// Входные параметры: ctx - контекст запроса, engine - нечеткий алгоритм по умолчанию
+func engineForRequest(ctx *gin.Context, engine *fuzzy.Engine) (*fuzzy.Engine, error) {
+	if method := ctx.Query("defuzzifier"); method != "" {
+		defuzzifier, err := fuzzy.NewDefuzzifier(method)
+		if err != nil {
+			return nil, fmt.Errorf("error from `NewDefuzzifier` function, package `fuzzy`: %#v", err)
+		}
+		engine = engine.WithDefuzzifier(defuzzifier)
+	}
+	if mode := ctx.Query("confidence"); mode != "" {
+		if err := fuzzy.ValidateConfidenceMode(mode); err != nil {
+			return nil, fmt.Errorf("error from `ValidateConfidenceMode` function, package `fuzzy`: %#v", err)
+		}
+		engine = engine.WithConfidenceMode(mode)
+	}
+
+	tNorm, sNorm, implication := engine.TNorm, engine.SNorm, engine.Implication
+	var err error
+	if name := ctx.Query("tnorm"); name != "" {
+		if tNorm, err = fuzzy.NewTNorm(name); err != nil {
+			return nil, fmt.Errorf("error from `NewTNorm` function, package `fuzzy`: %#v", err)
+		}
+	}
+	if name := ctx.Query("snorm"); name != "" {
+		if sNorm, err = fuzzy.NewSNorm(name); err != nil {
+			return nil, fmt.Errorf("error from `NewSNorm` function, package `fuzzy`: %#v", err)
+		}
+	}
+	if name := ctx.Query("implication"); name != "" {
+		if implication, err = fuzzy.NewImplication(name); err != nil {
+			return nil, fmt.Errorf("error from `NewImplication` function, package `fuzzy`: %#v", err)
+		}
+	}
+	if ctx.Query("tnorm") == "" && ctx.Query("snorm") == "" && ctx.Query("implication") == "" {
+		return engine, nil
+	}
+	return engine.WithOperators(tNorm, sNorm, implication), nil
+}
@@ -0,0 +1,22 @@
+package registry
+
+import (
+	"time"
+	"vehicles/packages/adapters/controller"
+	"vehicles/packages/adapters/gateway"
+	usecase "vehicles/packages/usecases/usecases"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// NewJobQueue создает очередь заданий сбора и ранжирования автомобилей, состояния которых хранятся в Redis
+// Входные параметры: rdb - клиент Redis, workers - количество заданий, которые выполняются одновременно,
+// ttl - время хранения состояния задания
+func NewJobQueue(rdb *redis.Client, workers int, ttl time.Duration) usecase.JobQueue {
+	return usecase.NewJobQueue(gateway.NewJobRepository(rdb, ttl), workers)
+}
+
+func NewJobController(ctx *gin.Context, jobs usecase.JobQueue) controller.Job {
+	return controller.NewJobController(ctx, jobs)
+}
@@ -5,25 +5,28 @@ import (
 	"vehicles/packages/adapters/controller"
 	"vehicles/packages/adapters/gateway"
 	"vehicles/packages/adapters/presenter"
+	"vehicles/packages/usecases/repository"
 	usecase "vehicles/packages/usecases/usecases"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 )
 
-func NewSearchController(ctx *gin.Context, rdb *redis.Client, pdb *sql.DB) controller.Search {
+func NewSearchController(ctx *gin.Context, rdb *redis.Client, pdb *sql.DB,
+	listingRepos []repository.ListingRepository, jobs usecase.JobQueue) controller.Search {
 	nur := usecase.NewUserUseCase(gateway.NewUserRepository(ctx))
 	ncr := gateway.NewCarsRepository(ctx, rdb)
 	nsp := presenter.NewSearchPresenter(ctx)
-	nsr := gateway.NewSearchRepository(ctx, rdb)
 	nsu := usecase.NewSearchUseCase(
-		nsr,
+		ctx,
+		listingRepos,
 		ncr,
 		nur,
 		usecase.NewQuestionUseCase(
 			gateway.NewQuestionRepository(ctx, pdb), ncr, nur, nsp,
 		),
 		nsp,
+		jobs,
 	)
 	return controller.NewSearchController(ctx, rdb, nsu)
 }
@@ -5,21 +5,28 @@ import (
 	"vehicles/packages/adapters/controller"
 	"vehicles/packages/adapters/gateway"
 	"vehicles/packages/adapters/presenter"
+	"vehicles/packages/domain/fuzzy"
 	"vehicles/packages/domain/models"
+	"vehicles/packages/usecases/repository"
 	usecase "vehicles/packages/usecases/usecases"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 )
 
-func NewSelectionController(ctx *gin.Context, rdb *redis.Client, vehiclesDB *sql.DB) controller.Selection {
+func NewSelectionController(ctx *gin.Context, rdb *redis.Client, vehiclesDB *sql.DB, engine *fuzzy.Engine,
+	listingRepos []repository.ListingRepository, jobs usecase.JobQueue) controller.Selection {
 	nsu := usecase.NewSelectionUseCase(
 		ctx,
 		gateway.NewSelectionRepository(ctx, vehiclesDB),
+		listingRepos,
 		gateway.NewCarsRepository(ctx, rdb),
+		gateway.NewFeedbackRepository(vehiclesDB),
 		usecase.NewUserUseCase(gateway.NewUserRepository(ctx)),
 		presenter.NewSelectionPresenter(ctx),
 		models.User{},
+		engine,
+		jobs,
 	)
 	return controller.NewSelectionController(ctx, nsu)
 }
@@ -1,6 +1,10 @@
 package repository
 
-import "vehicles/packages/domain/models"
+import (
+	"context"
+	"vehicles/packages/domain/fuzzy"
+	"vehicles/packages/domain/models"
+)
 
 type CarsRepository interface {
 	// LoadCarsData загружает в БД под управлением Redis данные об автомобилях, полученные из реляционной БД под управлением PostgreSQL
@@ -12,4 +16,28 @@ type CarsRepository interface {
 	// собранные из интернета данные об автомобилях
 	// Входные параметры: sessionID - идентификатор сессии
 	GetCarsData(sessionID string) ([]models.Car, error)
+
+	// LoadExplanationsData загружает в БД под управлением Redis объяснения результатов нечеткого алгоритма,
+	// упорядоченные так же, как ранжированные автомобили
+	// Входные параметры: sessionID - идентификатор сессии, explanations - объяснения
+	LoadExplanationsData(sessionID string, explanations []fuzzy.Explanation) error
+
+	// LoadJobCarsData загружает в БД под управлением Redis автомобили, собранные заданием, только если хранилище
+	// заданий содержит задание сессии с тем же идентификатором или не содержит задания сессии. Если задание заменено
+	// другим, автомобили не сохраняются и возвращается false
+	// Входные параметры: ctx - контекст задания, sessionID - идентификатор сессии, jobID - идентификатор задания,
+	// cars - автомобили
+	LoadJobCarsData(ctx context.Context, sessionID, jobID string, cars []models.Car) (bool, error)
+
+	// LoadJobSelectionData загружает в БД под управлением Redis ранжированные заданием автомобили вместе
+	// с объяснениями, только если хранилище заданий содержит задание сессии с тем же идентификатором или не содержит
+	// задания сессии. Если задание заменено другим, ничего не сохраняется и возвращается false
+	// Входные параметры: ctx - контекст задания, sessionID - идентификатор сессии, jobID - идентификатор задания,
+	// cars - автомобили, explanations - объяснения
+	LoadJobSelectionData(ctx context.Context, sessionID, jobID string, cars []models.Car,
+		explanations []fuzzy.Explanation) (bool, error)
+
+	// GetExplanationsData получает из БД под управлением Redis объяснения результатов нечеткого алгоритма
+	// Входной параметр: sessionID - идентификатор сессии
+	GetExplanationsData(sessionID string) ([]fuzzy.Explanation, error)
 }
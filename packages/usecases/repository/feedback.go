@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"vehicles/packages/domain/fuzzy"
+)
+
+type FeedbackRepository interface {
+	// InsertFeedback записывает в БД действие пользователя с автомобилем из рейтинга
+	// Входные параметры: ctx - контекст, event - действие пользователя
+	InsertFeedback(ctx context.Context, event fuzzy.FeedbackEvent) error
+
+	// LoadFeedback получает из БД действия пользователей, совершенные не раньше заданного времени
+	// Входные параметры: ctx - контекст, since - время, с которого учитываются действия
+	LoadFeedback(ctx context.Context, since time.Time) ([]fuzzy.FeedbackEvent, error)
+}
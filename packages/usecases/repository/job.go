@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"vehicles/packages/domain/models"
+)
+
+type JobRepository interface {
+	// SaveJob сохраняет состояние нового задания сбора автомобилей, заменяя задание сессии, поставленное раньше
+	// Входные параметры: ctx - контекст, job - задание
+	SaveJob(ctx context.Context, job models.Job) error
+
+	// UpdateJob сохраняет состояние задания, только если хранилище содержит задание сессии с тем же идентификатором
+	// или не содержит задания сессии. Если задание заменено другим, состояние не сохраняется и возвращается false
+	// Входные параметры: ctx - контекст, job - задание
+	UpdateJob(ctx context.Context, job models.Job) (bool, error)
+
+	// GetJob получает состояние задания сессии. Если задания нет, возвращается false
+	// Входные параметры: ctx - контекст, sessionID - идентификатор сессии
+	GetJob(ctx context.Context, sessionID string) (models.Job, bool, error)
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"vehicles/packages/domain/models"
+)
+
+// ListingRepository собирает данные автомобилей с одного интернет-портала объявлений о продаже автомобилей
+type ListingRepository interface {
+	// Source возвращает название интернет-портала, которым помечаются собранные автомобили
+	Source() string
+
+	// ScrapeSearchCars собирает данные автомобилей из интернета. Автомобили, данные которых не удалось собрать
+	// до отмены контекста или из-за ошибки, пропускаются
+	// Входные параметры: ctx - контекст запроса пользователя, search - параметры поиска пользователя, которые он вводил
+	// на главное странице в большой форме сверху, progress - получатель сведений о ходе сбора данных
+	ScrapeSearchCars(ctx context.Context, search models.Search, progress ScrapeProgress) ([]models.Car, error)
+
+	// ScrapeSelectionCars собирает данные автомобилей из интернета. Автомобили, данные которых не удалось собрать
+	// до отмены контекста или из-за ошибки, пропускаются
+	// Входные параметры: ctx - контекст запроса пользователя, minPrice  - минимальная цена, maxPrice - максимальная
+	// цена, makes - срез марок, progress - получатель сведений о ходе сбора данных
+	ScrapeSelectionCars(ctx context.Context, minPrice, maxPrice string, makes []models.Makes,
+		progress ScrapeProgress) ([]models.Car, error)
+}
+
+// ScrapeProgress получает сведения о ходе сбора данных автомобилей. Методы вызываются одновременно из нескольких
+// горутин
+type ScrapeProgress interface {
+	// PageFetched сообщает о загрузке веб-страницы
+	PageFetched()
+
+	// CarParsed сообщает о том, что данные автомобиля собраны
+	CarParsed()
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"vehicles/packages/domain/fuzzy"
+)
+
+type MembershipRepository interface {
+	// LoadMemberships получает из БД описания функций принадлежности последней одобренной версии
+	fuzzy.MembershipLoader
+
+	// PublishMemberships записывает в БД новую версию описаний функций принадлежности. Нечеткий алгоритм
+	// перейдет на эту версию только после ее одобрения
+	// Входные параметры: ctx - контекст, config - описания функций принадлежности (версия назначается БД)
+	PublishMemberships(ctx context.Context, config fuzzy.MembershipConfig) (string, error)
+
+	// ApproveMemberships одобряет версию описаний функций принадлежности после ее проверки
+	// Входные параметры: ctx - контекст, version - версия
+	ApproveMemberships(ctx context.Context, version string) error
+}
+
+type SurveyAnswersRepository interface {
+	// LoadSurveyAnswers получает из БД ответы пользователей на вопросы о расходе топлива и времени разгона
+	// Входной параметр: ctx - контекст
+	LoadSurveyAnswers(ctx context.Context) ([]fuzzy.SurveyAnswers, error)
+}
@@ -11,6 +11,11 @@ type SelectionRepository interface {
 	// Входной параметр: priorities - приоритеты, расставленные пользователем
 	SetPriorities(priorities []string)
 
+	// SetWeights сохраняет веса приоритетов, заданные пользователем, в cookie. Пустые веса удаляют
+	// сохраненные ранее веса, и автомобили ранжируются по расстановке приоритетов
+	// Входной параметр: weights - веса приоритетов (ключ - нечеткое множество)
+	SetWeights(weights map[string]float64)
+
 	// SetPrice сохраняет диапазон цен, заданный пользователем, в cookies
 	// Входные параметры: minPrice - минимальная цена, maxPrice - максимальная цена
 	SetPrice(minPrice, maxPrice string)
@@ -19,14 +24,15 @@ type SelectionRepository interface {
 	// Входной параметр: manufacturers - страны, выбранные пользователем
 	SetManufacturers(manufacturers []string)
 
+	// SetConstraints сохраняет жесткие ограничения, заданные пользователем, в cookie
+	// Входной параметр: constraints - ограничения
+	SetConstraints(constraints models.Constraints) error
+
 	// GetSelectionParams получает параметры, заданные ранее пользователем, из cookies
 	GetSelectionParams() (*models.Selection, error)
 
-	// SelectCars получает из реляционной БД под управлением PostgreSQL информацию об автомобилях
+	// SelectCars получает из реляционной БД под управлением PostgreSQL информацию об автомобилях,
+	// удовлетворяющих жестким ограничениям
 	// Входной параметр: sln - запрос пользователя
 	SelectCars(slc models.Selection) ([]models.Car, error)
-
-	// ScrapeSelectionCars собирает данные автомобилей из интернета
-	// Входные параметры: minPrice  - минимальная цена, maxPrice - максимальная цена, makes - срез марок
-	ScrapeSelectionCars(minPrice, maxPrice string, makes []models.Makes) ([]models.Car, error)
 }
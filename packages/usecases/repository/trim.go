@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"vehicles/packages/domain/models"
+)
+
+// TrimCache - общий для обычного поиска и подбора кэш характеристик, собранных со страниц комплектаций и поколений.
+// Ключ - ссылка на страницу комплектации; для страницы поколения к ссылке добавляется краткий перечень
+// характеристик, по которому выбирается комплектация
+type TrimCache interface {
+	// GetTrim получает характеристики страницы из кэша. Если их нет или время хранения истекло, возвращается false
+	// Входные параметры: ctx - контекст, link - ключ страницы
+	GetTrim(ctx context.Context, link string) (models.Trim, bool, error)
+
+	// SaveTrim сохраняет характеристики страницы в кэш
+	// Входные параметры: ctx - контекст, link - ключ страницы, trim - характеристики
+	SaveTrim(ctx context.Context, link string, trim models.Trim) error
+
+	// InvalidateTrim удаляет из кэша характеристики страницы комплектации или все варианты выбора комплектации
+	// на странице поколения
+	// Входные параметры: ctx - контекст, link - ссылка на страницу
+	InvalidateTrim(ctx context.Context, link string) error
+
+	// Clear удаляет из кэша характеристики всех страниц и возвращает количество удаленных записей
+	// Входной параметр: ctx - контекст
+	Clear(ctx context.Context) (int64, error)
+}
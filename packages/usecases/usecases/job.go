@@ -0,0 +1,273 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+	"vehicles/packages/domain/models"
+	"vehicles/packages/usecases/repository"
+
+	"github.com/google/uuid"
+)
+
+// jobProgressInterval - период сохранения хода выполнения задания
+const jobProgressInterval = 500 * time.Millisecond
+
+// jobSaveTimeout - наибольшее время сохранения состояния задания
+const jobSaveTimeout = 5 * time.Second
+
+// JobFunc - работа задания: сбор автомобилей и сохранение результатов
+// Входные параметры: ctx - контекст задания, который отменяется при отмене задания, jobID - идентификатор задания,
+// по которому сохраняются только результаты незамененного задания, progress - получатель сведений о ходе сбора данных
+type JobFunc func(ctx context.Context, jobID string, progress repository.ScrapeProgress) error
+
+// JobQueue выполняет в фоне задания сбора и ранжирования автомобилей. Очередь общая для всех запросов, у каждой
+// сессии в ней не больше одного задания
+type JobQueue interface {
+	// Enqueue ставит задание сессии в очередь и сразу возвращает управление. Незавершенное задание сессии
+	// отменяется
+	// Входные параметры: sessionID - идентификатор сессии, run - работа задания
+	Enqueue(sessionID string, run JobFunc) error
+
+	// GetJob получает состояние задания сессии. Если задания нет, возвращается false
+	// Входные параметры: ctx - контекст запроса, sessionID - идентификатор сессии
+	GetJob(ctx context.Context, sessionID string) (models.Job, bool, error)
+
+	// CancelJob отменяет незавершенное задание сессии
+	// Входные параметры: ctx - контекст запроса, sessionID - идентификатор сессии
+	CancelJob(ctx context.Context, sessionID string) error
+}
+
+type jobQueue struct {
+	// jobRepo - хранилище состояний заданий
+	jobRepo repository.JobRepository
+	// workers - места очереди, в которых задания выполняются одновременно
+	workers chan struct{}
+	// mutex - защита running и порядка сохранения состояний заданий одной сессии
+	mutex sync.Mutex
+	// running - функции отмены незавершенных заданий этого экземпляра сервера по сессиям
+	running map[string]*runningJob
+}
+
+// runningJob - незавершенное задание
+type runningJob struct {
+	// cancel - отмена контекста задания
+	cancel context.CancelFunc
+	// cancelled - признак отмены пользователем или новым заданием сессии
+	cancelled atomic.Bool
+}
+
+// NewJobQueue создает очередь заданий сбора и ранжирования автомобилей
+// Входные параметры: jobRepo - хранилище состояний заданий, workers - количество заданий, которые выполняются
+// одновременно (0 - 1)
+func NewJobQueue(jobRepo repository.JobRepository, workers int) JobQueue {
+	if workers <= 0 {
+		workers = 1
+	}
+	return &jobQueue{jobRepo: jobRepo, workers: make(chan struct{}, workers), running: make(map[string]*runningJob)}
+}
+
+// Enqueue ставит задание сессии в очередь и сразу возвращает управление. Незавершенное задание сессии отменяется
+// Входные параметры: sessionID - идентификатор сессии, run - работа задания
+func (jbq *jobQueue) Enqueue(sessionID string, run JobFunc) error {
+	now := time.Now()
+	job := models.Job{ID: uuid.New().String(), SessionID: sessionID, Status: models.JobQueued, CreatedAt: now,
+		UpdatedAt: now}
+	ctx, cancel := context.WithCancel(context.Background())
+	current := &runningJob{cancel: cancel}
+
+	// замена задания сессии и сохранение нового задания выполняются под mutex, поэтому прежнее задание этого
+	// экземпляра сервера не может сохранить свое состояние между ними
+	jbq.mutex.Lock()
+	if previous, ok := jbq.running[sessionID]; ok {
+		previous.cancelled.Store(true)
+		previous.cancel()
+	}
+	jbq.running[sessionID] = current
+	saveCtx, cancelSave := context.WithTimeout(context.Background(), jobSaveTimeout)
+	err := jbq.jobRepo.SaveJob(saveCtx, job)
+	cancelSave()
+	jbq.mutex.Unlock()
+
+	if err != nil {
+		jbq.finish(sessionID, current)
+		return fmt.Errorf("error from `SaveJob` method, package `gateway`: %#v", err)
+	}
+
+	go jbq.run(ctx, job, current, run)
+	return nil
+}
+
+// run дожидается свободного места в очереди, выполняет задание и периодически сохраняет ход его выполнения
+// Входные параметры: ctx - контекст задания, job - задание, current - незавершенное задание, run - работа задания
+func (jbq *jobQueue) run(ctx context.Context, job models.Job, current *runningJob, run JobFunc) {
+	defer jbq.finish(job.SessionID, current)
+
+	var err error
+	select {
+	case jbq.workers <- struct{}{}:
+		defer func() { <-jbq.workers }()
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	progress := &jobProgress{}
+	if err == nil {
+		job.Status = models.JobRunning
+		if errSave := jbq.save(job, current); errSave != nil {
+			fmt.Printf("error from `save` method, package `usecase`: %#v\n", errSave)
+		}
+
+		done := make(chan error, 1)
+		go func() {
+			done <- run(ctx, job.ID, progress)
+		}()
+
+		ticker := time.NewTicker(jobProgressInterval)
+		defer ticker.Stop()
+		ticks, stop := ticker.C, ctx.Done()
+		for waiting := true; waiting; {
+			select {
+			case err = <-done:
+				waiting = false
+			case <-stop:
+				// отмененное задание больше не сохраняет ход выполнения, а дожидается завершения работы
+				ticker.Stop()
+				ticks, stop = nil, nil
+			case <-ticks:
+				if progress.update(&job) {
+					if errSave := jbq.save(job, current); errSave != nil {
+						fmt.Printf("error from `save` method, package `usecase`: %#v\n", errSave)
+					}
+				}
+			}
+		}
+	}
+
+	progress.update(&job)
+	switch {
+	case current.cancelled.Load():
+		job.Status = models.JobCancelled
+	case err != nil:
+		job.Status = models.JobFailed
+		job.Error = err.Error()
+		fmt.Printf("error while running the job of session %s: %#v\n", job.SessionID, err)
+	default:
+		job.Status = models.JobDone
+	}
+
+	if errSave := jbq.save(job, current); errSave != nil {
+		fmt.Printf("error from `save` method, package `usecase`: %#v\n", errSave)
+	}
+}
+
+// finish убирает задание из незавершенных, если его не заменило задание, поставленное в очередь позже
+// Входные параметры: sessionID - идентификатор сессии, current - незавершенное задание
+func (jbq *jobQueue) finish(sessionID string, current *runningJob) {
+	current.cancel()
+
+	jbq.mutex.Lock()
+	defer jbq.mutex.Unlock()
+	if jbq.running[sessionID] == current {
+		delete(jbq.running, sessionID)
+	}
+}
+
+// save сохраняет состояние задания, если его не заменило задание сессии, поставленное в очередь позже: такое
+// задание сохраняет свое состояние само. Проверка и запись выполняются под mutex, а задание, замененное
+// на другом экземпляре сервера, отсекает условная запись хранилища (см. UpdateJob). Задание выполняется после
+// ответа на запрос, поэтому сохраняется с собственным ограничением времени
+// Входные параметры: job - задание, current - незавершенное задание
+func (jbq *jobQueue) save(job models.Job, current *runningJob) error {
+	jbq.mutex.Lock()
+	defer jbq.mutex.Unlock()
+	if jbq.running[job.SessionID] != current {
+		return nil
+	}
+	return jbq.update(job)
+}
+
+// update сохраняет состояние задания, если хранилище не содержит задания сессии, поставленного в очередь позже
+// Входной параметр: job - задание
+func (jbq *jobQueue) update(job models.Job) error {
+	ctx, cancel := context.WithTimeout(context.Background(), jobSaveTimeout)
+	defer cancel()
+
+	job.UpdatedAt = time.Now()
+	if _, err := jbq.jobRepo.UpdateJob(ctx, job); err != nil {
+		return fmt.Errorf("error from `UpdateJob` method, package `gateway`: %#v", err)
+	}
+	return nil
+}
+
+// GetJob получает состояние задания сессии. Если задания нет, возвращается false
+// Входные параметры: ctx - контекст запроса, sessionID - идентификатор сессии
+func (jbq *jobQueue) GetJob(ctx context.Context, sessionID string) (models.Job, bool, error) {
+	job, ok, err := jbq.jobRepo.GetJob(ctx, sessionID)
+	if err != nil {
+		return models.Job{}, false, fmt.Errorf("error from `GetJob` method, package `gateway`: %#v", err)
+	}
+	return job, ok, nil
+}
+
+// CancelJob отменяет незавершенное задание сессии. Состояние отмененного задания сохраняет само задание; если
+// задание выполняется не этим экземпляром сервера или уже потеряно, состояние сохраняется здесь
+// Входные параметры: ctx - контекст запроса, sessionID - идентификатор сессии
+func (jbq *jobQueue) CancelJob(ctx context.Context, sessionID string) error {
+	jbq.mutex.Lock()
+	current, ok := jbq.running[sessionID]
+	if ok {
+		current.cancelled.Store(true)
+		current.cancel()
+	}
+	jbq.mutex.Unlock()
+	if ok {
+		return nil
+	}
+
+	job, found, err := jbq.jobRepo.GetJob(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("error from `GetJob` method, package `gateway`: %#v", err)
+	}
+	if !found {
+		return fmt.Errorf("error, there is no job for session %s", sessionID)
+	}
+	if job.Finished() {
+		return nil
+	}
+
+	job.Status = models.JobCancelled
+	if err = jbq.update(job); err != nil {
+		return fmt.Errorf("error from `update` method, package `usecase`: %#v", err)
+	}
+	return nil
+}
+
+// jobProgress подсчитывает загруженные страницы и собранные автомобили задания
+type jobProgress struct {
+	// pagesFetched - количество загруженных веб-страниц
+	pagesFetched atomic.Int64
+	// carsParsed - количество автомобилей, данные которых собраны
+	carsParsed atomic.Int64
+}
+
+// PageFetched сообщает о загрузке веб-страницы
+func (jbp *jobProgress) PageFetched() {
+	jbp.pagesFetched.Add(1)
+}
+
+// CarParsed сообщает о том, что данные автомобиля собраны
+func (jbp *jobProgress) CarParsed() {
+	jbp.carsParsed.Add(1)
+}
+
+// update переносит счетчики в задание и сообщает, изменились ли они
+// Входной параметр: job - задание
+func (jbp *jobProgress) update(job *models.Job) bool {
+	pagesFetched, carsParsed := jbp.pagesFetched.Load(), jbp.carsParsed.Load()
+	changed := job.PagesFetched != pagesFetched || job.CarsParsed != carsParsed
+	job.PagesFetched, job.CarsParsed = pagesFetched, carsParsed
+	return changed
+}
@@ -0,0 +1,206 @@
+package usecase_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+	"vehicles/packages/domain/models"
+	"vehicles/packages/usecases/repository"
+	usecase "vehicles/packages/usecases/usecases"
+)
+
+// memoryJobRepository хранит состояния заданий в памяти и все сохраненные состояния по порядку
+type memoryJobRepository struct {
+	mutex sync.Mutex
+	jobs  map[string]models.Job
+	saved []models.Job
+}
+
+func (mjr *memoryJobRepository) SaveJob(ctx context.Context, job models.Job) error {
+	mjr.mutex.Lock()
+	defer mjr.mutex.Unlock()
+	mjr.jobs[job.SessionID] = job
+	mjr.saved = append(mjr.saved, job)
+	return nil
+}
+
+func (mjr *memoryJobRepository) UpdateJob(ctx context.Context, job models.Job) (bool, error) {
+	mjr.mutex.Lock()
+	defer mjr.mutex.Unlock()
+	if stored, ok := mjr.jobs[job.SessionID]; ok && stored.ID != job.ID {
+		return false, nil
+	}
+	mjr.jobs[job.SessionID] = job
+	mjr.saved = append(mjr.saved, job)
+	return true, nil
+}
+
+// history возвращает сохраненные состояния заданий сессии по порядку
+func (mjr *memoryJobRepository) history(sessionID string) []models.Job {
+	mjr.mutex.Lock()
+	defer mjr.mutex.Unlock()
+	var jobs []models.Job
+	for _, job := range mjr.saved {
+		if job.SessionID == sessionID {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
+
+func (mjr *memoryJobRepository) GetJob(ctx context.Context, sessionID string) (models.Job, bool, error) {
+	mjr.mutex.Lock()
+	defer mjr.mutex.Unlock()
+	job, ok := mjr.jobs[sessionID]
+	return job, ok, nil
+}
+
+// waitJob дожидается завершения задания сессии
+func waitJob(t *testing.T, jobs usecase.JobQueue, sessionID string) models.Job {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		job, ok, err := jobs.GetJob(context.Background(), sessionID)
+		if err != nil {
+			t.Fatalf("error from `GetJob` method: %#v", err)
+		}
+		if ok && job.Finished() {
+			return job
+		}
+	}
+	t.Fatalf("error, the job of session %s is not finished", sessionID)
+	return models.Job{}
+}
+
+// TestJobQueue проверяет сохранение хода выполнения и итогового состояния заданий: выполненного, завершившегося
+// ошибкой, отмененного пользователем и замененного новым заданием сессии
+func TestJobQueue(t *testing.T) {
+	jobs := usecase.NewJobQueue(&memoryJobRepository{jobs: make(map[string]models.Job)}, 2)
+
+	err := jobs.Enqueue("done", func(ctx context.Context, jobID string, progress repository.ScrapeProgress) error {
+		for i := 0; i < 3; i++ {
+			progress.PageFetched()
+		}
+		progress.CarParsed()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error from `Enqueue` method: %#v", err)
+	}
+	if job := waitJob(t, jobs, "done"); job.Status != models.JobDone || job.PagesFetched != 3 || job.CarsParsed != 1 {
+		t.Errorf("error, job %+v, expected done with 3 pages and 1 car", job)
+	}
+
+	err = jobs.Enqueue("failed", func(ctx context.Context, jobID string, progress repository.ScrapeProgress) error {
+		return fmt.Errorf("error, no listing source is available")
+	})
+	if err != nil {
+		t.Fatalf("error from `Enqueue` method: %#v", err)
+	}
+	if job := waitJob(t, jobs, "failed"); job.Status != models.JobFailed || job.Error == "" {
+		t.Errorf("error, job %+v, expected failed with the error", job)
+	}
+
+	started := make(chan struct{})
+	waitCancel := func(ctx context.Context, jobID string, progress repository.ScrapeProgress) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if err = jobs.Enqueue("cancelled", waitCancel); err != nil {
+		t.Fatalf("error from `Enqueue` method: %#v", err)
+	}
+	<-started
+	if err = jobs.CancelJob(context.Background(), "cancelled"); err != nil {
+		t.Fatalf("error from `CancelJob` method: %#v", err)
+	}
+	if job := waitJob(t, jobs, "cancelled"); job.Status != models.JobCancelled {
+		t.Errorf("error, job %+v, expected cancelled", job)
+	}
+
+	// новое задание сессии отменяет незавершенное, и его состояние не перезаписывается отмененным
+	started = make(chan struct{})
+	if err = jobs.Enqueue("replaced", waitCancel); err != nil {
+		t.Fatalf("error from `Enqueue` method: %#v", err)
+	}
+	<-started
+	err = jobs.Enqueue("replaced", func(ctx context.Context, jobID string, progress repository.ScrapeProgress) error {
+		time.Sleep(50 * time.Millisecond)
+		progress.CarParsed()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error from `Enqueue` method: %#v", err)
+	}
+	if job := waitJob(t, jobs, "replaced"); job.Status != models.JobDone || job.CarsParsed != 1 {
+		t.Errorf("error, job %+v, expected done with 1 car", job)
+	}
+
+	if err = jobs.CancelJob(context.Background(), "unknown"); err == nil {
+		t.Errorf("error, a job without a session was cancelled")
+	}
+}
+
+// TestJobQueueEnqueueWhileRunning проверяет, что задание, замененное новым заданием сессии во время выполнения,
+// не сохраняет ни ход выполнения, ни итоговое состояние после того, как сохранено новое задание
+func TestJobQueueEnqueueWhileRunning(t *testing.T) {
+	jobRepo := &memoryJobRepository{jobs: make(map[string]models.Job)}
+	jobs := usecase.NewJobQueue(jobRepo, 2)
+
+	started, stopped := make(chan struct{}), make(chan struct{})
+	err := jobs.Enqueue("session", func(ctx context.Context, jobID string, progress repository.ScrapeProgress) error {
+		close(started)
+		<-ctx.Done()
+		// работа отмененного задания завершается не сразу и продолжает сообщать о ходе выполнения
+		for deadline := time.Now().Add(1200 * time.Millisecond); time.Now().Before(deadline); {
+			progress.PageFetched()
+			time.Sleep(10 * time.Millisecond)
+		}
+		close(stopped)
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("error from `Enqueue` method: %#v", err)
+	}
+	<-started
+
+	release := make(chan struct{})
+	err = jobs.Enqueue("session", func(ctx context.Context, jobID string, progress repository.ScrapeProgress) error {
+		<-release
+		progress.CarParsed()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error from `Enqueue` method: %#v", err)
+	}
+	<-stopped
+	// итоговое состояние прежнего задания сохранялось бы сразу после завершения его работы
+	time.Sleep(50 * time.Millisecond)
+
+	stored, ok, err := jobs.GetJob(context.Background(), "session")
+	if err != nil || !ok {
+		t.Fatalf("error from `GetJob` method: %#v (found %v)", err, ok)
+	}
+	if stored.Finished() || stored.PagesFetched != 0 {
+		t.Errorf("error, job %+v, expected the new job that is not finished and fetched no pages", stored)
+	}
+
+	close(release)
+	final := waitJob(t, jobs, "session")
+	if final.ID != stored.ID || final.Status != models.JobDone || final.CarsParsed != 1 || final.PagesFetched != 0 {
+		t.Errorf("error, job %+v, expected the new job %s done with 1 car", final, stored.ID)
+	}
+
+	history := jobRepo.history("session")
+	for idx, job := range history {
+		if job.ID == final.ID {
+			for _, later := range history[idx:] {
+				if later.ID != final.ID {
+					t.Errorf("error, the replaced job %+v is saved after the new job", later)
+				}
+			}
+			break
+		}
+	}
+}
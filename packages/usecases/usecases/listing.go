@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"fmt"
+	"sync"
+	"vehicles/packages/domain/models"
+	"vehicles/packages/usecases/repository"
+)
+
+// scrapeListings собирает автомобили со всех включенных интернет-порталов объявлений, помечает каждый автомобиль
+// порталом, с которого он собран, и присваивает автомобилям сквозные идентификаторы. Порталы опрашиваются
+// одновременно; портал, с которого не удалось собрать данные, пропускается; ошибка возвращается, только если данные
+// не удалось собрать ни с одного портала
+// Входные параметры: listingRepos - порталы объявлений, scrape - сбор данных с одного портала
+func scrapeListings(listingRepos []repository.ListingRepository,
+	scrape func(listingRepo repository.ListingRepository) ([]models.Car, error)) ([]models.Car, error) {
+	if len(listingRepos) == 0 {
+		return nil, fmt.Errorf("error, there are no listing sources")
+	}
+
+	sourceCars := make([][]models.Car, len(listingRepos))
+	errs := make([]error, len(listingRepos))
+	var wg sync.WaitGroup
+	for i, listingRepo := range listingRepos {
+		wg.Add(1)
+		go func(i int, listingRepo repository.ListingRepository) {
+			defer wg.Done()
+			sourceCars[i], errs[i] = scrape(listingRepo)
+		}(i, listingRepo)
+	}
+	wg.Wait()
+
+	var cars []models.Car
+	var lastErr error
+	failed := 0
+	for i, listingRepo := range listingRepos {
+		if errs[i] != nil {
+			fmt.Printf("error while scraping cars from %s: %#v\n", listingRepo.Source(), errs[i])
+			lastErr = errs[i]
+			failed++
+			continue
+		}
+
+		for _, car := range sourceCars[i] {
+			car.ID = len(cars)
+			car.Source = listingRepo.Source()
+			cars = append(cars, car)
+		}
+	}
+
+	if failed == len(listingRepos) {
+		return nil, fmt.Errorf("error, no listing source is available, the last error from %s: %#v",
+			listingRepos[len(listingRepos)-1].Source(), lastErr)
+	}
+	return cars, nil
+}
@@ -0,0 +1,52 @@
+package usecase
+
+import (
+	"fmt"
+	"vehicles/packages/domain/fuzzy"
+	"vehicles/packages/domain/models"
+)
+
+// PresentParetoFronts ответственен за формирование веб-страницы, показывающей автомобили, разбитые на фронты Парето
+// по коэффициентам, вместо одного рейтинга
+// Входные параметры: sessionID - идентификатор сессии, criteria - нечеткие множества, по коэффициентам которых
+// сравниваются автомобили, choice - автомобили получены из интернета (true) или из БД (false)
+func (slu *selectionUseCase) PresentParetoFronts(sessionID string, criteria []string, choice bool) error {
+	cars, report, err := slu.paretoFronts(sessionID, criteria)
+	if err != nil {
+		return fmt.Errorf("error from `paretoFronts` method, package `usecase`: %#v", err)
+	}
+	slu.output.ShowParetoFronts(sessionID, cars, report, choice)
+	return nil
+}
+
+// ReportParetoFronts ответственен за отправку фронтов Парето
+// Входные параметры: sessionID - идентификатор сессии, criteria - нечеткие множества, по коэффициентам которых
+// сравниваются автомобили
+func (slu *selectionUseCase) ReportParetoFronts(sessionID string, criteria []string) error {
+	_, report, err := slu.paretoFronts(sessionID, criteria)
+	if err != nil {
+		return fmt.Errorf("error from `paretoFronts` method, package `usecase`: %#v", err)
+	}
+	slu.output.SendParetoFronts(report)
+	return nil
+}
+
+// paretoFronts разбивает автомобили, ранее сохраненные в БД под управлением Redis, на фронты Парето. Идентификатор
+// автомобиля равен его месту в сохраненном рейтинге, как и в ссылках на страницы автомобилей
+// Входные параметры: sessionID - идентификатор сессии, criteria - нечеткие множества, по коэффициентам которых
+// сравниваются автомобили
+func (slu *selectionUseCase) paretoFronts(sessionID string, criteria []string) ([]models.Car, fuzzy.ParetoReport, error) {
+	cars, err := slu.carsRepo.GetCarsData(sessionID)
+	if err != nil {
+		return nil, fuzzy.ParetoReport{}, fmt.Errorf("error from `GetCarsData` method, package `gateway`: %#v", err)
+	}
+	for idx := range cars {
+		cars[idx].ID = idx + 1
+	}
+
+	report, err := slu.engine.ParetoFronts(slu.ctx, cars, criteria)
+	if err != nil {
+		return nil, fuzzy.ParetoReport{}, fmt.Errorf("error from `ParetoFronts` method, package `fuzzy`: %#v", err)
+	}
+	return cars, report, nil
+}
@@ -1,7 +1,9 @@
 package usecase
 
 import (
+	"context"
 	"fmt"
+	"vehicles/packages/adapters"
 	"vehicles/packages/domain/models"
 	"vehicles/packages/usecases/repository"
 )
@@ -25,28 +27,43 @@ type SearchOutput interface {
 }
 
 type searchUseCase struct {
-	searchRepo      repository.SearchRepository
+	ctx             adapters.Context
+	listingRepos    []repository.ListingRepository
 	carsRepo        repository.CarsRepository
 	userUseCase     UserInput
 	questionUseCase QuestionInput
 	output          SearchOutput
+	jobs            JobQueue
 }
 
-func NewSearchUseCase(r repository.SearchRepository, cr repository.CarsRepository, u UserInput, q QuestionInput, o SearchOutput) SearchInput {
-	return &searchUseCase{r, cr, u, q, o}
+func NewSearchUseCase(ctx adapters.Context, lr []repository.ListingRepository, cr repository.CarsRepository, u UserInput,
+	q QuestionInput, o SearchOutput, j JobQueue) SearchInput {
+	return &searchUseCase{ctx, lr, cr, u, q, o, j}
 }
 
-// GetCars ответственен за получение списка автомобилей, чьи данные
-// собраны из интернета, и сохранение его в БД под управлением Redis
+// GetCars ставит в очередь задание получения списка автомобилей, чьи данные собраны из интернета со всех
+// включенных порталов объявлений, и сохранения его в БД под управлением Redis. Сбор данных прерывается, когда
+// задание отменяется или истекает время сбора данных; автомобили отмененного или замененного задания не сохраняются
 func (sru *searchUseCase) GetCars(search models.Search, sessionID string) error {
-	cars, err := sru.searchRepo.ScrapeSearchCars(search)
-	if err != nil {
-		return fmt.Errorf("error from `ScrapeSearchCars` method, package `gateway`: %#v", err)
-	}
+	err := sru.jobs.Enqueue(sessionID, func(ctx context.Context, jobID string, progress repository.ScrapeProgress) error {
+		cars, err := scrapeListings(sru.listingRepos, func(listingRepo repository.ListingRepository) ([]models.Car, error) {
+			return listingRepo.ScrapeSearchCars(ctx, search, progress)
+		})
+		if err != nil {
+			return fmt.Errorf("error from `scrapeListings` function, package `usecase`: %#v", err)
+		}
 
-	err = sru.carsRepo.LoadCarsData(sessionID, cars)
+		// сбор данных отмененного задания прерывается без ошибки, поэтому его неполные результаты не сохраняются
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if _, err = sru.carsRepo.LoadJobCarsData(ctx, sessionID, jobID, cars); err != nil {
+			return fmt.Errorf("error from `LoadJobCarsData` method, package `gateway`: %#v", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("error from `LoadCarsData` method, package `gateway`: %#v", err)
+		return fmt.Errorf("error from `Enqueue` method, package `usecase`: %#v", err)
 	}
 	return nil
 }
@@ -0,0 +1,115 @@
+package usecase_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"vehicles/packages/domain/fuzzy"
+	"vehicles/packages/domain/models"
+	"vehicles/packages/usecases/repository"
+	usecase "vehicles/packages/usecases/usecases"
+)
+
+// memoryCarsRepository хранит автомобили в памяти и все сохраненные автомобили по порядку. Как хранилище Redis,
+// результаты задания сохраняются, только если хранилище заданий не содержит задания сессии, поставленного
+// в очередь позже
+type memoryCarsRepository struct {
+	repository.CarsRepository
+	jobRepo *memoryJobRepository
+	mutex   sync.Mutex
+	cars    map[string][]models.Car
+	loaded  []models.Car
+}
+
+func (mcr *memoryCarsRepository) LoadJobCarsData(ctx context.Context, sessionID, jobID string,
+	cars []models.Car) (bool, error) {
+	mcr.jobRepo.mutex.Lock()
+	defer mcr.jobRepo.mutex.Unlock()
+	if stored, ok := mcr.jobRepo.jobs[sessionID]; ok && stored.ID != jobID {
+		return false, nil
+	}
+
+	mcr.mutex.Lock()
+	defer mcr.mutex.Unlock()
+	mcr.cars[sessionID] = cars
+	mcr.loaded = append(mcr.loaded, cars...)
+	return true, nil
+}
+
+func (mcr *memoryCarsRepository) LoadJobSelectionData(ctx context.Context, sessionID, jobID string, cars []models.Car,
+	explanations []fuzzy.Explanation) (bool, error) {
+	return mcr.LoadJobCarsData(ctx, sessionID, jobID, cars)
+}
+
+func (mcr *memoryCarsRepository) GetCarsData(sessionID string) ([]models.Car, error) {
+	mcr.mutex.Lock()
+	defer mcr.mutex.Unlock()
+	return mcr.cars[sessionID], nil
+}
+
+// blockingListingRepository при первом сборе данных дожидается отмены контекста и, как порталы объявлений,
+// возвращает без ошибки автомобили, собранные до отмены, а при следующих сборах сразу возвращает автомобили
+type blockingListingRepository struct {
+	started chan struct{}
+	calls   int
+}
+
+func (blr *blockingListingRepository) Source() string {
+	return "memory"
+}
+
+func (blr *blockingListingRepository) ScrapeSearchCars(ctx context.Context, search models.Search,
+	progress repository.ScrapeProgress) ([]models.Car, error) {
+	blr.calls++
+	if blr.calls == 1 {
+		close(blr.started)
+		<-ctx.Done()
+		return []models.Car{{Offering: models.Offering{Price: "replaced"}}}, nil
+	}
+	return []models.Car{{Offering: models.Offering{Price: "new"}}}, nil
+}
+
+func (blr *blockingListingRepository) ScrapeSelectionCars(ctx context.Context, minPrice, maxPrice string,
+	makes []models.Makes, progress repository.ScrapeProgress) ([]models.Car, error) {
+	return blr.ScrapeSearchCars(ctx, models.Search{}, progress)
+}
+
+// TestSearchGetCarsReplaced проверяет, что автомобили, собранные заданием до его замены новым заданием сессии,
+// не сохраняются ни до, ни после сохранения автомобилей нового задания
+func TestSearchGetCarsReplaced(t *testing.T) {
+	jobRepo := &memoryJobRepository{jobs: make(map[string]models.Job)}
+	// задания выполняются по одному, поэтому новое задание начинается только после завершения работы прежнего
+	jobs := usecase.NewJobQueue(jobRepo, 1)
+	carsRepo := &memoryCarsRepository{jobRepo: jobRepo, cars: make(map[string][]models.Car)}
+	listingRepo := &blockingListingRepository{started: make(chan struct{})}
+	search := usecase.NewSearchUseCase(nil, []repository.ListingRepository{listingRepo}, carsRepo, nil, nil, nil, jobs)
+
+	if err := search.GetCars(models.Search{}, "session"); err != nil {
+		t.Fatalf("error from `GetCars` method: %#v", err)
+	}
+	<-listingRepo.started
+	if err := search.GetCars(models.Search{}, "session"); err != nil {
+		t.Fatalf("error from `GetCars` method: %#v", err)
+	}
+
+	if job := waitJob(t, jobs, "session"); job.Status != models.JobDone {
+		t.Fatalf("error, job %+v, expected done", job)
+	}
+	cars, err := carsRepo.GetCarsData("session")
+	if err != nil {
+		t.Fatalf("error from `GetCarsData` method: %#v", err)
+	}
+	if len(cars) != 1 || cars[0].Offering.Price != "new" {
+		t.Errorf("error, cars %+v, expected only the cars of the new job", cars)
+	}
+	for _, car := range carsRepo.loaded {
+		if car.Offering.Price == "replaced" {
+			t.Errorf("error, the cars of the replaced job are saved")
+		}
+	}
+	for _, job := range jobRepo.history("session") {
+		if job.Status == models.JobFailed {
+			t.Errorf("error, job %+v failed", job)
+		}
+	}
+}
@@ -1,11 +1,14 @@
 package usecase
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math/rand"
 	"os"
+	"time"
 	"vehicles/packages/adapters"
+	"vehicles/packages/domain/fuzzy"
 	"vehicles/packages/domain/models"
 	"vehicles/packages/usecases/repository"
 )
@@ -17,52 +20,87 @@ const NumberOfCars = 10
 // использующий нечеткий алгоритм для ранжирования автомобилей
 type SelectionInput interface {
 	PickPriorities()
-	SelectPriorities(sessionID string, priorities []string)
+	SelectPriorities(sessionID string, priorities []string, weights map[string]float64) error
 	PickPrice()
 	SelectPrice(minPrice, maxPrice string)
 	PickManufacturers()
 	SelectManufacturers(manufacturers []string)
+	PickConstraints()
+	SelectConstraints(constraints models.Constraints) error
 	PickSource()
 	MakeSelectionFromDBCars(sessionID string) error
 	MakeSelectionFromInternetCars(sessionID string) error
 	PassSelectionCarsData(sessionID string, choice bool) error
 	PresentSelectionCarAd(sessionID string, carID int, choice bool) error
+	SaveFeedback(sessionID string, carID int, action string) error
+	PresentSensitivity(sessionID string, topN int, delta float64, choice bool) error
+	ReportSensitivity(sessionID string, selection models.Selection, topN int, delta float64) error
+	PresentParetoFronts(sessionID string, criteria []string, choice bool) error
+	ReportParetoFronts(sessionID string, criteria []string) error
 }
 
 // SelectionOutput содержит методы, которые рендерят html-шаблоны
 type SelectionOutput interface {
-	ShowPriorities()
+	ShowPriorities(variables []fuzzy.Variable, maxPriorities int)
 	ShowPrice()
 	ShowManufacturers()
+	ShowConstraints()
 	ShowSources()
-	ShowResultOfFuzzyAlgorithm(sessionID string, cars []models.Car, choice bool)
-	ShowSelectionCarAd(sessionID string, car models.Car, choice bool)
+	ShowResultOfFuzzyAlgorithm(sessionID string, cars []models.Car, explanations []fuzzy.Explanation, choice bool)
+	ShowSelectionCarAd(sessionID string, carID int, car models.Car, explanation *fuzzy.Explanation, choice bool)
+	ShowSensitivity(sessionID string, cars []models.Car, report fuzzy.SensitivityReport, choice bool)
+	SendSensitivity(report fuzzy.SensitivityReport)
+	ShowParetoFronts(sessionID string, cars []models.Car, report fuzzy.ParetoReport, choice bool)
+	SendParetoFronts(report fuzzy.ParetoReport)
 }
 
 type selectionUseCase struct {
 	ctx           adapters.Context
 	selectionRepo repository.SelectionRepository
+	listingRepos  []repository.ListingRepository
 	carsRepo      repository.CarsRepository
+	feedbackRepo  repository.FeedbackRepository
 	userUseCase   UserInput
 	output        SelectionOutput
 	User          models.User
+	engine        *fuzzy.Engine
+	jobs          JobQueue
 }
 
-func NewSelectionUseCase(ctx adapters.Context, sr repository.SelectionRepository, cr repository.CarsRepository, ut UserInput, ot SelectionOutput, ur models.User) SelectionInput {
-	return &selectionUseCase{ctx, sr, cr, ut, ot, ur}
+func NewSelectionUseCase(ctx adapters.Context, sr repository.SelectionRepository, lr []repository.ListingRepository,
+	cr repository.CarsRepository, fr repository.FeedbackRepository, ut UserInput, ot SelectionOutput, ur models.User,
+	eng *fuzzy.Engine, j JobQueue) SelectionInput {
+	return &selectionUseCase{ctx, sr, lr, cr, fr, ut, ot, ur, eng, j}
 }
 
 // PickPriorities ответственен за формирование веб-страницы, предлагающей пользователю
-// расставить приоритеты: "Комфорт", "Экономичность", "Безопасность", "Динамика", "Управляемость", по
-// которым будут ранжироваться автомобили
+// расставить приоритеты, по которым будут ранжироваться автомобили: все нечеткие множества нечеткого алгоритма,
+// например, "Комфорт", "Экономичность", "Безопасность" и т.д.
 func (slu *selectionUseCase) PickPriorities() {
-	slu.output.ShowPriorities()
+	slu.output.ShowPriorities(slu.engine.Variables.Variables(), fuzzy.MaxPriorities)
 }
 
-// SelectPriorities ответственен за сохранение приоритетов, расставленных пользователем, в cookie
-// Входной параметр: priorities - приоритеты, расставленные пользователем
-func (slu *selectionUseCase) SelectPriorities(sessionID string, priorities []string) {
+// SelectPriorities ответственен за сохранение приоритетов, расставленных пользователем, в cookie.
+// Если пользователь задал веса приоритетов, то приоритеты упорядочиваются по убыванию весов
+// Входные параметры: priorities - приоритеты, расставленные пользователем, weights - веса приоритетов
+// (ключ - нечеткое множество), если пользователь их задал
+func (slu *selectionUseCase) SelectPriorities(sessionID string, priorities []string, weights map[string]float64) error {
+	if len(weights) > 0 {
+		var err error
+		priorities, err = slu.engine.Variables.WeightedPriorities(weights)
+		if err != nil {
+			return fmt.Errorf("error from `WeightedPriorities` method, package `fuzzy`: %#v", err)
+		}
+	} else if len(priorities) > 0 {
+		// без приоритетов автомобили ранжируются по цене
+		if err := slu.engine.Variables.ValidatePriorities(priorities); err != nil {
+			return fmt.Errorf("error from `ValidatePriorities` method, package `fuzzy`: %#v", err)
+		}
+	}
+
 	slu.selectionRepo.SetPriorities(priorities)
+	slu.selectionRepo.SetWeights(weights)
+	return nil
 }
 
 // PickPrice ответственен за формирование веб-страницы, предлагающей пользователю задать
@@ -90,6 +128,25 @@ func (slu *selectionUseCase) SelectManufacturers(manufacturers []string) {
 	slu.selectionRepo.SetManufacturers(manufacturers)
 }
 
+// PickConstraints ответственен за формирование веб-страницы, предлагающей пользователю задать жесткие
+// ограничения: тип кузова, трансмиссию, привод, топливо, число мест, объем багажника, возраст автомобиля
+// и обязательные опции
+func (slu *selectionUseCase) PickConstraints() {
+	slu.output.ShowConstraints()
+}
+
+// SelectConstraints ответственен за проверку жестких ограничений, заданных пользователем, и их сохранение в cookie
+// Входной параметр: constraints - ограничения
+func (slu *selectionUseCase) SelectConstraints(constraints models.Constraints) error {
+	if err := constraints.Validate(); err != nil {
+		return fmt.Errorf("error from `Validate` method, package `models`: %#v", err)
+	}
+	if err := slu.selectionRepo.SetConstraints(constraints); err != nil {
+		return fmt.Errorf("error from `SetConstraints` method, package `gateway`: %#v", err)
+	}
+	return nil
+}
+
 // PickSource ответственен за формирование веб-страницы, предлагающей пользователю выбрать,
 // из какого источника: базы данных или интернета он хочет получить ранжированный по его предпочтениям
 // список автомобилей
@@ -111,7 +168,9 @@ func (slu *selectionUseCase) MakeSelectionFromDBCars(sessionID string) error {
 		return fmt.Errorf("error from `SelectCars` method, package `gateway`: %#v", err)
 	}
 
-	ids, err := generateResultOfFuzzyAlgorithm(cars, selection.Priorities)
+	// при A/B-тесте баз правил автомобили сессии ранжируются базой правил ее варианта
+	engine, _ := slu.engine.ForSession(sessionID)
+	ids, explanations, err := generateResultOfFuzzyAlgorithm(slu.ctx, engine, cars, selection.Priorities, selection.Weights)
 	if err != nil {
 		return fmt.Errorf("error from `generateResultOfFuzzyAlgorithm` function, package `usecase`: %#v", err)
 	}
@@ -130,11 +189,17 @@ func (slu *selectionUseCase) MakeSelectionFromDBCars(sessionID string) error {
 	if err != nil {
 		return fmt.Errorf("error from `LoadDBCarsData` method, package `gateway`: %#v", err)
 	}
+
+	err = slu.carsRepo.LoadExplanationsData(sessionID, explanations)
+	if err != nil {
+		return fmt.Errorf("error from `LoadExplanationsData` method, package `gateway`: %#v", err)
+	}
 	return nil
 }
 
-// MakeSelectionFromInternetCars ответственен за получение списка автомобилей из интернета,
-// его ранжирование и сохранение в БД под управлением Redis
+// MakeSelectionFromInternetCars ставит в очередь задание получения списка автомобилей из интернета,
+// его ранжирования и сохранения в БД под управлением Redis. Параметры подбора читаются до постановки в очередь,
+// результаты отмененного или замененного задания не сохраняются
 // Входной параметр: sessionID - идентификатор сессии
 func (slu *selectionUseCase) MakeSelectionFromInternetCars(sessionID string) error {
 	selection, err := slu.selectionRepo.GetSelectionParams()
@@ -146,20 +211,37 @@ func (slu *selectionUseCase) MakeSelectionFromInternetCars(sessionID string) err
 	if err != nil {
 		return fmt.Errorf("error from `chooseRandomMakes` function, package `usecase`: %#v", err)
 	}
-	cars, err := slu.selectionRepo.ScrapeSelectionCars(selection.MinPrice, selection.MaxPrice, makes)
-	if err != nil {
-		return fmt.Errorf("error from `ScrapeSelectionCars` method, package `gateway`: %#v", err)
-	}
 
-	ids, err := generateResultOfFuzzyAlgorithm(cars, selection.Priorities)
-	if err != nil {
-		return fmt.Errorf("error from `generateResultOfFuzzyAlgorithm` function, package `usecase`: %#v", err)
-	}
+	err = slu.jobs.Enqueue(sessionID, func(ctx context.Context, jobID string, progress repository.ScrapeProgress) error {
+		// автомобили каждой марки собираются с каждого включенного портала объявлений
+		cars, err := scrapeListings(slu.listingRepos, func(listingRepo repository.ListingRepository) ([]models.Car, error) {
+			return listingRepo.ScrapeSelectionCars(ctx, selection.MinPrice, selection.MaxPrice, makes, progress)
+		})
+		if err != nil {
+			return fmt.Errorf("error from `scrapeListings` function, package `usecase`: %#v", err)
+		}
+		// на сайте нельзя искать по жестким ограничениям, поэтому они проверяются после сбора данных
+		cars = models.FilterCars(cars, selection.Constraints, time.Now().Year())
+
+		// при A/B-тесте баз правил автомобили сессии ранжируются базой правил ее варианта
+		engine, _ := slu.engine.ForSession(sessionID)
+		ids, explanations, err := generateResultOfFuzzyAlgorithm(ctx, engine, cars, selection.Priorities, selection.Weights)
+		if err != nil {
+			return fmt.Errorf("error from `generateResultOfFuzzyAlgorithm` function, package `usecase`: %#v", err)
+		}
 
-	cars = getCarsForRendering(cars, ids)
-	err = slu.carsRepo.LoadCarsData(sessionID, cars)
+		// сбор данных отмененного задания прерывается без ошибки, поэтому его неполные результаты не сохраняются
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		cars = getCarsForRendering(cars, ids)
+		if _, err = slu.carsRepo.LoadJobSelectionData(ctx, sessionID, jobID, cars, explanations); err != nil {
+			return fmt.Errorf("error from `LoadJobSelectionData` method, package `gateway`: %#v", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("error from `LoadCarsData` method, package `gateway`: %#v", err)
+		return fmt.Errorf("error from `Enqueue` method, package `usecase`: %#v", err)
 	}
 	return nil
 }
@@ -300,7 +382,12 @@ func (slu *selectionUseCase) PassSelectionCarsData(sessionID string, choice bool
 		return fmt.Errorf("error from `GetCarsData` method, package `gateway`: %#v", err)
 	}
 
-	slu.output.ShowResultOfFuzzyAlgorithm(sessionID, cars, choice)
+	explanations, err := slu.carsRepo.GetExplanationsData(sessionID)
+	if err != nil {
+		return fmt.Errorf("error from `GetExplanationsData` method, package `gateway`: %#v", err)
+	}
+
+	slu.output.ShowResultOfFuzzyAlgorithm(sessionID, cars, explanations, choice)
 	return nil
 }
 
@@ -311,6 +398,76 @@ func (slu *selectionUseCase) PresentSelectionCarAd(sessionID string, carID int,
 	if err != nil {
 		return fmt.Errorf("error from `GetCarsData` method, package `gateway`: %#v", err)
 	}
-	slu.output.ShowSelectionCarAd(sessionID, cars[carID-1], choice)
+
+	explanations, err := slu.carsRepo.GetExplanationsData(sessionID)
+	if err != nil {
+		return fmt.Errorf("error from `GetExplanationsData` method, package `gateway`: %#v", err)
+	}
+
+	if carID < 1 || carID > len(cars) {
+		return fmt.Errorf("error, there is no car %d in the selection of the session %q", carID, sessionID)
+	}
+	// объяснения нет, если автомобили ранжировались по цене
+	var explanation *fuzzy.Explanation
+	if carID-1 < len(explanations) {
+		explanation = &explanations[carID-1]
+		// отзыв не должен мешать показать страницу автомобиля
+		if err = slu.recordFeedback(sessionID, carID, fuzzy.FeedbackOpen, *explanation); err != nil {
+			fmt.Printf("error from `recordFeedback` method, package `usecase`: %#v\n", err)
+		}
+	}
+	slu.output.ShowSelectionCarAd(sessionID, carID, cars[carID-1], explanation, choice)
+	return nil
+}
+
+// SaveFeedback ответственен за сохранение отзыва пользователя об автомобиле из рейтинга: добавления
+// в избранное или отказа
+// Входные параметры: sessionID - идентификатор сессии, carID - идентификатор автомобиля (место в рейтинге),
+// action - действие пользователя
+func (slu *selectionUseCase) SaveFeedback(sessionID string, carID int, action string) error {
+	if err := fuzzy.ValidateFeedbackAction(action); err != nil {
+		return fmt.Errorf("error from `ValidateFeedbackAction` function, package `fuzzy`: %#v", err)
+	}
+
+	explanations, err := slu.carsRepo.GetExplanationsData(sessionID)
+	if err != nil {
+		return fmt.Errorf("error from `GetExplanationsData` method, package `gateway`: %#v", err)
+	}
+	if carID < 1 || carID > len(explanations) {
+		return fmt.Errorf("error, there is no ranked car %d in the selection of the session %q", carID, sessionID)
+	}
+
+	if err = slu.recordFeedback(sessionID, carID, action, explanations[carID-1]); err != nil {
+		return fmt.Errorf("error from `recordFeedback` method, package `usecase`: %#v", err)
+	}
+	return nil
+}
+
+// recordFeedback записывает действие пользователя с автомобилем из рейтинга. Отзывы учитываются только для
+// рейтинга по расстановке приоритетов: правила для весов строятся по весам и не обучаются
+// Входные параметры: sessionID - идентификатор сессии, carID - место автомобиля в рейтинге, action - действие,
+// explanation - объяснение результата нечеткого алгоритма для автомобиля
+func (slu *selectionUseCase) recordFeedback(sessionID string, carID int, action string, explanation fuzzy.Explanation) error {
+	selection, err := slu.selectionRepo.GetSelectionParams()
+	if err != nil {
+		return fmt.Errorf("error from `GetSelectionParams` method, package `gateway`: %#v", err)
+	}
+	if len(selection.Weights) > 0 || len(selection.Priorities) == 0 {
+		return nil
+	}
+
+	var experiment string
+	if slu.engine.Experiment != nil {
+		experiment = slu.engine.Experiment.Name
+	}
+	_, variant := slu.engine.ForSession(sessionID)
+	event, err := fuzzy.NewFeedbackEvent(sessionID, selection.Priorities, experiment, variant, carID, action, explanation)
+	if err != nil {
+		return fmt.Errorf("error from `NewFeedbackEvent` function, package `fuzzy`: %#v", err)
+	}
+
+	if err = slu.feedbackRepo.InsertFeedback(slu.ctx, event); err != nil {
+		return fmt.Errorf("error from `InsertFeedback` method, package `gateway`: %#v", err)
+	}
 	return nil
 }
@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"fmt"
+	"vehicles/packages/domain/fuzzy"
+	"vehicles/packages/domain/models"
+)
+
+// PresentSensitivity ответственен за формирование веб-страницы, показывающей, как изменяется рейтинг автомобилей
+// при изменении приоритетов, сохраненных в cookie
+// Входные параметры: sessionID - идентификатор сессии, topN - количество первых мест рейтинга, delta - доля
+// изменения веса приоритета, choice - автомобили получены из интернета (true) или из БД (false)
+func (slu *selectionUseCase) PresentSensitivity(sessionID string, topN int, delta float64, choice bool) error {
+	selection, err := slu.selectionRepo.GetSelectionParams()
+	if err != nil {
+		return fmt.Errorf("error from `GetSelectionParams` method, package `gateway`: %#v", err)
+	}
+
+	cars, report, err := slu.analyzeSensitivity(sessionID, *selection, topN, delta)
+	if err != nil {
+		return fmt.Errorf("error from `analyzeSensitivity` method, package `usecase`: %#v", err)
+	}
+	slu.output.ShowSensitivity(sessionID, cars, report, choice)
+	return nil
+}
+
+// ReportSensitivity ответственен за отправку результата анализа чувствительности рейтинга к приоритетам,
+// заданным в запросе
+// Входные параметры: sessionID - идентификатор сессии, selection - запрос пользователя с приоритетами или весами,
+// topN - количество первых мест рейтинга, delta - доля изменения веса приоритета
+func (slu *selectionUseCase) ReportSensitivity(sessionID string, selection models.Selection, topN int, delta float64) error {
+	_, report, err := slu.analyzeSensitivity(sessionID, selection, topN, delta)
+	if err != nil {
+		return fmt.Errorf("error from `analyzeSensitivity` method, package `usecase`: %#v", err)
+	}
+	slu.output.SendSensitivity(report)
+	return nil
+}
+
+// analyzeSensitivity ранжирует автомобили, ранее сохраненные в БД под управлением Redis, в сценариях с измененными
+// приоритетами. Автомобили не собираются из интернета повторно. Идентификатор автомобиля в отчете равен его
+// месту в сохраненном рейтинге, как и в ссылках на страницы автомобилей
+// Входные параметры: sessionID - идентификатор сессии, selection - запрос пользователя с приоритетами или весами,
+// topN - количество первых мест рейтинга, delta - доля изменения веса приоритета
+func (slu *selectionUseCase) analyzeSensitivity(sessionID string, selection models.Selection, topN int,
+	delta float64) ([]models.Car, fuzzy.SensitivityReport, error) {
+	weights := selection.Weights
+	if len(weights) == 0 {
+		if len(selection.Priorities) == 0 {
+			return nil, fuzzy.SensitivityReport{}, fmt.Errorf("error, there are neither priorities nor weights")
+		}
+		weights = slu.engine.Variables.PriorityWeights(selection.Priorities)
+	}
+
+	scenarios, err := slu.engine.Variables.SensitivityScenarios(weights, delta)
+	if err != nil {
+		return nil, fuzzy.SensitivityReport{}, fmt.Errorf("error from `SensitivityScenarios` method, package `fuzzy`: %#v", err)
+	}
+
+	cars, err := slu.carsRepo.GetCarsData(sessionID)
+	if err != nil {
+		return nil, fuzzy.SensitivityReport{}, fmt.Errorf("error from `GetCarsData` method, package `gateway`: %#v", err)
+	}
+	for idx := range cars {
+		cars[idx].ID = idx + 1
+	}
+
+	report, err := slu.engine.Sensitivity(slu.ctx, cars, scenarios, topN)
+	if err != nil {
+		return nil, fuzzy.SensitivityReport{}, fmt.Errorf("error from `Sensitivity` method, package `fuzzy`: %#v", err)
+	}
+	return cars, report, nil
+}
@@ -2,16 +2,23 @@ package server
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
 	"time"
+	"vehicles/packages/adapters/gateway"
+	"vehicles/packages/domain/fuzzy"
 	"vehicles/packages/infrastructure/datastore"
 	ir "vehicles/packages/infrastructure/router"
+	"vehicles/packages/registry"
+	"vehicles/packages/usecases/repository"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
 )
 
 func Run(port string) error {
@@ -26,7 +33,106 @@ func Run(port string) error {
 	if err != nil {
 		panic(err)
 	}
+	// нечеткие множества со шкалами из конфигурации создаются один раз при запуске сервера и не изменяются;
+	// их используют нечеткий алгоритм и источники правил
+	scales := viper.GetStringMap("fuzzy.scales")
+	variables, err := scaledVariables(scales)
+	if err != nil {
+		panic(err)
+	}
+	// нечеткие правила загружаются в память один раз при запуске сервера
+	rules, err := loadRules(viper.GetString("fuzzy.rules_dir"), viper.GetString("fuzzy.rule_template"),
+		viper.GetBool("fuzzy.generate_missing_rules"), len(scales) > 0, variables)
+	if err != nil {
+		panic(err)
+	}
+
+	// нечеткий алгоритм, ранжирующий автомобили
+	engine := fuzzy.NewEngine(rules, variables)
+	if method := viper.GetString("fuzzy.defuzzifier"); method != "" {
+		if engine.Defuzzifier, err = fuzzy.NewDefuzzifier(method); err != nil {
+			panic(err)
+		}
+	}
+	if name := viper.GetString("fuzzy.tnorm"); name != "" {
+		if engine.TNorm, err = fuzzy.NewTNorm(name); err != nil {
+			panic(err)
+		}
+	}
+	if name := viper.GetString("fuzzy.snorm"); name != "" {
+		if engine.SNorm, err = fuzzy.NewSNorm(name); err != nil {
+			panic(err)
+		}
+	}
+	if name := viper.GetString("fuzzy.implication"); name != "" {
+		if engine.Implication, err = fuzzy.NewImplication(name); err != nil {
+			panic(err)
+		}
+	}
+
+	if mode := viper.GetString("fuzzy.confidence.mode"); mode != "" {
+		if err = fuzzy.ValidateConfidenceMode(mode); err != nil {
+			panic(err)
+		}
+		engine.ConfidenceMode = mode
+	}
+	engine.Workers = viper.GetInt("fuzzy.workers")
+	if viper.IsSet("fuzzy.confidence.threshold") {
+		engine.MinConfidence = viper.GetFloat64("fuzzy.confidence.threshold")
+	}
+	if engine.Imputer, err = loadImputer(viper.GetString("fuzzy.imputation"), vehiclesDB); err != nil {
+		panic(err)
+	}
+	if engine.Experiment, err = loadExperiment(rules, variables); err != nil {
+		panic(err)
+	}
+
+	// функции принадлежности и формулы коэффициентов перечитываются из источника во время работы сервера
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+	if err = watchMemberships(watchCtx, engine.Memberships, vehiclesDB); err != nil {
+		panic(err)
+	}
+	if err = watchFormulas(watchCtx, engine.Formulas); err != nil {
+		panic(err)
+	}
+	// интервальные функции принадлежности второго типа подбираются по ответам пользователей один раз
+	// при запуске сервера
+	if viper.GetBool("fuzzy.type2.enabled") {
+		engine.IntervalMemberships, err = loadIntervalMemberships(surveyDB, engine.Memberships.Functions(),
+			viper.GetFloat64("fuzzy.type2.spread"))
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	// в режиме replay страницы порталов читаются из каталога образцов, в режиме record - сохраняются в него
+	fetcher, err := gateway.NewFetcher(gateway.FetcherConfig{
+		Mode:        viper.GetString("scraping.fetcher.mode"),
+		FixturesDir: viper.GetString("scraping.fetcher.fixtures_dir"),
+		PageTimeout: viper.GetDuration("scraping.fetcher.page_timeout"),
+		Rate:        viper.GetFloat64("scraping.fetcher.rate"),
+		Burst:       viper.GetInt("scraping.fetcher.burst"),
+		Retries:     viper.GetInt("scraping.fetcher.retries"),
+		Backoff:     viper.GetDuration("scraping.fetcher.backoff"),
+	})
+	if err != nil {
+		panic(err)
+	}
+	// характеристики страниц комплектаций и поколений хранятся в кэше, общем для обычного поиска и подбора
+	var trims repository.TrimCache
+	if viper.GetBool("scraping.trim_cache.enabled") {
+		trims = gateway.NewTrimCache(datastore.CreateNewTrimCacheRDB(), viper.GetDuration("scraping.trim_cache.ttl"))
+	}
+	listingRepos, err := loadListingRepositories(viper.GetStringSlice("scraping.sources"), fetcher, trims,
+		viper.GetInt("scraping.workers"), viper.GetDuration("scraping.timeout"))
+	if err != nil {
+		panic(err)
+	}
+
 	router := gin.Default()
+	// контекст запроса отменяется при отключении клиента, и ранжирование автомобилей прерывается
+	router.ContextWithFallback = true
 	router.LoadHTMLGlob("../server/pages/*html")
 	router.Static("/styles", "../server/pages/styles")
 	router.Static("/scripts", "../server/pages/scripts")
@@ -39,7 +145,13 @@ func Run(port string) error {
 		router.StaticFS("/static"+num, dir)
 	}
 
-	router = ir.MakeNewRouter(router, redisSearchDB, redisSelectionDB, surveyDB, vehiclesDB)
+	// задания сбора автомобилей выполняются в фоне, их состояния хранятся рядом с автомобилями сессий
+	jobWorkers, jobTTL := viper.GetInt("scraping.jobs.workers"), viper.GetDuration("scraping.jobs.ttl")
+	searchJobs := registry.NewJobQueue(redisSearchDB, jobWorkers, jobTTL)
+	selectionJobs := registry.NewJobQueue(redisSelectionDB, jobWorkers, jobTTL)
+
+	router = ir.MakeNewRouter(router, redisSearchDB, redisSelectionDB, surveyDB, vehiclesDB, engine, listingRepos,
+		searchJobs, selectionJobs)
 
 	router.GET("/", func(c *gin.Context) {
 		c.Redirect(http.StatusMovedPermanently, "/main")
@@ -69,3 +181,192 @@ func Run(port string) error {
 
 	return httpServer.Shutdown(ctx)
 }
+
+// loadRules загружает нечеткие правила из каталога или, если каталог не задан, правила, встроенные в исполняемый файл.
+// Если задан шаблон, правила не читаются из файлов, а строятся по шаблону
+// Входные параметры: dir - каталог, содержащий файл priorities.txt и каталог rules, template - название шаблона правил,
+// generateMissing - строить по шаблону lexicographic правила для расстановок, которых нет в файлах,
+// scaled - шкалы нечетких множеств изменены; правил для них в файлах нет, variables - нечеткие множества со шкалами
+func loadRules(dir, template string, generateMissing, scaled bool, variables *fuzzy.VariableSet) (fuzzy.RuleSource, error) {
+	if template != "" {
+		ruleTemplate, err := fuzzy.NewRuleTemplate(template)
+		if err != nil {
+			return nil, fmt.Errorf("error from `NewRuleTemplate` function, package `fuzzy`: %#v", err)
+		}
+		return fuzzy.NewRuleGenerator(ruleTemplate, variables), nil
+	}
+	if scaled && !generateMissing {
+		return nil, fmt.Errorf("error, fuzzy.scales requires fuzzy.generate_missing_rules or fuzzy.rule_template")
+	}
+
+	var generator fuzzy.RuleSource
+	if generateMissing {
+		log.Printf("rules for priorities missing from the rule files are generated by the lexicographic template\n")
+		generator = fuzzy.NewRuleGenerator(fuzzy.LexicographicTemplate{}, variables)
+	}
+	if dir == "" {
+		return fuzzy.LoadEmbeddedRuleIndex(variables, generator)
+	}
+	return fuzzy.LoadRuleIndex(os.DirFS(dir), variables, generator)
+}
+
+// scaledVariables создает нечеткие множества по умолчанию, шкалы которых заменены шкалами из 3, 5 или 7 нечетких
+// подмножеств
+// Входной параметр: scales - количество нечетких подмножеств (ключ - название нечеткого множества)
+func scaledVariables(scales map[string]interface{}) (*fuzzy.VariableSet, error) {
+	variables := fuzzy.DefaultVariables()
+	for variable, value := range scales {
+		numberOfTerms, err := strconv.Atoi(fmt.Sprint(value))
+		if err != nil {
+			return nil, fmt.Errorf("error from `Atoi` function, package `strconv`, fuzzy set %q: %#v", variable, err)
+		}
+		if variables, err = variables.WithTermScale(variable, numberOfTerms); err != nil {
+			return nil, fmt.Errorf("error from `WithTermScale` method, package `fuzzy`: %#v", err)
+		}
+	}
+	return variables, nil
+}
+
+// loadImputer создает способ восстановления неизвестных характеристик автомобилей, заданный в конфигурации
+// Входные параметры: method - способ восстановления ("" - не восстанавливать, "median" - медианы по поколению
+// или типу кузова из БД vehicles), vehiclesDB - клиент для подключения к БД
+func loadImputer(method string, vehiclesDB *sql.DB) (fuzzy.Imputer, error) {
+	switch method {
+	case "":
+		return nil, nil
+	case "median":
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		imputer, err := fuzzy.LoadMedianImputer(ctx, gateway.NewCarSampleRepository(vehiclesDB))
+		if err != nil {
+			return nil, fmt.Errorf("error from `LoadMedianImputer` function, package `fuzzy`: %#v", err)
+		}
+		return imputer, nil
+	}
+	return nil, fmt.Errorf("error, unknown imputation method %q", method)
+}
+
+// loadListingRepositories создает репозитории интернет-порталов объявлений, с которых собираются данные автомобилей.
+// Если порталы не заданы, данные собираются с auto.drom.ru
+// Входные параметры: names - названия порталов из настройки scraping.sources, fetcher - загрузчик веб-страниц,
+// trims - кэш характеристик комплектаций (nil - без кэша), workers - количество автомобилей, данные которых
+// собираются с портала одновременно, timeout - наибольшее время сбора данных по одному запросу пользователя
+func loadListingRepositories(names []string, fetcher gateway.Fetcher, trims repository.TrimCache, workers int,
+	timeout time.Duration) ([]repository.ListingRepository, error) {
+	if len(names) == 0 {
+		names = []string{gateway.DromSource}
+	}
+
+	listingRepos := make([]repository.ListingRepository, 0, len(names))
+	for _, name := range names {
+		source, err := gateway.NewListingSource(name)
+		if err != nil {
+			return nil, fmt.Errorf("error from `NewListingSource` function, package `gateway`: %#v", err)
+		}
+		listingRepos = append(listingRepos, gateway.NewListingRepository(source, fetcher, trims, workers, timeout))
+	}
+	return listingRepos, nil
+}
+
+// loadExperiment создает A/B-тест баз нечетких правил, если в конфигурации задан каталог новой версии правил
+// Входные параметры: control - текущая база правил, variables - нечеткие множества со шкалами
+func loadExperiment(control fuzzy.RuleSource, variables *fuzzy.VariableSet) (*fuzzy.Experiment, error) {
+	dir := viper.GetString("fuzzy.experiment.candidate_dir")
+	if dir == "" {
+		return nil, nil
+	}
+
+	var generator fuzzy.RuleSource
+	if viper.GetBool("fuzzy.generate_missing_rules") {
+		generator = fuzzy.NewRuleGenerator(fuzzy.LexicographicTemplate{}, variables)
+	}
+	candidate, err := fuzzy.LoadRuleIndex(os.DirFS(dir), variables, generator)
+	if err != nil {
+		return nil, fmt.Errorf("error from `LoadRuleIndex` function, package `fuzzy`: %#v", err)
+	}
+	experiment, err := fuzzy.NewExperiment(viper.GetString("fuzzy.experiment.name"), control, candidate,
+		viper.GetFloat64("fuzzy.experiment.share"))
+	if err != nil {
+		return nil, fmt.Errorf("error from `NewExperiment` function, package `fuzzy`: %#v", err)
+	}
+	return experiment, nil
+}
+
+// loadIntervalMemberships подбирает интервальные функции принадлежности второго типа по разбросу ответов
+// пользователей на вопросы опроса
+// Входные параметры: surveyDB - клиент для подключения к БД опроса, current - текущие функции принадлежности,
+// spread - множитель стандартного отклонения ответов
+func loadIntervalMemberships(surveyDB *sql.DB, current map[string]map[string]fuzzy.MembershipFunction,
+	spread float64) (map[string]map[string]fuzzy.IntervalMembership, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	answers, err := gateway.NewSurveyAnswersRepository(surveyDB).LoadSurveyAnswers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error from `LoadSurveyAnswers` method, package `gateway`: %#v", err)
+	}
+
+	results, err := fuzzy.FitIntervalMemberships(answers, current, spread)
+	if err != nil {
+		return nil, fmt.Errorf("error from `FitIntervalMemberships` function, package `fuzzy`: %#v", err)
+	}
+	intervals, err := fuzzy.BuildIntervalMemberships(results)
+	if err != nil {
+		return nil, fmt.Errorf("error from `BuildIntervalMemberships` function, package `fuzzy`: %#v", err)
+	}
+	return intervals, nil
+}
+
+// watchMemberships загружает функции принадлежности из источника, заданного в конфигурации, и запускает
+// их периодическую перезагрузку. Если источник не задан, используются встроенные функции принадлежности
+// Входные параметры: ctx - контекст, завершающий перезагрузку, table - таблица функций принадлежности
+// нечеткого алгоритма, vehiclesDB - клиент для подключения к БД, хранящей таблицу membership_functions
+func watchMemberships(ctx context.Context, table *fuzzy.MembershipTable, vehiclesDB *sql.DB) error {
+	var loader fuzzy.MembershipLoader
+	switch source := viper.GetString("fuzzy.memberships.source"); source {
+	case "":
+		return nil
+	case "file":
+		loader = gateway.NewMembershipFileLoader(viper.GetString("fuzzy.memberships.file"))
+	case "db":
+		loader = gateway.NewMembershipRepository(vehiclesDB)
+	default:
+		return fmt.Errorf("error, unknown membership functions source %q", source)
+	}
+
+	if err := fuzzy.ReloadMemberships(ctx, table, loader); err != nil {
+		return fmt.Errorf("error from `ReloadMemberships` function, package `fuzzy`: %#v", err)
+	}
+
+	interval := viper.GetDuration("fuzzy.memberships.reload_interval")
+	if interval <= 0 {
+		return fmt.Errorf("error, reload interval of membership functions must be positive, got %s", interval)
+	}
+	go fuzzy.WatchMemberships(ctx, table, loader, interval)
+	return nil
+}
+
+// watchFormulas загружает формулы коэффициентов из источника, заданного в конфигурации, и запускает их
+// периодическую перезагрузку. Если источник не задан, используются встроенные формулы
+// Входные параметры: ctx - контекст, завершающий перезагрузку, table - таблица формул коэффициентов нечеткого алгоритма
+func watchFormulas(ctx context.Context, table *fuzzy.FormulaTable) error {
+	var loader fuzzy.FormulaLoader
+	switch source := viper.GetString("fuzzy.formulas.source"); source {
+	case "":
+		return nil
+	case "file":
+		loader = gateway.NewFormulaFileLoader(viper.GetString("fuzzy.formulas.file"))
+	default:
+		return fmt.Errorf("error, unknown coefficient formulas source %q", source)
+	}
+
+	if err := fuzzy.ReloadFormulas(ctx, table, loader); err != nil {
+		return fmt.Errorf("error from `ReloadFormulas` function, package `fuzzy`: %#v", err)
+	}
+
+	interval := viper.GetDuration("fuzzy.formulas.reload_interval")
+	if interval <= 0 {
+		return fmt.Errorf("error, reload interval of coefficient formulas must be positive, got %s", interval)
+	}
+	go fuzzy.WatchFormulas(ctx, table, loader, interval)
+	return nil
+}
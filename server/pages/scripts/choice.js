@@ -16,7 +16,8 @@ function goToInternet() {
       })
       .then(response => {
           if (response.ok) {
-            window.location.href = "http://localhost:8080/selection/internet?guest="+sessionID;
+            // автомобили собираются и ранжируются на сервере в фоне, рейтинг открывается после подбора
+            watchJob('selection', sessionID, "http://localhost:8080/selection/internet?guest="+sessionID, showChoiceBox);
           } else {
               throw new Error('HTTP Error: ' + response.status);
           }
@@ -54,3 +55,9 @@ function hideAllAndShowLoading() {
     loading.style.display = "block";
 }
 
+function showChoiceBox() {
+    var choice_box = document.getElementById('choice_box');
+    choice_box.style.display = "flex";
+    var loading = document.getElementById("loader");
+    loading.style.display = "none";
+}
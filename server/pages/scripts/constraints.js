@@ -0,0 +1,47 @@
+var btns = document.getElementsByClassName("answer");
+
+for (var i = 0; i < btns.length; i++) {
+    btns[i].addEventListener("click", changeStyleWhenClick);
+}
+
+function changeStyleWhenClick() {
+    this.classList.toggle("clicked");
+}
+
+// collectConstraints собирает выбранные пользователем ограничения
+function collectConstraints() {
+  var constraints = { bodies: [], gearboxes: [], drives: [], fuels: [], options: [] };
+  var clicked = document.getElementsByClassName("clicked");
+  for (var i = 0; i < clicked.length; i++) {
+    constraints[clicked[i].dataset.field].push(clicked[i].dataset.value);
+  }
+
+  constraints.minSeats = parseInt(document.getElementById("minSeats").value) || 0;
+  constraints.minTrunkVolume = parseFloat(document.getElementById("minTrunkVolume").value) || 0;
+  constraints.maxAge = parseInt(document.getElementById("maxAge").value) || 0;
+  return constraints;
+}
+
+function sendRequest() {
+var sessionID = sessionStorage.getItem('sessionID');
+if (sessionID) {
+  const url = 'http://localhost:8080/selection/constraints';
+  fetch(url, {
+      method: 'POST',
+      headers: {
+          'Content-Type': 'application/json'
+      },
+      body: JSON.stringify(collectConstraints())
+  })
+  .then(response => {
+      if (response.ok) {
+          window.location.href = "http://localhost:8080/selection/choice";
+      } else {
+          throw new Error('HTTP Error: ' + response.status);
+      }
+  })
+  .catch(error => console.error(error));
+} else {
+  console.log("Key 'sessionID' not found in sessionStorage");
+}
+}
@@ -0,0 +1,26 @@
+const feedback = document.querySelector('.feedback');
+const feedbackStatus = document.querySelector('.feedback__status');
+
+document.querySelectorAll('.feedback__button').forEach(function(button) {
+    button.addEventListener('click', function() {
+        fetch('/selection/feedback', {
+            method: 'POST',
+            headers: {'Content-Type': 'application/json'},
+            body: JSON.stringify({
+                sessionID: feedback.dataset.session,
+                carID: Number(feedback.dataset.car),
+                action: button.dataset.action
+            })
+        }).then(function(response) {
+            if (!response.ok) {
+                throw new Error(response.statusText);
+            }
+            document.querySelectorAll('.feedback__button').forEach(function(other) {
+                other.classList.toggle('feedback__button--chosen', other === button);
+            });
+            feedbackStatus.textContent = 'Спасибо, отзыв учтен';
+        }).catch(function() {
+            feedbackStatus.textContent = 'Не удалось сохранить отзыв';
+        });
+    });
+});
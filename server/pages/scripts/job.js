@@ -0,0 +1,78 @@
+// watchJob следит за заданием сбора автомобилей, выполняемым на сервере в фоне: показывает количество загруженных
+// страниц и собранных автомобилей и переходит на страницу результатов, когда задание выполнено.
+// Ход задания приходит через Server-Sent Events, а если браузер их не поддерживает или поток прервался, сервер
+// опрашивается раз в секунду. onStop вызывается, если задание завершилось ошибкой или отменено
+function watchJob(kind, sessionID, resultsURL, onStop) {
+    var progress = document.getElementById('job_progress');
+    var cancel = document.getElementById('job_cancel');
+    var jobURL = '/jobs/' + kind + '?guest=' + sessionID;
+    var finished = false;
+
+    progress.textContent = 'Задание поставлено в очередь';
+    progress.style.display = 'block';
+    cancel.style.display = 'block';
+    cancel.onclick = function () {
+        fetch(jobURL, { method: 'DELETE' }).catch(error => console.error(error));
+    };
+
+    function show(job) {
+        if (job.status === 'queued') {
+            progress.textContent = 'Задание поставлено в очередь';
+        } else {
+            progress.textContent = 'Загружено страниц: ' + job.pagesFetched + ', собрано автомобилей: ' + job.carsParsed;
+        }
+
+        if (job.status === 'done') {
+            finished = true;
+            window.location.href = resultsURL;
+        } else if (job.status === 'failed') {
+            stop('Не удалось собрать автомобили, попробуйте еще раз');
+        } else if (job.status === 'cancelled') {
+            stop('Сбор автомобилей отменен');
+        }
+    }
+
+    function stop(message) {
+        finished = true;
+        cancel.style.display = 'none';
+        progress.textContent = message;
+        if (onStop) {
+            onStop();
+        }
+    }
+
+    function poll() {
+        fetch(jobURL)
+        .then(response => {
+            if (!response.ok) {
+                throw new Error('HTTP Error: ' + response.status);
+            }
+            return response.json();
+        })
+        .then(job => show(job))
+        .catch(error => console.error(error))
+        .finally(() => {
+            if (!finished) {
+                setTimeout(poll, 1000);
+            }
+        });
+    }
+
+    if (!window.EventSource) {
+        poll();
+        return;
+    }
+    var source = new EventSource('/jobs/' + kind + '/events?guest=' + sessionID);
+    source.addEventListener('progress', event => show(JSON.parse(event.data)));
+    source.addEventListener('failure', event => {
+        source.close();
+        stop(JSON.parse(event.data).message);
+    });
+    source.onerror = function () {
+        // сервер закрывает поток после завершения задания; если поток прервался раньше, сервер опрашивается
+        source.close();
+        if (!finished) {
+            poll();
+        }
+    };
+}
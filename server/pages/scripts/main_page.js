@@ -65,6 +65,7 @@ function hideAllAndShowLoading() {
 	var fuzzy_algorithm = document.getElementById("fuzzy_algorithm")
 	fuzzy_algorithm.style.display = "none"
 	preloader.style.display = "block";
+	document.querySelector("#preloader .fa-spinner").style.display = "";
 
     const formData = new FormData(form);
     let formDataObject = {};
@@ -85,7 +86,8 @@ formData.forEach(function(value, key){
         })
         .then(response => {
             if (response.ok) {
-                window.location.href = "http://localhost:8080/search?guest="+sessionID;
+                // автомобили собираются на сервере в фоне, страница результатов открывается после сбора
+                watchJob('search', sessionID, "http://localhost:8080/search?guest="+sessionID, showSearchForm);
             } else {
                 throw new Error('HTTP Error: ' + response.status);
             }
@@ -95,3 +97,9 @@ formData.forEach(function(value, key){
         console.log("Key 'sessionID' not found in sessionStorage");
     }
 }
+
+function showSearchForm() {
+	document.getElementById("usual_search").style.display = "block";
+	document.getElementById("fuzzy_algorithm").style.display = "block";
+	document.querySelector("#preloader .fa-spinner").style.display = "none";
+}
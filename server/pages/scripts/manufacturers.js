@@ -50,7 +50,7 @@ if (sessionID) {
   })
   .then(response => {
       if (response.ok) {
-          window.location.href = "http://localhost:8080/selection/choice";
+          window.location.href = "http://localhost:8080/selection/constraints";
       } else {
           throw new Error('HTTP Error: ' + response.status);
       }
@@ -4,8 +4,9 @@ for (var i = 0; i < btns.length; i++) {
     btns[i].addEventListener("click", changeStyleWhenClick);
 }
 
+// changeStyleWhenClick выделяет кнопку, если характеристика выбрана
 function changeStyleWhenClick() {
-    if (this.classList.length <= 2) {
+    if (choices.indexOf(this.dataset.variable) >= 0) {
     this.classList.add("clicked");
 
   } else {
@@ -15,11 +16,16 @@ function changeStyleWhenClick() {
 }
 
 
+// maxPriorities - наибольшее количество приоритетов, которое принимает сервер
+var maxPriorities = parseInt(document.body.dataset.maxPriorities) || btns.length;
 var choices = [];
 var clicks = [];
 
 function changeChoice(choice, index) {
     if (clicks[index] == false || clicks[index] == undefined) {
+      if (choices.length >= maxPriorities) {
+        return;
+      }
       clicks[index] = true;
       
       choices.push(choice);
@@ -43,17 +49,25 @@ for (var i = 0; i < priority_variables.length; ++i) {
 
 var j = -1;
 var deleted_priorities = [];
-var priority_values = [1, 2, 3, 4, 5, 6];
-var left_priorities = [1, 2, 3, 4, 5, 6];
+// numbers возвращает номера приоритетов от 1 до maxPriorities
+function numbers() {
+  var values = [];
+  for (var i = 1; i <= maxPriorities; i++) {
+    values.push(i);
+  }
+  return values;
+}
+
+var left_priorities = numbers();
 
 function set_initial_values() {
     deleted_priorities = [];
-    left_priorities = [1, 2, 3, 4, 5, 6];
+    left_priorities = numbers();
     j = -1;
 }
 
 function addPriority(index) {
-  if (deleted_priorities.length == 6) {
+  if (deleted_priorities.length == maxPriorities) {
       set_initial_values();
   }
   if (deleted_priorities.length != 0) {
@@ -82,10 +96,29 @@ function deletePriority(index) {
 }
 
 
+// collectWeights возвращает веса характеристик, если пользователь задал хотя бы один ненулевой вес
+function collectWeights() {
+  var weights = {};
+  var hasWeights = false;
+  var inputs = document.getElementsByClassName("weight");
+  for (var i = 0; i < inputs.length; i++) {
+    var weight = parseFloat(inputs[i].value);
+    if (weight > 0) {
+      weights[inputs[i].dataset.variable] = weight;
+      hasWeights = true;
+    }
+  }
+  return hasWeights ? weights : null;
+}
+
 function sendRequest() {
   var sessionID = sessionStorage.getItem('sessionID');
   if (sessionID) {
   const data = { sessionID: sessionID, priorities: choices};
+  const weights = collectWeights();
+  if (weights) {
+    data.weights = weights;
+  }
   const url = 'http://localhost:8080/selection/priorities';
   fetch(url, {
     method: 'POST',
@@ -0,0 +1,29 @@
+-- скрипт для создания таблицы с отзывами пользователей о ранжированных автомобилях в базе данных "vehicles"
+
+BEGIN;
+-- действия пользователя с автомобилем из рейтинга
+CREATE TYPE feedback_action_enum AS ENUM ('open', 'favourite', 'dismiss');
+CREATE TABLE feedback_events (
+  id SERIAL PRIMARY KEY,
+  -- идентификатор сессии
+  session_id VARCHAR(100) NOT NULL,
+  -- приоритеты, по которым ранжировались автомобили
+  priorities VARCHAR(100)[] NOT NULL,
+  -- A/B-тест баз нечетких правил и его вариант; пустые строки, если эксперимента нет
+  experiment VARCHAR(100) NOT NULL DEFAULT '',
+  variant VARCHAR(100) NOT NULL DEFAULT '',
+  -- место автомобиля в рейтинге, начиная с 1
+  rank INTEGER NOT NULL CHECK (rank > 0),
+  -- действие пользователя
+  action feedback_action_enum NOT NULL,
+  -- коэффициенты автомобиля и их достоверности (ключ - название нечеткого множества)
+  coefficients JSONB NOT NULL,
+  confidences JSONB NOT NULL,
+  -- версии функций принадлежности и формул коэффициентов, которыми оценен автомобиль
+  memberships_version VARCHAR(100) NOT NULL DEFAULT '',
+  formulas_version VARCHAR(100) NOT NULL DEFAULT '',
+  -- время действия
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+CREATE INDEX feedback_events_created_at_idx ON feedback_events (created_at);
+COMMIT;
@@ -0,0 +1,51 @@
+-- скрипт для создания таблицы с описаниями функций принадлежности в базе данных "vehicles"
+
+BEGIN;
+-- описания функций принадлежности нечетких подмножеств
+CREATE TYPE membership_function_type_enum AS ENUM ('sigmoid', 'gaussian', 'triangular', 'trapezoidal');
+CREATE TABLE membership_functions (
+  id SERIAL PRIMARY KEY,
+  -- версия описаний
+  version INTEGER NOT NULL,
+  -- нечеткое множество
+  variable VARCHAR(100) NOT NULL,
+  -- нечеткое подмножество
+  term VARCHAR(100) NOT NULL,
+  -- тип функции принадлежности
+  function_type membership_function_type_enum NOT NULL,
+  -- параметры функции принадлежности
+  params DOUBLE PRECISION[] NOT NULL,
+  -- версия одобрена после проверки: нечеткий алгоритм использует последнюю одобренную версию
+  approved BOOLEAN NOT NULL DEFAULT FALSE,
+  CONSTRAINT unique_version_variable_term UNIQUE (version, variable, term)
+);
+
+-- значения параметров были получены путем аппроксимации точек методом Левенберга-Марквардта с помощью пакета "Lmfit" ЯП "Python"
+INSERT INTO membership_functions (version, variable, term, function_type, params, approved)
+VALUES
+(1, 'экономичность', 'низкий', 'sigmoid', ARRAY[1.043723139993038, 0.5194913435480255, 11.165188013054621], TRUE),
+(1, 'экономичность', 'средний', 'gaussian', ARRAY[2.2900397063026374, 9.43414665796981, 2.4138470099365112], TRUE),
+(1, 'экономичность', 'высокий', 'sigmoid', ARRAY[1.949834151590793, -0.3804532441502327, 5.188639378787266], TRUE),
+(1, 'динамика', 'низкий', 'sigmoid', ARRAY[1.0231319819933777, 0.5016231903133455, 13.44547910618538], TRUE),
+(1, 'динамика', 'средний', 'gaussian', ARRAY[4.59765854168931, 10.810654375352698, 3.529577571232097], TRUE),
+(1, 'динамика', 'высокий', 'sigmoid', ARRAY[1.1836613715914706, -0.3792245799359442, 7.418367289135995], TRUE),
+(1, 'управляемость', 'низкий', 'sigmoid', ARRAY[1.2027418825694678, -0.07501884950616336, 22.4088071782321], TRUE),
+(1, 'управляемость', 'средний', 'gaussian', ARRAY[31.124751770295614, 44.305695848946904, 19.042293165507264], TRUE),
+(1, 'управляемость', 'высокий', 'sigmoid', ARRAY[1.3245201627428753, 0.07214432798281176, 69.8908258450921], TRUE),
+(1, 'комфорт', 'низкий', 'sigmoid', ARRAY[1.1834768835495801, -0.2870468773928149, 5.7724209993240825], TRUE),
+(1, 'комфорт', 'средний', 'gaussian', ARRAY[5.0422852289029185, 10.10928688292464, 4.210219040980836], TRUE),
+(1, 'комфорт', 'высокий', 'sigmoid', ARRAY[1.2492396969207602, 0.27009941927484593, 14.702080359730674], TRUE),
+(1, 'безопасность', 'низкий', 'sigmoid', ARRAY[1.3490219429573107, -0.21934076866027877, 4.73473258614666], TRUE),
+(1, 'безопасность', 'средний', 'gaussian', ARRAY[5.450821590257078, 10.048764235757659, 4.185552288427339], TRUE),
+(1, 'безопасность', 'высокий', 'sigmoid', ARRAY[1.2799644032509998, 0.3119397892443973, 15.65152657451626], TRUE),
+-- функции принадлежности практичности, стоимости владения и проходимости заданы по границам типичных значений коэффициентов
+(1, 'практичность', 'низкий', 'trapezoidal', ARRAY[0, 0, 11, 15], TRUE),
+(1, 'практичность', 'средний', 'triangular', ARRAY[11, 15.5, 20], TRUE),
+(1, 'практичность', 'высокий', 'trapezoidal', ARRAY[16, 20, 100, 100], TRUE),
+(1, 'стоимость_владения', 'низкий', 'trapezoidal', ARRAY[250, 400, 100000, 100000], TRUE),
+(1, 'стоимость_владения', 'средний', 'triangular', ARRAY[120, 220, 400], TRUE),
+(1, 'стоимость_владения', 'высокий', 'trapezoidal', ARRAY[0, 0, 120, 220], TRUE),
+(1, 'проходимость', 'низкий', 'trapezoidal', ARRAY[0, 0, 15, 20], TRUE),
+(1, 'проходимость', 'средний', 'triangular', ARRAY[15, 22, 29], TRUE),
+(1, 'проходимость', 'высокий', 'trapezoidal', ARRAY[24, 30, 100, 100], TRUE);
+COMMIT;